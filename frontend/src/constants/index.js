@@ -48,9 +48,10 @@ export const AMENITIES = {
 // Sort Options
 export const SORT_OPTIONS = {
   RELEVANCE: 'relevance',
-  PRICE_LOW: 'price-low',
-  PRICE_HIGH: 'price-high',
-  RATING: 'rating',
+  PRICE_LOW: 'price_asc',
+  PRICE_HIGH: 'price_desc',
+  RATING: 'rating_desc',
+  NAME: 'name_asc',
 };
 
 // Local Storage Keys
@@ -80,7 +80,7 @@ const Dashboard = () => {
         authService.getAllUsers(),
       ]);
 
-      setHotels(hotelsRes || []);
+      setHotels(hotelsRes?.results || []);
       setUsers(usersRes || []);
     } catch (err) {
       console.error('Error fetching data:', err);
@@ -101,7 +101,8 @@ const Dashboard = () => {
       setDeleteLoading(true);
 
       if (type === 'hotel') {
-        await adminService.deleteHotel(item.id);
+        const hotel = await hotelsService.getById(item.id);
+        await adminService.deleteHotel(item.id, hotel.version);
         setHotels(hotels.filter((h) => h.id !== item.id));
         setSnackbar({ open: true, message: 'Hotel deleted successfully', severity: 'success' });
       } else if (type === 'user') {
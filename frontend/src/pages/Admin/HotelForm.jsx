@@ -45,6 +45,7 @@ const HotelForm = () => {
   const [snackbar, setSnackbar] = useState({ open: false, message: '', severity: 'success' });
   const [amenityInput, setAmenityInput] = useState('');
   const [imageInput, setImageInput] = useState('');
+  const [version, setVersion] = useState(null);
 
   const {
     register,
@@ -91,6 +92,7 @@ const HotelForm = () => {
     try {
       setFetchLoading(true);
       const hotel = await hotelsService.getById(id);
+      setVersion(hotel.version);
       reset({
         name: hotel.name || '',
         description: hotel.description || '',
@@ -140,7 +142,7 @@ const HotelForm = () => {
       };
 
       if (isEditing) {
-        await adminService.updateHotel(id, hotelData);
+        await adminService.updateHotel(id, hotelData, version);
         setSnackbar({ open: true, message: 'Hotel updated successfully', severity: 'success' });
       } else {
         await adminService.createHotel(hotelData);
@@ -3,7 +3,7 @@
  * Displays comprehensive hotel information with booking functionality
  */
 
-import { useState, useEffect } from 'react';
+import { useState, useEffect, useRef } from 'react';
 import { useParams, useNavigate } from 'react-router-dom';
 import {
   Box,
@@ -19,6 +19,7 @@ import {
   Alert,
   Divider,
   TextField,
+  MenuItem,
   Dialog,
   DialogTitle,
   DialogContent,
@@ -68,6 +69,10 @@ const HotelDetail = () => {
   const [snackbar, setSnackbar] = useState({ open: false, message: '', severity: 'success' });
   const [checkIn, setCheckIn] = useState('');
   const [checkOut, setCheckOut] = useState('');
+  const [roomTypeId, setRoomTypeId] = useState('');
+  const [quote, setQuote] = useState(null);
+  // One Idempotency-Key per booking attempt: resubmitting the same booking reuses it so the server deduplicates it
+  const bookingKey = useRef(null);
 
   useEffect(() => {
     const fetchHotel = async () => {
@@ -88,6 +93,28 @@ const HotelDetail = () => {
     }
   }, [id]);
 
+  // Different dates or room type are a different booking
+  useEffect(() => {
+    bookingKey.current = null;
+  }, [checkIn, checkOut, roomTypeId]);
+
+  useEffect(() => {
+    const roomTypesRequired = (hotel?.room_types || []).length > 0;
+    if (!hotel || !checkIn || !checkOut || new Date(checkIn) >= new Date(checkOut) || (roomTypesRequired && !roomTypeId)) {
+      setQuote(null);
+      return;
+    }
+
+    // Server-side price with seasonal/weekend rules; falls back to the local estimate on error
+    hotelsService
+      .quote(hotel.id, checkIn, checkOut, roomTypeId)
+      .then(setQuote)
+      .catch((err) => {
+        console.error('Error fetching quote:', err);
+        setQuote(null);
+      });
+  }, [hotel, checkIn, checkOut, roomTypeId]);
+
   const handleBookingOpen = () => {
     if (!isAuthenticated) {
       navigate(ROUTES.LOGIN, { state: { from: { pathname: `/hotels/${id}` } } });
@@ -100,6 +127,7 @@ const HotelDetail = () => {
     setBookingOpen(false);
     setCheckIn('');
     setCheckOut('');
+    setRoomTypeId('');
   };
 
   const handleBookingSubmit = async () => {
@@ -108,6 +136,11 @@ const HotelDetail = () => {
       return;
     }
 
+    if (roomTypes.length > 0 && !roomTypeId) {
+      setSnackbar({ open: true, message: 'Please select a room type', severity: 'warning' });
+      return;
+    }
+
     if (new Date(checkIn) >= new Date(checkOut)) {
       setSnackbar({ open: true, message: 'Check-out date must be after check-in date', severity: 'warning' });
       return;
@@ -118,7 +151,10 @@ const HotelDetail = () => {
       // Convert dates to ISO 8601 format with time for Go's time.Time parsing
       const checkInDateTime = new Date(checkIn + 'T15:00:00Z').toISOString();
       const checkOutDateTime = new Date(checkOut + 'T11:00:00Z').toISOString();
-      await reservationsService.create(hotel.id, hotel.name, String(user.id), checkInDateTime, checkOutDateTime);
+      if (!bookingKey.current) {
+        bookingKey.current = crypto.randomUUID();
+      }
+      await reservationsService.create(hotel.id, hotel.name, String(user.id), checkInDateTime, checkOutDateTime, roomTypeId, bookingKey.current);
       setSnackbar({ open: true, message: 'Reservation created successfully!', severity: 'success' });
       handleBookingClose();
     } catch (err) {
@@ -166,7 +202,9 @@ const HotelDetail = () => {
 
   const mainImage = hotel.images?.[0] || PLACEHOLDER_IMAGES[0];
   const galleryImages = hotel.images?.slice(1, 4) || PLACEHOLDER_IMAGES.slice(1);
-  const pricePerNight = hotel.price_per_night || hotel.pricePerNight || 0;
+  const roomTypes = hotel.room_types || [];
+  const selectedRoomType = roomTypes.find((roomType) => roomType.id === roomTypeId);
+  const pricePerNight = selectedRoomType?.base_price || hotel.price_per_night || hotel.pricePerNight || 0;
   const availableRooms = hotel.avaiable_rooms || hotel.avaiableRooms || 0;
   const checkInTime = hotel.check_in_time || hotel.checkInTime || DEFAULT_TIMES.CHECK_IN;
   const checkOutTime = hotel.check_out_time || hotel.checkOutTime || DEFAULT_TIMES.CHECK_OUT;
@@ -462,6 +500,23 @@ const HotelDetail = () => {
                 disabled={!checkIn}
               />
             </Grid>
+            {roomTypes.length > 0 && (
+              <Grid size={{ xs: 12 }}>
+                <TextField
+                  select
+                  fullWidth
+                  label="Room Type"
+                  value={roomTypeId}
+                  onChange={(e) => setRoomTypeId(e.target.value)}
+                >
+                  {roomTypes.map((roomType) => (
+                    <MenuItem key={roomType.id} value={roomType.id}>
+                      {roomType.name} · up to {roomType.capacity} guest{roomType.capacity !== 1 ? 's' : ''} · {formatPrice(roomType.base_price)}/night
+                    </MenuItem>
+                  ))}
+                </TextField>
+              </Grid>
+            )}
           </Grid>
           {checkIn && checkOut && (
             <Box sx={{ mt: 3, p: 2, bgcolor: 'background.default', borderRadius: 2 }}>
@@ -472,7 +527,9 @@ const HotelDetail = () => {
                 {calculateNights(checkIn, checkOut)} night{calculateNights(checkIn, checkOut) !== 1 ? 's' : ''}
               </Typography>
               <Typography variant="h5" sx={{ color: 'primary.main', fontWeight: 600 }}>
-                Total: {formatPrice(calculateTotalPrice(pricePerNight, checkIn, checkOut))}
+                Total: {quote
+                  ? formatPrice(quote.total, quote.currency)
+                  : formatPrice(calculateTotalPrice(pricePerNight, checkIn, checkOut))}
               </Typography>
             </Box>
           )}
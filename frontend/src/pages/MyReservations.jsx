@@ -34,7 +34,7 @@ import {
 import { reservationsService } from '../services';
 import { useAuth } from '../context/AuthContext';
 import { ROUTES } from '../constants';
-import { formatDate, calculateNights, getReservationStatus } from '../utils/helpers';
+import { formatDate, formatPrice, calculateNights, getReservationStatus } from '../utils/helpers';
 
 const MyReservations = () => {
   const navigate = useNavigate();
@@ -79,7 +79,11 @@ const MyReservations = () => {
     try {
       setDeleteLoading(true);
       await reservationsService.cancel(deleteDialog.reservation.id);
-      setReservations(reservations.filter((r) => r.id !== deleteDialog.reservation.id));
+      setReservations(
+        reservations.map((r) =>
+          r.id === deleteDialog.reservation.id ? { ...r, status: 'CANCELLED' } : r
+        )
+      );
       setSnackbar({ open: true, message: 'Reservation cancelled successfully', severity: 'success' });
     } catch (err) {
       console.error('Error canceling reservation:', err);
@@ -186,7 +190,7 @@ const MyReservations = () => {
             {reservations.map((reservation) => {
               const checkIn = reservation.check_in || reservation.checkIn;
               const checkOut = reservation.check_out || reservation.checkOut;
-              const status = getReservationStatus(checkIn, checkOut);
+              const status = getReservationStatus(checkIn, checkOut, reservation.status);
               const nights = calculateNights(checkIn, checkOut);
               const hotelId = reservation.hotel_id || reservation.hotelId;
               const hotelName = reservation.hotel_name || reservation.hotelName || 'Hotel';
@@ -241,6 +245,7 @@ const MyReservations = () => {
                           </Box>
                           <Typography variant="body2" color="text.secondary">
                             {nights} night{nights !== 1 ? 's' : ''}
+                            {reservation.total_price > 0 && ` · ${formatPrice(reservation.total_price, reservation.currency || 'USD')}`}
                           </Typography>
                         </Grid>
 
@@ -44,21 +44,11 @@ const Search = () => {
         setLoading(true);
         setError(null);
         const offset = (page - 1) * PAGINATION.DEFAULT_PAGE_SIZE;
-        const response = await hotelsService.search(query, offset, PAGINATION.DEFAULT_PAGE_SIZE);
+        // Sorting is done by search-api across all results, not just this page
+        const response = await hotelsService.search(query, offset, PAGINATION.DEFAULT_PAGE_SIZE, sortBy);
 
-        let hotelList = response || [];
-
-        // Sort based on selected criteria
-        if (sortBy === SORT_OPTIONS.PRICE_LOW) {
-          hotelList.sort((a, b) => (a.price_per_night || a.pricePerNight || 0) - (b.price_per_night || b.pricePerNight || 0));
-        } else if (sortBy === SORT_OPTIONS.PRICE_HIGH) {
-          hotelList.sort((a, b) => (b.price_per_night || b.pricePerNight || 0) - (a.price_per_night || a.pricePerNight || 0));
-        } else if (sortBy === SORT_OPTIONS.RATING) {
-          hotelList.sort((a, b) => (b.rating || 0) - (a.rating || 0));
-        }
-
-        setHotels(hotelList);
-        setTotalPages(Math.max(1, Math.ceil(hotelList.length / PAGINATION.DEFAULT_PAGE_SIZE)));
+        setHotels(response?.results || []);
+        setTotalPages(Math.max(1, Math.ceil((response?.total || 0) / PAGINATION.DEFAULT_PAGE_SIZE)));
       } catch (err) {
         console.error('Error searching hotels:', err);
         setError('Could not load hotels. Please try again later.');
@@ -167,6 +157,7 @@ const Search = () => {
               <MenuItem value={SORT_OPTIONS.PRICE_LOW}>Price: Low to High</MenuItem>
               <MenuItem value={SORT_OPTIONS.PRICE_HIGH}>Price: High to Low</MenuItem>
               <MenuItem value={SORT_OPTIONS.RATING}>Top Rated</MenuItem>
+              <MenuItem value={SORT_OPTIONS.NAME}>Name</MenuItem>
             </Select>
           </FormControl>
         </Box>
@@ -25,23 +25,29 @@ const adminService = {
   },
 
   /**
-   * Update an existing hotel
+   * Update an existing hotel (JSON Merge Patch: fields not sent are kept, null clears a field)
    * @param {string} hotelId - Hotel ID
    * @param {Partial<HotelCreateRequest>} hotelData - Hotel data to update
-   * @returns {Promise<void>}
+   * @param {number} version - Hotel version the changes were made on (sent as If-Match)
+   * @returns {Promise<Object>} Updated hotel
    */
-  updateHotel: async (hotelId, hotelData) => {
-    const response = await api.put(`/admin/hotels/${hotelId}`, hotelData);
+  updateHotel: async (hotelId, hotelData, version) => {
+    const response = await api.patch(`/admin/hotels/${hotelId}`, hotelData, {
+      headers: { 'Content-Type': 'application/merge-patch+json', 'If-Match': `"${version}"` },
+    });
     return response.data;
   },
 
   /**
    * Delete a hotel
    * @param {string} hotelId - Hotel ID
+   * @param {number} version - Hotel version to delete (sent as If-Match)
    * @returns {Promise<void>}
    */
-  deleteHotel: async (hotelId) => {
-    const response = await api.delete(`/admin/hotels/${hotelId}`);
+  deleteHotel: async (hotelId, version) => {
+    const response = await api.delete(`/admin/hotels/${hotelId}`, {
+      headers: { 'If-Match': `"${version}"` },
+    });
     return response.data;
   },
 
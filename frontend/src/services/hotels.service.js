@@ -21,18 +21,32 @@ const hotelsService = {
    * @param {string} [query=''] - Search query
    * @param {number} [offset=0] - Pagination offset
    * @param {number} [limit=20] - Results limit
-   * @returns {Promise<Hotel[]>} List of hotels
+   * @param {string} [sort] - Sort option (see SORT_OPTIONS), relevance by default
+   * @returns {Promise<import('../types').SearchResponse>} Hotels with total count and facets
    */
-  search: async (query = '', offset = PAGINATION.DEFAULT_OFFSET, limit = PAGINATION.DEFAULT_PAGE_SIZE) => {
+  search: async (query = '', offset = PAGINATION.DEFAULT_OFFSET, limit = PAGINATION.DEFAULT_PAGE_SIZE, sort) => {
     const params = new URLSearchParams();
     if (query) params.append('q', query);
     params.append('offset', offset.toString());
     params.append('limit', limit.toString());
+    if (sort) params.append('sort', sort);
 
     const response = await api.get(`/search?${params.toString()}`);
     return response.data;
   },
 
+  /**
+   * Autocomplete suggestions for cities and hotel names
+   * @param {string} query - What the user has typed so far
+   * @param {number} [limit=8] - Max suggestions (1-20)
+   * @returns {Promise<import('../types').Suggestion[]>} Cities first, then hotels
+   */
+  suggest: async (query, limit = 8) => {
+    const params = new URLSearchParams({ q: query, limit: limit.toString() });
+    const response = await api.get(`/search/suggest?${params.toString()}`);
+    return response.data.suggestions;
+  },
+
   /**
    * Get hotel by ID
    * @param {string} hotelId - Hotel ID
@@ -68,6 +82,35 @@ const hotelsService = {
     });
     return response.data;
   },
+
+  /**
+   * Get the price breakdown for a stay
+   * @param {string} hotelId - Hotel ID
+   * @param {string} checkIn - Check-in date (YYYY-MM-DD)
+   * @param {string} checkOut - Check-out date (YYYY-MM-DD)
+   * @param {string} [roomTypeId] - Room type ID (required for hotels with room types)
+   * @returns {Promise<import('../types').Quote>} Quote with nightly breakdown and total
+   */
+  quote: async (hotelId, checkIn, checkOut, roomTypeId) => {
+    const response = await api.post(`/hotels/${hotelId}/quote`, {
+      check_in: checkIn,
+      check_out: checkOut,
+      room_type_id: roomTypeId || undefined,
+    });
+    return response.data;
+  },
+
+  /**
+   * Get remaining rooms and nightly price for each night in [from, to)
+   * @param {string} hotelId - Hotel ID
+   * @param {string} from - First night (YYYY-MM-DD)
+   * @param {string} to - Day after the last night (YYYY-MM-DD)
+   * @returns {Promise<import('../types').Calendar>} Calendar with one entry per night
+   */
+  getCalendar: async (hotelId, from, to) => {
+    const response = await api.get(`/hotels/${hotelId}/calendar`, { params: { from, to } });
+    return response.data;
+  },
 };
 
 export default hotelsService;
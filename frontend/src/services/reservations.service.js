@@ -21,15 +21,20 @@ const reservationsService = {
    * @param {string} userId - User ID
    * @param {string} checkIn - Check-in date (ISO format)
    * @param {string} checkOut - Check-out date (ISO format)
+   * @param {string} [roomTypeId] - Room type ID (required for hotels with room types)
+   * @param {string} [idempotencyKey] - Reuse the same key when retrying the same booking
    * @returns {Promise<{ id: string }>} Created reservation ID
    */
-  create: async (hotelId, hotelName, userId, checkIn, checkOut) => {
+  create: async (hotelId, hotelName, userId, checkIn, checkOut, roomTypeId, idempotencyKey = crypto.randomUUID()) => {
     const response = await api.post('/reservations', {
       hotel_id: hotelId,
       hotel_name: hotelName,
       user_id: userId,
       check_in: checkIn,
       check_out: checkOut,
+      room_type_id: roomTypeId || undefined,
+    }, {
+      headers: { 'Idempotency-Key': idempotencyKey },
     });
     return response.data;
   },
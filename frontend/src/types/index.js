@@ -49,6 +49,39 @@
  * @property {string} check_out_time - Check-out time (HH:mm)
  * @property {string[]} amenities - List of amenities
  * @property {string[]} images - List of image URLs
+ * @property {RoomType[]} [room_types] - Room types with their own inventory
+ * @property {number} [latitude] - Latitude (-90 to 90)
+ * @property {number} [longitude] - Longitude (-180 to 180)
+ * @property {number} [distance_km] - Distance to the searched point (search results only)
+ * @property {boolean} [availability_unconfirmed] - Availability could not be confirmed (search with dates only)
+ */
+
+/**
+ * @typedef {Object} RoomType
+ * @property {string} id - Room type ID
+ * @property {string} name - Room type name (Single, Double, Suite...)
+ * @property {number} capacity - Guests per room
+ * @property {number} count - Rooms of this type
+ * @property {number} base_price - Price per night for this room type
+ * @property {string[]} amenities - Room amenities
+ */
+
+/**
+ * @typedef {Object} Quote
+ * @property {string} hotel_id - Hotel ID
+ * @property {string} [room_type_id] - Room type ID
+ * @property {string} currency - Currency code
+ * @property {{ date: string, base_price: number, price: number, rules?: string[] }[]} nights - Nightly breakdown
+ * @property {number} subtotal - Sum of nightly prices
+ * @property {number} discount - Minimum-stay discount
+ * @property {number} total - Amount to pay
+ */
+
+/**
+ * @typedef {Object} Calendar
+ * @property {string} hotel_id - Hotel ID
+ * @property {string} currency - Currency code
+ * @property {{ date: string, remaining_rooms: number, price: number, room_types?: { room_type_id: string, remaining_rooms: number, price: number }[] }[]} nights - One entry per night
  */
 
 /**
@@ -78,6 +111,10 @@
  * @property {number} user_id - User ID
  * @property {string} check_in - Check-in date (YYYY-MM-DD)
  * @property {string} check_out - Check-out date (YYYY-MM-DD)
+ * @property {string} [room_type_id] - Booked room type ID
+ * @property {number} [total_price] - Total quoted at booking time
+ * @property {string} [currency] - Currency of total_price
+ * @property {string} status - Lifecycle status (PENDING, CONFIRMED, CANCELLED, CHECKED_IN, COMPLETED, NO_SHOW)
  */
 
 /**
@@ -105,6 +142,37 @@
  * @property {string} [q=''] - Search query
  * @property {number} [offset=0] - Pagination offset
  * @property {number} [limit=20] - Results limit
+ * @property {string} [sort='relevance'] - Sort: relevance, price_asc, price_desc, rating_desc, name_asc or distance
+ */
+
+/**
+ * @typedef {Object} FacetBucket
+ * @property {string} value - Facet value ("Buenos Aires") or range label ("100-200")
+ * @property {number} count - Number of matching hotels
+ * @property {number} [from] - Range lower bound (price/rating facets)
+ * @property {number} [to] - Range upper bound, exclusive (absent for open ranges)
+ */
+
+/**
+ * @typedef {Object} SearchResponse
+ * @property {Hotel[]} results - Hotels in the requested page
+ * @property {{city: FacetBucket[], country: FacetBucket[], amenities: FacetBucket[], price: FacetBucket[], rating: FacetBucket[]}} facets - Facet counts for the current search
+ * @property {number} total - Total matching hotels
+ * @property {number} offset - Offset of this page
+ * @property {number} limit - Page size
+ * @property {?number} next_offset - Offset of the next page (null on the last page or with cursor paging)
+ * @property {?number} prev_offset - Offset of the previous page (null on the first page or with cursor paging)
+ * @property {string} [cursor] - Cursor used for this page (cursor paging only)
+ * @property {string} [next_cursor] - Cursor for the next page (absent on the last page)
+ */
+
+/**
+ * @typedef {Object} Suggestion
+ * @property {string} text - City or hotel name to show
+ * @property {'city'|'hotel'} type - Suggestion type
+ * @property {string} [hotel_id] - Hotel ID (hotel suggestions only)
+ * @property {string} [city] - Hotel city (hotel suggestions only)
+ * @property {number} [count] - Number of hotels (city suggestions only)
  */
 
 /**
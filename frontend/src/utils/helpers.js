@@ -62,12 +62,26 @@ export const calculateTotalPrice = (pricePerNight, checkIn, checkOut) => {
 };
 
 /**
- * Get reservation status based on dates
+ * Get reservation status based on the lifecycle status and dates
  * @param {string} checkIn - Check-in date
  * @param {string} checkOut - Check-out date
+ * @param {string} [status] - Reservation lifecycle status from hotels-api
  * @returns {{ label: string, color: string, canCancel: boolean }}
  */
-export const getReservationStatus = (checkIn, checkOut) => {
+export const getReservationStatus = (checkIn, checkOut, status) => {
+  if (status === 'CANCELLED') {
+    return { label: 'Cancelled', color: 'error', canCancel: false };
+  }
+  if (status === 'NO_SHOW') {
+    return { label: 'No Show', color: 'error', canCancel: false };
+  }
+  if (status === 'COMPLETED') {
+    return { label: 'Completed', color: 'default', canCancel: false };
+  }
+  if (status === 'CHECKED_IN') {
+    return { label: 'In Progress', color: 'success', canCancel: false };
+  }
+
   const checkInDate = parseISO(checkIn);
   const checkOutDate = parseISO(checkOut);
 
@@ -1,14 +1,18 @@
 package main
 
 import (
+	"context"
 	"log"
 	"time"
 
 	"github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/clients/queues"
+	controllersAudit "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/controllers/audit"
 	controllersHotels "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/controllers/hotels"
 	controllersMicroservices "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/controllers/microservices"
 	middleware "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/middlewares"
+	repositoriesAudit "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/repositories/audit"
 	repositoriesHotels "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/repositories/hotels"
+	repositoriesIdempotency "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/repositories/idempotency"
 	servicesHotels "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/services"
 
 	config "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/config"
@@ -27,6 +31,27 @@ func main() {
 		Database:                config.MongoDatabase,
 		Collection_hotels:       config.MongoCollectionHotels,
 		Collection_reservations: config.MongoCollectionReservations,
+		Collection_inventory:    config.MongoCollectionInventory,
+	})
+
+	idempotencyRepo := repositoriesIdempotency.NewMongo(repositoriesIdempotency.MongoConfig{
+		Host:       config.MongoHost,
+		Port:       config.MongoPort,
+		Username:   config.MongoUsername,
+		Password:   config.MongoPassword,
+		Database:   config.MongoDatabase,
+		Collection: config.MongoCollectionIdempotency,
+		TTL:        config.IdempotencyTTL,
+		Lease:      config.IdempotencyLease,
+	})
+
+	auditRepo := repositoriesAudit.NewMongo(repositoriesAudit.MongoConfig{
+		Host:       config.MongoHost,
+		Port:       config.MongoPort,
+		Username:   config.MongoUsername,
+		Password:   config.MongoPassword,
+		Database:   config.MongoDatabase,
+		Collection: config.MongoCollectionAudit,
 	})
 
 	cacheRepo := repositoriesHotels.NewCache(repositoriesHotels.CacheConfig{
@@ -44,37 +69,50 @@ func main() {
 	})
 
 	// Configuración de Servicios
-	hotelsService := servicesHotels.NewService(hotelsRepo, cacheRepo, eventsQueue)
+	hotelsService := servicesHotels.NewService(hotelsRepo, cacheRepo, auditRepo)
+
+	// Relay del outbox: publica en RabbitMQ los eventos de hoteles guardados en Mongo
+	outboxRelay := servicesHotels.NewOutboxRelay(hotelsRepo, eventsQueue, config.OutboxRelayInterval, config.OutboxBatchSize)
+	go outboxRelay.Run(context.Background())
 
 	// Configuración de Controladores
 	hotelsController := controllersHotels.NewController(hotelsService)
+	auditController := controllersAudit.NewController(hotelsService)
 	microservicesController := controllersMicroservices.NewController()
 
 	// Configuración de middlewares
 	jwtMiddleware := middleware.NewJWTMiddleware(config.JWTSecret)
+	idempotencyMiddleware := middleware.NewIdempotencyMiddleware(idempotencyRepo)
 
 	// Configuración del servidor HTTP
 	router := gin.Default()
 
+	// ID de la request para los logs y el registro de auditoria
+	router.Use(middleware.RequestID())
+
 	// Configuración de CORS
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Content-Type", "Authorization", "If-Match", middleware.IdempotencyKeyHeader, middleware.RequestIDHeader},
+		ExposeHeaders:    []string{"Content-Length", "ETag", middleware.IdempotentReplayedHeader, middleware.RequestIDHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
 	// Configuración de rutas
+	router.GET("/hotels", hotelsController.ListHotels)
 	router.GET("/hotels/:hotel_id", hotelsController.GetHotelByID)
 	router.GET("/hotels/:hotel_id/reservations", hotelsController.GetReservationsByHotelID)
+	router.GET("/hotels/:hotel_id/room-types", hotelsController.GetRoomTypes)
+	router.POST("/hotels/:hotel_id/quote", hotelsController.Quote)
+	router.GET("/hotels/:hotel_id/calendar", hotelsController.GetCalendar)
 	router.POST("/hotels/availability", hotelsController.GetAvailability)
 
 	// Rutas protegidas para usuarios autenticados
 	userRoutes := router.Group("/", jwtMiddleware.Authenticate(), middleware.LoggedUserOnly())
 	{
-		userRoutes.POST("/reservations", hotelsController.CreateReservation)
+		userRoutes.POST("/reservations", idempotencyMiddleware.Handle(), hotelsController.CreateReservation)
 		userRoutes.DELETE("/reservations/:id", hotelsController.CancelReservation)
 		userRoutes.GET("/users/:user_id/reservations", hotelsController.GetReservationsByUserID)
 		userRoutes.GET("/users/:user_id/hotels/:hotel_id/reservations", hotelsController.GetReservationsByUserAndHotelID)
@@ -84,10 +122,36 @@ func main() {
 	adminRoutes := router.Group("/admin", jwtMiddleware.Authenticate(), middleware.AdminOnly())
 	{
 		// Gestión de hoteles (solo admins)
-		adminRoutes.POST("/hotels", hotelsController.Create)
+		adminRoutes.GET("/hotels", hotelsController.AdminListHotels)
+		adminRoutes.POST("/hotels", idempotencyMiddleware.Handle(), hotelsController.Create)
 		adminRoutes.PUT("/hotels/:hotel_id", hotelsController.Update)
+		adminRoutes.PATCH("/hotels/:hotel_id", hotelsController.Patch)
 		adminRoutes.DELETE("/hotels/:hotel_id", hotelsController.Delete)
 
+		// Importacion y exportacion masiva de hoteles (solo admins)
+		adminRoutes.POST("/hotels/import", middleware.MaxBodySize(config.ImportMaxBytes), hotelsController.ImportHotels)
+		adminRoutes.GET("/hotels/export", hotelsController.ExportHotels)
+
+		// Tipos de habitacion (solo admins)
+		adminRoutes.POST("/hotels/:hotel_id/room-types", hotelsController.CreateRoomType)
+		adminRoutes.PUT("/hotels/:hotel_id/room-types/:room_type_id", hotelsController.UpdateRoomType)
+		adminRoutes.DELETE("/hotels/:hotel_id/room-types/:room_type_id", hotelsController.DeleteRoomType)
+
+		// Reglas de tarifa (solo admins)
+		adminRoutes.GET("/hotels/:hotel_id/rate-rules", hotelsController.GetRateRules)
+		adminRoutes.POST("/hotels/:hotel_id/rate-rules", hotelsController.CreateRateRule)
+		adminRoutes.PUT("/hotels/:hotel_id/rate-rules/:rule_id", hotelsController.UpdateRateRule)
+		adminRoutes.DELETE("/hotels/:hotel_id/rate-rules/:rule_id", hotelsController.DeleteRateRule)
+
+		// Ciclo de vida de reservas (solo admins)
+		adminRoutes.POST("/reservations/:id/check-in", hotelsController.CheckInReservation)
+		adminRoutes.POST("/reservations/:id/check-out", hotelsController.CheckOutReservation)
+		adminRoutes.POST("/reservations/:id/no-show", hotelsController.NoShowReservation)
+
+		// Registro de auditoria (solo admins)
+		adminRoutes.GET("/audit", auditController.ListAudit)
+		adminRoutes.GET("/audit/export", auditController.ExportAudit)
+
 		// Gestión de microservicios (solo admins)
 		adminRoutes.GET("/microservices", microservicesController.GetMicroservicesStatus)
 		adminRoutes.POST("/microservices/scale", microservicesController.ScaleService)
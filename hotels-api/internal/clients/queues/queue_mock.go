@@ -25,6 +25,13 @@ func (mq *MockQueue) Publish(hotelNew hotelsDomain.HotelNew) error {
 	return nil
 }
 
+func (mq *MockQueue) PublishBatch(hotelNews []hotelsDomain.HotelNew) (int, error) {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+	mq.messages = append(mq.messages, hotelNews...)
+	return len(hotelNews), nil
+}
+
 // Messages devuelve una copia de los mensajes publicados (para asserts en tests).
 func (mq *MockQueue) Messages() []hotelsDomain.HotelNew {
 	mq.mu.Lock()
@@ -18,6 +18,12 @@ const (
 	initialBackoff = 1 * time.Second
 	maxBackoff     = 30 * time.Second
 	backoffFactor  = 2.0
+
+	// Tiempo maximo de espera de la confirmacion del broker (publisher confirms)
+	confirmTimeout = 5 * time.Second
+
+	// Mensajes que PublishBatch envia antes de esperar sus confirmaciones, es el tamaño del buffer de confirms
+	publishBatchSize = 256
 )
 
 type RabbitConfig struct {
@@ -32,9 +38,12 @@ type RabbitQueue struct {
 	config     RabbitConfig
 	connection *amqp.Connection
 	channel    *amqp.Channel
+	confirms   chan amqp.Confirmation
 	queueName  string
 	mu         sync.RWMutex
 	connected  bool
+	// publishMu serializa las publicaciones para que cada una espere su propia confirmacion
+	publishMu sync.Mutex
 }
 
 // NewRabbit crea una nueva instancia de RabbitQueue con reconexión automática
@@ -132,8 +141,17 @@ func (rq *RabbitQueue) connect() error {
 		return fmt.Errorf("error declaring queue: %w", err)
 	}
 
+	// Activa publisher confirms: el broker confirma cada mensaje cuando lo persiste
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		conn.Close()
+		rq.connected = false
+		return fmt.Errorf("error enabling publisher confirms: %w", err)
+	}
+
 	rq.connection = conn
 	rq.channel = ch
+	rq.confirms = ch.NotifyPublish(make(chan amqp.Confirmation, publishBatchSize))
 	rq.connected = true
 
 	// Configurar notificación de cierre de conexión
@@ -186,8 +204,12 @@ func (rq *RabbitQueue) ensureConnection() error {
 	return rq.connectWithRetry()
 }
 
-// Publish publica un mensaje en la cola con reintentos
+// Publish publica un mensaje en la cola con reintentos y espera la confirmacion del broker.
+// Devuelve nil solo si RabbitMQ confirmo (ack) el mensaje
 func (rq *RabbitQueue) Publish(hotelNew hotelsDomain.HotelNew) error {
+	rq.publishMu.Lock()
+	defer rq.publishMu.Unlock()
+
 	// Asegurar conexión antes de publicar
 	if err := rq.ensureConnection(); err != nil {
 		return fmt.Errorf("RabbitMQ connection unavailable: %w", err)
@@ -203,7 +225,7 @@ func (rq *RabbitQueue) Publish(hotelNew hotelsDomain.HotelNew) error {
 	var lastErr error
 	for attempt := 1; attempt <= 3; attempt++ {
 		rq.mu.RLock()
-		channel := rq.channel
+		channel, confirms := rq.channel, rq.confirms
 		rq.mu.RUnlock()
 
 		if channel == nil {
@@ -213,7 +235,7 @@ func (rq *RabbitQueue) Publish(hotelNew hotelsDomain.HotelNew) error {
 				continue
 			}
 			rq.mu.RLock()
-			channel = rq.channel
+			channel, confirms = rq.channel, rq.confirms
 			rq.mu.RUnlock()
 		}
 
@@ -230,7 +252,10 @@ func (rq *RabbitQueue) Publish(hotelNew hotelsDomain.HotelNew) error {
 			})
 
 		if err == nil {
-			return nil
+			err = waitConfirm(confirms)
+			if err == nil {
+				return nil
+			}
 		}
 
 		lastErr = err
@@ -249,11 +274,96 @@ func (rq *RabbitQueue) Publish(hotelNew hotelsDomain.HotelNew) error {
 	return fmt.Errorf("error publishing message after retries: %w", lastErr)
 }
 
+// PublishBatch publica los mensajes en tandas de hasta publishBatchSize y espera las confirmaciones de cada tanda.
+// Devuelve cuantos mensajes, desde el primero, confirmo el broker; ante un error el resto queda sin confirmar
+// aunque se haya enviado, y quien llama lo vuelve a publicar
+func (rq *RabbitQueue) PublishBatch(hotelNews []hotelsDomain.HotelNew) (int, error) {
+	rq.publishMu.Lock()
+	defer rq.publishMu.Unlock()
+
+	if err := rq.ensureConnection(); err != nil {
+		return 0, fmt.Errorf("RabbitMQ connection unavailable: %w", err)
+	}
+
+	confirmed := 0
+	for start := 0; start < len(hotelNews); start += publishBatchSize {
+		end := min(start+publishBatchSize, len(hotelNews))
+		if err := rq.publishChunk(hotelNews[start:end]); err != nil {
+			// Las confirmaciones pendientes de la tanda quedan en el canal: se reconecta para empezar con uno limpio
+			rq.mu.Lock()
+			rq.connected = false
+			rq.mu.Unlock()
+			return confirmed, fmt.Errorf("error publishing batch: %w", err)
+		}
+		confirmed = end
+	}
+
+	return confirmed, nil
+}
+
+// publishChunk envia todos los mensajes y despues espera una confirmacion por cada uno.
+// Los mensajes tienen que entrar en el buffer de confirms para que el canal no se bloquee
+func (rq *RabbitQueue) publishChunk(hotelNews []hotelsDomain.HotelNew) error {
+	rq.mu.RLock()
+	channel, confirms := rq.channel, rq.confirms
+	rq.mu.RUnlock()
+	if channel == nil {
+		return fmt.Errorf("channel not available")
+	}
+
+	for _, hotelNew := range hotelNews {
+		body, err := json.Marshal(hotelNew)
+		if err != nil {
+			return fmt.Errorf("error marshaling message: %w", err)
+		}
+
+		err = channel.Publish(
+			"",           // exchange
+			rq.queueName, // routing key
+			false,        // mandatory
+			false,        // immediate
+			amqp.Publishing{
+				ContentType:  "application/json",
+				DeliveryMode: amqp.Persistent,
+				Body:         body,
+			})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Las confirmaciones llegan en el orden de publicacion; con un nack no se puede
+	// asegurar la tanda entera porque search-api necesita los eventos de cada hotel en orden
+	for range hotelNews {
+		if err := waitConfirm(confirms); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitConfirm espera la confirmacion del broker para el ultimo mensaje publicado
+func waitConfirm(confirms chan amqp.Confirmation) error {
+	select {
+	case confirmation, ok := <-confirms:
+		if !ok {
+			return fmt.Errorf("channel closed before confirming message")
+		}
+		if !confirmation.Ack {
+			return fmt.Errorf("message %d was nacked by the broker", confirmation.DeliveryTag)
+		}
+		return nil
+	case <-time.After(confirmTimeout):
+		return fmt.Errorf("timeout waiting for publisher confirm")
+	}
+}
+
 // closeUnsafe cierra las conexiones sin bloqueo (debe llamarse con mu bloqueado)
 func (rq *RabbitQueue) closeUnsafe() {
 	if rq.channel != nil {
 		rq.channel.Close()
 		rq.channel = nil
+		rq.confirms = nil
 	}
 	if rq.connection != nil {
 		rq.connection.Close()
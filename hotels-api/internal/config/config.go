@@ -15,6 +15,14 @@ var (
 	MongoDatabase               = getEnv("MONGO_DATABASE", "hotels-api")
 	MongoCollectionHotels       = getEnv("MONGO_COLLECTION_HOTELS", "hotels")
 	MongoCollectionReservations = getEnv("MONGO_COLLECTION_RESERVATIONS", "reservations")
+	MongoCollectionInventory    = getEnv("MONGO_COLLECTION_INVENTORY", "inventory")
+	MongoCollectionIdempotency  = getEnv("MONGO_COLLECTION_IDEMPOTENCY", "idempotency_keys")
+	MongoCollectionAudit        = getEnv("MONGO_COLLECTION_AUDIT", "audit_log")
+
+	// Idempotency-Key: tiempo que se conserva la primera respuesta
+	IdempotencyTTL = getDurationEnv("IDEMPOTENCY_TTL", 24*time.Hour)
+	// Tiempo que una request en curso tiene tomada su clave: si la replica se cae, pasado este tiempo se puede reintentar
+	IdempotencyLease = getDurationEnv("IDEMPOTENCY_LEASE", 30*time.Second)
 
 	// Cache
 	CacheMaxSize      = getInt64Env("CACHE_MAX_SIZE", 100000)
@@ -28,6 +36,13 @@ var (
 	RabbitPassword  = getEnv("RABBIT_PASSWORD", "root")
 	RabbitQueueName = getEnv("RABBIT_QUEUE_NAME", "hotels-news")
 
+	// Outbox: cada cuanto el relay publica los eventos pendientes y cuantos hoteles procesa por vuelta
+	OutboxRelayInterval = getDurationEnv("OUTBOX_RELAY_INTERVAL", 2*time.Second)
+	OutboxBatchSize     = getInt64Env("OUTBOX_BATCH_SIZE", 100)
+
+	// Tamaño maximo del archivo de POST /admin/hotels/import (50 MB)
+	ImportMaxBytes = getInt64Env("IMPORT_MAX_BYTES", 50<<20)
+
 	// JWT - debe coincidir con users-api
 	JWTSecret = getEnv("JWT_SECRET", "your-secret-key-change-in-production")
 
@@ -0,0 +1,151 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	hotelsDomain "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/domain/hotels"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Funciones del servicio para consultar el registro de auditoria
+type Service interface {
+	ListAudit(ctx context.Context, query hotelsDomain.AuditQuery) (hotelsDomain.AuditPage, error)
+	ExportAudit(ctx context.Context, query hotelsDomain.AuditQuery, write func(hotelsDomain.AuditEntry) error) error
+}
+
+type Controller struct {
+	service Service
+}
+
+func NewController(service Service) Controller {
+	return Controller{
+		service: service,
+	}
+}
+
+// Tamaño de pagina del registro de auditoria
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 200
+)
+
+// Funcion para consultar el registro de auditoria (GET), los cambios mas nuevos primero.
+// Filtros opcionales: user_id, action, target_type, target_id, hotel_id, from y to (RFC3339 o YYYY-MM-DD).
+// Para la pagina siguiente se manda el next_cursor de la respuesta como cursor
+func (controller Controller) ListAudit(ctx *gin.Context) {
+	query, err := auditQuery(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", err.Error()),
+		})
+		return
+	}
+
+	query.Cursor = strings.TrimSpace(ctx.Query("cursor"))
+	query.Limit = defaultAuditPageSize
+	if rawLimit := ctx.Query("limit"); rawLimit != "" {
+		limit, err := strconv.Atoi(rawLimit)
+		if err != nil || limit < 1 || limit > maxAuditPageSize {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("invalid request: limit must be between 1 and %d", maxAuditPageSize),
+			})
+			return
+		}
+		query.Limit = limit
+	}
+
+	page, err := controller.service.ListAudit(ctx.Request.Context(), query)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, hotelsDomain.ErrInvalidAuditQuery) {
+			status = http.StatusBadRequest
+		}
+		ctx.JSON(status, gin.H{
+			"error": fmt.Sprintf("error getting audit entries: %s", err.Error()),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+// Funcion para exportar el registro de auditoria como JSON Lines (GET), una entrada por linea y con los mismos filtros
+// que ListAudit pero sin paginar. Las entradas se escriben a medida que se leen de la base
+func (controller Controller) ExportAudit(ctx *gin.Context) {
+	query, err := auditQuery(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", err.Error()),
+		})
+		return
+	}
+
+	ctx.Header("Content-Type", "application/x-ndjson")
+	ctx.Header("Content-Disposition", `attachment; filename="audit.jsonl"`)
+	ctx.Status(http.StatusOK)
+
+	// Encode agrega el salto de linea despues de cada entrada
+	encoder := json.NewEncoder(ctx.Writer)
+	err = controller.service.ExportAudit(ctx.Request.Context(), query, func(entry hotelsDomain.AuditEntry) error {
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+		ctx.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		// La respuesta ya empezo, el cliente recibe el archivo cortado
+		log.Printf("error exporting audit entries: %v", err)
+	}
+}
+
+// auditQuery valida los filtros del registro de auditoria que vienen en la URL
+func auditQuery(ctx *gin.Context) (hotelsDomain.AuditQuery, error) {
+	query := hotelsDomain.AuditQuery{
+		UserID:     strings.TrimSpace(ctx.Query("user_id")),
+		Action:     strings.TrimSpace(ctx.Query("action")),
+		TargetType: strings.TrimSpace(ctx.Query("target_type")),
+		TargetID:   strings.TrimSpace(ctx.Query("target_id")),
+		HotelID:    strings.TrimSpace(ctx.Query("hotel_id")),
+	}
+
+	var err error
+	if query.From, err = auditTime(ctx.Query("from"), false); err != nil {
+		return hotelsDomain.AuditQuery{}, fmt.Errorf("from must be RFC3339 or YYYY-MM-DD")
+	}
+	if query.To, err = auditTime(ctx.Query("to"), true); err != nil {
+		return hotelsDomain.AuditQuery{}, fmt.Errorf("to must be RFC3339 or YYYY-MM-DD")
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && !query.To.After(query.From) {
+		return hotelsDomain.AuditQuery{}, fmt.Errorf("to must be after from")
+	}
+	return query, nil
+}
+
+// auditTime parsea una fecha del filtro. Un to con solo la fecha incluye ese dia entero
+func auditTime(value string, endOfDay bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at.UTC(), nil
+	}
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
+}
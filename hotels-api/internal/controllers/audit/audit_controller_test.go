@@ -0,0 +1,195 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	config "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/config"
+	hotelsDomain "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/domain/hotels"
+	middleware "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/middlewares"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type mockService struct {
+	listAuditFn   func(ctx context.Context, query hotelsDomain.AuditQuery) (hotelsDomain.AuditPage, error)
+	exportAuditFn func(ctx context.Context, query hotelsDomain.AuditQuery, write func(hotelsDomain.AuditEntry) error) error
+}
+
+func (m *mockService) ListAudit(ctx context.Context, query hotelsDomain.AuditQuery) (hotelsDomain.AuditPage, error) {
+	return m.listAuditFn(ctx, query)
+}
+
+func (m *mockService) ExportAudit(ctx context.Context, query hotelsDomain.AuditQuery, write func(hotelsDomain.AuditEntry) error) error {
+	return m.exportAuditFn(ctx, query, write)
+}
+
+func setupRouter(ctrl Controller) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(middleware.RequestID())
+
+	jwtMiddleware := middleware.NewJWTMiddleware(config.JWTSecret)
+	adminRoutes := r.Group("/admin", jwtMiddleware.Authenticate(), middleware.AdminOnly())
+	{
+		adminRoutes.GET("/audit", ctrl.ListAudit)
+		adminRoutes.GET("/audit/export", ctrl.ExportAudit)
+	}
+	return r
+}
+
+func makeJWT(t *testing.T, userType string, userID any) string {
+	t.Helper()
+
+	now := time.Now().UTC()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"tipo":    userType,
+		"user_id": userID,
+		"iat":     now.Unix(),
+		"exp":     now.Add(1 * time.Hour).Unix(),
+	})
+
+	signed, err := token.SignedString([]byte(config.JWTSecret))
+	if err != nil {
+		t.Fatalf("error signing token: %v", err)
+	}
+	return signed
+}
+
+func authBearer(token string) string {
+	return "Bearer " + token
+}
+
+func adminGet(t *testing.T, r *gin.Engine, url string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("Authorization", authBearer(makeJWT(t, "administrador", int64(999))))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestListAudit_OK(t *testing.T) {
+	var got hotelsDomain.AuditQuery
+	ms := &mockService{
+		listAuditFn: func(ctx context.Context, query hotelsDomain.AuditQuery) (hotelsDomain.AuditPage, error) {
+			got = query
+			return hotelsDomain.AuditPage{
+				Entries:    []hotelsDomain.AuditEntry{{ID: "e2", Action: hotelsDomain.AuditActionHotelUpdate, Changes: []hotelsDomain.AuditChange{}}},
+				NextCursor: "e2",
+			}, nil
+		},
+	}
+	r := setupRouter(NewController(ms))
+
+	w := adminGet(t, r, "/admin/audit?user_id=7&action=hotel.update&target_type=hotel&hotel_id=h1&from=2026-01-01&to=2026-01-31&limit=10&cursor=abc")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", w.Code, w.Body.String())
+	}
+	if got.UserID != "7" || got.Action != hotelsDomain.AuditActionHotelUpdate || got.TargetType != "hotel" || got.HotelID != "h1" || got.Limit != 10 || got.Cursor != "abc" {
+		t.Fatalf("unexpected query %+v", got)
+	}
+	// Un to con solo la fecha incluye ese dia entero
+	if !got.From.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) || !got.To.Equal(time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected range %v - %v", got.From, got.To)
+	}
+
+	var page hotelsDomain.AuditPage
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("error decoding page: %v", err)
+	}
+	if len(page.Entries) != 1 || page.NextCursor != "e2" {
+		t.Fatalf("unexpected page %+v", page)
+	}
+}
+
+func TestListAudit_BadRequest(t *testing.T) {
+	ms := &mockService{
+		listAuditFn: func(ctx context.Context, query hotelsDomain.AuditQuery) (hotelsDomain.AuditPage, error) {
+			if query.Cursor != "" {
+				return hotelsDomain.AuditPage{}, fmt.Errorf("%w: invalid cursor", hotelsDomain.ErrInvalidAuditQuery)
+			}
+			return hotelsDomain.AuditPage{}, nil
+		},
+	}
+	r := setupRouter(NewController(ms))
+
+	tests := []string{
+		"/admin/audit?limit=0",
+		"/admin/audit?limit=500",
+		"/admin/audit?from=yesterday",
+		"/admin/audit?from=2026-02-01&to=2026-01-01",
+		"/admin/audit?cursor=not-an-id",
+	}
+	for _, url := range tests {
+		if w := adminGet(t, r, url); w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d body=%s", url, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestListAudit_ForbiddenForNonAdmin(t *testing.T) {
+	r := setupRouter(NewController(&mockService{}))
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/audit", nil)
+	req.Header.Set("Authorization", authBearer(makeJWT(t, "cliente", int64(1))))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestExportAudit_JSONLines(t *testing.T) {
+	var got hotelsDomain.AuditQuery
+	ms := &mockService{
+		exportAuditFn: func(ctx context.Context, query hotelsDomain.AuditQuery, write func(hotelsDomain.AuditEntry) error) error {
+			got = query
+			for _, id := range []string{"e3", "e2", "e1"} {
+				if err := write(hotelsDomain.AuditEntry{ID: id, TargetType: hotelsDomain.AuditTargetReservation}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	r := setupRouter(NewController(ms))
+
+	w := adminGet(t, r, "/admin/audit/export?target_type=reservation")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", w.Code, w.Body.String())
+	}
+	if got.TargetType != hotelsDomain.AuditTargetReservation {
+		t.Fatalf("unexpected query %+v", got)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/x-ndjson" {
+		t.Fatalf("expected application/x-ndjson, got %s", contentType)
+	}
+	if disposition := w.Header().Get("Content-Disposition"); !strings.Contains(disposition, "audit.jsonl") {
+		t.Fatalf("expected attachment audit.jsonl, got %s", disposition)
+	}
+
+	// Una entrada JSON por linea, en el orden del servicio
+	var ids []string
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var entry hotelsDomain.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("error decoding line %q: %v", scanner.Text(), err)
+		}
+		ids = append(ids, entry.ID)
+	}
+	if strings.Join(ids, ",") != "e3,e2,e1" {
+		t.Fatalf("expected e3,e2,e1, got %v", ids)
+	}
+}
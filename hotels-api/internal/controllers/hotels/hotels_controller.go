@@ -2,9 +2,16 @@ package hotels
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"mime"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	hotelsDomain "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/domain/hotels"
 
@@ -14,17 +21,32 @@ import (
 // Estas son las funciones que se encargan de interactuar con el servicio, se encargan de recibir las peticiones y enviar las respuestas (Vienen del service)
 type Service interface {
 	GetHotelByID(ctx context.Context, id string) (hotelsDomain.Hotel, error)
+	ListHotels(ctx context.Context, query hotelsDomain.HotelListQuery) (hotelsDomain.HotelPage, error)
+	ImportHotels(ctx context.Context, format string, body io.Reader, dryRun bool) (hotelsDomain.HotelImportReport, error)
+	ExportHotels(ctx context.Context, format string, w io.Writer) error
 	Create(ctx context.Context, hotel hotelsDomain.Hotel) (string, error)
 	Update(ctx context.Context, hotel hotelsDomain.Hotel) error
-	Delete(ctx context.Context, id string) error
+	Patch(ctx context.Context, id string, version int64, patch map[string]json.RawMessage) (hotelsDomain.Hotel, error)
+	Delete(ctx context.Context, id string, version int64) error
 	CreateReservation(ctx context.Context, reservation hotelsDomain.Reservation) (string, error)
 	GetReservationByID(ctx context.Context, id string) (hotelsDomain.Reservation, error)
 	CancelReservation(ctx context.Context, id string) error
+	UpdateReservationStatus(ctx context.Context, id string, status string) error
 	GetReservationsByHotelID(ctx context.Context, hotelID string) ([]hotelsDomain.Reservation, error)
 	GetReservationsByUserID(ctx context.Context, userID string) ([]hotelsDomain.Reservation, error)
 	// IMPORTANT: el orden semántico es (hotelID, userID) para mantener consistencia con el service/repositories.
 	GetReservationsByUserAndHotelID(ctx context.Context, hotelID, userID string) ([]hotelsDomain.Reservation, error)
 	GetAvailability(ctx context.Context, hotelIDs []string, checkIn, checkOut string) (map[string]bool, error)
+	GetRoomTypes(ctx context.Context, hotelID string) ([]hotelsDomain.RoomType, error)
+	CreateRoomType(ctx context.Context, hotelID string, roomType hotelsDomain.RoomType) (string, error)
+	UpdateRoomType(ctx context.Context, hotelID string, roomType hotelsDomain.RoomType) error
+	DeleteRoomType(ctx context.Context, hotelID string, roomTypeID string) error
+	Quote(ctx context.Context, hotelID string, roomTypeID string, checkIn, checkOut time.Time) (hotelsDomain.Quote, error)
+	GetCalendar(ctx context.Context, hotelID string, from, to time.Time) (hotelsDomain.Calendar, error)
+	GetRateRules(ctx context.Context, hotelID string) ([]hotelsDomain.RateRule, error)
+	CreateRateRule(ctx context.Context, hotelID string, rule hotelsDomain.RateRule) (string, error)
+	UpdateRateRule(ctx context.Context, hotelID string, rule hotelsDomain.RateRule) error
+	DeleteRateRule(ctx context.Context, hotelID string, ruleID string) error
 }
 
 type Controller struct {
@@ -51,10 +73,165 @@ func (controller Controller) GetHotelByID(ctx *gin.Context) {
 		return
 	}
 
-	// Devuelve el hotel encontrado
+	// Devuelve el hotel encontrado con su version como ETag (se manda en If-Match para modificarlo)
+	ctx.Header("ETag", hotelETag(hotel.Version))
 	ctx.JSON(http.StatusOK, hotel)
 }
 
+// hotelETag arma el ETag de un hotel a partir de su version
+func hotelETag(version int64) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// ifMatchVersion lee la version del hotel del header If-Match (el ETag de GET /hotels/:hotel_id).
+// Sin If-Match responde 428 y con un valor que no es un ETag de hotel responde 412; en los dos casos devuelve false
+func ifMatchVersion(ctx *gin.Context) (int64, bool) {
+	ifMatch := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if ifMatch == "" {
+		ctx.JSON(http.StatusPreconditionRequired, gin.H{
+			"error": "If-Match header with the hotel ETag is required",
+		})
+		return 0, false
+	}
+
+	version, err := strconv.ParseInt(strings.Trim(ifMatch, `"`), 10, 64)
+	if err != nil || version < 0 || ifMatch != hotelETag(version) {
+		ctx.JSON(http.StatusPreconditionFailed, gin.H{
+			"error": fmt.Sprintf("If-Match %s does not match the hotel ETag", ifMatch),
+		})
+		return 0, false
+	}
+	return version, true
+}
+
+// hotelWriteStatus devuelve el codigo HTTP de un error al modificar o borrar un hotel
+func hotelWriteStatus(err error) int {
+	switch {
+	case errors.Is(err, hotelsDomain.ErrHotelNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, hotelsDomain.ErrVersionConflict):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, hotelsDomain.ErrInvalidHotelPatch), errors.Is(err, hotelsDomain.ErrInvalidLocation):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// Cantidad de hoteles por pagina por defecto y maxima de GET /hotels
+const (
+	defaultHotelsPageSize = 50
+	maxHotelsPageSize     = 200
+)
+
+// Funcion para listar los hoteles de a paginas (GET) con filtros (city, country, min_rating), orden (sort) y campos (fields).
+// Se pide la pagina siguiente con el next_cursor de la respuesta
+func (controller Controller) ListHotels(ctx *gin.Context) {
+	controller.listHotels(ctx, false)
+}
+
+// Funcion para el listado de hoteles de admin (GET): igual que ListHotels, y con include_deleted=true
+// tambien trae los hoteles borrados cuyo evento DELETE todavia no se publico (con deleted_at)
+func (controller Controller) AdminListHotels(ctx *gin.Context) {
+	includeDeleted := false
+	if rawIncludeDeleted := ctx.Query("include_deleted"); rawIncludeDeleted != "" {
+		var err error
+		includeDeleted, err = strconv.ParseBool(rawIncludeDeleted)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid request: include_deleted must be true or false",
+			})
+			return
+		}
+	}
+	controller.listHotels(ctx, includeDeleted)
+}
+
+// listHotels valida los parametros del listado, obtiene la pagina y, si vino fields, devuelve solo esos campos
+func (controller Controller) listHotels(ctx *gin.Context, includeDeleted bool) {
+	query := hotelsDomain.HotelListQuery{
+		City:           strings.TrimSpace(ctx.Query("city")),
+		Country:        strings.TrimSpace(ctx.Query("country")),
+		Sort:           strings.TrimSpace(ctx.Query("sort")),
+		Cursor:         strings.TrimSpace(ctx.Query("cursor")),
+		Limit:          defaultHotelsPageSize,
+		IncludeDeleted: includeDeleted,
+	}
+
+	// Valida el limit que viene en la URL (opcional)
+	if rawLimit := ctx.Query("limit"); rawLimit != "" {
+		limit, err := strconv.Atoi(rawLimit)
+		if err != nil || limit < 1 || limit > maxHotelsPageSize {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("invalid request: limit must be between 1 and %d", maxHotelsPageSize),
+			})
+			return
+		}
+		query.Limit = limit
+	}
+
+	// Valida el rating minimo (opcional)
+	if rawMinRating := ctx.Query("min_rating"); rawMinRating != "" {
+		minRating, err := strconv.ParseFloat(rawMinRating, 64)
+		if err != nil || minRating < 0 || minRating > 5 {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid request: min_rating must be a number between 0 and 5",
+			})
+			return
+		}
+		query.MinRating = &minRating
+	}
+
+	// Campos a devolver, separados por coma (opcional)
+	for _, field := range strings.Split(ctx.Query("fields"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			query.Fields = append(query.Fields, field)
+		}
+	}
+
+	// Obtiene la pagina de hoteles
+	page, err := controller.service.ListHotels(ctx.Request.Context(), query)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, hotelsDomain.ErrInvalidCursor) || errors.Is(err, hotelsDomain.ErrInvalidHotelQuery) {
+			status = http.StatusBadRequest
+		}
+		ctx.JSON(status, gin.H{
+			"error": fmt.Sprintf("error listing hotels: %s", err.Error()),
+		})
+		return
+	}
+
+	// Devuelve la pagina de hoteles
+	if len(query.Fields) == 0 {
+		ctx.JSON(http.StatusOK, page)
+		return
+	}
+	response := gin.H{"hotels": projectHotels(page.Hotels, query.Fields)}
+	if page.NextCursor != "" {
+		response["next_cursor"] = page.NextCursor
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// projectHotels deja en cada hotel solo los campos pedidos (y el id)
+func projectHotels(hotels []hotelsDomain.Hotel, fields []string) []map[string]interface{} {
+	projected := make([]map[string]interface{}, 0, len(hotels))
+	for _, hotel := range hotels {
+		var full map[string]interface{}
+		data, _ := json.Marshal(hotel)
+		_ = json.Unmarshal(data, &full)
+
+		item := map[string]interface{}{"id": hotel.ID}
+		for _, field := range fields {
+			if value, ok := full[field]; ok {
+				item[field] = value
+			}
+		}
+		projected = append(projected, item)
+	}
+	return projected
+}
+
 // Funcion para crear un hotel (POST)
 func (controller Controller) Create(ctx *gin.Context) {
 	// Le da formato al hotel que viene en el body de la peticiona un DAO
@@ -69,6 +246,12 @@ func (controller Controller) Create(ctx *gin.Context) {
 	// Crea el hotel
 	id, err := controller.service.Create(ctx.Request.Context(), hotel)
 	if err != nil {
+		if errors.Is(err, hotelsDomain.ErrInvalidLocation) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("invalid request: %s", err.Error()),
+			})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("error creating hotel: %s", err.Error()),
 		})
@@ -81,7 +264,8 @@ func (controller Controller) Create(ctx *gin.Context) {
 	})
 }
 
-// Funcion para actualizar un hotel (PUT)
+// Funcion para reemplazar un hotel (PUT), los campos que no vienen en el body quedan vacios.
+// Necesita If-Match con el ETag del hotel: si otro admin lo cambio mientras tanto responde 412
 func (controller Controller) Update(ctx *gin.Context) {
 	// Valida el ID del hotel que viene en la URL
 	id := strings.TrimSpace(ctx.Param("hotel_id"))
@@ -95,31 +279,89 @@ func (controller Controller) Update(ctx *gin.Context) {
 		return
 	}
 
-	// Asigna el ID al hotel
+	version, ok := ifMatchVersion(ctx)
+	if !ok {
+		return
+	}
+
+	// Asigna el ID y la version esperada al hotel (la del body se ignora)
 	hotel.ID = id
+	hotel.Version = version
 
 	// Actualiza el hotel
 	if err := controller.service.Update(ctx.Request.Context(), hotel); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+		status := hotelWriteStatus(err)
+		if status == http.StatusBadRequest {
+			ctx.JSON(status, gin.H{
+				"error": fmt.Sprintf("invalid request: %s", err.Error()),
+			})
+			return
+		}
+		ctx.JSON(status, gin.H{
 			"error": fmt.Sprintf("error updating hotel: %s", err.Error()),
 		})
 		return
 	}
 
-	// Devuelve el ID del hotel actualizado
+	// Devuelve el ID del hotel actualizado con el ETag de la nueva version
+	ctx.Header("ETag", hotelETag(version+1))
 	ctx.JSON(http.StatusOK, gin.H{
 		"message": id,
 	})
 }
 
-// Funcion para eliminar un hotel (DELETE)
+// Funcion para modificar algunos campos de un hotel (PATCH) con un JSON Merge Patch: los campos en null se vacian.
+// Necesita If-Match con el ETag del hotel, igual que PUT. Devuelve el hotel actualizado
+func (controller Controller) Patch(ctx *gin.Context) {
+	// Valida el ID del hotel que viene en la URL
+	id := strings.TrimSpace(ctx.Param("hotel_id"))
+
+	// El body tiene que ser un objeto JSON (application/merge-patch+json o application/json)
+	var patch map[string]json.RawMessage
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&patch); err != nil || patch == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: body must be a JSON object",
+		})
+		return
+	}
+
+	version, ok := ifMatchVersion(ctx)
+	if !ok {
+		return
+	}
+
+	hotel, err := controller.service.Patch(ctx.Request.Context(), id, version, patch)
+	if err != nil {
+		status := hotelWriteStatus(err)
+		if status == http.StatusBadRequest {
+			ctx.JSON(status, gin.H{
+				"error": fmt.Sprintf("invalid request: %s", err.Error()),
+			})
+			return
+		}
+		ctx.JSON(status, gin.H{
+			"error": fmt.Sprintf("error patching hotel: %s", err.Error()),
+		})
+		return
+	}
+
+	ctx.Header("ETag", hotelETag(hotel.Version))
+	ctx.JSON(http.StatusOK, hotel)
+}
+
+// Funcion para eliminar un hotel (DELETE), necesita If-Match con el ETag del hotel
 func (controller Controller) Delete(ctx *gin.Context) {
 	// Valida el ID del hotel que viene en la URL
 	id := strings.TrimSpace(ctx.Param("hotel_id"))
 
+	version, ok := ifMatchVersion(ctx)
+	if !ok {
+		return
+	}
+
 	// Elimina el hotel
-	if err := controller.service.Delete(ctx.Request.Context(), id); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+	if err := controller.service.Delete(ctx.Request.Context(), id, version); err != nil {
+		ctx.JSON(hotelWriteStatus(err), gin.H{
 			"error": fmt.Sprintf("error deleting hotel: %s", err.Error()),
 		})
 		return
@@ -170,7 +412,14 @@ func (controller Controller) CreateReservation(ctx *gin.Context) {
 	// Crea la reserva
 	id, err := controller.service.CreateReservation(ctx.Request.Context(), reservation)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, hotelsDomain.ErrNoAvailability):
+			status = http.StatusConflict
+		case errors.Is(err, hotelsDomain.ErrInvalidDateRange), errors.Is(err, hotelsDomain.ErrInvalidRoomType):
+			status = http.StatusBadRequest
+		}
+		ctx.JSON(status, gin.H{
 			"error": fmt.Sprintf("error creating reservation: %s", err.Error()),
 		})
 		return
@@ -220,9 +469,13 @@ func (controller Controller) CancelReservation(ctx *gin.Context) {
 		return
 	}
 
-	// Cancela la reserva
+	// Cancela la reserva (pasa a estado CANCELLED)
 	if err := controller.service.CancelReservation(ctx.Request.Context(), id); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+		status := http.StatusInternalServerError
+		if errors.Is(err, hotelsDomain.ErrInvalidStatusTransition) {
+			status = http.StatusConflict
+		}
+		ctx.JSON(status, gin.H{
 			"error": fmt.Sprintf("error canceling reservation: %s", err.Error()),
 		})
 		return
@@ -234,6 +487,43 @@ func (controller Controller) CancelReservation(ctx *gin.Context) {
 	})
 }
 
+// Funcion para registrar el check-in de una reserva (POST, solo admins)
+func (controller Controller) CheckInReservation(ctx *gin.Context) {
+	controller.updateReservationStatus(ctx, hotelsDomain.ReservationStatusCheckedIn)
+}
+
+// Funcion para registrar el check-out de una reserva (POST, solo admins)
+func (controller Controller) CheckOutReservation(ctx *gin.Context) {
+	controller.updateReservationStatus(ctx, hotelsDomain.ReservationStatusCompleted)
+}
+
+// Funcion para marcar que el huesped no se presento (POST, solo admins)
+func (controller Controller) NoShowReservation(ctx *gin.Context) {
+	controller.updateReservationStatus(ctx, hotelsDomain.ReservationStatusNoShow)
+}
+
+// updateReservationStatus cambia el estado de la reserva de la URL; una transicion invalida devuelve 409
+func (controller Controller) updateReservationStatus(ctx *gin.Context, status string) {
+	// Valida el ID de la reserva que viene en la URL
+	id := strings.TrimSpace(ctx.Param("id"))
+
+	if err := controller.service.UpdateReservationStatus(ctx.Request.Context(), id, status); err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, hotelsDomain.ErrInvalidStatusTransition) {
+			code = http.StatusConflict
+		}
+		ctx.JSON(code, gin.H{
+			"error": fmt.Sprintf("error updating reservation status: %s", err.Error()),
+		})
+		return
+	}
+
+	// Devuelve el ID de la reserva actualizada
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": id,
+	})
+}
+
 func (controller Controller) GetReservationsByHotelID(ctx *gin.Context) {
 	// Valida el ID del hotel que viene en la URL
 	hotelID := strings.TrimSpace(ctx.Param("hotel_id"))
@@ -372,3 +662,389 @@ func (controller Controller) GetAvailability(ctx *gin.Context) {
 	// Devuelve la disponibilidad de los hoteles
 	ctx.JSON(http.StatusOK, availability)
 }
+
+// Funcion para obtener los tipos de habitacion de un hotel (GET)
+func (controller Controller) GetRoomTypes(ctx *gin.Context) {
+	// Valida el ID del hotel que viene en la URL
+	hotelID := strings.TrimSpace(ctx.Param("hotel_id"))
+
+	roomTypes, err := controller.service.GetRoomTypes(ctx.Request.Context(), hotelID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": fmt.Sprintf("error getting room types: %s", err.Error()),
+		})
+		return
+	}
+
+	// Devuelve los tipos de habitacion del hotel
+	ctx.JSON(http.StatusOK, roomTypes)
+}
+
+// Funcion para agregar un tipo de habitacion a un hotel (POST, solo admins)
+func (controller Controller) CreateRoomType(ctx *gin.Context) {
+	// Valida el ID del hotel que viene en la URL
+	hotelID := strings.TrimSpace(ctx.Param("hotel_id"))
+
+	var roomType hotelsDomain.RoomType
+	if err := ctx.ShouldBindJSON(&roomType); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", err.Error()),
+		})
+		return
+	}
+
+	id, err := controller.service.CreateRoomType(ctx.Request.Context(), hotelID, roomType)
+	if err != nil {
+		ctx.JSON(roomTypeErrorStatus(err), gin.H{
+			"error": fmt.Sprintf("error creating room type: %s", err.Error()),
+		})
+		return
+	}
+
+	// Devuelve el ID del tipo de habitacion creado
+	ctx.JSON(http.StatusCreated, gin.H{
+		"id": id,
+	})
+}
+
+// Funcion para reemplazar un tipo de habitacion de un hotel (PUT, solo admins)
+func (controller Controller) UpdateRoomType(ctx *gin.Context) {
+	// Valida los IDs que vienen en la URL
+	hotelID := strings.TrimSpace(ctx.Param("hotel_id"))
+	roomTypeID := strings.TrimSpace(ctx.Param("room_type_id"))
+
+	var roomType hotelsDomain.RoomType
+	if err := ctx.ShouldBindJSON(&roomType); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", err.Error()),
+		})
+		return
+	}
+
+	// Asigna el ID al tipo de habitacion
+	roomType.ID = roomTypeID
+
+	if err := controller.service.UpdateRoomType(ctx.Request.Context(), hotelID, roomType); err != nil {
+		ctx.JSON(roomTypeErrorStatus(err), gin.H{
+			"error": fmt.Sprintf("error updating room type: %s", err.Error()),
+		})
+		return
+	}
+
+	// Devuelve el ID del tipo de habitacion actualizado
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": roomTypeID,
+	})
+}
+
+// Funcion para eliminar un tipo de habitacion de un hotel (DELETE, solo admins)
+func (controller Controller) DeleteRoomType(ctx *gin.Context) {
+	// Valida los IDs que vienen en la URL
+	hotelID := strings.TrimSpace(ctx.Param("hotel_id"))
+	roomTypeID := strings.TrimSpace(ctx.Param("room_type_id"))
+
+	if err := controller.service.DeleteRoomType(ctx.Request.Context(), hotelID, roomTypeID); err != nil {
+		ctx.JSON(roomTypeErrorStatus(err), gin.H{
+			"error": fmt.Sprintf("error deleting room type: %s", err.Error()),
+		})
+		return
+	}
+
+	// Devuelve el ID del tipo de habitacion eliminado
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": roomTypeID,
+	})
+}
+
+// roomTypeErrorStatus traduce los errores de tipos de habitacion a codigos HTTP
+func roomTypeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, hotelsDomain.ErrInvalidRoomType):
+		return http.StatusBadRequest
+	case errors.Is(err, hotelsDomain.ErrRoomTypeNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// Funcion para cotizar una estadia sin reservar (POST)
+func (controller Controller) Quote(ctx *gin.Context) {
+	// Valida el ID del hotel que viene en la URL
+	hotelID := strings.TrimSpace(ctx.Param("hotel_id"))
+
+	var req hotelsDomain.QuoteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", err.Error()),
+		})
+		return
+	}
+
+	// Las fechas vienen en formato YYYY-MM-DD, igual que en /hotels/availability
+	checkIn, err := time.Parse("2006-01-02", req.CheckIn)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid check_in: %s", err.Error()),
+		})
+		return
+	}
+	checkOut, err := time.Parse("2006-01-02", req.CheckOut)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid check_out: %s", err.Error()),
+		})
+		return
+	}
+
+	quote, err := controller.service.Quote(ctx.Request.Context(), hotelID, req.RoomTypeID, checkIn, checkOut)
+	if err != nil {
+		status := http.StatusNotFound
+		if errors.Is(err, hotelsDomain.ErrInvalidDateRange) || errors.Is(err, hotelsDomain.ErrInvalidRoomType) {
+			status = http.StatusBadRequest
+		}
+		ctx.JSON(status, gin.H{
+			"error": fmt.Sprintf("error quoting stay: %s", err.Error()),
+		})
+		return
+	}
+
+	// Devuelve el desglose de precio
+	ctx.JSON(http.StatusOK, quote)
+}
+
+// GetCalendar devuelve habitaciones libres y precio por noche del hotel entre from y to (excluye to)
+func (controller Controller) GetCalendar(ctx *gin.Context) {
+	// Valida el ID del hotel que viene en la URL
+	hotelID := strings.TrimSpace(ctx.Param("hotel_id"))
+
+	// Las fechas vienen en formato YYYY-MM-DD, igual que en /hotels/availability
+	from, err := time.Parse("2006-01-02", ctx.Query("from"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid from: %s", err.Error()),
+		})
+		return
+	}
+	to, err := time.Parse("2006-01-02", ctx.Query("to"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid to: %s", err.Error()),
+		})
+		return
+	}
+
+	calendar, err := controller.service.GetCalendar(ctx.Request.Context(), hotelID, from, to)
+	if err != nil {
+		status := http.StatusNotFound
+		if errors.Is(err, hotelsDomain.ErrInvalidDateRange) {
+			status = http.StatusBadRequest
+		}
+		ctx.JSON(status, gin.H{
+			"error": fmt.Sprintf("error getting calendar: %s", err.Error()),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, calendar)
+}
+
+// Funcion para obtener las reglas de tarifa de un hotel (GET, solo admins)
+func (controller Controller) GetRateRules(ctx *gin.Context) {
+	// Valida el ID del hotel que viene en la URL
+	hotelID := strings.TrimSpace(ctx.Param("hotel_id"))
+
+	rules, err := controller.service.GetRateRules(ctx.Request.Context(), hotelID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": fmt.Sprintf("error getting rate rules: %s", err.Error()),
+		})
+		return
+	}
+
+	// Devuelve las reglas de tarifa del hotel
+	ctx.JSON(http.StatusOK, rules)
+}
+
+// Funcion para agregar una regla de tarifa a un hotel (POST, solo admins)
+func (controller Controller) CreateRateRule(ctx *gin.Context) {
+	// Valida el ID del hotel que viene en la URL
+	hotelID := strings.TrimSpace(ctx.Param("hotel_id"))
+
+	var rule hotelsDomain.RateRule
+	if err := ctx.ShouldBindJSON(&rule); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", err.Error()),
+		})
+		return
+	}
+
+	id, err := controller.service.CreateRateRule(ctx.Request.Context(), hotelID, rule)
+	if err != nil {
+		ctx.JSON(rateRuleErrorStatus(err), gin.H{
+			"error": fmt.Sprintf("error creating rate rule: %s", err.Error()),
+		})
+		return
+	}
+
+	// Devuelve el ID de la regla creada
+	ctx.JSON(http.StatusCreated, gin.H{
+		"id": id,
+	})
+}
+
+// Funcion para reemplazar una regla de tarifa de un hotel (PUT, solo admins)
+func (controller Controller) UpdateRateRule(ctx *gin.Context) {
+	// Valida los IDs que vienen en la URL
+	hotelID := strings.TrimSpace(ctx.Param("hotel_id"))
+	ruleID := strings.TrimSpace(ctx.Param("rule_id"))
+
+	var rule hotelsDomain.RateRule
+	if err := ctx.ShouldBindJSON(&rule); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", err.Error()),
+		})
+		return
+	}
+
+	// Asigna el ID a la regla
+	rule.ID = ruleID
+
+	if err := controller.service.UpdateRateRule(ctx.Request.Context(), hotelID, rule); err != nil {
+		ctx.JSON(rateRuleErrorStatus(err), gin.H{
+			"error": fmt.Sprintf("error updating rate rule: %s", err.Error()),
+		})
+		return
+	}
+
+	// Devuelve el ID de la regla actualizada
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": ruleID,
+	})
+}
+
+// Funcion para eliminar una regla de tarifa de un hotel (DELETE, solo admins)
+func (controller Controller) DeleteRateRule(ctx *gin.Context) {
+	// Valida los IDs que vienen en la URL
+	hotelID := strings.TrimSpace(ctx.Param("hotel_id"))
+	ruleID := strings.TrimSpace(ctx.Param("rule_id"))
+
+	if err := controller.service.DeleteRateRule(ctx.Request.Context(), hotelID, ruleID); err != nil {
+		ctx.JSON(rateRuleErrorStatus(err), gin.H{
+			"error": fmt.Sprintf("error deleting rate rule: %s", err.Error()),
+		})
+		return
+	}
+
+	// Devuelve el ID de la regla eliminada
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": ruleID,
+	})
+}
+
+// rateRuleErrorStatus traduce los errores de reglas de tarifa a codigos HTTP
+func rateRuleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, hotelsDomain.ErrInvalidRateRule):
+		return http.StatusBadRequest
+	case errors.Is(err, hotelsDomain.ErrRateRuleNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// Funcion para importar hoteles desde un archivo CSV o NDJSON (POST). El formato sale del parametro format
+// o del Content-Type (text/csv, application/x-ndjson). Con dry_run=true solo valida las filas.
+// Responde el reporte con los errores por fila; si el archivo supera el limite responde 413 con lo procesado hasta ahi
+func (controller Controller) ImportHotels(ctx *gin.Context) {
+	format := importFormat(ctx)
+	if format == "" {
+		ctx.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error": "unsupported import format: use format=csv or format=ndjson, or Content-Type text/csv or application/x-ndjson",
+		})
+		return
+	}
+
+	dryRun := false
+	if rawDryRun := ctx.Query("dry_run"); rawDryRun != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(rawDryRun)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid request: dry_run must be a boolean",
+			})
+			return
+		}
+	}
+
+	report, err := controller.service.ImportHotels(ctx.Request.Context(), format, ctx.Request.Body, dryRun)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesErr):
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error":  fmt.Sprintf("import file exceeds %d bytes", maxBytesErr.Limit),
+				"report": report,
+			})
+		case errors.Is(err, hotelsDomain.ErrUnknownHotelFormat), errors.Is(err, hotelsDomain.ErrInvalidImport):
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error":  fmt.Sprintf("invalid request: %s", err.Error()),
+				"report": report,
+			})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"error":  fmt.Sprintf("error importing hotels: %s", err.Error()),
+				"report": report,
+			})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+// importFormat devuelve el formato de la importacion, vacio si no se reconoce
+func importFormat(ctx *gin.Context) string {
+	if format := strings.ToLower(strings.TrimSpace(ctx.Query("format"))); format != "" {
+		if format == hotelsDomain.HotelFormatCSV || format == hotelsDomain.HotelFormatNDJSON {
+			return format
+		}
+		return ""
+	}
+
+	mediaType, _, err := mime.ParseMediaType(ctx.GetHeader("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	switch mediaType {
+	case "text/csv":
+		return hotelsDomain.HotelFormatCSV
+	case "application/x-ndjson", "application/jsonl", "application/json-lines":
+		return hotelsDomain.HotelFormatNDJSON
+	}
+	return ""
+}
+
+// Funcion para exportar todos los hoteles (GET) en CSV o NDJSON segun el parametro format (ndjson por defecto).
+// El archivo se escribe a medida que se leen los hoteles, asi que un error a mitad de camino lo deja cortado
+func (controller Controller) ExportHotels(ctx *gin.Context) {
+	format := strings.ToLower(strings.TrimSpace(ctx.DefaultQuery("format", hotelsDomain.HotelFormatNDJSON)))
+	switch format {
+	case hotelsDomain.HotelFormatCSV:
+		ctx.Header("Content-Type", "text/csv; charset=utf-8")
+		ctx.Header("Content-Disposition", `attachment; filename="hotels.csv"`)
+	case hotelsDomain.HotelFormatNDJSON:
+		ctx.Header("Content-Type", "application/x-ndjson")
+		ctx.Header("Content-Disposition", `attachment; filename="hotels.jsonl"`)
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: format must be csv or ndjson",
+		})
+		return
+	}
+	ctx.Status(http.StatusOK)
+
+	if err := controller.service.ExportHotels(ctx.Request.Context(), format, ctx.Writer); err != nil {
+		// La respuesta ya empezo, el cliente recibe el archivo cortado
+		log.Printf("error exporting hotels: %v", err)
+	}
+}
@@ -2,7 +2,9 @@ package hotels
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -12,6 +14,7 @@ import (
 	config "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/config"
 	hotelsDomain "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/domain/hotels"
 	middleware "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/middlewares"
+	repositoriesIdempotency "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/repositories/idempotency"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -21,14 +24,50 @@ type mockService struct {
 	getHotelByIDFn                  func(context.Context, string) (hotelsDomain.Hotel, error)
 	createHotelFn                   func(context.Context, hotelsDomain.Hotel) (string, error)
 	updateHotelFn                   func(context.Context, hotelsDomain.Hotel) error
-	deleteHotelFn                   func(context.Context, string) error
+	patchHotelFn                    func(context.Context, string, int64, map[string]json.RawMessage) (hotelsDomain.Hotel, error)
+	deleteHotelFn                   func(context.Context, string, int64) error
 	createReservationFn             func(context.Context, hotelsDomain.Reservation) (string, error)
 	getReservationByIDFn            func(context.Context, string) (hotelsDomain.Reservation, error)
 	cancelReservationFn             func(context.Context, string) error
+	updateReservationStatusFn       func(context.Context, string, string) error
 	getReservationsByHotelIDFn      func(context.Context, string) ([]hotelsDomain.Reservation, error)
 	getReservationsByUserIDFn       func(context.Context, string) ([]hotelsDomain.Reservation, error)
 	getReservationsByUserAndHotelFn func(context.Context, string, string) ([]hotelsDomain.Reservation, error)
 	getAvailabilityFn               func(context.Context, []string, string, string) (map[string]bool, error)
+	getRoomTypesFn                  func(context.Context, string) ([]hotelsDomain.RoomType, error)
+	createRoomTypeFn                func(context.Context, string, hotelsDomain.RoomType) (string, error)
+	updateRoomTypeFn                func(context.Context, string, hotelsDomain.RoomType) error
+	deleteRoomTypeFn                func(context.Context, string, string) error
+	quoteFn                         func(context.Context, string, string, time.Time, time.Time) (hotelsDomain.Quote, error)
+	getCalendarFn                   func(context.Context, string, time.Time, time.Time) (hotelsDomain.Calendar, error)
+	getRateRulesFn                  func(context.Context, string) ([]hotelsDomain.RateRule, error)
+	createRateRuleFn                func(context.Context, string, hotelsDomain.RateRule) (string, error)
+	updateRateRuleFn                func(context.Context, string, hotelsDomain.RateRule) error
+	deleteRateRuleFn                func(context.Context, string, string) error
+	listHotelsFn                    func(context.Context, hotelsDomain.HotelListQuery) (hotelsDomain.HotelPage, error)
+	importHotelsFn                  func(context.Context, string, io.Reader, bool) (hotelsDomain.HotelImportReport, error)
+	exportHotelsFn                  func(context.Context, string, io.Writer) error
+}
+
+func (m mockService) ImportHotels(ctx context.Context, format string, body io.Reader, dryRun bool) (hotelsDomain.HotelImportReport, error) {
+	if m.importHotelsFn != nil {
+		return m.importHotelsFn(ctx, format, body, dryRun)
+	}
+	return hotelsDomain.HotelImportReport{}, nil
+}
+
+func (m mockService) ExportHotels(ctx context.Context, format string, w io.Writer) error {
+	if m.exportHotelsFn != nil {
+		return m.exportHotelsFn(ctx, format, w)
+	}
+	return nil
+}
+
+func (m mockService) ListHotels(ctx context.Context, query hotelsDomain.HotelListQuery) (hotelsDomain.HotelPage, error) {
+	if m.listHotelsFn != nil {
+		return m.listHotelsFn(ctx, query)
+	}
+	return hotelsDomain.HotelPage{}, nil
 }
 
 func (m mockService) GetHotelByID(ctx context.Context, id string) (hotelsDomain.Hotel, error) {
@@ -49,9 +88,15 @@ func (m mockService) Update(ctx context.Context, hotel hotelsDomain.Hotel) error
 	}
 	return nil
 }
-func (m mockService) Delete(ctx context.Context, id string) error {
+func (m mockService) Patch(ctx context.Context, id string, version int64, patch map[string]json.RawMessage) (hotelsDomain.Hotel, error) {
+	if m.patchHotelFn != nil {
+		return m.patchHotelFn(ctx, id, version, patch)
+	}
+	return hotelsDomain.Hotel{}, nil
+}
+func (m mockService) Delete(ctx context.Context, id string, version int64) error {
 	if m.deleteHotelFn != nil {
-		return m.deleteHotelFn(ctx, id)
+		return m.deleteHotelFn(ctx, id, version)
 	}
 	return nil
 }
@@ -73,6 +118,12 @@ func (m mockService) CancelReservation(ctx context.Context, id string) error {
 	}
 	return nil
 }
+func (m mockService) UpdateReservationStatus(ctx context.Context, id string, status string) error {
+	if m.updateReservationStatusFn != nil {
+		return m.updateReservationStatusFn(ctx, id, status)
+	}
+	return nil
+}
 func (m mockService) GetReservationsByHotelID(ctx context.Context, hotelID string) ([]hotelsDomain.Reservation, error) {
 	if m.getReservationsByHotelIDFn != nil {
 		return m.getReservationsByHotelIDFn(ctx, hotelID)
@@ -97,23 +148,92 @@ func (m mockService) GetAvailability(ctx context.Context, hotelIDs []string, che
 	}
 	return nil, nil
 }
+func (m mockService) GetRoomTypes(ctx context.Context, hotelID string) ([]hotelsDomain.RoomType, error) {
+	if m.getRoomTypesFn != nil {
+		return m.getRoomTypesFn(ctx, hotelID)
+	}
+	return nil, nil
+}
+func (m mockService) CreateRoomType(ctx context.Context, hotelID string, roomType hotelsDomain.RoomType) (string, error) {
+	if m.createRoomTypeFn != nil {
+		return m.createRoomTypeFn(ctx, hotelID, roomType)
+	}
+	return "", nil
+}
+func (m mockService) UpdateRoomType(ctx context.Context, hotelID string, roomType hotelsDomain.RoomType) error {
+	if m.updateRoomTypeFn != nil {
+		return m.updateRoomTypeFn(ctx, hotelID, roomType)
+	}
+	return nil
+}
+func (m mockService) DeleteRoomType(ctx context.Context, hotelID string, roomTypeID string) error {
+	if m.deleteRoomTypeFn != nil {
+		return m.deleteRoomTypeFn(ctx, hotelID, roomTypeID)
+	}
+	return nil
+}
+func (m mockService) Quote(ctx context.Context, hotelID string, roomTypeID string, checkIn, checkOut time.Time) (hotelsDomain.Quote, error) {
+	if m.quoteFn != nil {
+		return m.quoteFn(ctx, hotelID, roomTypeID, checkIn, checkOut)
+	}
+	return hotelsDomain.Quote{}, nil
+}
+func (m mockService) GetCalendar(ctx context.Context, hotelID string, from, to time.Time) (hotelsDomain.Calendar, error) {
+	if m.getCalendarFn != nil {
+		return m.getCalendarFn(ctx, hotelID, from, to)
+	}
+	return hotelsDomain.Calendar{}, nil
+}
+func (m mockService) GetRateRules(ctx context.Context, hotelID string) ([]hotelsDomain.RateRule, error) {
+	if m.getRateRulesFn != nil {
+		return m.getRateRulesFn(ctx, hotelID)
+	}
+	return nil, nil
+}
+func (m mockService) CreateRateRule(ctx context.Context, hotelID string, rule hotelsDomain.RateRule) (string, error) {
+	if m.createRateRuleFn != nil {
+		return m.createRateRuleFn(ctx, hotelID, rule)
+	}
+	return "", nil
+}
+func (m mockService) UpdateRateRule(ctx context.Context, hotelID string, rule hotelsDomain.RateRule) error {
+	if m.updateRateRuleFn != nil {
+		return m.updateRateRuleFn(ctx, hotelID, rule)
+	}
+	return nil
+}
+func (m mockService) DeleteRateRule(ctx context.Context, hotelID string, ruleID string) error {
+	if m.deleteRateRuleFn != nil {
+		return m.deleteRateRuleFn(ctx, hotelID, ruleID)
+	}
+	return nil
+}
+
+// Limite chico del archivo de importacion para probar el 413
+const testImportMaxBytes = 1024
 
 func setupRouter(ctrl Controller) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 	r.Use(gin.Recovery())
+	r.Use(middleware.RequestID())
 
 	jwtMiddleware := middleware.NewJWTMiddleware(config.JWTSecret)
+	idempotencyMiddleware := middleware.NewIdempotencyMiddleware(repositoriesIdempotency.NewMock(time.Minute))
 
 	// Rutas públicas (como en cmd/main.go)
+	r.GET("/hotels", ctrl.ListHotels)
 	r.GET("/hotels/:hotel_id", ctrl.GetHotelByID)
 	r.GET("/hotels/:hotel_id/reservations", ctrl.GetReservationsByHotelID)
+	r.GET("/hotels/:hotel_id/room-types", ctrl.GetRoomTypes)
+	r.POST("/hotels/:hotel_id/quote", ctrl.Quote)
+	r.GET("/hotels/:hotel_id/calendar", ctrl.GetCalendar)
 	r.POST("/hotels/availability", ctrl.GetAvailability)
 
 	// Rutas protegidas (usuarios autenticados)
 	userRoutes := r.Group("/", jwtMiddleware.Authenticate(), middleware.LoggedUserOnly())
 	{
-		userRoutes.POST("/reservations", ctrl.CreateReservation)
+		userRoutes.POST("/reservations", idempotencyMiddleware.Handle(), ctrl.CreateReservation)
 		userRoutes.DELETE("/reservations/:id", ctrl.CancelReservation)
 		userRoutes.GET("/users/:user_id/reservations", ctrl.GetReservationsByUserID)
 		userRoutes.GET("/users/:user_id/hotels/:hotel_id/reservations", ctrl.GetReservationsByUserAndHotelID)
@@ -122,9 +242,23 @@ func setupRouter(ctrl Controller) *gin.Engine {
 	// Rutas protegidas (admins)
 	adminRoutes := r.Group("/admin", jwtMiddleware.Authenticate(), middleware.AdminOnly())
 	{
-		adminRoutes.POST("/hotels", ctrl.Create)
+		adminRoutes.GET("/hotels", ctrl.AdminListHotels)
+		adminRoutes.POST("/hotels", idempotencyMiddleware.Handle(), ctrl.Create)
 		adminRoutes.PUT("/hotels/:hotel_id", ctrl.Update)
+		adminRoutes.PATCH("/hotels/:hotel_id", ctrl.Patch)
 		adminRoutes.DELETE("/hotels/:hotel_id", ctrl.Delete)
+		adminRoutes.POST("/hotels/import", middleware.MaxBodySize(testImportMaxBytes), ctrl.ImportHotels)
+		adminRoutes.GET("/hotels/export", ctrl.ExportHotels)
+		adminRoutes.POST("/hotels/:hotel_id/room-types", ctrl.CreateRoomType)
+		adminRoutes.PUT("/hotels/:hotel_id/room-types/:room_type_id", ctrl.UpdateRoomType)
+		adminRoutes.DELETE("/hotels/:hotel_id/room-types/:room_type_id", ctrl.DeleteRoomType)
+		adminRoutes.GET("/hotels/:hotel_id/rate-rules", ctrl.GetRateRules)
+		adminRoutes.POST("/hotels/:hotel_id/rate-rules", ctrl.CreateRateRule)
+		adminRoutes.PUT("/hotels/:hotel_id/rate-rules/:rule_id", ctrl.UpdateRateRule)
+		adminRoutes.DELETE("/hotels/:hotel_id/rate-rules/:rule_id", ctrl.DeleteRateRule)
+		adminRoutes.POST("/reservations/:id/check-in", ctrl.CheckInReservation)
+		adminRoutes.POST("/reservations/:id/check-out", ctrl.CheckOutReservation)
+		adminRoutes.POST("/reservations/:id/no-show", ctrl.NoShowReservation)
 	}
 
 	return r
@@ -196,6 +330,116 @@ func TestGetHotelByID_NotFound(t *testing.T) {
 	}
 }
 
+func TestListHotels_OK(t *testing.T) {
+	svc := mockService{
+		listHotelsFn: func(_ context.Context, query hotelsDomain.HotelListQuery) (hotelsDomain.HotelPage, error) {
+			if query.Cursor != "h1" || query.Limit != 2 || query.IncludeDeleted {
+				t.Fatalf("expected cursor=h1 limit=2, got %+v", query)
+			}
+			return hotelsDomain.HotelPage{Hotels: []hotelsDomain.Hotel{{ID: "h2"}, {ID: "h3"}}, NextCursor: "h3"}, nil
+		},
+	}
+
+	ctrl := NewController(svc)
+	r := setupRouter(ctrl)
+
+	req := httptest.NewRequest(http.MethodGet, "/hotels?cursor=h1&limit=2", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("code=%d want=%d body=%s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"next_cursor":"h3"`) {
+		t.Fatalf("expected next_cursor in body, got: %s", w.Body.String())
+	}
+}
+
+func TestListHotels_BadRequest(t *testing.T) {
+	svc := mockService{
+		listHotelsFn: func(_ context.Context, query hotelsDomain.HotelListQuery) (hotelsDomain.HotelPage, error) {
+			if query.Sort != "" {
+				return hotelsDomain.HotelPage{}, fmt.Errorf("%w: unknown sort %q", hotelsDomain.ErrInvalidHotelQuery, query.Sort)
+			}
+			return hotelsDomain.HotelPage{}, fmt.Errorf("%w: %s", hotelsDomain.ErrInvalidCursor, query.Cursor)
+		},
+	}
+
+	ctrl := NewController(svc)
+	r := setupRouter(ctrl)
+
+	for _, query := range []string{"limit=0", "limit=201", "limit=abc", "cursor=not-a-cursor", "min_rating=6", "min_rating=abc", "sort=stars"} {
+		req := httptest.NewRequest(http.MethodGet, "/hotels?"+query, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: code=%d want=%d body=%s", query, w.Code, http.StatusBadRequest, w.Body.String())
+		}
+	}
+}
+
+func TestListHotels_FiltersAndFields(t *testing.T) {
+	svc := mockService{
+		listHotelsFn: func(_ context.Context, query hotelsDomain.HotelListQuery) (hotelsDomain.HotelPage, error) {
+			if query.City != "Lima" || query.Country != "Peru" || query.MinRating == nil || *query.MinRating != 4 {
+				t.Fatalf("unexpected filters: %+v", query)
+			}
+			if query.Sort != hotelsDomain.HotelSortRatingDesc || strings.Join(query.Fields, ",") != "name,rating" {
+				t.Fatalf("unexpected sort or fields: %+v", query)
+			}
+			return hotelsDomain.HotelPage{Hotels: []hotelsDomain.Hotel{{ID: "h1", Name: "Hotel Lima", City: "Lima", Rating: 4.5}}}, nil
+		},
+	}
+
+	ctrl := NewController(svc)
+	r := setupRouter(ctrl)
+
+	req := httptest.NewRequest(http.MethodGet, "/hotels?city=Lima&country=Peru&min_rating=4&sort=rating_desc&fields=name,%20rating", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("code=%d want=%d body=%s", w.Code, http.StatusOK, w.Body.String())
+	}
+	// Solo vuelven los campos pedidos (y el id), sin next_cursor en la ultima pagina
+	want := `{"hotels":[{"id":"h1","name":"Hotel Lima","rating":4.5}]}`
+	if w.Body.String() != want {
+		t.Fatalf("body=%s want=%s", w.Body.String(), want)
+	}
+}
+
+func TestAdminListHotels_IncludeDeleted(t *testing.T) {
+	svc := mockService{
+		listHotelsFn: func(_ context.Context, query hotelsDomain.HotelListQuery) (hotelsDomain.HotelPage, error) {
+			if !query.IncludeDeleted {
+				t.Fatalf("expected include_deleted to be passed to the service")
+			}
+			return hotelsDomain.HotelPage{}, nil
+		},
+	}
+
+	ctrl := NewController(svc)
+	r := setupRouter(ctrl)
+	token := makeJWT(t, "administrador", int64(999))
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/hotels?include_deleted=true", nil)
+	req.Header.Set("Authorization", authBearer(token))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("code=%d want=%d body=%s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/hotels?include_deleted=maybe", nil)
+	req.Header.Set("Authorization", authBearer(token))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("code=%d want=%d body=%s", w.Code, http.StatusBadRequest, w.Body.String())
+	}
+}
+
 func TestGetAvailability_OK(t *testing.T) {
 	svc := mockService{
 		getAvailabilityFn: func(_ context.Context, ids []string, ci, co string) (map[string]bool, error) {
@@ -406,6 +650,51 @@ func TestCreateReservation_ForbiddenWhenUserMismatch(t *testing.T) {
 	}
 }
 
+func TestCreateReservation_IdempotencyKeyReplaysResponse(t *testing.T) {
+	calls := 0
+	svc := mockService{
+		createReservationFn: func(_ context.Context, _ hotelsDomain.Reservation) (string, error) {
+			calls++
+			return fmt.Sprintf("res%d", calls), nil
+		},
+	}
+	ctrl := NewController(svc)
+	r := setupRouter(ctrl)
+
+	token := makeJWT(t, "cliente", int64(1))
+	body := `{"hotel_id":"h1","user_id":"1","check_in":"2024-01-01T00:00:00Z","check_out":"2024-01-02T00:00:00Z"}`
+	send := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/reservations", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", authBearer(token))
+		req.Header.Set(middleware.IdempotencyKeyHeader, "retry-1")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	first := send(body)
+	retry := send(body)
+	if first.Code != http.StatusCreated || retry.Code != http.StatusCreated {
+		t.Fatalf("codes=%d/%d want=%d", first.Code, retry.Code, http.StatusCreated)
+	}
+	if calls != 1 || retry.Body.String() != first.Body.String() {
+		t.Fatalf("expected replayed response, calls=%d first=%s retry=%s", calls, first.Body.String(), retry.Body.String())
+	}
+	if retry.Header().Get(middleware.IdempotentReplayedHeader) != "true" {
+		t.Fatalf("expected %s header on replay", middleware.IdempotentReplayedHeader)
+	}
+
+	// Misma clave con otro body
+	other := send(strings.Replace(body, "2024-01-02", "2024-01-03", 1))
+	if other.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("code=%d want=%d body=%s", other.Code, http.StatusUnprocessableEntity, other.Body.String())
+	}
+	if calls != 1 {
+		t.Fatalf("expected service not to be called again, calls=%d", calls)
+	}
+}
+
 func TestCreateReservation_Created(t *testing.T) {
 	svc := mockService{
 		createReservationFn: func(_ context.Context, r hotelsDomain.Reservation) (string, error) {
@@ -439,6 +728,30 @@ func TestCreateReservation_Created(t *testing.T) {
 	}
 }
 
+func TestCreateReservation_ConflictWhenFull(t *testing.T) {
+	svc := mockService{
+		createReservationFn: func(_ context.Context, r hotelsDomain.Reservation) (string, error) {
+			return "", fmt.Errorf("hotel %s: %w", r.HotelID, hotelsDomain.ErrNoAvailability)
+		},
+	}
+
+	ctrl := NewController(svc)
+	r := setupRouter(ctrl)
+
+	token := makeJWT(t, "cliente", int64(1))
+	body := `{"hotel_id":"h1","user_id":"1","check_in":"2024-01-01T00:00:00Z","check_out":"2024-01-02T00:00:00Z"}`
+	req := httptest.NewRequest(http.MethodPost, "/reservations", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", authBearer(token))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("code=%d want=%d body=%s", w.Code, http.StatusConflict, w.Body.String())
+	}
+}
+
 func TestCancelReservation_ForbiddenWhenNotOwner(t *testing.T) {
 	svc := mockService{
 		getReservationByIDFn: func(_ context.Context, id string) (hotelsDomain.Reservation, error) {
@@ -486,3 +799,562 @@ func TestCancelReservation_OK(t *testing.T) {
 		t.Fatalf("code=%d want=%d body=%s", w.Code, http.StatusOK, w.Body.String())
 	}
 }
+
+func TestCheckInReservation_ForbiddenForNonAdmin(t *testing.T) {
+	ctrl := NewController(mockService{})
+	r := setupRouter(ctrl)
+
+	token := makeJWT(t, "cliente", int64(1))
+	req := httptest.NewRequest(http.MethodPost, "/admin/reservations/res1/check-in", nil)
+	req.Header.Set("Authorization", authBearer(token))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("code=%d want=%d body=%s", w.Code, http.StatusForbidden, w.Body.String())
+	}
+}
+
+func TestCheckOutReservation_OK(t *testing.T) {
+	svc := mockService{
+		updateReservationStatusFn: func(_ context.Context, id string, status string) error {
+			if id != "res1" {
+				t.Fatalf("expected id=res1, got %s", id)
+			}
+			if status != hotelsDomain.ReservationStatusCompleted {
+				t.Fatalf("expected status=%s, got %s", hotelsDomain.ReservationStatusCompleted, status)
+			}
+			return nil
+		},
+	}
+	ctrl := NewController(svc)
+	r := setupRouter(ctrl)
+
+	token := makeJWT(t, "administrador", int64(999))
+	req := httptest.NewRequest(http.MethodPost, "/admin/reservations/res1/check-out", nil)
+	req.Header.Set("Authorization", authBearer(token))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("code=%d want=%d body=%s", w.Code, http.StatusOK, w.Body.String())
+	}
+}
+
+func TestCheckInReservation_ConflictOnInvalidTransition(t *testing.T) {
+	svc := mockService{
+		updateReservationStatusFn: func(_ context.Context, id string, status string) error {
+			return fmt.Errorf("%w: CANCELLED -> CHECKED_IN", hotelsDomain.ErrInvalidStatusTransition)
+		},
+	}
+	ctrl := NewController(svc)
+	r := setupRouter(ctrl)
+
+	token := makeJWT(t, "administrador", int64(999))
+	req := httptest.NewRequest(http.MethodPost, "/admin/reservations/res1/check-in", nil)
+	req.Header.Set("Authorization", authBearer(token))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("code=%d want=%d body=%s", w.Code, http.StatusConflict, w.Body.String())
+	}
+}
+
+func TestCreateRoomType_Created(t *testing.T) {
+	svc := mockService{
+		createRoomTypeFn: func(_ context.Context, hotelID string, rt hotelsDomain.RoomType) (string, error) {
+			if hotelID != "h1" {
+				t.Fatalf("expected hotel_id=h1, got %s", hotelID)
+			}
+			if rt.Name != "Suite" || rt.Capacity != 4 || rt.Count != 2 {
+				t.Fatalf("unexpected room type: %+v", rt)
+			}
+			return "rt1", nil
+		},
+	}
+	ctrl := NewController(svc)
+	r := setupRouter(ctrl)
+
+	token := makeJWT(t, "administrador", int64(999))
+	body := `{"name":"Suite","capacity":4,"count":2,"base_price":250}`
+	req := httptest.NewRequest(http.MethodPost, "/admin/hotels/h1/room-types", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", authBearer(token))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("code=%d want=%d body=%s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"id":"rt1"`) {
+		t.Fatalf("expected id in body, got: %s", w.Body.String())
+	}
+}
+
+func TestPatchHotel_OK(t *testing.T) {
+	svc := mockService{
+		patchHotelFn: func(_ context.Context, id string, version int64, patch map[string]json.RawMessage) (hotelsDomain.Hotel, error) {
+			if id != "h1" || version != 3 {
+				t.Fatalf("expected id=h1 version=3, got %s %d", id, version)
+			}
+			if string(patch["rating"]) != "0" || string(patch["amenities"]) != "null" {
+				t.Fatalf("unexpected patch: %v", patch)
+			}
+			return hotelsDomain.Hotel{ID: id, Name: "Hotel Test", Version: 4}, nil
+		},
+	}
+	ctrl := NewController(svc)
+	r := setupRouter(ctrl)
+
+	token := makeJWT(t, "administrador", int64(999))
+	body := `{"rating":0,"amenities":null}`
+	req := httptest.NewRequest(http.MethodPatch, "/admin/hotels/h1", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("Authorization", authBearer(token))
+	req.Header.Set("If-Match", `"3"`)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("code=%d want=%d body=%s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"name":"Hotel Test"`) {
+		t.Fatalf("expected patched hotel in body, got: %s", w.Body.String())
+	}
+	if w.Header().Get("ETag") != `"4"` {
+		t.Fatalf("expected ETag of the new version, got %q", w.Header().Get("ETag"))
+	}
+}
+
+func TestPatchHotel_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		err  error
+		want int
+	}{
+		{name: "not an object", body: `[1,2]`, want: http.StatusBadRequest},
+		{name: "null body", body: `null`, want: http.StatusBadRequest},
+		{name: "invalid patch", body: `{"room_types":[]}`, err: hotelsDomain.ErrInvalidHotelPatch, want: http.StatusBadRequest},
+		{name: "not found", body: `{"name":"X"}`, err: hotelsDomain.ErrHotelNotFound, want: http.StatusNotFound},
+		{name: "version conflict", body: `{"name":"X"}`, err: hotelsDomain.ErrVersionConflict, want: http.StatusPreconditionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := mockService{
+				patchHotelFn: func(_ context.Context, _ string, _ int64, _ map[string]json.RawMessage) (hotelsDomain.Hotel, error) {
+					if tt.err == nil {
+						t.Fatal("service should not be called")
+					}
+					return hotelsDomain.Hotel{}, tt.err
+				},
+			}
+			r := setupRouter(NewController(svc))
+
+			token := makeJWT(t, "administrador", int64(999))
+			req := httptest.NewRequest(http.MethodPatch, "/admin/hotels/h1", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+			req.Header.Set("Authorization", authBearer(token))
+			req.Header.Set("If-Match", `"1"`)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("code=%d want=%d body=%s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestUpdateHotel_NotFound(t *testing.T) {
+	svc := mockService{
+		updateHotelFn: func(_ context.Context, _ hotelsDomain.Hotel) error {
+			return fmt.Errorf("%w: h404", hotelsDomain.ErrHotelNotFound)
+		},
+	}
+	r := setupRouter(NewController(svc))
+
+	token := makeJWT(t, "administrador", int64(999))
+	req := httptest.NewRequest(http.MethodPut, "/admin/hotels/h404", strings.NewReader(`{"name":"X"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", authBearer(token))
+	req.Header.Set("If-Match", `"1"`)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("code=%d want=%d body=%s", w.Code, http.StatusNotFound, w.Body.String())
+	}
+}
+
+func TestGetHotelByID_ETag(t *testing.T) {
+	svc := mockService{
+		getHotelByIDFn: func(_ context.Context, id string) (hotelsDomain.Hotel, error) {
+			return hotelsDomain.Hotel{ID: id, Name: "Hotel Test", Version: 7}, nil
+		},
+	}
+	r := setupRouter(NewController(svc))
+
+	req := httptest.NewRequest(http.MethodGet, "/hotels/h1", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Header().Get("ETag") != `"7"` {
+		t.Fatalf("expected ETag \"7\", got %q", w.Header().Get("ETag"))
+	}
+}
+
+func TestHotelWrites_IfMatch(t *testing.T) {
+	svc := mockService{
+		updateHotelFn: func(_ context.Context, hotel hotelsDomain.Hotel) error {
+			if hotel.Version != 2 {
+				t.Fatalf("expected version from If-Match, got %d", hotel.Version)
+			}
+			return fmt.Errorf("%w: h1", hotelsDomain.ErrVersionConflict)
+		},
+		deleteHotelFn: func(_ context.Context, id string, version int64) error {
+			if version != 5 {
+				t.Fatalf("expected version=5, got %d", version)
+			}
+			return nil
+		},
+	}
+	r := setupRouter(NewController(svc))
+	token := makeJWT(t, "administrador", int64(999))
+
+	tests := []struct {
+		name    string
+		method  string
+		ifMatch string
+		want    int
+	}{
+		{name: "PUT without If-Match", method: http.MethodPut, want: http.StatusPreconditionRequired},
+		{name: "PATCH without If-Match", method: http.MethodPatch, want: http.StatusPreconditionRequired},
+		{name: "DELETE without If-Match", method: http.MethodDelete, want: http.StatusPreconditionRequired},
+		{name: "malformed If-Match", method: http.MethodPut, ifMatch: `W/"2"`, want: http.StatusPreconditionFailed},
+		{name: "stale PUT", method: http.MethodPut, ifMatch: `"2"`, want: http.StatusPreconditionFailed},
+		{name: "DELETE", method: http.MethodDelete, ifMatch: `"5"`, want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/admin/hotels/h1", strings.NewReader(`{"name":"X"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", authBearer(token))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("code=%d want=%d body=%s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestUpdateRoomType_NotFound(t *testing.T) {
+	svc := mockService{
+		updateRoomTypeFn: func(_ context.Context, hotelID string, rt hotelsDomain.RoomType) error {
+			if rt.ID != "rt404" {
+				t.Fatalf("expected room type id=rt404, got %s", rt.ID)
+			}
+			return hotelsDomain.ErrRoomTypeNotFound
+		},
+	}
+	ctrl := NewController(svc)
+	r := setupRouter(ctrl)
+
+	token := makeJWT(t, "administrador", int64(999))
+	body := `{"name":"Suite","capacity":4,"count":2}`
+	req := httptest.NewRequest(http.MethodPut, "/admin/hotels/h1/room-types/rt404", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", authBearer(token))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("code=%d want=%d body=%s", w.Code, http.StatusNotFound, w.Body.String())
+	}
+}
+
+func TestCreateReservation_BadRequestOnInvalidRoomType(t *testing.T) {
+	svc := mockService{
+		createReservationFn: func(_ context.Context, _ hotelsDomain.Reservation) (string, error) {
+			return "", hotelsDomain.ErrInvalidRoomType
+		},
+	}
+	ctrl := NewController(svc)
+	r := setupRouter(ctrl)
+
+	token := makeJWT(t, "cliente", int64(123))
+	body := `{"hotel_id":"h1","user_id":"123","room_type_id":"nope","check_in":"2024-01-01T00:00:00Z","check_out":"2024-01-02T00:00:00Z"}`
+	req := httptest.NewRequest(http.MethodPost, "/reservations", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", authBearer(token))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("code=%d want=%d body=%s", w.Code, http.StatusBadRequest, w.Body.String())
+	}
+}
+
+func TestQuote_OK(t *testing.T) {
+	svc := mockService{
+		quoteFn: func(_ context.Context, hotelID string, roomTypeID string, checkIn, checkOut time.Time) (hotelsDomain.Quote, error) {
+			if hotelID != "h1" || roomTypeID != "suite" {
+				t.Fatalf("unexpected hotel/room type: %s/%s", hotelID, roomTypeID)
+			}
+			if checkIn.Format("2006-01-02") != "2024-01-01" || checkOut.Format("2006-01-02") != "2024-01-03" {
+				t.Fatalf("unexpected dates: %s - %s", checkIn, checkOut)
+			}
+			return hotelsDomain.Quote{HotelID: hotelID, Currency: "USD", Total: 300}, nil
+		},
+	}
+	ctrl := NewController(svc)
+	r := setupRouter(ctrl)
+
+	body := `{"check_in":"2024-01-01","check_out":"2024-01-03","room_type_id":"suite"}`
+	req := httptest.NewRequest(http.MethodPost, "/hotels/h1/quote", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("code=%d want=%d body=%s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"total":300`) {
+		t.Fatalf("expected total in body, got: %s", w.Body.String())
+	}
+}
+
+func TestQuote_BadRequestOnInvalidDate(t *testing.T) {
+	ctrl := NewController(mockService{})
+	r := setupRouter(ctrl)
+
+	body := `{"check_in":"01/01/2024","check_out":"2024-01-03"}`
+	req := httptest.NewRequest(http.MethodPost, "/hotels/h1/quote", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("code=%d want=%d body=%s", w.Code, http.StatusBadRequest, w.Body.String())
+	}
+}
+
+func TestGetCalendar_OK(t *testing.T) {
+	svc := mockService{
+		getCalendarFn: func(_ context.Context, hotelID string, from, to time.Time) (hotelsDomain.Calendar, error) {
+			if from.Format("2006-01-02") != "2024-01-01" || to.Format("2006-01-02") != "2024-01-02" {
+				t.Fatalf("unexpected range: %s - %s", from, to)
+			}
+			return hotelsDomain.Calendar{
+				HotelID: hotelID,
+				Nights:  []hotelsDomain.CalendarNight{{Date: from, RemainingRooms: 3, Price: 120}},
+			}, nil
+		},
+	}
+	ctrl := NewController(svc)
+	r := setupRouter(ctrl)
+
+	req := httptest.NewRequest(http.MethodGet, "/hotels/h1/calendar?from=2024-01-01&to=2024-01-02", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("code=%d want=%d body=%s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"remaining_rooms":3`) {
+		t.Fatalf("expected remaining rooms in body, got: %s", w.Body.String())
+	}
+}
+
+func TestGetCalendar_BadRequestOnInvalidRange(t *testing.T) {
+	svc := mockService{
+		getCalendarFn: func(_ context.Context, _ string, _, _ time.Time) (hotelsDomain.Calendar, error) {
+			return hotelsDomain.Calendar{}, hotelsDomain.ErrInvalidDateRange
+		},
+	}
+	ctrl := NewController(svc)
+	r := setupRouter(ctrl)
+
+	for _, query := range []string{"from=2024-01-01", "from=2024-01-05&to=2024-01-01"} {
+		req := httptest.NewRequest(http.MethodGet, "/hotels/h1/calendar?"+query, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("query=%s code=%d want=%d body=%s", query, w.Code, http.StatusBadRequest, w.Body.String())
+		}
+	}
+}
+
+func TestCreateRateRule_BadRequestOnInvalidRule(t *testing.T) {
+	svc := mockService{
+		createRateRuleFn: func(_ context.Context, _ string, rule hotelsDomain.RateRule) (string, error) {
+			return "", fmt.Errorf("%w: unknown type %q", hotelsDomain.ErrInvalidRateRule, rule.Type)
+		},
+	}
+	ctrl := NewController(svc)
+	r := setupRouter(ctrl)
+
+	token := makeJWT(t, "administrador", int64(999))
+	req := httptest.NewRequest(http.MethodPost, "/admin/hotels/h1/rate-rules", strings.NewReader(`{"name":"x","type":"BOGUS"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", authBearer(token))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("code=%d want=%d body=%s", w.Code, http.StatusBadRequest, w.Body.String())
+	}
+}
+
+func TestImportHotels_FormatAndDryRun(t *testing.T) {
+	var gotFormat string
+	var gotDryRun bool
+	var gotBody string
+	svc := mockService{
+		importHotelsFn: func(_ context.Context, format string, body io.Reader, dryRun bool) (hotelsDomain.HotelImportReport, error) {
+			data, err := io.ReadAll(body)
+			if err != nil {
+				return hotelsDomain.HotelImportReport{}, err
+			}
+			gotFormat, gotDryRun, gotBody = format, dryRun, string(data)
+			return hotelsDomain.HotelImportReport{DryRun: dryRun, Rows: 2, Valid: 1, Failed: 1, Errors: []hotelsDomain.HotelImportError{{Line: 3, Error: "invalid hotel: name is required"}}}, nil
+		},
+	}
+	r := setupRouter(NewController(svc))
+
+	tests := []struct {
+		url         string
+		contentType string
+		format      string
+		dryRun      bool
+	}{
+		{url: "/admin/hotels/import", contentType: "text/csv; charset=utf-8", format: hotelsDomain.HotelFormatCSV},
+		{url: "/admin/hotels/import?dry_run=true", contentType: "application/x-ndjson", format: hotelsDomain.HotelFormatNDJSON, dryRun: true},
+		{url: "/admin/hotels/import?format=csv", contentType: "application/octet-stream", format: hotelsDomain.HotelFormatCSV},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.url, strings.NewReader("name\nA\n\n"))
+		req.Header.Set("Content-Type", tt.contentType)
+		req.Header.Set("Authorization", authBearer(makeJWT(t, "administrador", int64(999))))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("url=%s code=%d want=%d body=%s", tt.url, w.Code, http.StatusOK, w.Body.String())
+		}
+		if gotFormat != tt.format || gotDryRun != tt.dryRun || gotBody != "name\nA\n\n" {
+			t.Fatalf("url=%s got format=%q dry_run=%v body=%q", tt.url, gotFormat, gotDryRun, gotBody)
+		}
+
+		var report hotelsDomain.HotelImportReport
+		if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+			t.Fatalf("error decoding report: %v", err)
+		}
+		if report.Rows != 2 || len(report.Errors) != 1 || report.Errors[0].Line != 3 {
+			t.Fatalf("unexpected report %+v", report)
+		}
+	}
+}
+
+func TestImportHotels_Errors(t *testing.T) {
+	svc := mockService{
+		importHotelsFn: func(_ context.Context, format string, body io.Reader, _ bool) (hotelsDomain.HotelImportReport, error) {
+			if _, err := io.ReadAll(body); err != nil {
+				return hotelsDomain.HotelImportReport{Rows: 10}, fmt.Errorf("error reading hotels import: %w", err)
+			}
+			return hotelsDomain.HotelImportReport{}, fmt.Errorf("%w: missing name column", hotelsDomain.ErrInvalidImport)
+		},
+	}
+	r := setupRouter(NewController(svc))
+
+	tests := []struct {
+		name        string
+		url         string
+		contentType string
+		body        string
+		code        int
+	}{
+		{name: "unknown content type", url: "/admin/hotels/import", contentType: "application/json", body: "[]", code: http.StatusUnsupportedMediaType},
+		{name: "unknown format", url: "/admin/hotels/import?format=xml", contentType: "text/csv", body: "name", code: http.StatusUnsupportedMediaType},
+		{name: "invalid dry_run", url: "/admin/hotels/import?dry_run=maybe", contentType: "text/csv", body: "name", code: http.StatusBadRequest},
+		{name: "invalid file", url: "/admin/hotels/import", contentType: "text/csv", body: "city", code: http.StatusBadRequest},
+		{name: "too large", url: "/admin/hotels/import", contentType: "text/csv", body: strings.Repeat("x", testImportMaxBytes+1), code: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.url, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			req.Header.Set("Authorization", authBearer(makeJWT(t, "administrador", int64(999))))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.code {
+				t.Fatalf("code=%d want=%d body=%s", w.Code, tt.code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestExportHotels_Formats(t *testing.T) {
+	svc := mockService{
+		exportHotelsFn: func(_ context.Context, format string, w io.Writer) error {
+			_, err := fmt.Fprintf(w, "exported as %s\n", format)
+			return err
+		},
+	}
+	r := setupRouter(NewController(svc))
+
+	tests := []struct {
+		url         string
+		contentType string
+		filename    string
+		body        string
+	}{
+		{url: "/admin/hotels/export", contentType: "application/x-ndjson", filename: "hotels.jsonl", body: "exported as ndjson\n"},
+		{url: "/admin/hotels/export?format=csv", contentType: "text/csv; charset=utf-8", filename: "hotels.csv", body: "exported as csv\n"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+		req.Header.Set("Authorization", authBearer(makeJWT(t, "administrador", int64(999))))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("url=%s code=%d want=%d body=%s", tt.url, w.Code, http.StatusOK, w.Body.String())
+		}
+		if w.Header().Get("Content-Type") != tt.contentType || !strings.Contains(w.Header().Get("Content-Disposition"), tt.filename) {
+			t.Fatalf("url=%s unexpected headers %v", tt.url, w.Header())
+		}
+		if w.Body.String() != tt.body {
+			t.Fatalf("url=%s body=%q want=%q", tt.url, w.Body.String(), tt.body)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/hotels/export?format=xml", nil)
+	req.Header.Set("Authorization", authBearer(makeJWT(t, "administrador", int64(999))))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("code=%d want=%d body=%s", w.Code, http.StatusBadRequest, w.Body.String())
+	}
+}
@@ -0,0 +1,44 @@
+package audit
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInvalidCursor indica que el cursor del registro de auditoria no es un ID valido
+var ErrInvalidCursor = errors.New("invalid audit cursor")
+
+// Entry es un cambio del registro de auditoria. El _id es un ObjectID, asi el orden por _id es el orden en que se guardaron
+type Entry struct {
+	ID         string    `bson:"_id,omitempty"`
+	UserID     string    `bson:"user_id"`
+	UserType   string    `bson:"user_type"`
+	Action     string    `bson:"action"`
+	TargetType string    `bson:"target_type"`
+	TargetID   string    `bson:"target_id"`
+	HotelID    string    `bson:"hotel_id,omitempty"`
+	Changes    []Change  `bson:"changes"`
+	RequestID  string    `bson:"request_id,omitempty"`
+	CreatedAt  time.Time `bson:"created_at"`
+}
+
+// Change es el valor anterior y el nuevo de un campo, guardados como JSON (vacio = sin valor)
+type Change struct {
+	Field  string `bson:"field"`
+	Before string `bson:"before,omitempty"`
+	After  string `bson:"after,omitempty"`
+}
+
+// Query son los filtros del registro de auditoria (los vacios no filtran), los mas nuevos primero.
+// La pagina es por keyset: las entradas con _id menor a BeforeID
+type Query struct {
+	UserID     string
+	Action     string
+	TargetType string
+	TargetID   string
+	HotelID    string
+	From       time.Time
+	To         time.Time
+	BeforeID   string
+	Limit      int // 0 = sin limite
+}
@@ -1,31 +1,135 @@
 package hotels
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// ErrInvalidCursor indica que el cursor de ListHotels no es un ID de hotel valido
+var ErrInvalidCursor = errors.New("invalid hotels cursor")
+
+// ErrHotelNotFound indica que el hotel no existe o esta borrado
+var ErrHotelNotFound = errors.New("hotel not found")
+
+// ErrVersionConflict indica que el hotel existe pero ya no esta en la version esperada
+var ErrVersionConflict = errors.New("hotel version conflict")
+
+// HotelQuery es un listado de hoteles: filtros, orden, pagina y campos a traer.
+// La pagina es por keyset: los hoteles que en el orden van despues de (AfterValue, AfterID)
+type HotelQuery struct {
+	City           string   // Sin distinguir mayusculas
+	Country        string   // Sin distinguir mayusculas
+	MinRating      *float64 // Rating minimo
+	SortField      string   // Campo por el que se ordena (vacio = _id); los empates se ordenan por _id
+	Descending     bool
+	AfterValue     interface{} // Valor de SortField del ultimo hotel de la pagina anterior
+	AfterID        string      // ID del ultimo hotel de la pagina anterior (vacio = desde el principio)
+	Limit          int
+	Fields         []string // Campos a traer (vacio = todos), _id viene siempre
+	IncludeDeleted bool     // Incluye los hoteles borrados cuyo evento DELETE todavia no se publico
+}
 
 type Hotel struct {
-	ID            string    `bson:"_id,omitempty"`
-	Name          string    `bson:"name"`
-	Description   string    `bson:"description"`
-	Address       string    `bson:"address"`
-	City          string    `bson:"city"`
-	State         string    `bson:"state"`
-	Country       string    `bson:"country"`
-	Phone         string    `bson:"phone"`
-	Email         string    `bson:"email"`
-	PricePerNight float64   `bson:"price_per_night"`
-	Rating        float64   `bson:"rating"`
-	AvaiableRooms int       `bson:"avaiable_rooms"`
-	CheckInTime   time.Time `bson:"check_in_time"`
-	CheckOutTime  time.Time `bson:"check_out_time"`
-	Amenities     []string  `bson:"amenities"`
-	Images        []string  `bson:"images"`
+	ID            string     `bson:"_id,omitempty"`
+	Name          string     `bson:"name"`
+	Description   string     `bson:"description"`
+	Address       string     `bson:"address"`
+	City          string     `bson:"city"`
+	State         string     `bson:"state"`
+	Country       string     `bson:"country"`
+	Phone         string     `bson:"phone"`
+	Email         string     `bson:"email"`
+	PricePerNight float64    `bson:"price_per_night"`
+	Rating        float64    `bson:"rating"`
+	AvaiableRooms int        `bson:"avaiable_rooms"`
+	CheckInTime   time.Time  `bson:"check_in_time"`
+	CheckOutTime  time.Time  `bson:"check_out_time"`
+	Amenities     []string   `bson:"amenities"`
+	Images        []string   `bson:"images"`
+	RoomTypes     []RoomType `bson:"room_types"`
+	Currency      string     `bson:"currency"`
+	RateRules     []RateRule `bson:"rate_rules"`
+	// Location es la ubicacion del hotel como punto GeoJSON (indice 2dsphere)
+	Location *GeoPoint `bson:"location,omitempty"`
+	// Outbox guarda los eventos pendientes de publicar, escritos en la misma operacion que el cambio del hotel
+	Outbox []OutboxEvent `bson:"outbox,omitempty"`
+	// DeletedAt marca un hotel borrado cuyo evento DELETE todavia no se publico (se borra al publicarlo)
+	DeletedAt *time.Time `bson:"deleted_at,omitempty"`
+	// EventSequence cuenta los eventos ya publicados, el siguiente evento del hotel lleva EventSequence+1
+	EventSequence int64 `bson:"event_sequence,omitempty"`
+	// Version aumenta con cada cambio del hotel, las escrituras de admin solo se aplican sobre la version esperada.
+	// Los hoteles de antes del versionado no la tienen y cuentan como version 0
+	Version int64 `bson:"version"`
+}
+
+// GeoPoint es un punto GeoJSON, Coordinates va en orden [longitud, latitud]
+type GeoPoint struct {
+	Type        string    `bson:"type"` // Siempre "Point"
+	Coordinates []float64 `bson:"coordinates"`
+}
+
+// OutboxEvent es un evento de cambio de hotel pendiente de publicar en RabbitMQ
+type OutboxEvent struct {
+	ID        string    `bson:"id"`
+	Operation string    `bson:"operation"` // CREATE, UPDATE o DELETE
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// RoomType es un tipo de habitacion del hotel (single, doble, suite...) con su propio inventario
+type RoomType struct {
+	ID        string   `bson:"id"`
+	Name      string   `bson:"name"`
+	Capacity  int      `bson:"capacity"` // huespedes por habitacion
+	Count     int      `bson:"count"`    // cantidad de habitaciones de este tipo
+	BasePrice float64  `bson:"base_price"`
+	Amenities []string `bson:"amenities"`
+}
+
+// RateRule es una regla de tarifa del hotel (temporada, fin de semana o descuento por estadia minima)
+type RateRule struct {
+	ID              string    `bson:"id"`
+	Name            string    `bson:"name"`
+	Type            string    `bson:"type"`
+	RoomTypeID      string    `bson:"room_type_id"`
+	StartDate       time.Time `bson:"start_date"`
+	EndDate         time.Time `bson:"end_date"`
+	Multiplier      float64   `bson:"multiplier"`
+	MinNights       int       `bson:"min_nights"`
+	DiscountPercent float64   `bson:"discount_percent"`
 }
 
 type Reservation struct {
-	ID        string    `bson:"_id,omitempty"`
-	HotelName string    `bson:"hotel_name"`
-	HotelID   string    `bson:"hotel_id"`
-	UserID    string    `bson:"user_id"`
-	CheckIn   time.Time `bson:"check_in"`
-	CheckOut  time.Time `bson:"check_out"`
+	ID            string                    `bson:"_id,omitempty"`
+	HotelName     string                    `bson:"hotel_name"`
+	HotelID       string                    `bson:"hotel_id"`
+	UserID        string                    `bson:"user_id"`
+	CheckIn       time.Time                 `bson:"check_in"`
+	CheckOut      time.Time                 `bson:"check_out"`
+	RoomTypeID    string                    `bson:"room_type_id"`
+	TotalPrice    float64                   `bson:"total_price"` // Total cotizado al momento de reservar
+	Currency      string                    `bson:"currency"`
+	Status        string                    `bson:"status"`
+	StatusHistory []ReservationStatusChange `bson:"status_history"`
+}
+
+type ReservationStatusChange struct {
+	Status string    `bson:"status"`
+	At     time.Time `bson:"at"`
+}
+
+// Inventory es el contador de habitaciones ocupadas de un hotel para una noche.
+// Se usa para reservar de forma atomica y evitar overbooking entre requests concurrentes.
+type Inventory struct {
+	ID         string    `bson:"_id"` // "<hotel_id>:<YYYY-MM-DD>" o "<hotel_id>:<room_type_id>:<YYYY-MM-DD>"
+	HotelID    string    `bson:"hotel_id"`
+	RoomTypeID string    `bson:"room_type_id"`
+	Night      time.Time `bson:"night"`
+	Reserved   int       `bson:"reserved"`
+}
+
+// NightOccupancy es el resultado de agregar reservas: habitaciones ocupadas de un tipo en una noche
+type NightOccupancy struct {
+	Night      time.Time `bson:"night"`
+	RoomTypeID string    `bson:"room_type_id"`
+	Reserved   int       `bson:"reserved"`
 }
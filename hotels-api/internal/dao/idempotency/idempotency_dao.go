@@ -0,0 +1,21 @@
+package idempotency
+
+import "time"
+
+// Estados de una clave de idempotencia
+const (
+	StatusPending   = "PENDING"   // La primera request todavia se esta procesando
+	StatusCompleted = "COMPLETED" // La respuesta quedo guardada y se puede repetir
+)
+
+// Record guarda la primera respuesta de una request con Idempotency-Key
+type Record struct {
+	Key            string    `bson:"_id"`          // "<user_id>:<METHOD> <ruta>:<Idempotency-Key>"
+	RequestHash    string    `bson:"request_hash"` // sha256 del body de la request
+	Status         string    `bson:"status"`
+	ResponseStatus int       `bson:"response_status"`
+	ContentType    string    `bson:"content_type"`
+	ResponseBody   []byte    `bson:"response_body"`
+	CreatedAt      time.Time `bson:"created_at"`   // Indice TTL: el registro expira solo
+	LockedUntil    time.Time `bson:"locked_until"` // Mientras esta PENDING: despues de esta hora otra request con la misma clave lo puede tomar
+}
@@ -0,0 +1,112 @@
+package hotels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrInvalidAuditQuery indica filtros o un cursor de GET /admin/audit que no se pueden usar
+var ErrInvalidAuditQuery = errors.New("invalid audit query")
+
+// Acciones del registro de auditoria (una por operacion que modifica datos)
+const (
+	AuditActionHotelCreate       = "hotel.create"
+	AuditActionHotelUpdate       = "hotel.update" // PUT
+	AuditActionHotelPatch        = "hotel.patch"  // PATCH
+	AuditActionHotelDelete       = "hotel.delete"
+	AuditActionHotelImport       = "hotel.import" // POST /admin/hotels/import, una entrada por hotel creado
+	AuditActionRoomTypeCreate    = "room_type.create"
+	AuditActionRoomTypeUpdate    = "room_type.update"
+	AuditActionRoomTypeDelete    = "room_type.delete"
+	AuditActionRateRuleCreate    = "rate_rule.create"
+	AuditActionRateRuleUpdate    = "rate_rule.update"
+	AuditActionRateRuleDelete    = "rate_rule.delete"
+	AuditActionReservationCreate = "reservation.create"
+	AuditActionReservationCancel = "reservation.cancel"
+	AuditActionReservationStatus = "reservation.status" // check-in, check-out y no-show
+)
+
+// Tipos de objetivo del registro de auditoria
+const (
+	AuditTargetHotel       = "hotel"
+	AuditTargetRoomType    = "room_type"
+	AuditTargetRateRule    = "rate_rule"
+	AuditTargetReservation = "reservation"
+)
+
+// Actor es el usuario que hizo un cambio, sale del JWT (vacio en los cambios sin usuario, como los procesos internos)
+type Actor struct {
+	UserID   string `json:"user_id"`
+	UserType string `json:"user_type"`
+}
+
+// AuditChange es el valor anterior y el nuevo de un campo (null si no tenia o ya no tiene valor)
+type AuditChange struct {
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// AuditEntry es un cambio registrado: quien, que accion, sobre que objeto, que campos cambiaron y en que request
+type AuditEntry struct {
+	ID         string        `json:"id"`
+	Actor      Actor         `json:"actor"`
+	Action     string        `json:"action"`
+	TargetType string        `json:"target_type"`
+	TargetID   string        `json:"target_id"`
+	HotelID    string        `json:"hotel_id,omitempty"` // Hotel al que pertenece el objetivo
+	Changes    []AuditChange `json:"changes"`
+	RequestID  string        `json:"request_id,omitempty"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+// AuditQuery son los filtros y la pagina de GET /admin/audit (los vacios no filtran).
+// Cursor es el next_cursor de la pagina anterior
+type AuditQuery struct {
+	UserID     string
+	Action     string
+	TargetType string
+	TargetID   string
+	HotelID    string
+	From       time.Time // Desde (inclusive)
+	To         time.Time // Hasta (exclusive)
+	Cursor     string
+	Limit      int
+}
+
+// AuditPage es una pagina del registro de auditoria, los cambios mas nuevos primero
+type AuditPage struct {
+	Entries    []AuditEntry `json:"entries"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+type contextKey string
+
+const (
+	actorContextKey     contextKey = "actor"
+	requestIDContextKey contextKey = "request_id"
+)
+
+// WithActor guarda en el contexto el usuario que hace la request, para el registro de auditoria
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorContextKey, actor)
+}
+
+// ActorFromContext devuelve el usuario de la request (vacio si no hay)
+func ActorFromContext(ctx context.Context) Actor {
+	actor, _ := ctx.Value(actorContextKey).(Actor)
+	return actor
+}
+
+// WithRequestID guarda en el contexto el ID de la request, para el registro de auditoria
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, requestID)
+}
+
+// RequestIDFromContext devuelve el ID de la request (vacio si no hay)
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey).(string)
+	return requestID
+}
@@ -0,0 +1,30 @@
+package hotels
+
+import "time"
+
+// MaxCalendarNights es el rango maximo (en noches) que se puede pedir en el calendario
+const MaxCalendarNights = 366
+
+// Calendar es la disponibilidad y el precio por noche de un hotel en un rango [From, To)
+type Calendar struct {
+	HotelID  string          `json:"hotel_id"`
+	From     time.Time       `json:"from"`
+	To       time.Time       `json:"to"`
+	Currency string          `json:"currency"`
+	Nights   []CalendarNight `json:"nights"`
+}
+
+// CalendarNight resume una noche: habitaciones libres y el menor precio entre los tipos con lugar
+type CalendarNight struct {
+	Date           time.Time               `json:"date"`
+	RemainingRooms int                     `json:"remaining_rooms"`
+	Price          float64                 `json:"price"`
+	RoomTypes      []CalendarRoomTypeNight `json:"room_types,omitempty"`
+}
+
+// CalendarRoomTypeNight es el detalle de una noche para un tipo de habitacion
+type CalendarRoomTypeNight struct {
+	RoomTypeID     string  `json:"room_type_id"`
+	RemainingRooms int     `json:"remaining_rooms"`
+	Price          float64 `json:"price"`
+}
@@ -1,27 +1,119 @@
 package hotels
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 type Hotel struct {
-	ID            string    `json:"id"`
-	Name          string    `json:"name"`
-	Description   string    `json:"description"`
-	Address       string    `json:"address"`
-	City          string    `json:"city"`
-	State         string    `json:"state"`
-	Country       string    `json:"country"`
-	Phone         string    `json:"phone"`
-	Email         string    `json:"email"`
-	PricePerNight float64   `json:"price_per_night"`
-	Rating        float64   `json:"rating"`
-	AvaiableRooms int       `json:"avaiable_rooms"`
-	CheckInTime   time.Time `json:"check_in_time"`
-	CheckOutTime  time.Time `json:"check_out_time"`
-	Amenities     []string  `json:"amenities"`
-	Images        []string  `json:"images"`
+	ID            string     `json:"id"`
+	Name          string     `json:"name"`
+	Description   string     `json:"description"`
+	Address       string     `json:"address"`
+	City          string     `json:"city"`
+	State         string     `json:"state"`
+	Country       string     `json:"country"`
+	Phone         string     `json:"phone"`
+	Email         string     `json:"email"`
+	PricePerNight float64    `json:"price_per_night"`
+	Rating        float64    `json:"rating"`
+	AvaiableRooms int        `json:"avaiable_rooms"`
+	CheckInTime   time.Time  `json:"check_in_time"`
+	CheckOutTime  time.Time  `json:"check_out_time"`
+	Amenities     []string   `json:"amenities"`
+	Images        []string   `json:"images"`
+	RoomTypes     []RoomType `json:"room_types"`
+	Currency      string     `json:"currency"`
+	RateRules     []RateRule `json:"rate_rules"`
+	// Ubicacion del hotel (opcional, van las dos o ninguna) para la busqueda por cercania de search-api
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+	// Solo en el listado de admin con include_deleted: hotel borrado cuyo evento todavia no se publico
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Version del hotel (la misma del ETag), se manda en If-Match para modificarlo o borrarlo
+	Version int64 `json:"version"`
+	// Secuencia del ultimo evento publicado del hotel (solo lectura): search-api la indexa en un reindex
+	// para seguir descartando los eventos viejos que se reintenten despues
+	EventSequence int64 `json:"event_sequence"`
 }
 
+// ErrInvalidLocation indica coordenadas incompletas o fuera de rango
+var ErrInvalidLocation = errors.New("invalid hotel location")
+
+// ErrHotelNotFound indica que el hotel no existe o esta borrado
+var ErrHotelNotFound = errors.New("hotel not found")
+
+// ErrVersionConflict indica que el hotel cambio desde la version del If-Match
+var ErrVersionConflict = errors.New("hotel was modified by another request")
+
+// ErrInvalidHotelPatch indica un PATCH de hotel que no es un JSON Merge Patch valido o que cambia un campo no editable
+var ErrInvalidHotelPatch = errors.New("invalid hotel patch")
+
+// ErrInvalidCursor indica un cursor de GET /hotels que no salio de una pagina anterior con el mismo orden
+var ErrInvalidCursor = errors.New("invalid hotels cursor")
+
+// ErrInvalidHotelQuery indica un orden o un campo de GET /hotels que no existe
+var ErrInvalidHotelQuery = errors.New("invalid hotels query")
+
+// Ordenes de GET /hotels (los empates se ordenan por ID)
+const (
+	HotelSortID         = "id"
+	HotelSortNameAsc    = "name_asc"
+	HotelSortNameDesc   = "name_desc"
+	HotelSortRatingAsc  = "rating_asc"
+	HotelSortRatingDesc = "rating_desc"
+	HotelSortPriceAsc   = "price_asc"
+	HotelSortPriceDesc  = "price_desc"
+)
+
+// HotelListQuery son los filtros, el orden, la pagina y los campos de GET /hotels.
+// Cursor es el next_cursor de la pagina anterior (vacio = primera pagina)
+type HotelListQuery struct {
+	City           string
+	Country        string
+	MinRating      *float64
+	Sort           string   // Uno de los HotelSort (vacio = HotelSortID)
+	Fields         []string // Campos del JSON del hotel a devolver (vacio = todos), id viene siempre
+	Cursor         string
+	Limit          int
+	IncludeDeleted bool // Solo en el listado de admin
+}
+
+// HotelPage es una pagina de GET /hotels.
+// NextCursor se pasa como cursor (con los mismos filtros y orden) para pedir la pagina siguiente y viene vacio en la ultima
+type HotelPage struct {
+	Hotels     []Hotel `json:"hotels"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+type RoomType struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Capacity  int      `json:"capacity"`
+	Count     int      `json:"count"`
+	BasePrice float64  `json:"base_price"`
+	Amenities []string `json:"amenities"`
+}
+
+// Operaciones de los eventos de hoteles que consume search-api
+const (
+	OperationCreate = "CREATE"
+	OperationUpdate = "UPDATE"
+	OperationDelete = "DELETE"
+)
+
+// Version actual del sobre de eventos de hoteles (v1 solo tenia operation y hotel_id)
+const HotelEventSchemaVersion = 2
+
+// HotelNew es el evento de cambio de un hotel que consume search-api.
+// Desde v2 lleva el hotel completo (salvo en DELETE) para que search-api no tenga que pedirlo a hotels-api
 type HotelNew struct {
-	Operation string `json:"operation"`
-	HotelID   string `json:"hotel_id"`
+	EventID       string    `json:"event_id"`
+	Type          string    `json:"type"` // CREATE, UPDATE o DELETE
+	SchemaVersion int       `json:"schema_version"`
+	OccurredAt    time.Time `json:"occurred_at"`
+	Sequence      int64     `json:"sequence"`  // Creciente por hotel: search-api descarta los eventos con una secuencia que ya aplico
+	Operation     string    `json:"operation"` // Igual a Type, se mantiene para los consumidores v1
+	HotelID       string    `json:"hotel_id"`
+	Hotel         *Hotel    `json:"hotel,omitempty"`
 }
@@ -0,0 +1,49 @@
+package hotels
+
+import "errors"
+
+var (
+	// ErrUnknownHotelFormat indica un formato de importacion o exportacion que no es csv ni ndjson
+	ErrUnknownHotelFormat = errors.New("unknown hotels file format")
+	// ErrInvalidImport indica un archivo de importacion que no se puede leer (por ejemplo un encabezado CSV invalido)
+	ErrInvalidImport = errors.New("invalid hotels import")
+	// ErrInvalidImportRow indica una fila de la importacion con datos invalidos, se informa en el reporte y no se guarda
+	ErrInvalidImportRow = errors.New("invalid hotel")
+)
+
+// Formatos de POST /admin/hotels/import y GET /admin/hotels/export
+const (
+	HotelFormatCSV    = "csv"
+	HotelFormatNDJSON = "ndjson" // JSON Lines, un hotel por linea
+)
+
+// MaxImportErrors es la cantidad maxima de errores de fila que se devuelven en el reporte (el total se cuenta igual)
+const MaxImportErrors = 1000
+
+// HotelImportError es el error de una fila de la importacion. Line es la linea del archivo (en CSV el encabezado es la linea 1)
+type HotelImportError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// HotelImportReport es el resultado de una importacion. En dry run las filas se validan pero no se guardan (Created queda en 0)
+type HotelImportReport struct {
+	DryRun          bool               `json:"dry_run"`
+	Rows            int                `json:"rows"`    // Filas leidas
+	Valid           int                `json:"valid"`   // Filas que pasaron la validacion
+	Created         int                `json:"created"` // Hoteles guardados
+	Failed          int                `json:"failed"`  // Filas invalidas o que no se pudieron guardar
+	IDs             []string           `json:"ids,omitempty"`
+	Errors          []HotelImportError `json:"errors"`
+	ErrorsTruncated bool               `json:"errors_truncated,omitempty"` // Hubo mas de MaxImportErrors errores
+}
+
+// AddError agrega el error de una fila al reporte (hasta MaxImportErrors) y la cuenta como fallida
+func (report *HotelImportReport) AddError(line int, err error) {
+	report.Failed++
+	if len(report.Errors) >= MaxImportErrors {
+		report.ErrorsTruncated = true
+		return
+	}
+	report.Errors = append(report.Errors, HotelImportError{Line: line, Error: err.Error()})
+}
@@ -0,0 +1,63 @@
+package hotels
+
+import (
+	"errors"
+	"time"
+)
+
+// Errores de negocio de tarifas
+var (
+	ErrInvalidRateRule  = errors.New("invalid rate rule")
+	ErrRateRuleNotFound = errors.New("rate rule not found")
+)
+
+// Tipos de regla de tarifa
+const (
+	RateRuleSeason  = "SEASON"   // Multiplicador para las noches dentro de un rango de fechas
+	RateRuleWeekend = "WEEKEND"  // Multiplicador para las noches de viernes y sabado
+	RateRuleMinStay = "MIN_STAY" // Descuento porcentual sobre el total para estadias de al menos MinNights noches
+)
+
+// DefaultCurrency es la moneda de los hoteles que no tienen una configurada
+const DefaultCurrency = "USD"
+
+// RateRule es una regla de tarifa del hotel; si tiene RoomTypeID solo aplica a ese tipo de habitacion
+type RateRule struct {
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	Type            string    `json:"type"`
+	RoomTypeID      string    `json:"room_type_id,omitempty"`
+	StartDate       time.Time `json:"start_date,omitempty"` // SEASON, inclusive
+	EndDate         time.Time `json:"end_date,omitempty"`   // SEASON, inclusive
+	Multiplier      float64   `json:"multiplier,omitempty"` // SEASON y WEEKEND
+	MinNights       int       `json:"min_nights,omitempty"` // MIN_STAY
+	DiscountPercent float64   `json:"discount_percent,omitempty"`
+}
+
+// QuoteRequest es el body de POST /hotels/:hotel_id/quote (fechas en formato YYYY-MM-DD)
+type QuoteRequest struct {
+	CheckIn    string `json:"check_in"`
+	CheckOut   string `json:"check_out"`
+	RoomTypeID string `json:"room_type_id"`
+}
+
+// Quote es el desglose de precio de una estadia
+type Quote struct {
+	HotelID    string        `json:"hotel_id"`
+	RoomTypeID string        `json:"room_type_id,omitempty"`
+	CheckIn    time.Time     `json:"check_in"`
+	CheckOut   time.Time     `json:"check_out"`
+	Currency   string        `json:"currency"`
+	Nights     []NightlyRate `json:"nights"`
+	Subtotal   float64       `json:"subtotal"`
+	Discount   float64       `json:"discount"`
+	Total      float64       `json:"total"`
+}
+
+// NightlyRate es el precio de una noche con las reglas que se le aplicaron
+type NightlyRate struct {
+	Date      time.Time `json:"date"`
+	BasePrice float64   `json:"base_price"`
+	Price     float64   `json:"price"`
+	Rules     []string  `json:"rules,omitempty"`
+}
@@ -1,17 +1,50 @@
 package hotels
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// Errores de negocio de reservas, los controllers los traducen a status HTTP
+var (
+	ErrNoAvailability          = errors.New("no rooms available for the requested dates")
+	ErrInvalidDateRange        = errors.New("check-out date must be after check-in date")
+	ErrInvalidStatusTransition = errors.New("invalid reservation status transition")
+	ErrInvalidRoomType         = errors.New("invalid room type")
+	ErrRoomTypeNotFound        = errors.New("room type not found")
+)
+
+// Estados del ciclo de vida de una reserva
+const (
+	ReservationStatusPending   = "PENDING"
+	ReservationStatusConfirmed = "CONFIRMED"
+	ReservationStatusCancelled = "CANCELLED"
+	ReservationStatusCheckedIn = "CHECKED_IN"
+	ReservationStatusCompleted = "COMPLETED"
+	ReservationStatusNoShow    = "NO_SHOW"
+)
 
 type Reservation struct {
-	ID       string    `json:"id"`
-	HotelID  string    `json:"hotel_id"`
-	HotelName string   `json:"hotel_name"`
-	UserID   string    `json:"user_id"`
-	CheckIn  time.Time `json:"check_in"`
-	CheckOut time.Time `json:"check_out"`
+	ID            string                    `json:"id"`
+	HotelID       string                    `json:"hotel_id"`
+	HotelName     string                    `json:"hotel_name"`
+	UserID        string                    `json:"user_id"`
+	CheckIn       time.Time                 `json:"check_in"`
+	CheckOut      time.Time                 `json:"check_out"`
+	RoomTypeID    string                    `json:"room_type_id"`
+	TotalPrice    float64                   `json:"total_price"`
+	Currency      string                    `json:"currency"`
+	Status        string                    `json:"status"`
+	StatusHistory []ReservationStatusChange `json:"status_history"`
+}
+
+// ReservationStatusChange registra cuando una reserva paso a un estado
+type ReservationStatusChange struct {
+	Status string    `json:"status"`
+	At     time.Time `json:"at"`
 }
 
 type ReservationNew struct {
-	Operation string `json:"operation"`
-	ReservationID   string `json:"reservation_id"`
-}
\ No newline at end of file
+	Operation     string `json:"operation"`
+	ReservationID string `json:"reservation_id"`
+}
@@ -7,6 +7,8 @@ import (
 	"strconv"
 	"strings"
 
+	hotelsDomain "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/domain/hotels"
+
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -76,6 +78,8 @@ func (m JWTMiddleware) Authenticate() gin.HandlerFunc {
 		// Almacena el tipo de usuario y user_id en el contexto para usarlo posteriormente
 		c.Set("userType", userType)
 		c.Set("userID", userID)
+		// Y en el contexto de la request, para que el servicio registre quien hizo cada cambio
+		c.Request = c.Request.WithContext(hotelsDomain.WithActor(c.Request.Context(), hotelsDomain.Actor{UserID: userID, UserType: userType}))
 
 		c.Next()
 	}
@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxBodySize corta el body de la request en maxBytes. El handler recibe un *http.MaxBytesError al leer de mas
+// y decide la respuesta (413); sirve para rutas que leen el body en streaming, como la importacion de hoteles
+func MaxBodySize(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		c.Next()
+	}
+}
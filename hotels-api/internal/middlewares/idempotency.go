@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	idempotencyDAO "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/dao/idempotency"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// IdempotencyKeyHeader es el header que manda el cliente para poder reintentar sin duplicar
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marca las respuestas repetidas desde el registro guardado
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// IdempotencyStore guarda la primera respuesta de cada clave (Mongo en produccion, compartido entre replicas)
+type IdempotencyStore interface {
+	Reserve(ctx context.Context, record idempotencyDAO.Record) (idempotencyDAO.Record, bool, error)
+	Complete(ctx context.Context, key string, responseStatus int, contentType string, body []byte) error
+	Release(ctx context.Context, key string) error
+}
+
+type IdempotencyMiddleware struct {
+	store IdempotencyStore
+}
+
+func NewIdempotencyMiddleware(store IdempotencyStore) IdempotencyMiddleware {
+	return IdempotencyMiddleware{store: store}
+}
+
+// responseRecorder copia el body de la respuesta mientras se escribe al cliente
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Handle repite la respuesta guardada cuando llega la misma Idempotency-Key con el mismo body.
+// Debe ir despues de Authenticate: la clave se separa por usuario y por ruta.
+// Sin header la request sigue normalmente.
+func (m IdempotencyMiddleware) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idempotencyKey := strings.TrimSpace(c.GetHeader(IdempotencyKeyHeader))
+		if idempotencyKey == "" {
+			c.Next()
+			return
+		}
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength)})
+			return
+		}
+
+		// Leer el body para calcular el hash y volver a dejarlo disponible para el controller
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "error reading request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		hash := sha256.Sum256(body)
+
+		userID, _ := c.Get("userID")
+		record := idempotencyDAO.Record{
+			Key:         fmt.Sprintf("%v:%s %s:%s", userID, c.Request.Method, c.FullPath(), idempotencyKey),
+			RequestHash: hex.EncodeToString(hash[:]),
+		}
+
+		ctx := c.Request.Context()
+		stored, created, err := m.store.Reserve(ctx, record)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("error checking %s: %s", IdempotencyKeyHeader, err.Error())})
+			return
+		}
+
+		if !created {
+			switch {
+			case stored.RequestHash != record.RequestHash:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("%s was already used with a different request body", IdempotencyKeyHeader)})
+			case stored.Status != idempotencyDAO.StatusCompleted:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("a request with this %s is still being processed", IdempotencyKeyHeader)})
+			default:
+				c.Header(IdempotentReplayedHeader, "true")
+				c.Data(stored.ResponseStatus, stored.ContentType, stored.ResponseBody)
+				c.Abort()
+			}
+			return
+		}
+
+		// El cliente pudo haber cortado por timeout: la respuesta se guarda igual para su reintento
+		ctx = context.WithoutCancel(ctx)
+
+		// Si el handler hace panic la clave se libera antes de que Recovery responda 500
+		defer func() {
+			if r := recover(); r != nil {
+				m.release(ctx, record.Key)
+				panic(r)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// Los errores 5xx no se guardan: el cliente puede reintentar con la misma clave
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			m.release(ctx, record.Key)
+			return
+		}
+		if err := m.store.Complete(ctx, record.Key, status, recorder.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+			log.Printf("error storing idempotent response for %s: %v", record.Key, err)
+		}
+	}
+}
+
+// release borra la clave PENDING para que el cliente pueda reintentar
+func (m IdempotencyMiddleware) release(ctx context.Context, key string) {
+	if err := m.store.Release(ctx, key); err != nil {
+		log.Printf("error releasing idempotency key %s: %v", key, err)
+	}
+}
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	idempotencyDAO "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/dao/idempotency"
+
+	repositoriesIdempotency "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/repositories/idempotency"
+	"github.com/gin-gonic/gin"
+)
+
+// setupIdempotencyRouter simula Authenticate poniendo el userID del header X-User
+func setupIdempotencyRouter(handler gin.HandlerFunc) *gin.Engine {
+	return setupIdempotencyRouterWithStore(repositoriesIdempotency.NewMock(time.Minute), handler)
+}
+
+func setupIdempotencyRouterWithStore(store IdempotencyStore, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(gin.Recovery())
+	idempotency := NewIdempotencyMiddleware(store)
+	r.POST("/reservations", func(c *gin.Context) {
+		c.Set("userID", c.GetHeader("X-User"))
+		c.Next()
+	}, idempotency.Handle(), handler)
+	return r
+}
+
+func sendWithKey(r *gin.Engine, user string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/reservations", strings.NewReader(`{"hotel_id":"h1"}`))
+	req.Header.Set("X-User", user)
+	req.Header.Set(IdempotencyKeyHeader, "key-1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_ServerErrorIsNotStored(t *testing.T) {
+	calls := 0
+	r := setupIdempotencyRouter(func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "boom"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": "res1"})
+	})
+
+	if w := sendWithKey(r, "1"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("code=%d want=%d", w.Code, http.StatusInternalServerError)
+	}
+	// El reintento con la misma clave se procesa de nuevo
+	if w := sendWithKey(r, "1"); w.Code != http.StatusCreated || calls != 2 {
+		t.Fatalf("code=%d calls=%d, expected retry to run the handler", w.Code, calls)
+	}
+}
+
+func TestIdempotency_KeysAreScopedByUser(t *testing.T) {
+	calls := 0
+	r := setupIdempotencyRouter(func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusCreated, gin.H{"id": c.GetString("userID")})
+	})
+
+	sendWithKey(r, "1")
+	w := sendWithKey(r, "2")
+	if calls != 2 || w.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Fatalf("expected a different user to get its own response, calls=%d", calls)
+	}
+}
+
+func TestIdempotency_PanicReleasesKey(t *testing.T) {
+	calls := 0
+	r := setupIdempotencyRouter(func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": "res1"})
+	})
+
+	if w := sendWithKey(r, "1"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("code=%d want=%d", w.Code, http.StatusInternalServerError)
+	}
+	// La clave no queda tomada: el reintento se procesa de nuevo
+	if w := sendWithKey(r, "1"); w.Code != http.StatusCreated || calls != 2 {
+		t.Fatalf("code=%d calls=%d, expected retry to run the handler", w.Code, calls)
+	}
+}
+
+func TestIdempotency_PendingLease(t *testing.T) {
+	// Registro PENDING que dejo una replica que se cayo a mitad de la request
+	hash := sha256.Sum256([]byte(`{"hotel_id":"h1"}`))
+	pending := idempotencyDAO.Record{Key: "1:POST /reservations:key-1", RequestHash: hex.EncodeToString(hash[:])}
+
+	t.Run("in progress", func(t *testing.T) {
+		store := repositoriesIdempotency.NewMock(time.Minute)
+		store.Reserve(context.Background(), pending)
+		r := setupIdempotencyRouterWithStore(store, func(c *gin.Context) {
+			c.JSON(http.StatusCreated, gin.H{"id": "res1"})
+		})
+
+		if w := sendWithKey(r, "1"); w.Code != http.StatusConflict {
+			t.Fatalf("code=%d want=%d", w.Code, http.StatusConflict)
+		}
+	})
+
+	t.Run("expired lease is taken over", func(t *testing.T) {
+		store := repositoriesIdempotency.NewMock(-time.Second)
+		store.Reserve(context.Background(), pending)
+		r := setupIdempotencyRouterWithStore(store, func(c *gin.Context) {
+			c.JSON(http.StatusCreated, gin.H{"id": "res1"})
+		})
+
+		if w := sendWithKey(r, "1"); w.Code != http.StatusCreated {
+			t.Fatalf("code=%d want=%d", w.Code, http.StatusCreated)
+		}
+	})
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	hotelsDomain "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/domain/hotels"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// RequestIDHeader identifica la request en los logs y en el registro de auditoria (nginx lo manda con $request_id)
+	RequestIDHeader = "X-Request-ID"
+
+	maxRequestIDLength = 128
+)
+
+// RequestID usa el X-Request-ID que llega (o genera uno), lo devuelve en la respuesta y lo deja en el contexto de la request
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.New().String()
+		}
+
+		c.Header(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(hotelsDomain.WithRequestID(c.Request.Context(), requestID))
+
+		c.Next()
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	hotelsDomain "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/domain/hotels"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestID())
+	r.GET("/ping", func(c *gin.Context) {
+		// El handler lo ve en el contexto de la request, igual que el servicio
+		c.String(http.StatusOK, hotelsDomain.RequestIDFromContext(c.Request.Context()))
+	})
+
+	tests := []struct {
+		name     string
+		incoming string
+		reused   bool
+	}{
+		{name: "reuses incoming id", incoming: "abc-123", reused: true},
+		{name: "generates when missing", incoming: "", reused: false},
+		{name: "generates when too long", incoming: strings.Repeat("x", maxRequestIDLength+1), reused: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			requestID := w.Header().Get(RequestIDHeader)
+			if requestID == "" || w.Body.String() != requestID {
+				t.Fatalf("expected the same id in header and context, got %q and %q", requestID, w.Body.String())
+			}
+			if (requestID == tt.incoming) != tt.reused {
+				t.Fatalf("incoming %q, got %q", tt.incoming, requestID)
+			}
+		})
+	}
+}
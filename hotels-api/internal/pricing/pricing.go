@@ -0,0 +1,90 @@
+package pricing
+
+import (
+	"math"
+	"time"
+
+	hotelsDomain "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/domain/hotels"
+)
+
+// Quote calcula el desglose por noche de una estadia (excluye el dia de checkout).
+// Cada noche parte del precio base y se le aplican, multiplicando, las reglas SEASON que la cubren y las WEEKEND si es viernes o sabado.
+// Sobre el subtotal se aplica el mayor descuento MIN_STAY que alcance la cantidad de noches.
+// Las reglas con RoomTypeID solo aplican a ese tipo de habitacion.
+func Quote(basePrice float64, currency string, roomTypeID string, rules []hotelsDomain.RateRule, checkIn, checkOut time.Time) (hotelsDomain.Quote, error) {
+	start := dateOf(checkIn)
+	end := dateOf(checkOut)
+	if !end.After(start) {
+		return hotelsDomain.Quote{}, hotelsDomain.ErrInvalidDateRange
+	}
+	if currency == "" {
+		currency = hotelsDomain.DefaultCurrency
+	}
+
+	// Solo se usan las reglas generales y las del tipo de habitacion pedido
+	var applicable []hotelsDomain.RateRule
+	for _, rule := range rules {
+		if rule.RoomTypeID == "" || rule.RoomTypeID == roomTypeID {
+			applicable = append(applicable, rule)
+		}
+	}
+
+	quote := hotelsDomain.Quote{
+		RoomTypeID: roomTypeID,
+		CheckIn:    checkIn,
+		CheckOut:   checkOut,
+		Currency:   currency,
+		Nights:     make([]hotelsDomain.NightlyRate, 0),
+	}
+
+	for night := start; night.Before(end); night = night.AddDate(0, 0, 1) {
+		price := basePrice
+		var applied []string
+		for _, rule := range applicable {
+			switch rule.Type {
+			case hotelsDomain.RateRuleSeason:
+				if !night.Before(dateOf(rule.StartDate)) && !night.After(dateOf(rule.EndDate)) {
+					price *= rule.Multiplier
+					applied = append(applied, rule.Name)
+				}
+			case hotelsDomain.RateRuleWeekend:
+				if night.Weekday() == time.Friday || night.Weekday() == time.Saturday {
+					price *= rule.Multiplier
+					applied = append(applied, rule.Name)
+				}
+			}
+		}
+
+		price = round(price)
+		quote.Nights = append(quote.Nights, hotelsDomain.NightlyRate{
+			Date:      night,
+			BasePrice: basePrice,
+			Price:     price,
+			Rules:     applied,
+		})
+		quote.Subtotal += price
+	}
+	quote.Subtotal = round(quote.Subtotal)
+
+	// Descuento por estadia minima: se toma el mayor de los que aplican, no se acumulan
+	discountPercent := 0.0
+	for _, rule := range applicable {
+		if rule.Type == hotelsDomain.RateRuleMinStay && len(quote.Nights) >= rule.MinNights && rule.DiscountPercent > discountPercent {
+			discountPercent = rule.DiscountPercent
+		}
+	}
+	quote.Discount = round(quote.Subtotal * discountPercent / 100)
+	quote.Total = round(quote.Subtotal - quote.Discount)
+
+	return quote, nil
+}
+
+// dateOf devuelve el dia calendario de t a medianoche UTC
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// round redondea a centavos
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,86 @@
+package pricing
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	hotelsDomain "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/domain/hotels"
+)
+
+func date(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		t.Fatalf("error parsing date %s: %v", value, err)
+	}
+	return parsed
+}
+
+func TestQuote_BasePriceOnly(t *testing.T) {
+	// 2024-01-01 es lunes: tres noches sin reglas
+	quote, err := Quote(100, "", "", nil, date(t, "2024-01-01"), date(t, "2024-01-04"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(quote.Nights) != 3 || quote.Total != 300 || quote.Currency != hotelsDomain.DefaultCurrency {
+		t.Fatalf("unexpected quote: %+v", quote)
+	}
+}
+
+func TestQuote_SeasonWeekendAndMinStay(t *testing.T) {
+	rules := []hotelsDomain.RateRule{
+		{Name: "High season", Type: hotelsDomain.RateRuleSeason, StartDate: date(t, "2024-01-05"), EndDate: date(t, "2024-01-06"), Multiplier: 1.5},
+		{Name: "Weekend", Type: hotelsDomain.RateRuleWeekend, Multiplier: 1.2},
+		{Name: "Week stay", Type: hotelsDomain.RateRuleMinStay, MinNights: 7, DiscountPercent: 10},
+		{Name: "Long stay", Type: hotelsDomain.RateRuleMinStay, MinNights: 5, DiscountPercent: 5},
+	}
+
+	// Jueves 4 a lunes 8: jueves normal, viernes y sabado temporada + fin de semana, domingo normal
+	quote, err := Quote(100, "EUR", "", rules, date(t, "2024-01-04"), date(t, "2024-01-08"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []float64{100, 180, 180, 100}
+	for i, night := range quote.Nights {
+		if night.Price != expected[i] {
+			t.Fatalf("night %d: price=%v want=%v", i, night.Price, expected[i])
+		}
+	}
+	if quote.Subtotal != 560 || quote.Discount != 0 || quote.Total != 560 || quote.Currency != "EUR" {
+		t.Fatalf("unexpected totals: %+v", quote)
+	}
+
+	// Con 7 noches aplica el mayor descuento (10%), no se acumula con el de 5 noches
+	quote, err = Quote(100, "EUR", "", rules, date(t, "2024-01-08"), date(t, "2024-01-15"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Lunes 8 a lunes 15: viernes 12 y sabado 13 con fin de semana
+	if quote.Subtotal != 740 || quote.Discount != 74 || quote.Total != 666 {
+		t.Fatalf("unexpected totals: %+v", quote)
+	}
+}
+
+func TestQuote_RoomTypeScopedRules(t *testing.T) {
+	rules := []hotelsDomain.RateRule{
+		{Name: "Suite season", Type: hotelsDomain.RateRuleSeason, RoomTypeID: "suite", StartDate: date(t, "2024-01-01"), EndDate: date(t, "2024-01-31"), Multiplier: 2},
+	}
+
+	quote, _ := Quote(100, "", "single", rules, date(t, "2024-01-01"), date(t, "2024-01-02"))
+	if quote.Total != 100 {
+		t.Fatalf("rule for another room type should not apply, got %+v", quote)
+	}
+	quote, _ = Quote(100, "", "suite", rules, date(t, "2024-01-01"), date(t, "2024-01-02"))
+	if quote.Total != 200 {
+		t.Fatalf("rule for the room type should apply, got %+v", quote)
+	}
+}
+
+func TestQuote_InvalidDateRange(t *testing.T) {
+	_, err := Quote(100, "", "", nil, date(t, "2024-01-02"), date(t, "2024-01-02"))
+	if !errors.Is(err, hotelsDomain.ErrInvalidDateRange) {
+		t.Fatalf("expected ErrInvalidDateRange, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	auditDAO "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/dao/audit"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Mock simula la coleccion del registro de auditoria en memoria
+type Mock struct {
+	mu      *sync.Mutex
+	entries *[]auditDAO.Entry
+}
+
+func NewMock() Mock {
+	return Mock{
+		mu:      &sync.Mutex{},
+		entries: &[]auditDAO.Entry{},
+	}
+}
+
+// Igual que Mongo, el ID es un ObjectID nuevo (crecen en el orden en que se guardan)
+func (m Mock) Insert(ctx context.Context, entry auditDAO.Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry.ID = primitive.NewObjectID().Hex()
+	*m.entries = append(*m.entries, entry)
+	return nil
+}
+
+func (m Mock) InsertMany(ctx context.Context, entries []auditDAO.Entry) error {
+	for _, entry := range entries {
+		if err := m.Insert(ctx, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m Mock) Find(ctx context.Context, query auditDAO.Query) ([]auditDAO.Entry, error) {
+	entries := make([]auditDAO.Entry, 0)
+	err := m.Stream(ctx, query, func(entry auditDAO.Entry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	return entries, err
+}
+
+func (m Mock) Stream(ctx context.Context, query auditDAO.Query, fn func(auditDAO.Entry) error) error {
+	if query.BeforeID != "" {
+		if _, err := primitive.ObjectIDFromHex(query.BeforeID); err != nil {
+			return fmt.Errorf("%w: %s", auditDAO.ErrInvalidCursor, query.BeforeID)
+		}
+	}
+
+	m.mu.Lock()
+	var matched []auditDAO.Entry
+	for _, entry := range *m.entries {
+		if matches(entry, query) {
+			matched = append(matched, entry)
+		}
+	}
+	m.mu.Unlock()
+
+	// Los IDs en hex tienen el mismo largo, el orden de los strings es el de los ObjectID
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID > matched[j].ID })
+	if query.Limit > 0 && len(matched) > query.Limit {
+		matched = matched[:query.Limit]
+	}
+	for _, entry := range matched {
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matches aplica los filtros de query a una entrada
+func matches(entry auditDAO.Entry, query auditDAO.Query) bool {
+	switch {
+	case query.UserID != "" && entry.UserID != query.UserID,
+		query.Action != "" && entry.Action != query.Action,
+		query.TargetType != "" && entry.TargetType != query.TargetType,
+		query.TargetID != "" && entry.TargetID != query.TargetID,
+		query.HotelID != "" && entry.HotelID != query.HotelID,
+		!query.From.IsZero() && entry.CreatedAt.Before(query.From),
+		!query.To.IsZero() && !entry.CreatedAt.Before(query.To),
+		query.BeforeID != "" && entry.ID >= query.BeforeID:
+		return false
+	}
+	return true
+}
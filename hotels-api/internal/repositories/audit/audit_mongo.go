@@ -0,0 +1,182 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	auditDAO "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/dao/audit"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const connectionURI = "mongodb://%s:%s"
+
+type MongoConfig struct {
+	Host       string
+	Port       string
+	Username   string
+	Password   string
+	Database   string
+	Collection string
+}
+
+// Mongo guarda el registro de auditoria en una coleccion que solo recibe inserciones
+type Mongo struct {
+	client     *mongo.Client
+	database   string
+	collection string
+}
+
+func NewMongo(config MongoConfig) Mongo {
+	credentials := options.Credential{
+		Username: config.Username,
+		Password: config.Password,
+	}
+
+	//Crea el contexto
+	ctx := context.Background()
+	//Crea la URI de conexion
+	uri := fmt.Sprintf(connectionURI, config.Host, config.Port)
+	//Crea la configuracion de conexion
+	cfg := options.Client().ApplyURI(uri).SetAuth(credentials)
+
+	//Crea la conexion a MongoDB
+	client, err := mongo.Connect(ctx, cfg)
+	if err != nil {
+		log.Panicf("error connecting to mongo DB: %v", err)
+	}
+
+	repository := Mongo{
+		client:     client,
+		database:   config.Database,
+		collection: config.Collection,
+	}
+
+	// Indices de los filtros de GET /admin/audit, todos con _id al final para paginar de los mas nuevos a los mas viejos
+	_, err = repository.entries().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "action", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "target_type", Value: 1}, {Key: "target_id", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "hotel_id", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+	})
+	if err != nil {
+		log.Printf("error creating audit indexes: %v", err)
+	}
+
+	return repository
+}
+
+func (repository Mongo) entries() *mongo.Collection {
+	return repository.client.Database(repository.database).Collection(repository.collection)
+}
+
+// Insert guarda una entrada del registro
+func (repository Mongo) Insert(ctx context.Context, entry auditDAO.Entry) error {
+	if _, err := repository.entries().InsertOne(ctx, entry); err != nil {
+		return fmt.Errorf("error inserting audit entry: %w", err)
+	}
+	return nil
+}
+
+// InsertMany guarda varias entradas en un solo InsertMany (las de una importacion)
+func (repository Mongo) InsertMany(ctx context.Context, entries []auditDAO.Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	documents := make([]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		documents = append(documents, entry)
+	}
+	if _, err := repository.entries().InsertMany(ctx, documents, options.InsertMany().SetOrdered(false)); err != nil {
+		return fmt.Errorf("error inserting audit entries: %w", err)
+	}
+	return nil
+}
+
+// Find devuelve una pagina del registro con los filtros de query, las entradas mas nuevas primero
+func (repository Mongo) Find(ctx context.Context, query auditDAO.Query) ([]auditDAO.Entry, error) {
+	entries := make([]auditDAO.Entry, 0)
+	err := repository.Stream(ctx, query, func(entry auditDAO.Entry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Stream recorre el registro con los filtros de query (las entradas mas nuevas primero) sin cargarlo entero en memoria.
+// Corta en el primer error de fn
+func (repository Mongo) Stream(ctx context.Context, query auditDAO.Query, fn func(auditDAO.Entry) error) error {
+	filter, err := auditFilter(query)
+	if err != nil {
+		return err
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}})
+	if query.Limit > 0 {
+		opts.SetLimit(int64(query.Limit))
+	}
+	cursor, err := repository.entries().Find(ctx, filter, opts)
+	if err != nil {
+		return fmt.Errorf("error finding audit entries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var entry auditDAO.Entry
+		if err := cursor.Decode(&entry); err != nil {
+			return fmt.Errorf("error decoding audit entry: %w", err)
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("error reading audit entries: %w", err)
+	}
+	return nil
+}
+
+// auditFilter arma el filtro de Mongo con los filtros de query que no estan vacios
+func auditFilter(query auditDAO.Query) (bson.M, error) {
+	filter := bson.M{}
+	fields := map[string]string{
+		"user_id":     query.UserID,
+		"action":      query.Action,
+		"target_type": query.TargetType,
+		"target_id":   query.TargetID,
+		"hotel_id":    query.HotelID,
+	}
+	for field, value := range fields {
+		if value != "" {
+			filter[field] = value
+		}
+	}
+
+	createdAt := bson.M{}
+	if !query.From.IsZero() {
+		createdAt["$gte"] = query.From
+	}
+	if !query.To.IsZero() {
+		createdAt["$lt"] = query.To
+	}
+	if len(createdAt) > 0 {
+		filter["created_at"] = createdAt
+	}
+
+	if query.BeforeID != "" {
+		objectID, err := primitive.ObjectIDFromHex(query.BeforeID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", auditDAO.ErrInvalidCursor, query.BeforeID)
+		}
+		filter["_id"] = bson.M{"$lt": objectID}
+	}
+	return filter, nil
+}
@@ -19,17 +19,104 @@ func normalizeDate(t time.Time) time.Time {
 	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
 }
 
+// countOccupancy cuenta las habitaciones ocupadas por tipo y noche dentro de [from, to) (excluye checkout).
+// Es el equivalente en memoria de la agregacion que usa Mongo en GetNightlyOccupancy.
+func countOccupancy(reservations []hotelsDAO.Reservation, from, to time.Time) []hotelsDAO.NightOccupancy {
+	from = normalizeDate(from)
+	to = normalizeDate(to)
+
+	type nightKey struct {
+		night      time.Time
+		roomTypeID string
+	}
+	counts := make(map[nightKey]int)
+	var order []nightKey
+	for _, reservation := range reservations {
+		// Las reservas canceladas no ocupan habitaciones
+		if reservation.Status == statusCancelled {
+			continue
+		}
+
+		// Iterar noches ocupadas: incluye check-in, excluye check-out
+		for date := normalizeDate(reservation.CheckIn); date.Before(normalizeDate(reservation.CheckOut)); date = date.AddDate(0, 0, 1) {
+			if date.Before(from) || !date.Before(to) {
+				continue
+			}
+			key := nightKey{night: date, roomTypeID: reservation.RoomTypeID}
+			if _, ok := counts[key]; !ok {
+				order = append(order, key)
+			}
+			counts[key]++
+		}
+	}
+
+	occupancy := make([]hotelsDAO.NightOccupancy, 0, len(order))
+	for _, key := range order {
+		occupancy = append(occupancy, hotelsDAO.NightOccupancy{
+			Night:      key.night,
+			RoomTypeID: key.roomTypeID,
+			Reserved:   counts[key],
+		})
+	}
+	return occupancy
+}
+
+// roomCapacities devuelve la capacidad por tipo de habitacion; los hoteles sin tipos usan un unico pool ""
+func roomCapacities(hotel hotelsDAO.Hotel) map[string]int {
+	if len(hotel.RoomTypes) == 0 {
+		return map[string]int{"": hotel.AvaiableRooms}
+	}
+	capacities := make(map[string]int, len(hotel.RoomTypes))
+	for _, roomType := range hotel.RoomTypes {
+		capacities[roomType.ID] = roomType.Count
+	}
+	return capacities
+}
+
+// roomsAvailable indica si queda al menos un tipo de habitacion libre en todas las noches del rango (excluye checkout).
+// occupancy es el conteo por noche y tipo de habitacion (ver countOccupancy / GetNightlyOccupancy).
+func roomsAvailable(hotel hotelsDAO.Hotel, occupancy []hotelsDAO.NightOccupancy, checkIn, checkOut time.Time) bool {
+	checkIn = normalizeDate(checkIn)
+	checkOut = normalizeDate(checkOut)
+
+	reserved := make(map[string]map[time.Time]int)
+	for _, night := range occupancy {
+		if reserved[night.RoomTypeID] == nil {
+			reserved[night.RoomTypeID] = make(map[time.Time]int)
+		}
+		reserved[night.RoomTypeID][normalizeDate(night.Night)] += night.Reserved
+	}
+
+	for roomTypeID, capacity := range roomCapacities(hotel) {
+		available := true
+		for date := checkIn; date.Before(checkOut); date = date.AddDate(0, 0, 1) {
+			if reserved[roomTypeID][date] >= capacity {
+				available = false
+				break
+			}
+		}
+		if available {
+			return true
+		}
+	}
+	return false
+}
+
 // updateHotelReservationsList mantiene sincronizada la lista agregada de reservas por hotel.
+// La lista solo existe completa (la carga SetReservationsByHotelID desde MongoDB): si no esta, no se crea con una sola reserva,
+// porque el calendario y la disponibilidad la tomarian como todas las reservas del hotel
 func (repository Cache) updateHotelReservationsList(_ context.Context, reservation hotelsDAO.Reservation, add bool) {
 	key := fmt.Sprintf("reservations:hotel:%s", reservation.HotelID)
 	item := repository.client.Get(key)
-
-	var reservations []hotelsDAO.Reservation
-	if item != nil && !item.Expired() {
-		if existingReservations, ok := item.Value().([]hotelsDAO.Reservation); ok {
-			reservations = existingReservations
-		}
+	if item == nil || item.Expired() {
+		return
+	}
+	existingReservations, ok := item.Value().([]hotelsDAO.Reservation)
+	if !ok {
+		return
 	}
+	// Copia: los lectores pueden estar recorriendo la lista guardada
+	reservations := append([]hotelsDAO.Reservation{}, existingReservations...)
 
 	if add {
 		// Agrega o reemplaza la reserva
@@ -54,11 +141,8 @@ func (repository Cache) updateHotelReservationsList(_ context.Context, reservati
 		}
 	}
 
-	if len(reservations) > 0 {
-		repository.client.Set(key, reservations, repository.duration)
-	} else {
-		repository.client.Delete(key)
-	}
+	// Una lista vacia tambien es completa: el hotel no tiene reservas
+	repository.client.Set(key, reservations, repository.duration)
 }
 
 // updateUserReservationsList mantiene sincronizada la lista agregada de reservas por usuario.
@@ -193,81 +277,25 @@ func (repository Cache) Create(ctx context.Context, hotel hotelsDAO.Hotel) (stri
 	return hotel.ID, nil
 }
 
-// Actualiza un hotel en la cache
-func (repository Cache) Update(ctx context.Context, hotel hotelsDAO.Hotel) error {
-	key := fmt.Sprintf(keyFormat, hotel.ID)
-
-	// Busca el item actual en la cache y regresa un error si no se encuentra o esta expirado
-	item := repository.client.Get(key)
-	if item == nil {
-		return fmt.Errorf("hotel with ID %s not found in cache", hotel.ID)
-	}
-	if item.Expired() {
-		return fmt.Errorf("item with key %s is expired", key)
-	}
-
-	// Convierte el item a un hotel
-	currentHotel, ok := item.Value().(hotelsDAO.Hotel)
-	if !ok {
-		return fmt.Errorf("error converting item with key %s", key)
-	}
-
-	// Actualiza solo los campos que no son cero o vacios
-	if hotel.Name != "" {
-		currentHotel.Name = hotel.Name
-	}
-	if hotel.Description != "" {
-		currentHotel.Description = hotel.Description
-	}
-	if hotel.Address != "" {
-		currentHotel.Address = hotel.Address
-	}
-	if hotel.City != "" {
-		currentHotel.City = hotel.City
-	}
-	if hotel.State != "" {
-		currentHotel.State = hotel.State
-	}
-	if hotel.Country != "" {
-		currentHotel.Country = hotel.Country
-	}
-	if hotel.Phone != "" {
-		currentHotel.Phone = hotel.Phone
-	}
-	if hotel.Email != "" {
-		currentHotel.Email = hotel.Email
-	}
-	if hotel.PricePerNight != 0 {
-		currentHotel.PricePerNight = hotel.PricePerNight
-	}
-	if hotel.AvaiableRooms != 0 {
-		currentHotel.AvaiableRooms = hotel.AvaiableRooms
+// CreateMany guarda varios hoteles en la cache, igual que Create
+func (repository Cache) CreateMany(ctx context.Context, hotels []hotelsDAO.Hotel) ([]string, error) {
+	ids := make([]string, 0, len(hotels))
+	for _, hotel := range hotels {
+		id, _ := repository.Create(ctx, hotel)
+		ids = append(ids, id)
 	}
-	if !hotel.CheckInTime.IsZero() {
-		currentHotel.CheckInTime = hotel.CheckInTime
-	}
-	if !hotel.CheckOutTime.IsZero() {
-		currentHotel.CheckOutTime = hotel.CheckOutTime
-	}
-	if hotel.Rating != 0 {
-		currentHotel.Rating = hotel.Rating
-	}
-	if len(hotel.Amenities) > 0 {
-		currentHotel.Amenities = hotel.Amenities
-	}
-	if len(hotel.Images) > 0 {
-		currentHotel.Images = hotel.Images
-	}
-
-	// Guarda el hotel actualizado en la cache y reinicia el tiempo de expiracion
-	repository.client.Set(key, currentHotel, repository.duration)
+	return ids, nil
+}
 
-	//Devuelve nil si no hay errores
+// Update descarta el hotel de la cache: el service vuelve a cargar el documento de la base,
+// asi la cache nunca guarda lo que vino en el request
+func (repository Cache) Update(ctx context.Context, hotel hotelsDAO.Hotel) error {
+	repository.client.Delete(fmt.Sprintf(keyFormat, hotel.ID))
 	return nil
 }
 
 // Elimina un hotel de la cache
-func (repository Cache) Delete(ctx context.Context, id string) error {
+func (repository Cache) Delete(ctx context.Context, id string, version int64, event hotelsDAO.OutboxEvent) error {
 	key := fmt.Sprintf(keyFormat, id)
 	// Elimina el hotel de la cache
 	repository.client.Delete(key)
@@ -305,26 +333,30 @@ func (repository Cache) GetReservationByID(ctx context.Context, id string) (hote
 	return reservation, nil
 }
 
-// Cancela una reserva en la cache
-func (repository Cache) CancelReservation(ctx context.Context, id string) error {
-	// Obtener la reserva para limpiar listas
+// Cambia el estado de una reserva en la cache (reserva individual y listas agregadas).
+// Si la reserva no esta en cache no hay nada que actualizar y devuelve false.
+func (repository Cache) UpdateReservationStatus(ctx context.Context, id string, from string, to string, at time.Time) (bool, error) {
 	reservation, err := repository.GetReservationByID(ctx, id)
 	if err != nil {
-		// Si no existe, eliminar la clave individual y salir
-		key := fmt.Sprintf("reservation:%s", id)
-		repository.client.Delete(key)
-		return nil
+		return false, nil
+	}
+	if reservation.Status != from {
+		// La copia en cache esta desactualizada, se descarta (junto con sus listas) para forzar la lectura desde la base
+		repository.client.Delete(fmt.Sprintf("reservation:%s", id))
+		repository.client.Delete(fmt.Sprintf("reservations:hotel:%s", reservation.HotelID))
+		repository.client.Delete(fmt.Sprintf("reservations:user:%s", reservation.UserID))
+		repository.client.Delete(fmt.Sprintf("reservations:hotel:%s:user:%s", reservation.HotelID, reservation.UserID))
+		return false, nil
 	}
 
-	key := fmt.Sprintf("reservation:%s", id)
-	repository.client.Delete(key)
-
-	// Limpiar listas agregadas
-	repository.updateHotelReservationsList(ctx, reservation, false)
-	repository.updateUserReservationsList(ctx, reservation, false)
-	repository.updateUserHotelReservationsList(ctx, reservation, false)
+	reservation.Status = to
+	reservation.StatusHistory = append(append([]hotelsDAO.ReservationStatusChange{}, reservation.StatusHistory...), hotelsDAO.ReservationStatusChange{Status: to, At: at})
 
-	return nil
+	// CreateReservation reemplaza la reserva individual y en las listas agregadas
+	if _, err := repository.CreateReservation(ctx, reservation); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 // Obtiene las reservas por ID de hotel y usuario de la cache
@@ -361,6 +393,14 @@ func (repository Cache) GetReservationsByHotelID(ctx context.Context, hotelID st
 	return reservations, nil
 }
 
+// SetReservationsByHotelID guarda la lista completa de reservas de un hotel leida de MongoDB (puede estar vacia).
+// Es la unica forma de cargar la lista: CreateReservation solo actualiza una lista que ya esta
+func (repository Cache) SetReservationsByHotelID(ctx context.Context, hotelID string, reservations []hotelsDAO.Reservation) error {
+	key := fmt.Sprintf("reservations:hotel:%s", hotelID)
+	repository.client.Set(key, append([]hotelsDAO.Reservation{}, reservations...), repository.duration)
+	return nil
+}
+
 // Obtiene las reservas por ID de usuario de la cache
 func (repository Cache) GetReservationsByUserID(ctx context.Context, userID string) ([]hotelsDAO.Reservation, error) {
 	key := fmt.Sprintf("reservations:user:%s", userID)
@@ -411,16 +451,20 @@ func (repository Cache) GetAvailability(ctx context.Context, hotelIDs []string,
 		}(id)
 	}
 
+	// Si a algun hotel le faltan datos en cache es un miss de la tanda completa: el servicio consulta MongoDB
 	availability := make(map[string]bool)
+	var missErr error
 	for i := 0; i < len(hotelIDs); i++ {
 		r := <-results
 		if r.err != nil {
-			// En caché, podemos continuar incluso si hay error en un hotel
-			availability[r.hotelID] = false
+			missErr = r.err
 			continue
 		}
 		availability[r.hotelID] = r.available
 	}
+	if missErr != nil {
+		return nil, missErr
+	}
 
 	return availability, nil
 }
@@ -454,9 +498,8 @@ func (repository Cache) IsHotelAvailable(ctx context.Context, hotelID, checkIn,
 	key := fmt.Sprintf("reservations:hotel:%s", hotelID)
 	item := repository.client.Get(key)
 	if item == nil || item.Expired() {
-		// Si no hay datos en caché, asumimos que no hay disponibilidad
-		// Esta es una decisión conservadora para evitar overboking
-		return false, nil
+		// Sin la lista completa no se sabe cuantas habitaciones hay ocupadas: es un miss, no un hotel completo
+		return false, fmt.Errorf("reservations for hotel %s not found in cache", hotelID)
 	}
 
 	reservations, ok := item.Value().([]hotelsDAO.Reservation)
@@ -464,31 +507,29 @@ func (repository Cache) IsHotelAvailable(ctx context.Context, hotelID, checkIn,
 		return false, fmt.Errorf("error converting cached reservations")
 	}
 
-	// Contar reservas por día usando un mapa (fechas normalizadas)
-	reservationsByDay := make(map[time.Time]int)
-	for _, reservation := range reservations {
-		resCheckIn := normalizeDate(reservation.CheckIn)
-		resCheckOut := normalizeDate(reservation.CheckOut)
-
-		// Se solapan si resCheckIn < checkOutTime y resCheckOut > checkInTime
-		if resCheckOut.After(checkInTime) && resCheckIn.Before(checkOutTime) {
-			// Iterar noches ocupadas: incluye check-in, excluye check-out
-			for date := resCheckIn; date.Before(resCheckOut); date = date.AddDate(0, 0, 1) {
-				if !date.Before(checkInTime) && date.Before(checkOutTime) {
-					reservationsByDay[date]++
-				}
-			}
-		}
+	return roomsAvailable(hotel, countOccupancy(reservations, checkInTime, checkOutTime), checkInTime, checkOutTime), nil
+}
+
+// GetNightlyOccupancy calcula la ocupacion por noche desde la lista de reservas en cache.
+// La lista solo esta si se cargo completa desde Mongo; si no esta devuelve error para que el servicio consulte Mongo.
+func (repository Cache) GetNightlyOccupancy(ctx context.Context, hotelID string, from, to time.Time) ([]hotelsDAO.NightOccupancy, error) {
+	key := fmt.Sprintf("reservations:hotel:%s", hotelID)
+	item := repository.client.Get(key)
+	if item == nil || item.Expired() {
+		return nil, fmt.Errorf("reservations for hotel %s not found in cache", hotelID)
 	}
 
-	// Verificar disponibilidad para cada noche solicitada (excluye día de checkout)
-	for date := checkInTime; date.Before(checkOutTime); date = date.AddDate(0, 0, 1) {
-		if reservationsByDay[date] >= hotel.AvaiableRooms {
-			return false, nil
-		}
+	reservations, ok := item.Value().([]hotelsDAO.Reservation)
+	if !ok {
+		return nil, fmt.Errorf("error converting cached reservations")
 	}
 
-	return true, nil
+	return countOccupancy(reservations, from, to), nil
+}
+
+// ListHotels siempre es un miss: la cache solo guarda hoteles sueltos, el listado sale de MongoDB
+func (repository Cache) ListHotels(ctx context.Context, query hotelsDAO.HotelQuery) ([]hotelsDAO.Hotel, error) {
+	return nil, fmt.Errorf("not found item: hotel listings are not cached")
 }
 
 // Elimina todas las reservas de un hotel de la cache
@@ -515,3 +556,49 @@ func (repository Cache) DeleteReservationsByHotelID(ctx context.Context, hotelID
 
 	return nil
 }
+
+// ReserveRooms no se soporta en cache: el inventario es compartido entre replicas y solo vive en MongoDB
+func (repository Cache) ReserveRooms(ctx context.Context, hotelID string, roomTypeID string, checkIn, checkOut time.Time, capacity int) (bool, error) {
+	return false, fmt.Errorf("room inventory is not managed by the cache")
+}
+
+// ReleaseRooms no hace nada en cache: no hay contadores de inventario que liberar
+func (repository Cache) ReleaseRooms(ctx context.Context, hotelID string, roomTypeID string, checkIn, checkOut time.Time) error {
+	return nil
+}
+
+// AddRoomType descarta el hotel de la cache para que la siguiente lectura traiga los tipos de habitacion desde la base
+func (repository Cache) AddRoomType(ctx context.Context, hotelID string, roomType hotelsDAO.RoomType, event hotelsDAO.OutboxEvent) error {
+	repository.client.Delete(fmt.Sprintf(keyFormat, hotelID))
+	return nil
+}
+
+// UpdateRoomType descarta el hotel de la cache para que la siguiente lectura traiga los tipos de habitacion desde la base
+func (repository Cache) UpdateRoomType(ctx context.Context, hotelID string, roomType hotelsDAO.RoomType, event hotelsDAO.OutboxEvent) (bool, error) {
+	repository.client.Delete(fmt.Sprintf(keyFormat, hotelID))
+	return true, nil
+}
+
+// DeleteRoomType descarta el hotel de la cache para que la siguiente lectura traiga los tipos de habitacion desde la base
+func (repository Cache) DeleteRoomType(ctx context.Context, hotelID string, roomTypeID string, event hotelsDAO.OutboxEvent) (bool, error) {
+	repository.client.Delete(fmt.Sprintf(keyFormat, hotelID))
+	return true, nil
+}
+
+// AddRateRule descarta el hotel de la cache para que la siguiente lectura traiga las reglas de tarifa desde la base
+func (repository Cache) AddRateRule(ctx context.Context, hotelID string, rule hotelsDAO.RateRule) error {
+	repository.client.Delete(fmt.Sprintf(keyFormat, hotelID))
+	return nil
+}
+
+// UpdateRateRule descarta el hotel de la cache para que la siguiente lectura traiga las reglas de tarifa desde la base
+func (repository Cache) UpdateRateRule(ctx context.Context, hotelID string, rule hotelsDAO.RateRule) (bool, error) {
+	repository.client.Delete(fmt.Sprintf(keyFormat, hotelID))
+	return true, nil
+}
+
+// DeleteRateRule descarta el hotel de la cache para que la siguiente lectura traiga las reglas de tarifa desde la base
+func (repository Cache) DeleteRateRule(ctx context.Context, hotelID string, ruleID string) (bool, error) {
+	repository.client.Delete(fmt.Sprintf(keyFormat, hotelID))
+	return true, nil
+}
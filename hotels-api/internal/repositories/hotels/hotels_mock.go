@@ -3,6 +3,8 @@ package hotels
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	hotelsDAO "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/dao/hotels"
@@ -43,8 +45,8 @@ func NewMockCache() MockCache {
 // CRUD de hoteles
 func (m Mock) GetHotelByID(ctx context.Context, id string) (hotelsDAO.Hotel, error) {
 	hotel, ok := m.hotels[id]
-	if !ok {
-		return hotelsDAO.Hotel{}, fmt.Errorf("hotel with ID %s not found", id)
+	if !ok || hotel.DeletedAt != nil {
+		return hotelsDAO.Hotel{}, fmt.Errorf("%w: %s", hotelsDAO.ErrHotelNotFound, id)
 	}
 	return hotel, nil
 }
@@ -56,24 +58,90 @@ func (m Mock) Create(ctx context.Context, hotel hotelsDAO.Hotel) (string, error)
 	return id, nil
 }
 
-func (m Mock) Update(ctx context.Context, hotel hotelsDAO.Hotel) error {
-	_, ok := m.hotels[hotel.ID]
-	if !ok {
-		return fmt.Errorf("hotel with ID %s not found", hotel.ID)
+func (m Mock) CreateMany(ctx context.Context, hotels []hotelsDAO.Hotel) ([]string, error) {
+	ids := make([]string, 0, len(hotels))
+	for _, hotel := range hotels {
+		id, _ := m.Create(ctx, hotel)
+		ids = append(ids, id)
 	}
+	return ids, nil
+}
+
+// Igual que Mongo, reemplaza los datos del hotel si sigue en hotel.Version y conserva tipos de habitacion, reglas de tarifa y outbox
+func (m Mock) Update(ctx context.Context, hotel hotelsDAO.Hotel) error {
+	current, ok := m.hotels[hotel.ID]
+	if !ok || current.DeletedAt != nil {
+		return fmt.Errorf("%w: %s", hotelsDAO.ErrHotelNotFound, hotel.ID)
+	}
+	if current.Version != hotel.Version {
+		return fmt.Errorf("%w: %s", hotelsDAO.ErrVersionConflict, hotel.ID)
+	}
+	hotel.Version++
+	hotel.RoomTypes = current.RoomTypes
+	hotel.RateRules = current.RateRules
+	hotel.EventSequence = current.EventSequence
+	hotel.Outbox = append(append([]hotelsDAO.OutboxEvent{}, current.Outbox...), hotel.Outbox...)
 	m.hotels[hotel.ID] = hotel
 	return nil
 }
 
-func (m Mock) Delete(ctx context.Context, id string) error {
-	_, ok := m.hotels[id]
+// Igual que Mongo, el hotel queda marcado como borrado hasta que se publica su evento DELETE
+func (m Mock) Delete(ctx context.Context, id string, version int64, event hotelsDAO.OutboxEvent) error {
+	hotel, ok := m.hotels[id]
+	if !ok || hotel.DeletedAt != nil {
+		return fmt.Errorf("%w: %s", hotelsDAO.ErrHotelNotFound, id)
+	}
+	if hotel.Version != version {
+		return fmt.Errorf("%w: %s", hotelsDAO.ErrVersionConflict, id)
+	}
+	hotel.Version++
+	now := time.Now().UTC()
+	hotel.DeletedAt = &now
+	m.hotels[id] = appendOutbox(hotel, event)
+	return nil
+}
+
+// Outbox de eventos de hoteles
+func (m Mock) GetPendingOutbox(ctx context.Context, limit int64) ([]hotelsDAO.Hotel, error) {
+	var pending []hotelsDAO.Hotel
+	for _, hotel := range m.hotels {
+		if len(hotel.Outbox) > 0 && int64(len(pending)) < limit {
+			pending = append(pending, hotel)
+		}
+	}
+	return pending, nil
+}
+
+func (m Mock) AckOutboxEvent(ctx context.Context, hotelID string, eventID string) error {
+	hotel, ok := m.hotels[hotelID]
 	if !ok {
-		return fmt.Errorf("hotel with ID %s not found", id)
+		return nil
 	}
-	delete(m.hotels, id)
+	var outbox []hotelsDAO.OutboxEvent
+	for _, event := range hotel.Outbox {
+		if event.ID != eventID {
+			outbox = append(outbox, event)
+		}
+	}
+	if len(outbox) == len(hotel.Outbox) {
+		return nil
+	}
+	hotel.Outbox = outbox
+	hotel.EventSequence++
+	if hotel.DeletedAt != nil && len(outbox) == 0 {
+		delete(m.hotels, hotelID)
+		return nil
+	}
+	m.hotels[hotelID] = hotel
 	return nil
 }
 
+// appendOutbox agrega un evento al outbox del hotel sin compartir el slice original
+func appendOutbox(hotel hotelsDAO.Hotel, event hotelsDAO.OutboxEvent) hotelsDAO.Hotel {
+	hotel.Outbox = append(append([]hotelsDAO.OutboxEvent{}, hotel.Outbox...), event)
+	return hotel
+}
+
 // CRUD de reservas
 func (m Mock) CreateReservation(ctx context.Context, reservation hotelsDAO.Reservation) (string, error) {
 	id := uuid.New().String()
@@ -90,13 +158,18 @@ func (m Mock) GetReservationByID(ctx context.Context, id string) (hotelsDAO.Rese
 	return reservation, nil
 }
 
-func (m Mock) CancelReservation(ctx context.Context, id string) error {
-	_, ok := m.reservas[id]
+func (m Mock) UpdateReservationStatus(ctx context.Context, id string, from string, to string, at time.Time) (bool, error) {
+	reservation, ok := m.reservas[id]
 	if !ok {
-		return fmt.Errorf("reservation with ID %s not found", id)
+		return false, fmt.Errorf("reservation with ID %s not found", id)
 	}
-	delete(m.reservas, id)
-	return nil
+	if reservation.Status != from {
+		return false, nil
+	}
+	reservation.Status = to
+	reservation.StatusHistory = append(append([]hotelsDAO.ReservationStatusChange{}, reservation.StatusHistory...), hotelsDAO.ReservationStatusChange{Status: to, At: at})
+	m.reservas[id] = reservation
+	return true, nil
 }
 
 func (m Mock) GetReservationsByHotelID(ctx context.Context, hotelID string) ([]hotelsDAO.Reservation, error) {
@@ -109,6 +182,11 @@ func (m Mock) GetReservationsByHotelID(ctx context.Context, hotelID string) ([]h
 	return result, nil
 }
 
+// SetReservationsByHotelID no hace nada: las reservas ya estan en el repositorio principal
+func (m Mock) SetReservationsByHotelID(ctx context.Context, hotelID string, reservations []hotelsDAO.Reservation) error {
+	return nil
+}
+
 func (m Mock) GetReservationsByUserAndHotelID(ctx context.Context, hotelID, userID string) ([]hotelsDAO.Reservation, error) {
 	var result []hotelsDAO.Reservation
 	for _, r := range m.reservas {
@@ -182,30 +260,213 @@ func (m Mock) IsHotelAvailable(ctx context.Context, hotelID, checkIn, checkOut s
 		return false, fmt.Errorf("check-out date must be after check-in date")
 	}
 
-	reservationsByDay := make(map[time.Time]int)
+	var reservations []hotelsDAO.Reservation
 	for _, reservation := range m.reservas {
-		if reservation.HotelID != hotelID {
+		if reservation.HotelID == hotelID {
+			reservations = append(reservations, reservation)
+		}
+	}
+
+	return roomsAvailable(hotel, countOccupancy(reservations, checkInTime, checkOutTime), checkInTime, checkOutTime), nil
+}
+
+// ListHotels aplica los filtros, el orden y la pagina de query sobre los hoteles del mock (no recorta campos)
+func (m Mock) ListHotels(ctx context.Context, query hotelsDAO.HotelQuery) ([]hotelsDAO.Hotel, error) {
+	var hotels []hotelsDAO.Hotel
+	for _, hotel := range m.hotels {
+		if hotel.DeletedAt != nil && !query.IncludeDeleted {
 			continue
 		}
+		if query.City != "" && !strings.EqualFold(hotel.City, query.City) {
+			continue
+		}
+		if query.Country != "" && !strings.EqualFold(hotel.Country, query.Country) {
+			continue
+		}
+		if query.MinRating != nil && hotel.Rating < *query.MinRating {
+			continue
+		}
+		if query.AfterID != "" && compareListed(hotel, query.SortField, query.AfterValue, query.AfterID, query.Descending) <= 0 {
+			continue
+		}
+		hotels = append(hotels, hotel)
+	}
+	sort.Slice(hotels, func(i, j int) bool {
+		return compareListed(hotels[i], query.SortField, sortValue(hotels[j], query.SortField), hotels[j].ID, query.Descending) < 0
+	})
+	if len(hotels) > query.Limit {
+		hotels = hotels[:query.Limit]
+	}
+	return hotels, nil
+}
 
-		resCheckIn := normalizeDate(reservation.CheckIn)
-		resCheckOut := normalizeDate(reservation.CheckOut)
+// compareListed compara la posicion de un hotel en el listado contra (value, id): negativo si va antes
+func compareListed(hotel hotelsDAO.Hotel, field string, value interface{}, otherID string, descending bool) int {
+	result := 0
+	switch current := sortValue(hotel, field).(type) {
+	case string:
+		result = strings.Compare(strings.ToLower(current), strings.ToLower(fmt.Sprint(value)))
+	case float64:
+		other, _ := value.(float64)
+		if current < other {
+			result = -1
+		} else if current > other {
+			result = 1
+		}
+	}
+	if result == 0 {
+		result = strings.Compare(hotel.ID, otherID)
+	}
+	if descending {
+		return -result
+	}
+	return result
+}
 
-		if resCheckOut.After(checkInTime) && resCheckIn.Before(checkOutTime) {
-			for date := resCheckIn; date.Before(resCheckOut); date = date.AddDate(0, 0, 1) {
-				if !date.Before(checkInTime) && date.Before(checkOutTime) {
-					reservationsByDay[date]++
-				}
-			}
+// sortValue devuelve el valor del campo de orden de un hotel (nil si se ordena solo por ID)
+func sortValue(hotel hotelsDAO.Hotel, field string) interface{} {
+	switch field {
+	case "name":
+		return hotel.Name
+	case "rating":
+		return hotel.Rating
+	case "price_per_night":
+		return hotel.PricePerNight
+	}
+	return nil
+}
+
+// GetNightlyOccupancy cuenta las habitaciones ocupadas por noche usando las reservas guardadas en el mock
+func (m Mock) GetNightlyOccupancy(ctx context.Context, hotelID string, from, to time.Time) ([]hotelsDAO.NightOccupancy, error) {
+	var reservations []hotelsDAO.Reservation
+	for _, reservation := range m.reservas {
+		if reservation.HotelID == hotelID {
+			reservations = append(reservations, reservation)
 		}
 	}
+	return countOccupancy(reservations, from, to), nil
+}
 
-	for date := checkInTime; date.Before(checkOutTime); date = date.AddDate(0, 0, 1) {
-		if reservationsByDay[date] >= hotel.AvaiableRooms {
+// ReserveRooms replica el control de capacidad por noche usando las reservas guardadas en el mock
+func (m Mock) ReserveRooms(ctx context.Context, hotelID string, roomTypeID string, checkIn, checkOut time.Time, capacity int) (bool, error) {
+	for night := normalizeDate(checkIn); night.Before(normalizeDate(checkOut)); night = night.AddDate(0, 0, 1) {
+		occupied := 0
+		for _, reservation := range m.reservas {
+			if reservation.HotelID == hotelID && reservation.RoomTypeID == roomTypeID && reservation.Status != statusCancelled &&
+				!normalizeDate(reservation.CheckIn).After(night) &&
+				normalizeDate(reservation.CheckOut).After(night) {
+				occupied++
+			}
+		}
+		if occupied >= capacity {
 			return false, nil
 		}
 	}
+	return true, nil
+}
+
+// ReleaseRooms no hace nada: la ocupacion del mock se calcula desde las reservas
+func (m Mock) ReleaseRooms(ctx context.Context, hotelID string, roomTypeID string, checkIn, checkOut time.Time) error {
+	return nil
+}
+
+// Tipos de habitacion
+func (m Mock) AddRoomType(ctx context.Context, hotelID string, roomType hotelsDAO.RoomType, event hotelsDAO.OutboxEvent) error {
+	hotel, ok := m.hotels[hotelID]
+	if !ok {
+		return fmt.Errorf("hotel with ID %s not found", hotelID)
+	}
+	hotel.RoomTypes = append(append([]hotelsDAO.RoomType{}, hotel.RoomTypes...), roomType)
+	hotel.Version++
+	m.hotels[hotelID] = appendOutbox(hotel, event)
+	return nil
+}
+
+func (m Mock) UpdateRoomType(ctx context.Context, hotelID string, roomType hotelsDAO.RoomType, event hotelsDAO.OutboxEvent) (bool, error) {
+	hotel, ok := m.hotels[hotelID]
+	if !ok {
+		return false, nil
+	}
+	roomTypes := append([]hotelsDAO.RoomType{}, hotel.RoomTypes...)
+	for i, rt := range roomTypes {
+		if rt.ID == roomType.ID {
+			roomTypes[i] = roomType
+			hotel.RoomTypes = roomTypes
+			hotel.Version++
+			m.hotels[hotelID] = appendOutbox(hotel, event)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m Mock) DeleteRoomType(ctx context.Context, hotelID string, roomTypeID string, event hotelsDAO.OutboxEvent) (bool, error) {
+	hotel, ok := m.hotels[hotelID]
+	if !ok {
+		return false, nil
+	}
+	var roomTypes []hotelsDAO.RoomType
+	for _, rt := range hotel.RoomTypes {
+		if rt.ID != roomTypeID {
+			roomTypes = append(roomTypes, rt)
+		}
+	}
+	if len(roomTypes) == len(hotel.RoomTypes) {
+		return false, nil
+	}
+	hotel.RoomTypes = roomTypes
+	hotel.Version++
+	m.hotels[hotelID] = appendOutbox(hotel, event)
+	return true, nil
+}
+
+// Reglas de tarifa
+func (m Mock) AddRateRule(ctx context.Context, hotelID string, rule hotelsDAO.RateRule) error {
+	hotel, ok := m.hotels[hotelID]
+	if !ok {
+		return fmt.Errorf("hotel with ID %s not found", hotelID)
+	}
+	hotel.RateRules = append(append([]hotelsDAO.RateRule{}, hotel.RateRules...), rule)
+	hotel.Version++
+	m.hotels[hotelID] = hotel
+	return nil
+}
+
+func (m Mock) UpdateRateRule(ctx context.Context, hotelID string, rule hotelsDAO.RateRule) (bool, error) {
+	hotel, ok := m.hotels[hotelID]
+	if !ok {
+		return false, nil
+	}
+	rules := append([]hotelsDAO.RateRule{}, hotel.RateRules...)
+	for i, r := range rules {
+		if r.ID == rule.ID {
+			rules[i] = rule
+			hotel.RateRules = rules
+			hotel.Version++
+			m.hotels[hotelID] = hotel
+			return true, nil
+		}
+	}
+	return false, nil
+}
 
+func (m Mock) DeleteRateRule(ctx context.Context, hotelID string, ruleID string) (bool, error) {
+	hotel, ok := m.hotels[hotelID]
+	if !ok {
+		return false, nil
+	}
+	var rules []hotelsDAO.RateRule
+	for _, r := range hotel.RateRules {
+		if r.ID != ruleID {
+			rules = append(rules, r)
+		}
+	}
+	if len(rules) == len(hotel.RateRules) {
+		return false, nil
+	}
+	hotel.RateRules = rules
+	hotel.Version++
+	m.hotels[hotelID] = hotel
 	return true, nil
 }
 
@@ -217,6 +478,15 @@ func (m MockCache) Create(ctx context.Context, hotel hotelsDAO.Hotel) (string, e
 	return hotel.ID, nil
 }
 
+func (m MockCache) CreateMany(ctx context.Context, hotels []hotelsDAO.Hotel) ([]string, error) {
+	ids := make([]string, 0, len(hotels))
+	for _, hotel := range hotels {
+		m.hotels[hotel.ID] = hotel
+		ids = append(ids, hotel.ID)
+	}
+	return ids, nil
+}
+
 // La cache NO crea reservas, solo las almacena
 func (m MockCache) CreateReservation(ctx context.Context, reservation hotelsDAO.Reservation) (string, error) {
 	m.reservas[reservation.ID] = reservation
@@ -241,31 +511,32 @@ func (m MockCache) GetHotelByID(ctx context.Context, id string) (hotelsDAO.Hotel
 	return hotel, nil
 }
 
+// La cache descarta el hotel cuando se actualiza, el service lo vuelve a cargar desde la base
 func (m MockCache) Update(ctx context.Context, hotel hotelsDAO.Hotel) error {
-	_, ok := m.hotels[hotel.ID]
-	if !ok {
-		return fmt.Errorf("hotel with ID %s not found in cache", hotel.ID)
-	}
-	m.hotels[hotel.ID] = hotel
+	delete(m.hotels, hotel.ID)
 	return nil
 }
 
-func (m MockCache) Delete(ctx context.Context, id string) error {
+func (m MockCache) Delete(ctx context.Context, id string, version int64, event hotelsDAO.OutboxEvent) error {
 	// La cache real no devuelve error si no existe
 	delete(m.hotels, id)
 	return nil
 }
 
-func (m MockCache) CancelReservation(ctx context.Context, id string) error {
-	if _, ok := m.reservas[id]; !ok {
+func (m MockCache) UpdateReservationStatus(ctx context.Context, id string, from string, to string, at time.Time) (bool, error) {
+	reservation, ok := m.reservas[id]
+	if !ok {
 		// La cache real no devuelve error si no existe
+		return false, nil
+	}
+	if reservation.Status != from {
 		delete(m.reservas, id)
-		return nil
+		return false, nil
 	}
-
-	delete(m.reservas, id)
-
-	return nil
+	reservation.Status = to
+	reservation.StatusHistory = append(append([]hotelsDAO.ReservationStatusChange{}, reservation.StatusHistory...), hotelsDAO.ReservationStatusChange{Status: to, At: at})
+	m.reservas[id] = reservation
+	return true, nil
 }
 
 func (m MockCache) GetReservationsByHotelID(ctx context.Context, hotelID string) ([]hotelsDAO.Reservation, error) {
@@ -281,6 +552,13 @@ func (m MockCache) GetReservationsByHotelID(ctx context.Context, hotelID string)
 	return result, nil
 }
 
+func (m MockCache) SetReservationsByHotelID(ctx context.Context, hotelID string, reservations []hotelsDAO.Reservation) error {
+	for _, reservation := range reservations {
+		m.reservas[reservation.ID] = reservation
+	}
+	return nil
+}
+
 func (m MockCache) GetReservationsByUserAndHotelID(ctx context.Context, hotelID, userID string) ([]hotelsDAO.Reservation, error) {
 	var result []hotelsDAO.Reservation
 	for _, r := range m.reservas {
@@ -346,31 +624,30 @@ func (m MockCache) IsHotelAvailable(ctx context.Context, hotelID, checkIn, check
 		return false, fmt.Errorf("check-out date must be after check-in date")
 	}
 
-	reservationsByDay := make(map[time.Time]int)
+	var reservations []hotelsDAO.Reservation
 	for _, reservation := range m.reservas {
-		if reservation.HotelID != hotelID {
-			continue
+		if reservation.HotelID == hotelID {
+			reservations = append(reservations, reservation)
 		}
+	}
 
-		resCheckIn := normalizeDate(reservation.CheckIn)
-		resCheckOut := normalizeDate(reservation.CheckOut)
+	return roomsAvailable(hotel, countOccupancy(reservations, checkInTime, checkOutTime), checkInTime, checkOutTime), nil
+}
 
-		if resCheckOut.After(checkInTime) && resCheckIn.Before(checkOutTime) {
-			for date := resCheckIn; date.Before(resCheckOut); date = date.AddDate(0, 0, 1) {
-				if !date.Before(checkInTime) && date.Before(checkOutTime) {
-					reservationsByDay[date]++
-				}
-			}
-		}
-	}
+// ListHotels siempre es un miss en el mock cache, igual que en la cache real
+func (m MockCache) ListHotels(ctx context.Context, query hotelsDAO.HotelQuery) ([]hotelsDAO.Hotel, error) {
+	return nil, fmt.Errorf("not found item: hotel listings are not cached")
+}
 
-	for date := checkInTime; date.Before(checkOutTime); date = date.AddDate(0, 0, 1) {
-		if reservationsByDay[date] >= hotel.AvaiableRooms {
-			return false, nil
+// GetNightlyOccupancy cuenta las habitaciones ocupadas por noche usando las reservas guardadas en el mock cache
+func (m MockCache) GetNightlyOccupancy(ctx context.Context, hotelID string, from, to time.Time) ([]hotelsDAO.NightOccupancy, error) {
+	var reservations []hotelsDAO.Reservation
+	for _, reservation := range m.reservas {
+		if reservation.HotelID == hotelID {
+			reservations = append(reservations, reservation)
 		}
 	}
-
-	return true, nil
+	return countOccupancy(reservations, from, to), nil
 }
 
 // Elimina todas las reservas de un hotel del mock cache
@@ -390,3 +667,44 @@ func (m MockCache) DeleteReservationsByHotelID(ctx context.Context, hotelID stri
 
 	return nil
 }
+
+// La cache no maneja inventario de habitaciones
+func (m MockCache) ReserveRooms(ctx context.Context, hotelID string, roomTypeID string, checkIn, checkOut time.Time, capacity int) (bool, error) {
+	return false, fmt.Errorf("room inventory is not managed by the cache")
+}
+
+func (m MockCache) ReleaseRooms(ctx context.Context, hotelID string, roomTypeID string, checkIn, checkOut time.Time) error {
+	return nil
+}
+
+// La cache descarta el hotel cuando cambian sus tipos de habitacion
+func (m MockCache) AddRoomType(ctx context.Context, hotelID string, roomType hotelsDAO.RoomType, event hotelsDAO.OutboxEvent) error {
+	delete(m.hotels, hotelID)
+	return nil
+}
+
+func (m MockCache) UpdateRoomType(ctx context.Context, hotelID string, roomType hotelsDAO.RoomType, event hotelsDAO.OutboxEvent) (bool, error) {
+	delete(m.hotels, hotelID)
+	return true, nil
+}
+
+func (m MockCache) DeleteRoomType(ctx context.Context, hotelID string, roomTypeID string, event hotelsDAO.OutboxEvent) (bool, error) {
+	delete(m.hotels, hotelID)
+	return true, nil
+}
+
+// La cache descarta el hotel cuando cambian sus reglas de tarifa
+func (m MockCache) AddRateRule(ctx context.Context, hotelID string, rule hotelsDAO.RateRule) error {
+	delete(m.hotels, hotelID)
+	return nil
+}
+
+func (m MockCache) UpdateRateRule(ctx context.Context, hotelID string, rule hotelsDAO.RateRule) (bool, error) {
+	delete(m.hotels, hotelID)
+	return true, nil
+}
+
+func (m MockCache) DeleteRateRule(ctx context.Context, hotelID string, ruleID string) (bool, error) {
+	delete(m.hotels, hotelID)
+	return true, nil
+}
@@ -2,6 +2,7 @@ package hotels
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -22,6 +23,7 @@ type MongoConfig struct {
 	Database                string
 	Collection_hotels       string
 	Collection_reservations string
+	Collection_inventory    string
 }
 
 type Mongo struct {
@@ -29,10 +31,14 @@ type Mongo struct {
 	database               string
 	collection_hotel       string
 	collection_reservation string
+	collection_inventory   string
 }
 
 const (
 	connectionURI = "mongodb://%s:%s"
+
+	// Estado de las reservas canceladas, no ocupan habitaciones (igual a hotelsDomain.ReservationStatusCancelled)
+	statusCancelled = "CANCELLED"
 )
 
 // Crea una nueva instancia de Mongo
@@ -55,12 +61,45 @@ func NewMongo(config MongoConfig) Mongo {
 		log.Panicf("error connecting to mongo DB: %v", err)
 	}
 
-	return Mongo{
+	repository := Mongo{
 		client:                 client,
 		database:               config.Database,
 		collection_hotel:       config.Collection_hotels,
 		collection_reservation: config.Collection_reservations,
+		collection_inventory:   config.Collection_inventory,
+	}
+
+	// Indice sobre el outbox: el relay busca los hoteles con eventos pendientes sin recorrer toda la coleccion
+	_, err = client.Database(config.Database).Collection(config.Collection_hotels).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "outbox.created_at", Value: 1}},
+		Options: options.Index().SetSparse(true),
+	})
+	if err != nil {
+		log.Printf("error creating hotels outbox index: %v", err)
+	}
+
+	// Indice geoespacial sobre la ubicacion (los hoteles sin location no entran en el indice)
+	_, err = client.Database(config.Database).Collection(config.Collection_hotels).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "location", Value: "2dsphere"}},
+	})
+	if err != nil {
+		log.Printf("error creating hotels location index: %v", err)
+	}
+
+	// Indices del listado de hoteles: uno por filtro y uno por orden, todos con _id al final para paginar por keyset.
+	// Los de texto usan la collation del listado (sin distinguir mayusculas)
+	_, err = client.Database(config.Database).Collection(config.Collection_hotels).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "city", Value: 1}, {Key: "_id", Value: 1}}, Options: options.Index().SetCollation(listCollation)},
+		{Keys: bson.D{{Key: "country", Value: 1}, {Key: "_id", Value: 1}}, Options: options.Index().SetCollation(listCollation)},
+		{Keys: bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}, Options: options.Index().SetCollation(listCollation)},
+		{Keys: bson.D{{Key: "rating", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "price_per_night", Value: 1}, {Key: "_id", Value: 1}}},
+	})
+	if err != nil {
+		log.Printf("error creating hotels listing indexes: %v", err)
 	}
+
+	return repository
 }
 
 // Obtiene un hotel por su ID de MongoDB
@@ -73,7 +112,10 @@ func (repository Mongo) GetHotelByID(ctx context.Context, id string) (hotelsDAO.
 	}
 
 	// Buscar el documento en MongoDB por su ID
-	result := repository.client.Database(repository.database).Collection(repository.collection_hotel).FindOne(ctx, bson.M{"_id": objectID})
+	result := repository.client.Database(repository.database).Collection(repository.collection_hotel).FindOne(ctx, activeHotelFilter(objectID))
+	if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+		return hotelsDAO.Hotel{}, fmt.Errorf("%w: %s", hotelsDAO.ErrHotelNotFound, id)
+	}
 	if result.Err() != nil {
 		return hotelsDAO.Hotel{}, fmt.Errorf("error finding document: %w", result.Err())
 	}
@@ -104,103 +146,203 @@ func (repository Mongo) Create(ctx context.Context, hotel hotelsDAO.Hotel) (stri
 	return objectID.Hex(), nil
 }
 
-// Actualiza un hotel en MongoDB
-func (repository Mongo) Update(ctx context.Context, hotel hotelsDAO.Hotel) error {
-	// Convert hotel ID to MongoDB ObjectID
-	objectID, err := primitive.ObjectIDFromHex(hotel.ID)
-	if err != nil {
-		return fmt.Errorf("error converting id to mongo ID: %w", err)
+// CreateMany inserta varios hoteles en un solo InsertMany sin orden (uno que falla no corta a los demas).
+// Devuelve un ID por hotel, vacio en los que no se insertaron; si fallo alguno tambien devuelve el error
+func (repository Mongo) CreateMany(ctx context.Context, hotels []hotelsDAO.Hotel) ([]string, error) {
+	if len(hotels) == 0 {
+		return []string{}, nil
 	}
 
-	// Crea un mapa con los campos a actualizar
-	update := bson.M{}
-
-	// Actualiza solo los campos que no son cero o vacios
-	if hotel.Name != "" {
-		update["name"] = hotel.Name
-	}
-	if hotel.Address != "" {
-		update["address"] = hotel.Address
-	}
-	if hotel.Description != "" {
-		update["description"] = hotel.Description
-	}
-	if hotel.City != "" {
-		update["city"] = hotel.City
-	}
-	if hotel.State != "" {
-		update["state"] = hotel.State
-	}
-	if hotel.Country != "" {
-		update["country"] = hotel.Country
-	}
-	if hotel.Phone != "" {
-		update["phone"] = hotel.Phone
-	}
-	if hotel.Email != "" {
-		update["email"] = hotel.Email
-	}
-	if hotel.PricePerNight != 0 { // Asumiendo que 0 es el valor por defecto para PricePerNight
-		update["price_per_night"] = hotel.PricePerNight
+	documents := make([]interface{}, 0, len(hotels))
+	for _, hotel := range hotels {
+		documents = append(documents, hotel)
 	}
-	if hotel.AvaiableRooms != 0 { // Asumiendo que 0 es el valor por defecto para AvaiableRooms
-		update["avaiable_rooms"] = hotel.AvaiableRooms
-	}
-	if !hotel.CheckInTime.IsZero() { // Asumiendo que una fecha cero es el valor por defecto para CheckInTime
-		update["check_in_time"] = hotel.CheckInTime
-	}
-	if !hotel.CheckOutTime.IsZero() { // Asumiendo que una fecha cero es el valor por defecto para CheckOutTime
-		update["check_out_time"] = hotel.CheckOutTime
+	result, err := repository.client.Database(repository.database).Collection(repository.collection_hotel).
+		InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+
+	var bulkErr mongo.BulkWriteException
+	if err != nil && (!errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil || result == nil) {
+		return nil, fmt.Errorf("error creating documents: %w", err)
 	}
-	if hotel.Rating != 0 { // Asumiendo que 0 es el valor por defecto para Rating
-		update["rating"] = hotel.Rating
+
+	ids := make([]string, len(hotels))
+	for i, insertedID := range result.InsertedIDs {
+		if objectID, ok := insertedID.(primitive.ObjectID); ok && i < len(ids) {
+			ids[i] = objectID.Hex()
+		}
 	}
-	if len(hotel.Amenities) > 0 { // Asumiendo que un slice vacio es el valor por defecto para Amenities
-		update["amenities"] = hotel.Amenities
+	// InsertedIDs trae el ID de todos los documentos, tambien de los que fallaron
+	for _, writeErr := range bulkErr.WriteErrors {
+		if writeErr.Index < len(ids) {
+			ids[writeErr.Index] = ""
+		}
 	}
-	if len(hotel.Images) > 0 { // Asumiendo que un slice vacio es el valor por defecto para Images
-		update["images"] = hotel.Images
+	if err != nil {
+		return ids, fmt.Errorf("error creating documents: %w", err)
 	}
+	return ids, nil
+}
 
-	// Actualiza el documento en MongoDB
-	if len(update) == 0 {
-		return fmt.Errorf("no fields to update for hotel ID %s", hotel.ID)
+// Reemplaza los datos de un hotel en MongoDB (PUT): se escriben todos los campos, aunque sean cero o vacios.
+// Los tipos de habitacion y las reglas de tarifa no se tocan, tienen sus propias operaciones.
+// Solo se aplica si el hotel sigue en hotel.Version (devuelve ErrVersionConflict si no) y lo pasa a la siguiente version
+func (repository Mongo) Update(ctx context.Context, hotel hotelsDAO.Hotel) error {
+	// Convert hotel ID to MongoDB ObjectID
+	objectID, err := primitive.ObjectIDFromHex(hotel.ID)
+	if err != nil {
+		return fmt.Errorf("error converting id to mongo ID: %w", err)
 	}
 
-	// Saca el objectID del documento y actualiza los campos en MongoDB
-	filter := bson.M{"_id": objectID}
-	result, err := repository.client.Database(repository.database).Collection(repository.collection_hotel).UpdateOne(ctx, filter, bson.M{"$set": update})
+	set := bson.M{
+		"name":            hotel.Name,
+		"description":     hotel.Description,
+		"address":         hotel.Address,
+		"city":            hotel.City,
+		"state":           hotel.State,
+		"country":         hotel.Country,
+		"phone":           hotel.Phone,
+		"email":           hotel.Email,
+		"price_per_night": hotel.PricePerNight,
+		"rating":          hotel.Rating,
+		"avaiable_rooms":  hotel.AvaiableRooms,
+		"check_in_time":   hotel.CheckInTime,
+		"check_out_time":  hotel.CheckOutTime,
+		"amenities":       hotel.Amenities,
+		"images":          hotel.Images,
+		"currency":        hotel.Currency,
+	}
+	update := bson.M{"$set": set, "$inc": bson.M{"version": 1}}
+	// Sin ubicacion se saca el campo (el indice 2dsphere no acepta location vacia)
+	if hotel.Location != nil {
+		set["location"] = hotel.Location
+	} else {
+		update["$unset"] = bson.M{"location": ""}
+	}
+
+	// Los eventos del outbox se agregan en la misma operacion que el cambio
+	filter := hotelVersionFilter(objectID, hotel.Version)
+	result, err := repository.client.Database(repository.database).Collection(repository.collection_hotel).UpdateOne(ctx, filter, withOutbox(update, hotel.Outbox))
 	if err != nil {
 		return fmt.Errorf("error updating document: %w", err)
 	}
 	if result.MatchedCount == 0 {
-		return fmt.Errorf("no document found with ID %s", hotel.ID)
+		return repository.versionMismatch(ctx, objectID, hotel.ID)
 	}
 
 	return nil
 }
 
-// Elimina un hotel de MongoDB
-func (repository Mongo) Delete(ctx context.Context, id string) error {
+// Elimina un hotel de MongoDB si sigue en la version indicada (devuelve ErrVersionConflict si no)
+func (repository Mongo) Delete(ctx context.Context, id string, version int64, event hotelsDAO.OutboxEvent) error {
 	// Convert hotel ID to MongoDB ObjectID
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return fmt.Errorf("error converting id to mongo ID: %w", err)
 	}
 
-	// Elimina el documento de MongoDB
-	filter := bson.M{"_id": objectID}
-	result, err := repository.client.Database(repository.database).Collection(repository.collection_hotel).DeleteOne(ctx, filter)
+	// Marca el hotel como borrado y guarda el evento DELETE en la misma operacion.
+	// El documento se elimina cuando el relay publica el evento (ver AckOutboxEvent)
+	update := withOutbox(bson.M{"$set": bson.M{"deleted_at": time.Now().UTC()}, "$inc": bson.M{"version": 1}}, []hotelsDAO.OutboxEvent{event})
+	result, err := repository.client.Database(repository.database).Collection(repository.collection_hotel).UpdateOne(ctx, hotelVersionFilter(objectID, version), update)
 	if err != nil {
 		return fmt.Errorf("error deleting document: %w", err)
 	}
-	if result.DeletedCount == 0 {
-		return fmt.Errorf("no document found with ID %s", id)
+	if result.MatchedCount == 0 {
+		return repository.versionMismatch(ctx, objectID, id)
 	}
 
 	return nil
 }
 
+// GetPendingOutbox devuelve los hoteles (incluidos los borrados) que tienen eventos sin publicar, los mas viejos primero
+func (repository Mongo) GetPendingOutbox(ctx context.Context, limit int64) ([]hotelsDAO.Hotel, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "outbox.created_at", Value: 1}}).SetLimit(limit)
+	cursor, err := repository.client.Database(repository.database).Collection(repository.collection_hotel).
+		Find(ctx, bson.M{"outbox.0": bson.M{"$exists": true}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding pending outbox events: %w", err)
+	}
+
+	var hotels []hotelsDAO.Hotel
+	if err := cursor.All(ctx, &hotels); err != nil {
+		return nil, fmt.Errorf("error decoding pending outbox events: %w", err)
+	}
+	return hotels, nil
+}
+
+// AckOutboxEvent quita un evento ya publicado del outbox y avanza el numero de secuencia del hotel.
+// Si el hotel estaba borrado y no quedan eventos, elimina el documento
+func (repository Mongo) AckOutboxEvent(ctx context.Context, hotelID string, eventID string) error {
+	objectID, err := primitive.ObjectIDFromHex(hotelID)
+	if err != nil {
+		return fmt.Errorf("error converting id to mongo ID: %w", err)
+	}
+
+	collection := repository.client.Database(repository.database).Collection(repository.collection_hotel)
+	// El filtro por outbox.id evita avanzar la secuencia dos veces si el mismo evento se confirma de nuevo
+	update := bson.M{
+		"$pull": bson.M{"outbox": bson.M{"id": eventID}},
+		"$inc":  bson.M{"event_sequence": 1},
+	}
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": objectID, "outbox.id": eventID}, update); err != nil {
+		return fmt.Errorf("error removing outbox event: %w", err)
+	}
+
+	if _, err := collection.DeleteOne(ctx, bson.M{
+		"_id":        objectID,
+		"deleted_at": bson.M{"$exists": true},
+		"outbox":     bson.M{"$size": 0},
+	}); err != nil {
+		return fmt.Errorf("error deleting document: %w", err)
+	}
+	return nil
+}
+
+// activeHotelFilter busca un hotel por ID ignorando los borrados que esperan publicar su evento DELETE
+func activeHotelFilter(objectID primitive.ObjectID) bson.M {
+	return bson.M{"_id": objectID, "deleted_at": bson.M{"$exists": false}}
+}
+
+// hotelVersionFilter busca un hotel activo que siga en la version indicada.
+// Los hoteles sin el campo version (anteriores al versionado) cuentan como version 0
+func hotelVersionFilter(objectID primitive.ObjectID, version int64) bson.M {
+	filter := activeHotelFilter(objectID)
+	if version == 0 {
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	} else {
+		filter["version"] = version
+	}
+	return filter
+}
+
+// versionMismatch explica por que una escritura condicional no encontro el hotel: si el hotel sigue activo
+// es que cambio de version (ErrVersionConflict), si no es que no existe (ErrHotelNotFound)
+func (repository Mongo) versionMismatch(ctx context.Context, objectID primitive.ObjectID, id string) error {
+	count, err := repository.client.Database(repository.database).Collection(repository.collection_hotel).
+		CountDocuments(ctx, activeHotelFilter(objectID), options.Count().SetLimit(1))
+	if err != nil {
+		return fmt.Errorf("error checking hotel version: %w", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: %s", hotelsDAO.ErrHotelNotFound, id)
+	}
+	return fmt.Errorf("%w: %s", hotelsDAO.ErrVersionConflict, id)
+}
+
+// withOutbox agrega los eventos al update para que se escriban en la misma operacion que el cambio del hotel
+func withOutbox(update bson.M, events []hotelsDAO.OutboxEvent) bson.M {
+	if len(events) == 0 {
+		return update
+	}
+	push, _ := update["$push"].(bson.M)
+	if push == nil {
+		push = bson.M{}
+	}
+	push["outbox"] = bson.M{"$each": events}
+	update["$push"] = push
+	return update
+}
+
 // Funcion para crear una reserva en MongoDB
 func (repository Mongo) CreateReservation(ctx context.Context, reservation hotelsDAO.Reservation) (string, error) {
 	// Insertar el documento en MongoDB
@@ -244,25 +386,31 @@ func (repository Mongo) GetReservationByID(ctx context.Context, id string) (hote
 	return reservation, nil
 }
 
-// Funcion para cancelar una reserva en MongoDB
-func (repository Mongo) CancelReservation(ctx context.Context, id string) error {
+// Funcion para cambiar el estado de una reserva en MongoDB.
+// El cambio es condicional al estado actual (from), asi dos transiciones concurrentes no se pisan;
+// devuelve false si la reserva ya no estaba en ese estado. Las reservas sin estado (anteriores al ciclo de vida) se matchean con from vacio.
+func (repository Mongo) UpdateReservationStatus(ctx context.Context, id string, from string, to string, at time.Time) (bool, error) {
 	// Convert reservation ID to MongoDB ObjectID
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return fmt.Errorf("error converting id to mongo ID: %w", err)
+		return false, fmt.Errorf("error converting id to mongo ID: %w", err)
 	}
 
-	// Elimina el documento de MongoDB
-	filter := bson.M{"_id": objectID}
-	result, err := repository.client.Database(repository.database).Collection(repository.collection_reservation).DeleteOne(ctx, filter)
-	if err != nil {
-		return fmt.Errorf("error deleting document: %w", err)
+	filter := bson.M{"_id": objectID, "status": from}
+	if from == "" {
+		filter["status"] = bson.M{"$in": bson.A{nil, ""}}
 	}
-	if result.DeletedCount == 0 {
-		return fmt.Errorf("no document found with ID %s", id)
+	update := bson.M{
+		"$set":  bson.M{"status": to},
+		"$push": bson.M{"status_history": hotelsDAO.ReservationStatusChange{Status: to, At: at}},
 	}
 
-	return nil
+	result, err := repository.client.Database(repository.database).Collection(repository.collection_reservation).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("error updating reservation status: %w", err)
+	}
+
+	return result.MatchedCount > 0, nil
 }
 
 // Funcion para encontrar todas las reservas de un usuario en MongoDB
@@ -281,6 +429,11 @@ func (repository Mongo) GetReservationsByUserID(ctx context.Context, userID stri
 	return reservations, nil
 }
 
+// SetReservationsByHotelID no se soporta en MongoDB: la lista completa de reservas por hotel solo se guarda en la cache
+func (repository Mongo) SetReservationsByHotelID(ctx context.Context, hotelID string, reservations []hotelsDAO.Reservation) error {
+	return fmt.Errorf("hotel reservation lists are only cached")
+}
+
 // Funcion para encontrar las reservas de un usuario en un hotel en MongoDB
 func (repository Mongo) GetReservationsByHotelID(ctx context.Context, hotelID string) ([]hotelsDAO.Reservation, error) {
 	// Buscar el documento en MongoDB por su ID
@@ -324,6 +477,11 @@ func (repository Mongo) DeleteReservationsByHotelID(ctx context.Context, hotelID
 	// Log para debugging
 	fmt.Printf("Deleted %d reservations for hotel %s\n", result.DeletedCount, hotelID)
 
+	// Eliminar tambien los contadores de inventario del hotel
+	if _, err := repository.client.Database(repository.database).Collection(repository.collection_inventory).DeleteMany(ctx, bson.M{"hotel_id": hotelID}); err != nil {
+		return fmt.Errorf("error deleting inventory for hotel %s: %w", hotelID, err)
+	}
+
 	return nil
 }
 
@@ -363,14 +521,9 @@ func (repository Mongo) GetAvailability(ctx context.Context, hotelIDs []string,
 	return availability, nil
 }
 
-// IsHotelAvailable verifica la disponibilidad de un hotel para un rango de fechas
+// IsHotelAvailable verifica la disponibilidad de un hotel para un rango de fechas.
+// Trae en una sola consulta las reservas activas que se solapan con el rango y calcula la ocupacion por tipo de habitacion y noche.
 func (repository Mongo) IsHotelAvailable(ctx context.Context, hotelID, checkIn, checkOut string) (bool, error) {
-	// Convertir el ID del hotel a ObjectID
-	objectID, err := primitive.ObjectIDFromHex(hotelID)
-	if err != nil {
-		return false, fmt.Errorf("error converting hotel ID to object ID: %w", err)
-	}
-
 	// Convertir las fechas
 	checkInTime, err := time.Parse("2006-01-02", checkIn)
 	if err != nil {
@@ -380,103 +533,343 @@ func (repository Mongo) IsHotelAvailable(ctx context.Context, hotelID, checkIn,
 	if err != nil {
 		return false, fmt.Errorf("error parsing check-out date: %w", err)
 	}
-
-	// Primero obtener el hotel y su capacidad en una sola consulta
-	var av struct {
-		AvailableRooms int32 `bson:"avaiable_rooms"`
+	if !checkOutTime.After(checkInTime) {
+		return false, fmt.Errorf("check-out date must be after check-in date")
 	}
-	err = repository.client.Database(repository.database).Collection(repository.collection_hotel).
-		FindOne(ctx, bson.M{"_id": objectID}, options.FindOne().SetProjection(bson.M{"avaiable_rooms": 1, "_id": 0})).
-		Decode(&av)
+
+	// Obtener el hotel con su capacidad (AvaiableRooms o tipos de habitacion)
+	hotel, err := repository.GetHotelByID(ctx, hotelID)
 	if err != nil {
 		return false, fmt.Errorf("error finding hotel: %w", err)
 	}
 
-	// Lista para almacenar los días
-	var days []string
+	// Ocupacion por noche y tipo de habitacion en una sola agregacion
+	occupancy, err := repository.GetNightlyOccupancy(ctx, hotelID, checkInTime, checkOutTime)
+	if err != nil {
+		return false, err
+	}
+
+	return roomsAvailable(hotel, occupancy, checkInTime, checkOutTime), nil
+}
 
-	// Iterar desde checkIn hasta checkOut
-	for current := checkInTime; !current.After(checkOutTime); current = current.AddDate(0, 0, 1) {
-		days = append(days, current.Format("2006-01-02"))
+// listCollation compara los textos sin distinguir mayusculas, para filtrar por ciudad/pais y ordenar por nombre.
+// Solo se usa cuando hace falta: con otra collation Mongo no puede ordenar con los indices de _id, rating y precio
+var listCollation = &options.Collation{Locale: "en", Strength: 2}
+
+// ListHotels devuelve una pagina de hoteles con los filtros y el orden de query.
+// Pagina por rango (keyset) sobre el campo de orden y _id en vez de skip, asi cada pagina usa un indice aunque la coleccion sea grande
+func (repository Mongo) ListHotels(ctx context.Context, query hotelsDAO.HotelQuery) ([]hotelsDAO.Hotel, error) {
+	filter := bson.M{}
+	if !query.IncludeDeleted {
+		filter["deleted_at"] = bson.M{"$exists": false}
+	}
+	if query.City != "" {
+		filter["city"] = query.City
+	}
+	if query.Country != "" {
+		filter["country"] = query.Country
+	}
+	if query.MinRating != nil {
+		filter["rating"] = bson.M{"$gte": *query.MinRating}
+	}
+
+	// Orden: campo pedido y desempate por _id, los dos en la misma direccion (asi sirve un solo indice para asc y desc)
+	direction, operator := 1, "$gt"
+	if query.Descending {
+		direction, operator = -1, "$lt"
+	}
+	sort := bson.D{{Key: "_id", Value: direction}}
+	if query.SortField != "" {
+		sort = bson.D{{Key: query.SortField, Value: direction}, {Key: "_id", Value: direction}}
 	}
 
-	var maxreservations int = -1
-	for _, day := range days {
-		time, err := time.Parse("2006-01-02", day)
+	// Pagina: los hoteles despues del ultimo de la pagina anterior
+	if query.AfterID != "" {
+		objectID, err := primitive.ObjectIDFromHex(query.AfterID)
 		if err != nil {
-			return false, fmt.Errorf("error parsing date: %w", err)
+			return nil, fmt.Errorf("%w: %v", hotelsDAO.ErrInvalidCursor, err)
 		}
-		pipeline := []bson.M{
-			{
-				"$match": bson.M{
-					"hotel_id":  hotelID,
-					"check_in":  bson.M{"$lte": time},
-					"check_out": bson.M{"$gt": time},
-				},
-			},
-			{
-				"$group": bson.M{
-					"_id":              nil,
-					"reservas_activas": bson.M{"$sum": 1},
-				},
-			},
-			{
-				"$project": bson.M{
-					"_id":              0,
-					"reservas_activas": 1,
-				},
-			},
-			{
-				"$unionWith": bson.M{
-					"coll": nil, // Esto es necesario para indicar que estamos agregando un documento manual
-					"pipeline": []bson.M{
-						{
-							"$documents": []bson.M{
-								{"reservas_activas": 0},
-							},
-						},
-					},
-				},
-			},
-			{
-				"$group": bson.M{
-					"_id":              nil,
-					"reservas_activas": bson.M{"$max": "$reservas_activas"},
-				},
-			},
-			{
-				"$project": bson.M{
-					"_id":              0,
-					"reservas_activas": 1,
-				},
-			},
+		if query.SortField == "" {
+			filter["_id"] = bson.M{operator: objectID}
+		} else {
+			filter["$or"] = bson.A{
+				bson.M{query.SortField: bson.M{operator: query.AfterValue}},
+				bson.M{query.SortField: query.AfterValue, "_id": bson.M{operator: objectID}},
+			}
 		}
+	}
 
-		// Ejecutar el pipeline
-		cursor, err := repository.client.Database(repository.database).
-			Collection(repository.collection_reservation).
-			Aggregate(ctx, pipeline)
-		if err != nil {
-			return false, fmt.Errorf("error aggregating reservations: %w", err)
+	opts := options.Find().SetSort(sort).SetLimit(int64(query.Limit))
+	if query.City != "" || query.Country != "" || query.SortField == "name" {
+		opts.SetCollation(listCollation)
+	}
+	if len(query.Fields) > 0 {
+		projection := bson.M{}
+		for _, field := range query.Fields {
+			projection[field] = 1
 		}
-		defer cursor.Close(ctx)
+		opts.SetProjection(projection)
+	}
+
+	cursor, err := repository.client.Database(repository.database).Collection(repository.collection_hotel).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error listing hotels: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	hotels := make([]hotelsDAO.Hotel, 0, query.Limit)
+	if err := cursor.All(ctx, &hotels); err != nil {
+		return nil, fmt.Errorf("error decoding hotels: %w", err)
+	}
+	return hotels, nil
+}
+
+// GetNightlyOccupancy devuelve cuantas habitaciones de cada tipo estan ocupadas en cada noche de [from, to).
+// Expande cada reserva no cancelada en sus noches y agrupa en una unica agregacion, sin consultar dia por dia.
+func (repository Mongo) GetNightlyOccupancy(ctx context.Context, hotelID string, from, to time.Time) ([]hotelsDAO.NightOccupancy, error) {
+	from = normalizeDate(from)
+	to = normalizeDate(to)
+
+	pipeline := mongo.Pipeline{
+		// Reservas no canceladas que ocupan alguna noche del rango
+		{{Key: "$match", Value: bson.M{
+			"hotel_id":  hotelID,
+			"check_in":  bson.M{"$lt": to},
+			"check_out": bson.M{"$gt": from},
+			"status":    bson.M{"$ne": statusCancelled},
+		}}},
+		// Una entrada por noche ocupada: incluye check-in, excluye check-out
+		{{Key: "$project", Value: bson.M{
+			"_id":          0,
+			"room_type_id": bson.M{"$ifNull": bson.A{"$room_type_id", ""}},
+			"nights": bson.M{"$map": bson.M{
+				"input": bson.M{"$range": bson.A{0, bson.M{"$dateDiff": bson.M{
+					"startDate": bson.M{"$dateTrunc": bson.M{"date": "$check_in", "unit": "day"}},
+					"endDate":   bson.M{"$dateTrunc": bson.M{"date": "$check_out", "unit": "day"}},
+					"unit":      "day",
+				}}}},
+				"as": "offset",
+				"in": bson.M{"$dateAdd": bson.M{
+					"startDate": bson.M{"$dateTrunc": bson.M{"date": "$check_in", "unit": "day"}},
+					"unit":      "day",
+					"amount":    "$$offset",
+				}},
+			}},
+		}}},
+		{{Key: "$unwind", Value: "$nights"}},
+		{{Key: "$match", Value: bson.M{"nights": bson.M{"$gte": from, "$lt": to}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      bson.M{"night": "$nights", "room_type_id": "$room_type_id"},
+			"reserved": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":          0,
+			"night":        "$_id.night",
+			"room_type_id": "$_id.room_type_id",
+			"reserved":     1,
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "night", Value: 1}, {Key: "room_type_id", Value: 1}}}},
+	}
+
+	cursor, err := repository.client.Database(repository.database).Collection(repository.collection_reservation).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("error aggregating reservations: %w", err)
+	}
 
-		// Obtener el resultado
-		var result struct {
-			ReservasActivas int `bson:"reservas_activas"`
+	var occupancy []hotelsDAO.NightOccupancy
+	if err := cursor.All(ctx, &occupancy); err != nil {
+		return nil, fmt.Errorf("error decoding occupancy: %w", err)
+	}
+	return occupancy, nil
+}
+
+// ReserveRooms ocupa una habitacion del tipo indicado por cada noche de la estadia (excluye el dia de checkout).
+// Cada noche tiene un documento contador en la coleccion de inventario y el incremento es condicional
+// (reserved < capacity), asi dos reservas concurrentes por la ultima habitacion no pueden tener exito a la vez.
+// Devuelve false si alguna noche esta completa; en ese caso libera las noches que ya habia ocupado.
+// Los hoteles sin tipos de habitacion usan roomTypeID vacio.
+func (repository Mongo) ReserveRooms(ctx context.Context, hotelID string, roomTypeID string, checkIn, checkOut time.Time, capacity int) (bool, error) {
+	collection := repository.client.Database(repository.database).Collection(repository.collection_inventory)
+
+	var reserved []time.Time
+	for night := normalizeDate(checkIn); night.Before(normalizeDate(checkOut)); night = night.AddDate(0, 0, 1) {
+		if err := repository.ensureInventory(ctx, hotelID, roomTypeID, night); err != nil {
+			repository.releaseNights(ctx, hotelID, roomTypeID, reserved)
+			return false, err
 		}
-		if cursor.Next(ctx) {
-			if err := cursor.Decode(&result); err != nil {
-				return false, fmt.Errorf("error decoding result: %w", err)
-			}
-		} else {
-			return false, fmt.Errorf("no results found")
+
+		// Incrementa el contador solo si queda al menos una habitacion libre esa noche
+		filter := bson.M{"_id": inventoryID(hotelID, roomTypeID, night), "reserved": bson.M{"$lt": capacity}}
+		result, err := collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"reserved": 1}})
+		if err != nil {
+			repository.releaseNights(ctx, hotelID, roomTypeID, reserved)
+			return false, fmt.Errorf("error reserving night %s: %w", night.Format("2006-01-02"), err)
+		}
+		if result.MatchedCount == 0 {
+			repository.releaseNights(ctx, hotelID, roomTypeID, reserved)
+			return false, nil
 		}
+		reserved = append(reserved, night)
+	}
+
+	return true, nil
+}
+
+// ReleaseRooms libera la habitacion ocupada en cada noche de la estadia (al cancelar una reserva)
+func (repository Mongo) ReleaseRooms(ctx context.Context, hotelID string, roomTypeID string, checkIn, checkOut time.Time) error {
+	var nights []time.Time
+	for night := normalizeDate(checkIn); night.Before(normalizeDate(checkOut)); night = night.AddDate(0, 0, 1) {
+		nights = append(nights, night)
+	}
+	return repository.releaseNights(ctx, hotelID, roomTypeID, nights)
+}
 
-		if result.ReservasActivas > maxreservations {
-			maxreservations = result.ReservasActivas
+// releaseNights decrementa los contadores de las noches indicadas sin bajar de cero
+func (repository Mongo) releaseNights(ctx context.Context, hotelID string, roomTypeID string, nights []time.Time) error {
+	collection := repository.client.Database(repository.database).Collection(repository.collection_inventory)
+	for _, night := range nights {
+		filter := bson.M{"_id": inventoryID(hotelID, roomTypeID, night), "reserved": bson.M{"$gt": 0}}
+		if _, err := collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"reserved": -1}}); err != nil {
+			return fmt.Errorf("error releasing night %s: %w", night.Format("2006-01-02"), err)
 		}
 	}
-	// Verificar disponibilidad
-	return maxreservations < int(av.AvailableRooms), nil
+	return nil
+}
+
+// ensureInventory crea el contador de una noche si no existe, inicializado con las reservas ya guardadas
+// (reservas anteriores a la existencia del inventario). Si otro request lo creo primero se ignora el duplicado.
+func (repository Mongo) ensureInventory(ctx context.Context, hotelID string, roomTypeID string, night time.Time) error {
+	collection := repository.client.Database(repository.database).Collection(repository.collection_inventory)
+
+	err := collection.FindOne(ctx, bson.M{"_id": inventoryID(hotelID, roomTypeID, night)}).Err()
+	if err == nil {
+		return nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return fmt.Errorf("error finding inventory: %w", err)
+	}
+
+	count, err := repository.client.Database(repository.database).Collection(repository.collection_reservation).CountDocuments(ctx, bson.M{
+		"hotel_id":     hotelID,
+		"room_type_id": roomTypeFilter(roomTypeID),
+		"check_in":     bson.M{"$lte": night},
+		"check_out":    bson.M{"$gt": night},
+		"status":       bson.M{"$ne": statusCancelled},
+	})
+	if err != nil {
+		return fmt.Errorf("error counting reservations: %w", err)
+	}
+
+	_, err = collection.InsertOne(ctx, hotelsDAO.Inventory{
+		ID:         inventoryID(hotelID, roomTypeID, night),
+		HotelID:    hotelID,
+		RoomTypeID: roomTypeID,
+		Night:      night,
+		Reserved:   int(count),
+	})
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("error creating inventory: %w", err)
+	}
+	return nil
+}
+
+// inventoryID arma el ID del contador de inventario de un hotel (y tipo de habitacion) para una noche
+func inventoryID(hotelID string, roomTypeID string, night time.Time) string {
+	if roomTypeID == "" {
+		return fmt.Sprintf("%s:%s", hotelID, night.Format("2006-01-02"))
+	}
+	return fmt.Sprintf("%s:%s:%s", hotelID, roomTypeID, night.Format("2006-01-02"))
+}
+
+// roomTypeFilter matchea el tipo de habitacion de una reserva; las reservas sin tipo pueden no tener el campo
+func roomTypeFilter(roomTypeID string) interface{} {
+	if roomTypeID == "" {
+		return bson.M{"$in": bson.A{nil, ""}}
+	}
+	return roomTypeID
+}
+
+// Agrega un tipo de habitacion al hotel en MongoDB
+func (repository Mongo) AddRoomType(ctx context.Context, hotelID string, roomType hotelsDAO.RoomType, event hotelsDAO.OutboxEvent) error {
+	return repository.addEmbedded(ctx, hotelID, "room_types", roomType, event)
+}
+
+// Reemplaza un tipo de habitacion del hotel en MongoDB, devuelve false si el tipo no existe
+func (repository Mongo) UpdateRoomType(ctx context.Context, hotelID string, roomType hotelsDAO.RoomType, event hotelsDAO.OutboxEvent) (bool, error) {
+	return repository.updateEmbedded(ctx, hotelID, "room_types", roomType.ID, roomType, event)
+}
+
+// Elimina un tipo de habitacion del hotel en MongoDB, devuelve false si el tipo no existe
+func (repository Mongo) DeleteRoomType(ctx context.Context, hotelID string, roomTypeID string, event hotelsDAO.OutboxEvent) (bool, error) {
+	return repository.deleteEmbedded(ctx, hotelID, "room_types", roomTypeID, event)
+}
+
+// Agrega una regla de tarifa al hotel en MongoDB
+func (repository Mongo) AddRateRule(ctx context.Context, hotelID string, rule hotelsDAO.RateRule) error {
+	return repository.addEmbedded(ctx, hotelID, "rate_rules", rule)
+}
+
+// Reemplaza una regla de tarifa del hotel en MongoDB, devuelve false si la regla no existe
+func (repository Mongo) UpdateRateRule(ctx context.Context, hotelID string, rule hotelsDAO.RateRule) (bool, error) {
+	return repository.updateEmbedded(ctx, hotelID, "rate_rules", rule.ID, rule)
+}
+
+// Elimina una regla de tarifa del hotel en MongoDB, devuelve false si la regla no existe
+func (repository Mongo) DeleteRateRule(ctx context.Context, hotelID string, ruleID string) (bool, error) {
+	return repository.deleteEmbedded(ctx, hotelID, "rate_rules", ruleID)
+}
+
+// addEmbedded agrega un elemento a un array embebido del hotel (room_types, rate_rules)
+// Los eventos del outbox, si los hay, se escriben en el mismo update. Los cambios de los arrays embebidos
+// tambien pasan el hotel a la siguiente version (cambian el documento que se devuelve con el ETag)
+func (repository Mongo) addEmbedded(ctx context.Context, hotelID string, field string, value interface{}, events ...hotelsDAO.OutboxEvent) error {
+	objectID, err := primitive.ObjectIDFromHex(hotelID)
+	if err != nil {
+		return fmt.Errorf("error converting id to mongo ID: %w", err)
+	}
+
+	result, err := repository.client.Database(repository.database).Collection(repository.collection_hotel).
+		UpdateOne(ctx, activeHotelFilter(objectID), withOutbox(bson.M{"$push": bson.M{field: value}, "$inc": bson.M{"version": 1}}, events))
+	if err != nil {
+		return fmt.Errorf("error adding to %s: %w", field, err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("no document found with ID %s", hotelID)
+	}
+	return nil
+}
+
+// updateEmbedded reemplaza el elemento con el id indicado de un array embebido del hotel, devuelve false si no existe
+func (repository Mongo) updateEmbedded(ctx context.Context, hotelID string, field string, id string, value interface{}, events ...hotelsDAO.OutboxEvent) (bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(hotelID)
+	if err != nil {
+		return false, fmt.Errorf("error converting id to mongo ID: %w", err)
+	}
+
+	// El operador posicional $ apunta al elemento que matcheo <field>.id
+	filter := activeHotelFilter(objectID)
+	filter[field+".id"] = id
+	result, err := repository.client.Database(repository.database).Collection(repository.collection_hotel).
+		UpdateOne(ctx, filter, withOutbox(bson.M{"$set": bson.M{field + ".$": value}, "$inc": bson.M{"version": 1}}, events))
+	if err != nil {
+		return false, fmt.Errorf("error updating %s: %w", field, err)
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// deleteEmbedded elimina el elemento con el id indicado de un array embebido del hotel, devuelve false si no existe
+func (repository Mongo) deleteEmbedded(ctx context.Context, hotelID string, field string, id string, events ...hotelsDAO.OutboxEvent) (bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(hotelID)
+	if err != nil {
+		return false, fmt.Errorf("error converting id to mongo ID: %w", err)
+	}
+
+	filter := activeHotelFilter(objectID)
+	filter[field+".id"] = id
+	result, err := repository.client.Database(repository.database).Collection(repository.collection_hotel).
+		UpdateOne(ctx, filter, withOutbox(bson.M{"$pull": bson.M{field: bson.M{"id": id}}, "$inc": bson.M{"version": 1}}, events))
+	if err != nil {
+		return false, fmt.Errorf("error deleting from %s: %w", field, err)
+	}
+	return result.MatchedCount > 0, nil
 }
@@ -0,0 +1,64 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	idempotencyDAO "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/dao/idempotency"
+)
+
+// Mock simula la coleccion de claves de idempotencia en memoria
+type Mock struct {
+	mu      *sync.Mutex
+	records map[string]idempotencyDAO.Record
+	lease   time.Duration
+}
+
+func NewMock(lease time.Duration) Mock {
+	return Mock{
+		mu:      &sync.Mutex{},
+		records: make(map[string]idempotencyDAO.Record),
+		lease:   lease,
+	}
+}
+
+func (m Mock) Reserve(ctx context.Context, record idempotencyDAO.Record) (idempotencyDAO.Record, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	existing, ok := m.records[record.Key]
+	expired := existing.Status == idempotencyDAO.StatusPending && existing.RequestHash == record.RequestHash && existing.LockedUntil.Before(now)
+	if ok && !expired {
+		return existing, false, nil
+	}
+	record.Status = idempotencyDAO.StatusPending
+	record.CreatedAt = now
+	record.LockedUntil = now.Add(m.lease)
+	m.records[record.Key] = record
+	return record, true, nil
+}
+
+func (m Mock) Complete(ctx context.Context, key string, responseStatus int, contentType string, body []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record := m.records[key]
+	record.Status = idempotencyDAO.StatusCompleted
+	record.ResponseStatus = responseStatus
+	record.ContentType = contentType
+	record.ResponseBody = append([]byte(nil), body...)
+	m.records[key] = record
+	return nil
+}
+
+func (m Mock) Release(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if record, ok := m.records[key]; ok && record.Status == idempotencyDAO.StatusPending {
+		delete(m.records, key)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	idempotencyDAO "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/dao/idempotency"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const connectionURI = "mongodb://%s:%s"
+
+type MongoConfig struct {
+	Host       string
+	Port       string
+	Username   string
+	Password   string
+	Database   string
+	Collection string
+	TTL        time.Duration // Tiempo que se conserva cada respuesta
+	Lease      time.Duration // Tiempo que una request PENDING tiene tomada la clave (si la replica se cae, despues se libera)
+}
+
+// Mongo guarda las claves de idempotencia en una coleccion compartida por todas las replicas
+type Mongo struct {
+	client     *mongo.Client
+	database   string
+	collection string
+	lease      time.Duration
+}
+
+func NewMongo(config MongoConfig) Mongo {
+	credentials := options.Credential{
+		Username: config.Username,
+		Password: config.Password,
+	}
+
+	//Crea el contexto
+	ctx := context.Background()
+	//Crea la URI de conexion
+	uri := fmt.Sprintf(connectionURI, config.Host, config.Port)
+	//Crea la configuracion de conexion
+	cfg := options.Client().ApplyURI(uri).SetAuth(credentials)
+
+	//Crea la conexion a MongoDB
+	client, err := mongo.Connect(ctx, cfg)
+	if err != nil {
+		log.Panicf("error connecting to mongo DB: %v", err)
+	}
+
+	repository := Mongo{
+		client:     client,
+		database:   config.Database,
+		collection: config.Collection,
+		lease:      config.Lease,
+	}
+
+	// Indice TTL: Mongo borra los registros cuando vencen
+	_, err = repository.records().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "created_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(config.TTL.Seconds())),
+	})
+	if err != nil {
+		log.Printf("error creating idempotency TTL index: %v", err)
+	}
+
+	return repository
+}
+
+func (repository Mongo) records() *mongo.Collection {
+	return repository.client.Database(repository.database).Collection(repository.collection)
+}
+
+// Reserve inserta el registro en estado PENDING. Si la clave ya existe devuelve el registro guardado y false.
+// El _id unico hace que solo una replica gane la clave aunque lleguen reintentos en paralelo.
+// Una clave PENDING con el lease vencido (la replica se cayo a mitad de la request) la toma la request nueva con el mismo body
+func (repository Mongo) Reserve(ctx context.Context, record idempotencyDAO.Record) (idempotencyDAO.Record, bool, error) {
+	now := time.Now().UTC()
+	record.Status = idempotencyDAO.StatusPending
+	record.CreatedAt = now
+	record.LockedUntil = now.Add(repository.lease)
+
+	_, err := repository.records().InsertOne(ctx, record)
+	if err == nil {
+		return record, true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return idempotencyDAO.Record{}, false, fmt.Errorf("error inserting idempotency key: %w", err)
+	}
+
+	// El filtro hace que solo una request tome el lease vencido
+	err = repository.records().FindOneAndUpdate(ctx, bson.M{
+		"_id":          record.Key,
+		"status":       idempotencyDAO.StatusPending,
+		"request_hash": record.RequestHash,
+		"locked_until": bson.M{"$lt": now},
+	}, bson.M{"$set": bson.M{
+		"created_at":   record.CreatedAt,
+		"locked_until": record.LockedUntil,
+	}}).Err()
+	if err == nil {
+		return record, true, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return idempotencyDAO.Record{}, false, fmt.Errorf("error taking over idempotency key: %w", err)
+	}
+
+	var existing idempotencyDAO.Record
+	if err := repository.records().FindOne(ctx, bson.M{"_id": record.Key}).Decode(&existing); err != nil {
+		return idempotencyDAO.Record{}, false, fmt.Errorf("error finding idempotency key: %w", err)
+	}
+	return existing, false, nil
+}
+
+// Complete guarda la respuesta de la primera request para repetirla en los reintentos
+func (repository Mongo) Complete(ctx context.Context, key string, responseStatus int, contentType string, body []byte) error {
+	_, err := repository.records().UpdateOne(ctx, bson.M{"_id": key}, bson.M{"$set": bson.M{
+		"status":          idempotencyDAO.StatusCompleted,
+		"response_status": responseStatus,
+		"content_type":    contentType,
+		"response_body":   body,
+	}})
+	if err != nil {
+		return fmt.Errorf("error completing idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Release borra una clave PENDING para que el cliente pueda reintentar (por ejemplo, tras un error 5xx)
+func (repository Mongo) Release(ctx context.Context, key string) error {
+	_, err := repository.records().DeleteOne(ctx, bson.M{"_id": key, "status": idempotencyDAO.StatusPending})
+	if err != nil {
+		return fmt.Errorf("error releasing idempotency key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	auditDAO "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/dao/audit"
+	hotelsDomain "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/domain/hotels"
+)
+
+// Funciones del repositorio del registro de auditoria (Mongo en produccion)
+type AuditRepository interface {
+	Insert(ctx context.Context, entry auditDAO.Entry) error
+	InsertMany(ctx context.Context, entries []auditDAO.Entry) error
+	Find(ctx context.Context, query auditDAO.Query) ([]auditDAO.Entry, error)
+	Stream(ctx context.Context, query auditDAO.Query, fn func(auditDAO.Entry) error) error
+}
+
+// Tiempo maximo para guardar una entrada, se guarda aunque el cliente ya haya cortado la request
+const auditInsertTimeout = 5 * time.Second
+
+// Campos que no se comparan: cambian en cada escritura o ya quedan en la accion
+var auditIgnoredFields = map[string]bool{
+	"id":             true,
+	"version":        true,
+	"status_history": true,
+	"deleted_at":     true,
+}
+
+// audit registra un cambio ya guardado con el usuario y el ID de la request del contexto.
+// before y after son el objeto (de dominio) antes y despues del cambio, nil si no existia o ya no existe.
+// El cambio ya se hizo, asi que si no se puede registrar solo se loguea
+func (service Service) audit(ctx context.Context, action string, targetType string, targetID string, hotelID string, before interface{}, after interface{}) {
+	entry, err := newAuditEntry(ctx, action, targetType, targetID, hotelID, before, after)
+	if err != nil {
+		log.Printf("error building audit entry %s %s: %v", action, targetID, err)
+		return
+	}
+
+	insertCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), auditInsertTimeout)
+	defer cancel()
+	if err := service.auditRepository.Insert(insertCtx, entry); err != nil {
+		log.Printf("error saving audit entry %s %s: %v", action, targetID, err)
+	}
+}
+
+// auditMany registra varios cambios ya guardados en una sola escritura (como audit, si falla solo se loguea)
+func (service Service) auditMany(ctx context.Context, entries []auditDAO.Entry) {
+	insertCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), auditInsertTimeout)
+	defer cancel()
+	if err := service.auditRepository.InsertMany(insertCtx, entries); err != nil {
+		log.Printf("error saving %d audit entries: %v", len(entries), err)
+	}
+}
+
+// newAuditEntry arma la entrada de un cambio con el usuario y el ID de la request del contexto
+func newAuditEntry(ctx context.Context, action string, targetType string, targetID string, hotelID string, before interface{}, after interface{}) (auditDAO.Entry, error) {
+	changes, err := auditChanges(before, after)
+	if err != nil {
+		return auditDAO.Entry{}, err
+	}
+
+	actor := hotelsDomain.ActorFromContext(ctx)
+	return auditDAO.Entry{
+		UserID:     actor.UserID,
+		UserType:   actor.UserType,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		HotelID:    hotelID,
+		Changes:    changes,
+		RequestID:  hotelsDomain.RequestIDFromContext(ctx),
+		CreatedAt:  time.Now().UTC(),
+	}, nil
+}
+
+// auditRoomType obtiene de la base de datos principal el tipo de habitacion antes de cambiarlo (nil si no se encuentra)
+func (service Service) auditRoomType(ctx context.Context, hotelID string, roomTypeID string) interface{} {
+	hotel, err := service.mainRepository.GetHotelByID(ctx, hotelID)
+	if err != nil {
+		return nil
+	}
+	for _, roomType := range roomTypesToDomain(hotel.RoomTypes) {
+		if roomType.ID == roomTypeID {
+			return roomType
+		}
+	}
+	return nil
+}
+
+// auditRateRule obtiene de la base de datos principal la regla de tarifa antes de cambiarla (nil si no se encuentra)
+func (service Service) auditRateRule(ctx context.Context, hotelID string, ruleID string) interface{} {
+	hotel, err := service.mainRepository.GetHotelByID(ctx, hotelID)
+	if err != nil {
+		return nil
+	}
+	for _, rule := range rateRulesToDomain(hotel.RateRules) {
+		if rule.ID == ruleID {
+			return rule
+		}
+	}
+	return nil
+}
+
+// auditChanges compara los campos del JSON de before y after. En un alta o una baja los valores vacios cuentan
+// como que no hay valor, asi se muestran solo los campos cargados. En una modificacion solo null es "sin valor":
+// un precio que pasa a 0 queda registrado como 0, distinto de un campo que se borro
+func auditChanges(before interface{}, after interface{}) ([]auditDAO.Change, error) {
+	snapshot := before == nil || after == nil
+	beforeFields, err := auditFields(before, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditFields(after, snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]bool)
+	for field := range beforeFields {
+		fields[field] = true
+	}
+	for field := range afterFields {
+		fields[field] = true
+	}
+
+	changes := make([]auditDAO.Change, 0)
+	for field := range fields {
+		if auditIgnoredFields[field] {
+			continue
+		}
+		beforeValue, afterValue := beforeFields[field], afterFields[field]
+		if beforeValue == afterValue {
+			continue
+		}
+		changes = append(changes, auditDAO.Change{Field: field, Before: beforeValue, After: afterValue})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+// auditFields devuelve los campos del primer nivel del JSON del objeto que tienen valor (sin null, y sin los valores
+// vacios si skipEmpty)
+func auditFields(object interface{}, skipEmpty bool) (map[string]string, error) {
+	fields := make(map[string]string)
+	if object == nil {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(object)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding audit object: %w", err)
+	}
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("error encoding audit object: %w", err)
+	}
+
+	for field, value := range document {
+		if isNullJSON(value) || (skipEmpty && isEmptyJSON(value)) {
+			continue
+		}
+		fields[field] = string(value)
+	}
+	return fields, nil
+}
+
+// isNullJSON indica si un valor del JSON es null
+func isNullJSON(value json.RawMessage) bool {
+	return string(bytes.TrimSpace(value)) == "null"
+}
+
+// isEmptyJSON indica si un valor del JSON es el valor vacio de su tipo
+func isEmptyJSON(value json.RawMessage) bool {
+	switch string(bytes.TrimSpace(value)) {
+	case "null", `""`, "0", "false", "[]", "{}", `"0001-01-01T00:00:00Z"`:
+		return true
+	}
+	return false
+}
+
+// Funcion que se encarga de obtener una pagina del registro de auditoria, los cambios mas nuevos primero.
+// next_cursor es el ID de la ultima entrada cuando hay mas paginas
+func (service Service) ListAudit(ctx context.Context, query hotelsDomain.AuditQuery) (hotelsDomain.AuditPage, error) {
+	filter := auditQueryToDAO(query)
+	// Se pide una entrada de mas para saber si hay otra pagina
+	filter.Limit = query.Limit + 1
+
+	entries, err := service.auditRepository.Find(ctx, filter)
+	if errors.Is(err, auditDAO.ErrInvalidCursor) {
+		return hotelsDomain.AuditPage{}, fmt.Errorf("%w: invalid cursor", hotelsDomain.ErrInvalidAuditQuery)
+	}
+	if err != nil {
+		return hotelsDomain.AuditPage{}, fmt.Errorf("error getting audit entries: %w", err)
+	}
+
+	page := hotelsDomain.AuditPage{Entries: make([]hotelsDomain.AuditEntry, 0, len(entries))}
+	if len(entries) > query.Limit {
+		entries = entries[:query.Limit]
+		page.NextCursor = entries[len(entries)-1].ID
+	}
+	for _, entry := range entries {
+		page.Entries = append(page.Entries, auditEntryToDomain(entry))
+	}
+	return page, nil
+}
+
+// Funcion que se encarga de recorrer todo el registro de auditoria que coincide con los filtros (sin paginar),
+// pasando cada entrada a write a medida que se lee de la base
+func (service Service) ExportAudit(ctx context.Context, query hotelsDomain.AuditQuery, write func(hotelsDomain.AuditEntry) error) error {
+	filter := auditQueryToDAO(query)
+	filter.Limit = 0
+
+	err := service.auditRepository.Stream(ctx, filter, func(entry auditDAO.Entry) error {
+		return write(auditEntryToDomain(entry))
+	})
+	if errors.Is(err, auditDAO.ErrInvalidCursor) {
+		return fmt.Errorf("%w: invalid cursor", hotelsDomain.ErrInvalidAuditQuery)
+	}
+	return err
+}
+
+func auditQueryToDAO(query hotelsDomain.AuditQuery) auditDAO.Query {
+	return auditDAO.Query{
+		UserID:     query.UserID,
+		Action:     query.Action,
+		TargetType: query.TargetType,
+		TargetID:   query.TargetID,
+		HotelID:    query.HotelID,
+		From:       query.From,
+		To:         query.To,
+		BeforeID:   query.Cursor,
+		Limit:      query.Limit,
+	}
+}
+
+func auditEntryToDomain(entry auditDAO.Entry) hotelsDomain.AuditEntry {
+	changes := make([]hotelsDomain.AuditChange, 0, len(entry.Changes))
+	for _, change := range entry.Changes {
+		changes = append(changes, hotelsDomain.AuditChange{
+			Field:  change.Field,
+			Before: auditValue(change.Before),
+			After:  auditValue(change.After),
+		})
+	}
+	return hotelsDomain.AuditEntry{
+		ID:         entry.ID,
+		Actor:      hotelsDomain.Actor{UserID: entry.UserID, UserType: entry.UserType},
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		HotelID:    entry.HotelID,
+		Changes:    changes,
+		RequestID:  entry.RequestID,
+		CreatedAt:  entry.CreatedAt,
+	}
+}
+
+// auditValue pasa un valor guardado a JSON (null si no tenia valor)
+func auditValue(value string) json.RawMessage {
+	if value == "" {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(value)
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	hotelsDomain "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/domain/hotels"
+	"github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/repositories/audit"
+	"github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/repositories/hotels"
+)
+
+// Contexto como el que arman los middlewares RequestID y Authenticate
+func auditContext(userID string, requestID string) context.Context {
+	ctx := hotelsDomain.WithActor(context.Background(), hotelsDomain.Actor{UserID: userID, UserType: "administrador"})
+	return hotelsDomain.WithRequestID(ctx, requestID)
+}
+
+// changesByField indexa los cambios de una entrada por campo
+func changesByField(entry hotelsDomain.AuditEntry) map[string]hotelsDomain.AuditChange {
+	changes := make(map[string]hotelsDomain.AuditChange)
+	for _, change := range entry.Changes {
+		changes[change.Field] = change
+	}
+	return changes
+}
+
+func TestAudit_HotelChanges(t *testing.T) {
+	service := NewService(hotels.NewMock(), hotels.NewMockCache(), audit.NewMock())
+
+	id, err := service.Create(auditContext("7", "req-create"), hotelsDomain.Hotel{Name: "Audit Hotel", City: "Cordoba", PricePerNight: 100})
+	if err != nil {
+		t.Fatalf("error creating hotel: %v", err)
+	}
+	if err := service.Update(auditContext("8", "req-update"), hotelsDomain.Hotel{ID: id, Version: 1, Name: "Audit Hotel", City: "Cordoba", PricePerNight: 120}); err != nil {
+		t.Fatalf("error updating hotel: %v", err)
+	}
+	if err := service.Delete(auditContext("9", "req-delete"), id, 2); err != nil {
+		t.Fatalf("error deleting hotel: %v", err)
+	}
+
+	page, err := service.ListAudit(context.Background(), hotelsDomain.AuditQuery{TargetID: id, Limit: 10})
+	if err != nil {
+		t.Fatalf("error listing audit: %v", err)
+	}
+	if len(page.Entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d: %+v", len(page.Entries), page.Entries)
+	}
+
+	// Los mas nuevos primero
+	deleted, updated, created := page.Entries[0], page.Entries[1], page.Entries[2]
+	if created.Action != hotelsDomain.AuditActionHotelCreate || updated.Action != hotelsDomain.AuditActionHotelUpdate || deleted.Action != hotelsDomain.AuditActionHotelDelete {
+		t.Fatalf("unexpected actions: %s, %s, %s", created.Action, updated.Action, deleted.Action)
+	}
+	if updated.Actor.UserID != "8" || updated.Actor.UserType != "administrador" || updated.RequestID != "req-update" {
+		t.Fatalf("expected actor 8 and request req-update, got %+v %s", updated.Actor, updated.RequestID)
+	}
+	if updated.TargetType != hotelsDomain.AuditTargetHotel || updated.HotelID != id {
+		t.Fatalf("unexpected target %s %s", updated.TargetType, updated.HotelID)
+	}
+
+	// El PUT solo cambio el precio
+	if len(updated.Changes) != 1 {
+		t.Fatalf("expected only price_per_night to change, got %+v", updated.Changes)
+	}
+	price := updated.Changes[0]
+	if price.Field != "price_per_night" || string(price.Before) != "100" || string(price.After) != "120" {
+		t.Fatalf("unexpected price change %s: %s -> %s", price.Field, price.Before, price.After)
+	}
+
+	// El alta y la baja muestran los campos cargados, del otro lado en null
+	createdChanges := changesByField(created)
+	if change, ok := createdChanges["city"]; !ok || string(change.Before) != "null" || string(change.After) != `"Cordoba"` {
+		t.Fatalf("expected city in create entry, got %+v", created.Changes)
+	}
+	if _, ok := createdChanges["state"]; ok {
+		t.Fatalf("expected empty fields to be left out, got %+v", created.Changes)
+	}
+	if change, ok := changesByField(deleted)["price_per_night"]; !ok || string(change.Before) != "120" || string(change.After) != "null" {
+		t.Fatalf("expected price in delete entry, got %+v", deleted.Changes)
+	}
+}
+
+func TestAudit_UpdateToZeroValue(t *testing.T) {
+	service := NewService(hotels.NewMock(), hotels.NewMockCache(), audit.NewMock())
+
+	id, err := service.Create(auditContext("7", "req-create"), hotelsDomain.Hotel{Name: "Zero Hotel", City: "Cordoba", PricePerNight: 100, Rating: 4})
+	if err != nil {
+		t.Fatalf("error creating hotel: %v", err)
+	}
+	if err := service.Update(auditContext("8", "req-update"), hotelsDomain.Hotel{ID: id, Version: 1, Name: "Zero Hotel", City: "Cordoba"}); err != nil {
+		t.Fatalf("error updating hotel: %v", err)
+	}
+
+	page, err := service.ListAudit(context.Background(), hotelsDomain.AuditQuery{TargetID: id, Action: hotelsDomain.AuditActionHotelUpdate, Limit: 10})
+	if err != nil || len(page.Entries) != 1 {
+		t.Fatalf("expected the update entry, got %+v (err %v)", page.Entries, err)
+	}
+
+	// Los valores en cero se registran como 0, no como un campo borrado
+	changes := changesByField(page.Entries[0])
+	if change, ok := changes["price_per_night"]; !ok || string(change.Before) != "100" || string(change.After) != "0" {
+		t.Fatalf("expected price change to 0, got %+v", page.Entries[0].Changes)
+	}
+	if change, ok := changes["rating"]; !ok || string(change.Before) != "4" || string(change.After) != "0" {
+		t.Fatalf("expected rating change to 0, got %+v", page.Entries[0].Changes)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("expected only price_per_night and rating to change, got %+v", page.Entries[0].Changes)
+	}
+}
+
+func TestAudit_FailedWriteIsNotRecorded(t *testing.T) {
+	service := NewService(hotels.NewMock(), hotels.NewMockCache(), audit.NewMock())
+	ctx := auditContext("7", "req")
+
+	id, err := service.Create(ctx, hotelsDomain.Hotel{Name: "Audit Hotel"})
+	if err != nil {
+		t.Fatalf("error creating hotel: %v", err)
+	}
+	if err := service.Update(ctx, hotelsDomain.Hotel{ID: id, Version: 5, Name: "Stale"}); !errors.Is(err, hotelsDomain.ErrVersionConflict) {
+		t.Fatalf("expected ErrVersionConflict, got %v", err)
+	}
+
+	page, err := service.ListAudit(ctx, hotelsDomain.AuditQuery{Limit: 10})
+	if err != nil {
+		t.Fatalf("error listing audit: %v", err)
+	}
+	if len(page.Entries) != 1 || page.Entries[0].Action != hotelsDomain.AuditActionHotelCreate {
+		t.Fatalf("expected only the create entry, got %+v", page.Entries)
+	}
+}
+
+func TestAudit_ReservationAndRateRule(t *testing.T) {
+	service := NewService(hotels.NewMock(), hotels.NewMockCache(), audit.NewMock())
+	ctx := auditContext("42", "req")
+
+	hotelID, err := service.Create(ctx, hotelsDomain.Hotel{Name: "Audit Hotel", AvaiableRooms: 1, PricePerNight: 100})
+	if err != nil {
+		t.Fatalf("error creating hotel: %v", err)
+	}
+	reservationID, err := service.CreateReservation(ctx, hotelsDomain.Reservation{
+		HotelID:  hotelID,
+		UserID:   "42",
+		CheckIn:  parseDate(t, "2024-01-01"),
+		CheckOut: parseDate(t, "2024-01-02"),
+	})
+	if err != nil {
+		t.Fatalf("error creating reservation: %v", err)
+	}
+	if err := service.CancelReservation(ctx, reservationID); err != nil {
+		t.Fatalf("error cancelling reservation: %v", err)
+	}
+
+	page, err := service.ListAudit(ctx, hotelsDomain.AuditQuery{Action: hotelsDomain.AuditActionReservationCancel, Limit: 10})
+	if err != nil {
+		t.Fatalf("error listing audit: %v", err)
+	}
+	if len(page.Entries) != 1 {
+		t.Fatalf("expected 1 cancel entry, got %+v", page.Entries)
+	}
+	cancelled := page.Entries[0]
+	if cancelled.TargetID != reservationID || cancelled.HotelID != hotelID || cancelled.Actor.UserID != "42" {
+		t.Fatalf("unexpected cancel entry %+v", cancelled)
+	}
+	if len(cancelled.Changes) != 1 || cancelled.Changes[0].Field != "status" || string(cancelled.Changes[0].After) != `"`+hotelsDomain.ReservationStatusCancelled+`"` {
+		t.Fatalf("expected only the status change, got %+v", cancelled.Changes)
+	}
+
+	ruleID, err := service.CreateRateRule(ctx, hotelID, hotelsDomain.RateRule{Name: "Weekend", Type: hotelsDomain.RateRuleWeekend, Multiplier: 1.2})
+	if err != nil {
+		t.Fatalf("error creating rate rule: %v", err)
+	}
+	if err := service.UpdateRateRule(ctx, hotelID, hotelsDomain.RateRule{ID: ruleID, Name: "Weekend", Type: hotelsDomain.RateRuleWeekend, Multiplier: 1.5}); err != nil {
+		t.Fatalf("error updating rate rule: %v", err)
+	}
+
+	page, err = service.ListAudit(ctx, hotelsDomain.AuditQuery{TargetType: hotelsDomain.AuditTargetRateRule, Limit: 10})
+	if err != nil {
+		t.Fatalf("error listing audit: %v", err)
+	}
+	if len(page.Entries) != 2 || page.Entries[0].Action != hotelsDomain.AuditActionRateRuleUpdate {
+		t.Fatalf("expected create and update entries, got %+v", page.Entries)
+	}
+	if change, ok := changesByField(page.Entries[0])["multiplier"]; !ok || string(change.Before) != "1.2" || string(change.After) != "1.5" {
+		t.Fatalf("expected multiplier change, got %+v", page.Entries[0].Changes)
+	}
+}
+
+func TestListAudit_Pages(t *testing.T) {
+	service := NewService(hotels.NewMock(), hotels.NewMockCache(), audit.NewMock())
+	ctx := auditContext("7", "req")
+
+	for i := 0; i < 3; i++ {
+		if _, err := service.Create(ctx, hotelsDomain.Hotel{Name: "Audit Hotel"}); err != nil {
+			t.Fatalf("error creating hotel: %v", err)
+		}
+	}
+
+	first, err := service.ListAudit(ctx, hotelsDomain.AuditQuery{Limit: 2})
+	if err != nil {
+		t.Fatalf("error listing audit: %v", err)
+	}
+	if len(first.Entries) != 2 || first.NextCursor == "" {
+		t.Fatalf("expected a full first page with cursor, got %+v", first)
+	}
+	second, err := service.ListAudit(ctx, hotelsDomain.AuditQuery{Limit: 2, Cursor: first.NextCursor})
+	if err != nil {
+		t.Fatalf("error listing audit: %v", err)
+	}
+	if len(second.Entries) != 1 || second.NextCursor != "" {
+		t.Fatalf("expected a last page with 1 entry, got %+v", second)
+	}
+	if second.Entries[0].ID == first.Entries[0].ID || second.Entries[0].ID == first.Entries[1].ID {
+		t.Fatalf("expected pages not to overlap")
+	}
+
+	if _, err := service.ListAudit(ctx, hotelsDomain.AuditQuery{Limit: 2, Cursor: "not-an-id"}); !errors.Is(err, hotelsDomain.ErrInvalidAuditQuery) {
+		t.Fatalf("expected ErrInvalidAuditQuery, got %v", err)
+	}
+}
@@ -0,0 +1,463 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	auditDAO "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/dao/audit"
+	hotelsDAO "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/dao/hotels"
+	hotelsDomain "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/domain/hotels"
+)
+
+const (
+	// Hoteles por InsertMany en la importacion
+	hotelImportBatchSize = 500
+	// Hoteles por pagina al recorrer la base en la exportacion
+	hotelExportPageSize = 500
+	// Separador de las listas (amenities e images) dentro de una celda del CSV
+	csvListSeparator = "|"
+)
+
+// hotelCSVColumns son las columnas del CSV de hoteles. La exportacion agrega id y version al principio,
+// la importacion las acepta pero las ignora (siempre crea hoteles nuevos). Los tipos de habitacion
+// y las reglas de tarifa solo van en NDJSON
+var hotelCSVColumns = []string{
+	"name", "description", "address", "city", "state", "country", "phone", "email",
+	"price_per_night", "rating", "avaiable_rooms", "check_in_time", "check_out_time",
+	"amenities", "images", "currency", "latitude", "longitude",
+}
+
+// Funcion que se encarga de importar hoteles desde un archivo CSV o NDJSON. El archivo se lee fila por fila:
+// cada fila se valida y las validas se guardan de a hotelImportBatchSize con su evento CREATE en el outbox
+// (el OutboxRelay los publica en tandas). Las filas invalidas quedan en el reporte y no cortan la importacion.
+// En dry run solo se valida. Si falla la lectura del archivo devuelve el error junto con el reporte de lo procesado hasta ahi
+func (service Service) ImportHotels(ctx context.Context, format string, body io.Reader, dryRun bool) (hotelsDomain.HotelImportReport, error) {
+	report := hotelsDomain.HotelImportReport{DryRun: dryRun, Errors: []hotelsDomain.HotelImportError{}}
+
+	reader, err := newHotelReader(format, body)
+	if err != nil {
+		return report, err
+	}
+
+	batch := make([]hotelsDAO.Hotel, 0, hotelImportBatchSize)
+	lines := make([]int, 0, hotelImportBatchSize)
+	for {
+		line, hotel, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil && !errors.Is(err, hotelsDomain.ErrInvalidImportRow) {
+			service.saveImportBatch(ctx, &report, batch, lines)
+			return report, fmt.Errorf("error reading hotels import: %w", err)
+		}
+
+		report.Rows++
+		if err != nil {
+			report.AddError(line, err)
+			continue
+		}
+		record, err := importedHotelToDAO(hotel)
+		if err != nil {
+			report.AddError(line, err)
+			continue
+		}
+		report.Valid++
+		if dryRun {
+			continue
+		}
+
+		batch = append(batch, record)
+		lines = append(lines, line)
+		if len(batch) == hotelImportBatchSize {
+			service.saveImportBatch(ctx, &report, batch, lines)
+			batch, lines = batch[:0], lines[:0]
+		}
+	}
+
+	service.saveImportBatch(ctx, &report, batch, lines)
+	return report, nil
+}
+
+// saveImportBatch guarda una tanda de hoteles validados en un solo InsertMany y registra los creados en la auditoria.
+// Los que no se pudieron guardar quedan como error de su linea
+func (service Service) saveImportBatch(ctx context.Context, report *hotelsDomain.HotelImportReport, batch []hotelsDAO.Hotel, lines []int) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ids, err := service.mainRepository.CreateMany(ctx, batch)
+	entries := make([]auditDAO.Entry, 0, len(batch))
+	for i, record := range batch {
+		if ids == nil || ids[i] == "" {
+			report.AddError(lines[i], fmt.Errorf("error saving hotel: %w", err))
+			continue
+		}
+
+		record.ID = ids[i]
+		record.Outbox = nil
+		report.Created++
+		report.IDs = append(report.IDs, record.ID)
+
+		entry, auditErr := newAuditEntry(ctx, hotelsDomain.AuditActionHotelImport, hotelsDomain.AuditTargetHotel, record.ID, record.ID, nil, hotelToDomain(record))
+		if auditErr != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) > 0 {
+		service.auditMany(ctx, entries)
+	}
+}
+
+// importedHotelToDAO valida un hotel de la importacion y lo convierte al documento que se guarda (version 1 y evento CREATE)
+func importedHotelToDAO(hotel hotelsDomain.Hotel) (hotelsDAO.Hotel, error) {
+	if strings.TrimSpace(hotel.Name) == "" {
+		return hotelsDAO.Hotel{}, fmt.Errorf("%w: name is required", hotelsDomain.ErrInvalidImportRow)
+	}
+	if hotel.PricePerNight < 0 || hotel.AvaiableRooms < 0 {
+		return hotelsDAO.Hotel{}, fmt.Errorf("%w: price_per_night and avaiable_rooms cannot be negative", hotelsDomain.ErrInvalidImportRow)
+	}
+	if hotel.Rating < 0 || hotel.Rating > 5 {
+		return hotelsDAO.Hotel{}, fmt.Errorf("%w: rating must be between 0 and 5", hotelsDomain.ErrInvalidImportRow)
+	}
+	location, err := locationToDAO(hotel.Latitude, hotel.Longitude)
+	if err != nil {
+		return hotelsDAO.Hotel{}, err
+	}
+	for _, roomType := range hotel.RoomTypes {
+		if err := validateRoomType(roomType); err != nil {
+			return hotelsDAO.Hotel{}, err
+		}
+	}
+	for _, rule := range hotel.RateRules {
+		if err := validateRateRule(rule); err != nil {
+			return hotelsDAO.Hotel{}, err
+		}
+	}
+
+	return hotelsDAO.Hotel{
+		Name:          hotel.Name,
+		Description:   hotel.Description,
+		Address:       hotel.Address,
+		City:          hotel.City,
+		State:         hotel.State,
+		Country:       hotel.Country,
+		Phone:         hotel.Phone,
+		Email:         hotel.Email,
+		PricePerNight: hotel.PricePerNight,
+		Rating:        hotel.Rating,
+		AvaiableRooms: hotel.AvaiableRooms,
+		CheckInTime:   hotel.CheckInTime,
+		CheckOutTime:  hotel.CheckOutTime,
+		Amenities:     hotel.Amenities,
+		Images:        hotel.Images,
+		RoomTypes:     roomTypesToDAO(hotel.RoomTypes),
+		Currency:      hotel.Currency,
+		RateRules:     rateRulesToDAO(hotel.RateRules),
+		Location:      location,
+		Version:       1,
+		Outbox:        []hotelsDAO.OutboxEvent{newOutboxEvent(hotelsDomain.OperationCreate)},
+	}, nil
+}
+
+// Funcion que se encarga de exportar todos los hoteles (sin los borrados) en CSV o NDJSON. Recorre la base
+// de a hotelExportPageSize hoteles y escribe cada pagina apenas la lee; si w tiene Flush se llama despues de cada pagina
+func (service Service) ExportHotels(ctx context.Context, format string, w io.Writer) error {
+	writer, err := newHotelWriter(format, w)
+	if err != nil {
+		return err
+	}
+
+	afterID := ""
+	for {
+		page, err := service.mainRepository.ListHotels(ctx, hotelsDAO.HotelQuery{AfterID: afterID, Limit: hotelExportPageSize})
+		if err != nil {
+			return fmt.Errorf("error listing hotels from repository: %w", err)
+		}
+		for _, hotel := range page {
+			if err := writer.Write(hotelToDomain(hotel)); err != nil {
+				return fmt.Errorf("error writing hotel %s: %w", hotel.ID, err)
+			}
+		}
+		if err := writer.Flush(); err != nil {
+			return fmt.Errorf("error writing hotels: %w", err)
+		}
+		if flusher, ok := w.(interface{ Flush() }); ok {
+			flusher.Flush()
+		}
+
+		if len(page) < hotelExportPageSize {
+			return nil
+		}
+		afterID = page[len(page)-1].ID
+	}
+}
+
+// hotelReader lee los hoteles de un archivo de importacion. Read devuelve la linea de cada fila,
+// ErrInvalidImportRow si la fila no se puede leer (se sigue con la siguiente) e io.EOF al terminar
+type hotelReader interface {
+	Read() (int, hotelsDomain.Hotel, error)
+}
+
+// hotelWriter escribe los hoteles de una exportacion
+type hotelWriter interface {
+	Write(hotel hotelsDomain.Hotel) error
+	Flush() error
+}
+
+func newHotelReader(format string, body io.Reader) (hotelReader, error) {
+	switch format {
+	case hotelsDomain.HotelFormatCSV:
+		return newCSVHotelReader(body)
+	case hotelsDomain.HotelFormatNDJSON:
+		return &ndjsonHotelReader{reader: bufio.NewReader(body)}, nil
+	}
+	return nil, fmt.Errorf("%w: %q", hotelsDomain.ErrUnknownHotelFormat, format)
+}
+
+func newHotelWriter(format string, w io.Writer) (hotelWriter, error) {
+	switch format {
+	case hotelsDomain.HotelFormatCSV:
+		writer := csvHotelWriter{writer: csv.NewWriter(w)}
+		if err := writer.writer.Write(append([]string{"id", "version"}, hotelCSVColumns...)); err != nil {
+			return nil, fmt.Errorf("error writing csv header: %w", err)
+		}
+		return writer, nil
+	case hotelsDomain.HotelFormatNDJSON:
+		return ndjsonHotelWriter{encoder: json.NewEncoder(w)}, nil
+	}
+	return nil, fmt.Errorf("%w: %q", hotelsDomain.ErrUnknownHotelFormat, format)
+}
+
+// ndjsonHotelReader lee un hotel en JSON por linea, las lineas vacias se saltean
+type ndjsonHotelReader struct {
+	reader *bufio.Reader
+	line   int
+}
+
+func (r *ndjsonHotelReader) Read() (int, hotelsDomain.Hotel, error) {
+	for {
+		data, err := r.reader.ReadBytes('\n')
+		if len(data) == 0 && err != nil {
+			return r.line, hotelsDomain.Hotel{}, err
+		}
+		r.line++
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+
+		// Los campos desconocidos se rechazan para no perder en silencio un campo mal escrito
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		var hotel hotelsDomain.Hotel
+		if err := decoder.Decode(&hotel); err != nil {
+			return r.line, hotelsDomain.Hotel{}, fmt.Errorf("%w: %s", hotelsDomain.ErrInvalidImportRow, err.Error())
+		}
+		return r.line, hotel, nil
+	}
+}
+
+type ndjsonHotelWriter struct {
+	encoder *json.Encoder
+}
+
+// Encode agrega el salto de linea despues de cada hotel
+func (w ndjsonHotelWriter) Write(hotel hotelsDomain.Hotel) error {
+	return w.encoder.Encode(hotel)
+}
+
+func (w ndjsonHotelWriter) Flush() error {
+	return nil
+}
+
+// csvHotelReader lee los hoteles de un CSV con encabezado. Las columnas pueden venir en cualquier orden
+// y las que faltan quedan vacias, pero name es obligatoria y no se aceptan columnas desconocidas
+type csvHotelReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCSVHotelReader(body io.Reader) (*csvHotelReader, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: empty csv", hotelsDomain.ErrInvalidImport)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", hotelsDomain.ErrInvalidImport, err.Error())
+	}
+
+	known := map[string]bool{"id": true, "version": true}
+	for _, column := range hotelCSVColumns {
+		known[column] = true
+	}
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		if !known[column] {
+			return nil, fmt.Errorf("%w: unknown csv column %q", hotelsDomain.ErrInvalidImport, column)
+		}
+		columns[column] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, fmt.Errorf("%w: csv header must have a name column", hotelsDomain.ErrInvalidImport)
+	}
+	return &csvHotelReader{reader: reader, columns: columns}, nil
+}
+
+func (r *csvHotelReader) Read() (int, hotelsDomain.Hotel, error) {
+	record, err := r.reader.Read()
+	line, _ := r.reader.FieldPos(0)
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return parseErr.Line, hotelsDomain.Hotel{}, fmt.Errorf("%w: %s", hotelsDomain.ErrInvalidImportRow, parseErr.Err.Error())
+		}
+		return line, hotelsDomain.Hotel{}, err
+	}
+
+	value := func(column string) string {
+		if i, ok := r.columns[column]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	hotel := hotelsDomain.Hotel{
+		Name:        value("name"),
+		Description: value("description"),
+		Address:     value("address"),
+		City:        value("city"),
+		State:       value("state"),
+		Country:     value("country"),
+		Phone:       value("phone"),
+		Email:       value("email"),
+		Currency:    value("currency"),
+		Amenities:   csvList(value("amenities")),
+		Images:      csvList(value("images")),
+	}
+
+	// Los numeros y fechas vacios quedan en cero
+	var errs []string
+	if hotel.PricePerNight, err = csvFloat(value("price_per_night")); err != nil {
+		errs = append(errs, "price_per_night must be a number")
+	}
+	if hotel.Rating, err = csvFloat(value("rating")); err != nil {
+		errs = append(errs, "rating must be a number")
+	}
+	if raw := value("avaiable_rooms"); raw != "" {
+		if hotel.AvaiableRooms, err = strconv.Atoi(raw); err != nil {
+			errs = append(errs, "avaiable_rooms must be an integer")
+		}
+	}
+	if hotel.CheckInTime, err = csvTime(value("check_in_time")); err != nil {
+		errs = append(errs, "check_in_time must be RFC3339")
+	}
+	if hotel.CheckOutTime, err = csvTime(value("check_out_time")); err != nil {
+		errs = append(errs, "check_out_time must be RFC3339")
+	}
+	if raw := value("latitude"); raw != "" {
+		latitude, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			errs = append(errs, "latitude must be a number")
+		}
+		hotel.Latitude = &latitude
+	}
+	if raw := value("longitude"); raw != "" {
+		longitude, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			errs = append(errs, "longitude must be a number")
+		}
+		hotel.Longitude = &longitude
+	}
+	if len(errs) > 0 {
+		return line, hotelsDomain.Hotel{}, fmt.Errorf("%w: %s", hotelsDomain.ErrInvalidImportRow, strings.Join(errs, ", "))
+	}
+	return line, hotel, nil
+}
+
+type csvHotelWriter struct {
+	writer *csv.Writer
+}
+
+func (w csvHotelWriter) Write(hotel hotelsDomain.Hotel) error {
+	latitude, longitude := "", ""
+	if hotel.Latitude != nil && hotel.Longitude != nil {
+		latitude = strconv.FormatFloat(*hotel.Latitude, 'f', -1, 64)
+		longitude = strconv.FormatFloat(*hotel.Longitude, 'f', -1, 64)
+	}
+	return w.writer.Write([]string{
+		hotel.ID,
+		strconv.FormatInt(hotel.Version, 10),
+		hotel.Name,
+		hotel.Description,
+		hotel.Address,
+		hotel.City,
+		hotel.State,
+		hotel.Country,
+		hotel.Phone,
+		hotel.Email,
+		strconv.FormatFloat(hotel.PricePerNight, 'f', -1, 64),
+		strconv.FormatFloat(hotel.Rating, 'f', -1, 64),
+		strconv.Itoa(hotel.AvaiableRooms),
+		csvTimeValue(hotel.CheckInTime),
+		csvTimeValue(hotel.CheckOutTime),
+		strings.Join(hotel.Amenities, csvListSeparator),
+		strings.Join(hotel.Images, csvListSeparator),
+		hotel.Currency,
+		latitude,
+		longitude,
+	})
+}
+
+func (w csvHotelWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// csvList separa una celda con valores separados por csvListSeparator (nil si esta vacia)
+func csvList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	var list []string
+	for _, item := range strings.Split(value, csvListSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func csvFloat(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+func csvTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// csvTimeValue escribe una fecha en RFC3339 (vacia si no tiene)
+func csvTimeValue(value time.Time) string {
+	if value.IsZero() {
+		return ""
+	}
+	return value.Format(time.RFC3339)
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	hotelsDomain "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/domain/hotels"
+)
+
+func TestImportHotels_CSV(t *testing.T) {
+	service, mainRepo, _ := getTestService()
+	ctx := auditContext("7", "req-import")
+
+	file := "\ufeffname,city,price_per_night,avaiable_rooms,amenities,latitude,longitude\n" +
+		"Hotel Uno,Cordoba,100,5,wifi|pool,-31.4,-64.2\n" +
+		",Rosario,80,3,,,\n" +
+		"Hotel Dos,Mendoza,abc,2,,,\n" +
+		"Hotel Tres,Salta,90,-1,,,\n" +
+		"Hotel Cuatro,Jujuy,70,1,,,\n"
+
+	report, err := service.ImportHotels(ctx, hotelsDomain.HotelFormatCSV, strings.NewReader(file), false)
+	if err != nil {
+		t.Fatalf("error importing hotels: %v", err)
+	}
+	if report.Rows != 5 || report.Valid != 2 || report.Created != 2 || report.Failed != 3 || len(report.IDs) != 2 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	// Las lineas cuentan el encabezado como linea 1
+	var lines []int
+	for _, rowErr := range report.Errors {
+		lines = append(lines, rowErr.Line)
+	}
+	if len(lines) != 3 || lines[0] != 3 || lines[1] != 4 || lines[2] != 5 {
+		t.Fatalf("expected errors in lines 3, 4 and 5, got %+v", report.Errors)
+	}
+
+	created, err := mainRepo.GetHotelByID(ctx, report.IDs[0])
+	if err != nil {
+		t.Fatalf("error getting imported hotel: %v", err)
+	}
+	if created.Name != "Hotel Uno" || created.AvaiableRooms != 5 || len(created.Amenities) != 2 || created.Amenities[1] != "pool" || created.Location == nil {
+		t.Fatalf("unexpected imported hotel %+v", created)
+	}
+	if created.Version != 1 || len(created.Outbox) != 1 || created.Outbox[0].Operation != hotelsDomain.OperationCreate {
+		t.Fatalf("expected version 1 and a CREATE outbox event, got version %d outbox %+v", created.Version, created.Outbox)
+	}
+
+	page, err := service.ListAudit(ctx, hotelsDomain.AuditQuery{Action: hotelsDomain.AuditActionHotelImport, Limit: 10})
+	if err != nil {
+		t.Fatalf("error listing audit: %v", err)
+	}
+	if len(page.Entries) != 2 || page.Entries[0].RequestID != "req-import" || page.Entries[0].Actor.UserID != "7" {
+		t.Fatalf("expected 2 import entries, got %+v", page.Entries)
+	}
+}
+
+func TestImportHotels_DryRun(t *testing.T) {
+	service, _, _ := getTestService()
+	ctx := context.Background()
+
+	file := `{"name":"Hotel Uno","city":"Cordoba"}` + "\n\n" + `{"name":"Hotel Dos","citi":"Typo"}` + "\n"
+	report, err := service.ImportHotels(ctx, hotelsDomain.HotelFormatNDJSON, strings.NewReader(file), true)
+	if err != nil {
+		t.Fatalf("error importing hotels: %v", err)
+	}
+	if !report.DryRun || report.Rows != 2 || report.Valid != 1 || report.Created != 0 || report.Failed != 1 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	// La linea vacia tambien cuenta
+	if report.Errors[0].Line != 3 {
+		t.Fatalf("expected error in line 3, got %+v", report.Errors)
+	}
+
+	page, err := service.ListHotels(ctx, hotelsDomain.HotelListQuery{Limit: 10})
+	if err != nil {
+		t.Fatalf("error listing hotels: %v", err)
+	}
+	if len(page.Hotels) != 0 {
+		t.Fatalf("expected dry run not to create hotels, got %+v", page.Hotels)
+	}
+}
+
+func TestImportHotels_InvalidFile(t *testing.T) {
+	service, _, _ := getTestService()
+	ctx := context.Background()
+
+	tests := []struct {
+		format string
+		file   string
+		err    error
+	}{
+		{format: hotelsDomain.HotelFormatCSV, file: "", err: hotelsDomain.ErrInvalidImport},
+		{format: hotelsDomain.HotelFormatCSV, file: "city\nCordoba\n", err: hotelsDomain.ErrInvalidImport},
+		{format: hotelsDomain.HotelFormatCSV, file: "name,stars\nHotel,5\n", err: hotelsDomain.ErrInvalidImport},
+		{format: "xml", file: "<hotels/>", err: hotelsDomain.ErrUnknownHotelFormat},
+	}
+	for _, tt := range tests {
+		if _, err := service.ImportHotels(ctx, tt.format, strings.NewReader(tt.file), false); !errors.Is(err, tt.err) {
+			t.Fatalf("format=%s file=%q: expected %v, got %v", tt.format, tt.file, tt.err, err)
+		}
+	}
+}
+
+func TestImportHotels_NDJSONWithRoomTypes(t *testing.T) {
+	service, _, _ := getTestService()
+	ctx := context.Background()
+
+	file := `{"name":"Hotel Uno","price_per_night":100,"room_types":[{"name":"Doble","capacity":2,"count":4,"base_price":120}],"rate_rules":[{"name":"Finde","type":"WEEKEND","multiplier":1.2}]}` + "\n" +
+		`{"name":"Hotel Dos","room_types":[{"name":"","capacity":0}]}` + "\n"
+	report, err := service.ImportHotels(ctx, hotelsDomain.HotelFormatNDJSON, strings.NewReader(file), false)
+	if err != nil {
+		t.Fatalf("error importing hotels: %v", err)
+	}
+	if report.Created != 1 || report.Failed != 1 || report.Errors[0].Line != 2 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	roomTypes, err := service.GetRoomTypes(ctx, report.IDs[0])
+	if err != nil {
+		t.Fatalf("error getting room types: %v", err)
+	}
+	if len(roomTypes) != 1 || roomTypes[0].ID == "" || roomTypes[0].Count != 4 {
+		t.Fatalf("expected the imported room type with an id, got %+v", roomTypes)
+	}
+	rules, err := service.GetRateRules(ctx, report.IDs[0])
+	if err != nil {
+		t.Fatalf("error getting rate rules: %v", err)
+	}
+	if len(rules) != 1 || rules[0].Type != hotelsDomain.RateRuleWeekend {
+		t.Fatalf("expected the imported rate rule, got %+v", rules)
+	}
+}
+
+func TestExportHotels_RoundTrip(t *testing.T) {
+	for _, format := range []string{hotelsDomain.HotelFormatCSV, hotelsDomain.HotelFormatNDJSON} {
+		t.Run(format, func(t *testing.T) {
+			service, _, _ := getTestService()
+			ctx := context.Background()
+
+			latitude, longitude := -31.4, -64.2
+			for _, hotel := range []hotelsDomain.Hotel{
+				{Name: "Hotel Uno", City: "Cordoba", PricePerNight: 100.5, Amenities: []string{"wifi", "pool"}, Latitude: &latitude, Longitude: &longitude},
+				{Name: "Hotel, \"Dos\"", City: "Rosario", AvaiableRooms: 3},
+			} {
+				if _, err := service.Create(ctx, hotel); err != nil {
+					t.Fatalf("error creating hotel: %v", err)
+				}
+			}
+
+			var exported bytes.Buffer
+			if err := service.ExportHotels(ctx, format, &exported); err != nil {
+				t.Fatalf("error exporting hotels: %v", err)
+			}
+
+			// Lo exportado se vuelve a importar tal cual: id y version se ignoran y se crean hoteles nuevos
+			target, _, _ := getTestService()
+			report, err := target.ImportHotels(ctx, format, &exported, false)
+			if err != nil {
+				t.Fatalf("error importing exported hotels: %v", err)
+			}
+			if report.Created != 2 || report.Failed != 0 {
+				t.Fatalf("unexpected report %+v", report)
+			}
+
+			byName := make(map[string]hotelsDomain.Hotel)
+			for _, id := range report.IDs {
+				hotel, err := target.GetHotelByID(ctx, id)
+				if err != nil {
+					t.Fatalf("error getting imported hotel: %v", err)
+				}
+				byName[hotel.Name] = hotel
+			}
+			uno, dos := byName["Hotel Uno"], byName["Hotel, \"Dos\""]
+			if uno.PricePerNight != 100.5 || len(uno.Amenities) != 2 || uno.Latitude == nil || *uno.Latitude != latitude {
+				t.Fatalf("unexpected round trip of Hotel Uno: %+v", uno)
+			}
+			if dos.City != "Rosario" || dos.AvaiableRooms != 3 || dos.Version != 1 {
+				t.Fatalf("unexpected round trip of Hotel Dos: %+v", dos)
+			}
+		})
+	}
+}
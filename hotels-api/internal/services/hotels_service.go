@@ -2,44 +2,61 @@ package services
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
+
+	"github.com/google/uuid"
 
 	hotelsDAO "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/dao/hotels"
 	hotelsDomain "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/domain/hotels"
+	"github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/pricing"
 )
 
 // Estas funciones salen de los repositorios, se encargan de interactuar tanto de la base de datos como de la cache, ambas tienen las mismas funciones pero con diferentes implementaciones para cada cosa
 type Repository interface {
 	GetHotelByID(ctx context.Context, id string) (hotelsDAO.Hotel, error)
 	Create(ctx context.Context, hotel hotelsDAO.Hotel) (string, error)
+	CreateMany(ctx context.Context, hotels []hotelsDAO.Hotel) ([]string, error)
 	Update(ctx context.Context, hotel hotelsDAO.Hotel) error
-	Delete(ctx context.Context, id string) error
+	Delete(ctx context.Context, id string, version int64, event hotelsDAO.OutboxEvent) error
 	CreateReservation(ctx context.Context, reservation hotelsDAO.Reservation) (string, error)
 	GetReservationByID(ctx context.Context, id string) (hotelsDAO.Reservation, error)
-	CancelReservation(ctx context.Context, id string) error
+	UpdateReservationStatus(ctx context.Context, id string, from string, to string, at time.Time) (bool, error)
 	GetReservationsByHotelID(ctx context.Context, hotelID string) ([]hotelsDAO.Reservation, error)
+	SetReservationsByHotelID(ctx context.Context, hotelID string, reservations []hotelsDAO.Reservation) error
 	GetReservationsByUserAndHotelID(ctx context.Context, hotelID string, userID string) ([]hotelsDAO.Reservation, error)
 	GetReservationsByUserID(ctx context.Context, userID string) ([]hotelsDAO.Reservation, error)
 	DeleteReservationsByHotelID(ctx context.Context, hotelID string) error
 	GetAvailability(ctx context.Context, hotelIDs []string, checkIn, checkOut string) (map[string]bool, error)
-}
-
-type Queue interface {
-	Publish(hotelNew hotelsDomain.HotelNew) error
+	ReserveRooms(ctx context.Context, hotelID string, roomTypeID string, checkIn, checkOut time.Time, capacity int) (bool, error)
+	ReleaseRooms(ctx context.Context, hotelID string, roomTypeID string, checkIn, checkOut time.Time) error
+	AddRoomType(ctx context.Context, hotelID string, roomType hotelsDAO.RoomType, event hotelsDAO.OutboxEvent) error
+	UpdateRoomType(ctx context.Context, hotelID string, roomType hotelsDAO.RoomType, event hotelsDAO.OutboxEvent) (bool, error)
+	DeleteRoomType(ctx context.Context, hotelID string, roomTypeID string, event hotelsDAO.OutboxEvent) (bool, error)
+	AddRateRule(ctx context.Context, hotelID string, rule hotelsDAO.RateRule) error
+	UpdateRateRule(ctx context.Context, hotelID string, rule hotelsDAO.RateRule) (bool, error)
+	DeleteRateRule(ctx context.Context, hotelID string, ruleID string) (bool, error)
+	GetNightlyOccupancy(ctx context.Context, hotelID string, from, to time.Time) ([]hotelsDAO.NightOccupancy, error)
+	ListHotels(ctx context.Context, query hotelsDAO.HotelQuery) ([]hotelsDAO.Hotel, error)
 }
 
 type Service struct {
 	mainRepository  Repository
 	cacheRepository Repository
-	eventsQueue     Queue
+	auditRepository AuditRepository
 }
 
-// Funcion que se encarga de crear un nuevo servicio con los repositorios y la cola de eventos
-func NewService(mainRepository Repository, cacheRepository Repository, eventsQueue Queue) Service {
+// Funcion que se encarga de crear un nuevo servicio con los repositorios.
+// Los eventos de cambios de hoteles no se publican desde aca: se guardan en el outbox del hotel y los publica el OutboxRelay.
+// Cada cambio que se guarda queda en el registro de auditoria con el usuario y la request del contexto
+func NewService(mainRepository Repository, cacheRepository Repository, auditRepository AuditRepository) Service {
 	return Service{
 		mainRepository:  mainRepository,
 		cacheRepository: cacheRepository,
-		eventsQueue:     eventsQueue,
+		auditRepository: auditRepository,
 	}
 }
 
@@ -61,6 +78,171 @@ func (service Service) GetHotelByID(ctx context.Context, id string) (hotelsDomai
 
 	// Lo pasa de formato de base de datos a formato de dominio para las respuestas
 	//Lo devuelve en formato de dominio
+	return hotelToDomain(hotelDAO), nil
+}
+
+// hotelSort es el campo de Mongo y la direccion de un orden de GET /hotels
+type hotelSort struct {
+	field      string // Vacio = solo por _id
+	descending bool
+}
+
+// hotelSorts son los ordenes permitidos en GET /hotels
+var hotelSorts = map[string]hotelSort{
+	hotelsDomain.HotelSortID:         {},
+	hotelsDomain.HotelSortNameAsc:    {field: "name"},
+	hotelsDomain.HotelSortNameDesc:   {field: "name", descending: true},
+	hotelsDomain.HotelSortRatingAsc:  {field: "rating"},
+	hotelsDomain.HotelSortRatingDesc: {field: "rating", descending: true},
+	hotelsDomain.HotelSortPriceAsc:   {field: "price_per_night"},
+	hotelsDomain.HotelSortPriceDesc:  {field: "price_per_night", descending: true},
+}
+
+// hotelFields relaciona los campos del JSON del hotel con los de Mongo, para traer solo los pedidos en fields
+var hotelFields = map[string]string{
+	"id":              "_id",
+	"name":            "name",
+	"description":     "description",
+	"address":         "address",
+	"city":            "city",
+	"state":           "state",
+	"country":         "country",
+	"phone":           "phone",
+	"email":           "email",
+	"price_per_night": "price_per_night",
+	"rating":          "rating",
+	"avaiable_rooms":  "avaiable_rooms",
+	"check_in_time":   "check_in_time",
+	"check_out_time":  "check_out_time",
+	"amenities":       "amenities",
+	"images":          "images",
+	"room_types":      "room_types",
+	"currency":        "currency",
+	"rate_rules":      "rate_rules",
+	"latitude":        "location",
+	"longitude":       "location",
+	"deleted_at":      "deleted_at",
+	"version":         "version",
+	"event_sequence":  "event_sequence",
+}
+
+// hotelCursor es lo que guarda el cursor de GET /hotels: el orden y la posicion del ultimo hotel de la pagina
+type hotelCursor struct {
+	Sort  string      `json:"s"`
+	Value interface{} `json:"v,omitempty"`
+	ID    string      `json:"id"`
+}
+
+// Funcion que se encarga de listar los hoteles de a paginas con los filtros, el orden y los campos de query.
+// Se lee siempre de la base de datos principal: la cache solo tiene hoteles sueltos
+func (service Service) ListHotels(ctx context.Context, query hotelsDomain.HotelListQuery) (hotelsDomain.HotelPage, error) {
+	sortName := query.Sort
+	if sortName == "" {
+		sortName = hotelsDomain.HotelSortID
+	}
+	sort, ok := hotelSorts[sortName]
+	if !ok {
+		return hotelsDomain.HotelPage{}, fmt.Errorf("%w: unknown sort %q", hotelsDomain.ErrInvalidHotelQuery, query.Sort)
+	}
+
+	daoQuery := hotelsDAO.HotelQuery{
+		City:           query.City,
+		Country:        query.Country,
+		MinRating:      query.MinRating,
+		SortField:      sort.field,
+		Descending:     sort.descending,
+		Limit:          query.Limit + 1, // Se pide uno de mas para saber si hay pagina siguiente
+		IncludeDeleted: query.IncludeDeleted,
+	}
+
+	// Proyeccion: los campos pedidos mas el del orden, que hace falta para armar el cursor
+	if len(query.Fields) > 0 {
+		fields := map[string]bool{}
+		for _, field := range query.Fields {
+			mongoField, ok := hotelFields[field]
+			if !ok {
+				return hotelsDomain.HotelPage{}, fmt.Errorf("%w: unknown field %q", hotelsDomain.ErrInvalidHotelQuery, field)
+			}
+			fields[mongoField] = true
+		}
+		if sort.field != "" {
+			fields[sort.field] = true
+		}
+		if query.IncludeDeleted {
+			fields["deleted_at"] = true
+		}
+		for field := range fields {
+			daoQuery.Fields = append(daoQuery.Fields, field)
+		}
+	}
+
+	// El cursor solo vale para el mismo orden con el que se armo
+	if query.Cursor != "" {
+		cursor, err := decodeHotelCursor(query.Cursor)
+		if err != nil || cursor.Sort != sortName {
+			return hotelsDomain.HotelPage{}, fmt.Errorf("%w: %s", hotelsDomain.ErrInvalidCursor, query.Cursor)
+		}
+		daoQuery.AfterValue, daoQuery.AfterID = cursor.Value, cursor.ID
+	}
+
+	hotelsDAOList, err := service.mainRepository.ListHotels(ctx, daoQuery)
+	if errors.Is(err, hotelsDAO.ErrInvalidCursor) {
+		return hotelsDomain.HotelPage{}, fmt.Errorf("%w: %s", hotelsDomain.ErrInvalidCursor, query.Cursor)
+	}
+	if err != nil {
+		return hotelsDomain.HotelPage{}, fmt.Errorf("error listing hotels from repository: %w", err)
+	}
+
+	page := hotelsDomain.HotelPage{Hotels: make([]hotelsDomain.Hotel, 0, query.Limit)}
+	if len(hotelsDAOList) > query.Limit {
+		hotelsDAOList = hotelsDAOList[:query.Limit]
+		last := hotelsDAOList[query.Limit-1]
+		page.NextCursor = encodeHotelCursor(hotelCursor{Sort: sortName, Value: sortFieldValue(last, sort.field), ID: last.ID})
+	}
+	for _, hotelDAO := range hotelsDAOList {
+		page.Hotels = append(page.Hotels, hotelToDomain(hotelDAO))
+	}
+	return page, nil
+}
+
+// sortFieldValue devuelve el valor del campo de orden de un hotel (nil si se ordena solo por ID)
+func sortFieldValue(hotel hotelsDAO.Hotel, field string) interface{} {
+	switch field {
+	case "name":
+		return hotel.Name
+	case "rating":
+		return hotel.Rating
+	case "price_per_night":
+		return hotel.PricePerNight
+	}
+	return nil
+}
+
+// encodeHotelCursor arma el cursor opaco de GET /hotels (JSON en base64 para URL)
+func encodeHotelCursor(cursor hotelCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeHotelCursor lee un cursor de GET /hotels
+func decodeHotelCursor(value string) (hotelCursor, error) {
+	var cursor hotelCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, err
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, err
+	}
+	if cursor.ID == "" {
+		return cursor, fmt.Errorf("cursor without hotel ID")
+	}
+	return cursor, nil
+}
+
+// hotelToDomain convierte un hotel de formato de base de datos a formato de dominio
+func hotelToDomain(hotelDAO hotelsDAO.Hotel) hotelsDomain.Hotel {
+	latitude, longitude := locationToDomain(hotelDAO.Location)
 	return hotelsDomain.Hotel{
 		ID:            hotelDAO.ID,
 		Name:          hotelDAO.Name,
@@ -78,11 +260,24 @@ func (service Service) GetHotelByID(ctx context.Context, id string) (hotelsDomai
 		CheckOutTime:  hotelDAO.CheckOutTime,
 		Amenities:     hotelDAO.Amenities,
 		Images:        hotelDAO.Images,
-	}, nil
+		RoomTypes:     roomTypesToDomain(hotelDAO.RoomTypes),
+		Currency:      hotelDAO.Currency,
+		RateRules:     rateRulesToDomain(hotelDAO.RateRules),
+		Latitude:      latitude,
+		Longitude:     longitude,
+		DeletedAt:     hotelDAO.DeletedAt,
+		Version:       hotelDAO.Version,
+		EventSequence: hotelDAO.EventSequence,
+	}
 }
 
-// Funcion que se encarga de crear un nuevo hotel, primero se crea en la base de datos principal, luego en la cache y por ultimo se publica un evento para notificar que se creo un nuevo hotel
+// Funcion que se encarga de crear un nuevo hotel, se crea en la base de datos principal junto con su evento CREATE en el outbox y luego en la cache
 func (service Service) Create(ctx context.Context, hotel hotelsDomain.Hotel) (string, error) {
+	location, err := locationToDAO(hotel.Latitude, hotel.Longitude)
+	if err != nil {
+		return "", err
+	}
+
 	// Convierte el modelo de dominio a modelo DAO
 	//Modelo de como viene -> modelo base de datos
 	record := hotelsDAO.Hotel{
@@ -101,6 +296,13 @@ func (service Service) Create(ctx context.Context, hotel hotelsDomain.Hotel) (st
 		CheckOutTime:  hotel.CheckOutTime,
 		Amenities:     hotel.Amenities,
 		Images:        hotel.Images,
+		RoomTypes:     roomTypesToDAO(hotel.RoomTypes),
+		Currency:      hotel.Currency,
+		RateRules:     rateRulesToDAO(hotel.RateRules),
+		Location:      location,
+		Version:       1,
+		// El evento para search-api se guarda en el mismo documento (RabbitMQ lo recibe desde el OutboxRelay)
+		Outbox: []hotelsDAO.OutboxEvent{newOutboxEvent(hotelsDomain.OperationCreate)},
 	}
 	// Crea el hotel en el repositorio principal (base de datos -> MongoDB)
 	id, err := service.mainRepository.Create(ctx, record)
@@ -110,22 +312,75 @@ func (service Service) Create(ctx context.Context, hotel hotelsDomain.Hotel) (st
 	// Crea el hotel en el repositorio de cache
 	//El id que usan es el ObjectId de MongoDB
 	record.ID = id
+	record.Outbox = nil
 	if _, err := service.cacheRepository.Create(ctx, record); err != nil {
 		return "", fmt.Errorf("error creating hotel in cache: %w", err)
 	}
-	// Publica un evento para notificar la creación del hotel (RabbitMQ)
-	if err := service.eventsQueue.Publish(hotelsDomain.HotelNew{
-		Operation: "CREATE",
-		HotelID:   id,
-	}); err != nil {
-		return "", fmt.Errorf("error publishing hotel new: %w", err)
-	}
 
+	service.audit(ctx, hotelsDomain.AuditActionHotelCreate, hotelsDomain.AuditTargetHotel, id, id, nil, hotelToDomain(record))
 	return id, nil
 }
 
-// Funcion que se encarga de actualizar un hotel, primero se actualiza en la base de datos principal, luego en la cache y por ultimo se publica un evento para notificar que se actualizo un hotel
+// Funcion que se encarga de reemplazar los datos de un hotel (PUT): los campos que no vienen quedan vacios.
+// Los tipos de habitacion y las reglas de tarifa no se tocan, se editan con sus propios endpoints.
+// hotel.Version es la version que vio el cliente, si el hotel cambio desde entonces devuelve ErrVersionConflict
 func (service Service) Update(ctx context.Context, hotel hotelsDomain.Hotel) error {
+	current, err := service.currentHotel(ctx, hotel.ID, hotel.Version)
+	if err != nil {
+		return err
+	}
+	_, err = service.replace(ctx, hotelsDomain.AuditActionHotelUpdate, current, hotel)
+	return err
+}
+
+// Funcion que se encarga de aplicar un JSON Merge Patch (RFC 7396) a un hotel (PATCH): los campos que vienen
+// reemplazan a los actuales, los que vienen en null se vacian y los que no vienen quedan igual.
+// Devuelve el hotel como quedo en la base de datos principal, o ErrVersionConflict si ya no esta en version
+func (service Service) Patch(ctx context.Context, id string, version int64, patch map[string]json.RawMessage) (hotelsDomain.Hotel, error) {
+	// El patch se aplica sobre el documento de la base, no sobre el de la cache
+	current, err := service.currentHotel(ctx, id, version)
+	if err != nil {
+		return hotelsDomain.Hotel{}, err
+	}
+
+	hotel, err := applyHotelPatch(hotelToDomain(current), patch)
+	if err != nil {
+		return hotelsDomain.Hotel{}, err
+	}
+	hotel.ID = id
+	hotel.Version = version
+
+	updated, err := service.replace(ctx, hotelsDomain.AuditActionHotelPatch, current, hotel)
+	if err != nil {
+		return hotelsDomain.Hotel{}, err
+	}
+	return hotelToDomain(updated), nil
+}
+
+// currentHotel obtiene el hotel de la base de datos principal y valida que siga en la version que vio el cliente.
+// Si ya cambio no hace falta intentar la escritura (igual es condicional por si cambia en el medio)
+func (service Service) currentHotel(ctx context.Context, id string, version int64) (hotelsDAO.Hotel, error) {
+	current, err := service.mainRepository.GetHotelByID(ctx, id)
+	if errors.Is(err, hotelsDAO.ErrHotelNotFound) {
+		return hotelsDAO.Hotel{}, fmt.Errorf("%w: %s", hotelsDomain.ErrHotelNotFound, id)
+	}
+	if err != nil {
+		return hotelsDAO.Hotel{}, fmt.Errorf("error getting hotel from main repository: %w", err)
+	}
+	if current.Version != version {
+		return hotelsDAO.Hotel{}, fmt.Errorf("%w: %s", hotelsDomain.ErrVersionConflict, id)
+	}
+	return current, nil
+}
+
+// replace guarda el hotel completo en la base de datos principal junto con su evento UPDATE en el outbox
+// y vuelve a cargar en la cache el documento que quedo guardado. before es el hotel que se reemplaza, para el registro de auditoria
+func (service Service) replace(ctx context.Context, action string, before hotelsDAO.Hotel, hotel hotelsDomain.Hotel) (hotelsDAO.Hotel, error) {
+	location, err := locationToDAO(hotel.Latitude, hotel.Longitude)
+	if err != nil {
+		return hotelsDAO.Hotel{}, err
+	}
+
 	// Convierte el modelo de dominio a modelo DAO
 	record := hotelsDAO.Hotel{
 		ID:            hotel.ID,
@@ -144,33 +399,124 @@ func (service Service) Update(ctx context.Context, hotel hotelsDomain.Hotel) err
 		CheckOutTime:  hotel.CheckOutTime,
 		Amenities:     hotel.Amenities,
 		Images:        hotel.Images,
+		Currency:      hotel.Currency,
+		Location:      location,
+		Version:       hotel.Version,
+		Outbox:        []hotelsDAO.OutboxEvent{newOutboxEvent(hotelsDomain.OperationUpdate)},
+	}
+
+	// Actualiza el hotel en el repositorio principal (MongoDB) si sigue en la version esperada
+	if err := service.mainRepository.Update(ctx, record); err != nil {
+		return hotelsDAO.Hotel{}, hotelWriteError("error updating hotel in main repository", hotel.ID, err)
 	}
 
-	// Actualiza el hotel en el repositorio principal (MongoDB)
-	err := service.mainRepository.Update(ctx, record)
+	updated, err := service.reloadCachedHotel(ctx, hotel.ID)
 	if err != nil {
-		return fmt.Errorf("error updating hotel in main repository: %w", err)
+		return hotelsDAO.Hotel{}, err
 	}
+	service.audit(ctx, action, hotelsDomain.AuditTargetHotel, hotel.ID, hotel.ID, hotelToDomain(before), hotelToDomain(updated))
+	return updated, nil
+}
 
-	//INTENTA actualizar el hotel en el repositorio de cache
-	if err := service.cacheRepository.Update(ctx, record); err != nil {
-		return fmt.Errorf("error updating hotel in cache: %w", err)
+// hotelWriteError pasa los errores de una escritura condicional del hotel a los errores de dominio
+func hotelWriteError(message string, id string, err error) error {
+	switch {
+	case errors.Is(err, hotelsDAO.ErrHotelNotFound):
+		return fmt.Errorf("%w: %s", hotelsDomain.ErrHotelNotFound, id)
+	case errors.Is(err, hotelsDAO.ErrVersionConflict):
+		return fmt.Errorf("%w: %s", hotelsDomain.ErrVersionConflict, id)
 	}
+	return fmt.Errorf("%s: %w", message, err)
+}
 
-	// Publica un evento para notificar la actualización del hotel (RabbitMQ)
-	if err := service.eventsQueue.Publish(hotelsDomain.HotelNew{
-		Operation: "UPDATE",
-		HotelID:   hotel.ID,
-	}); err != nil {
-		return fmt.Errorf("error publishing hotel update: %w", err)
+// reloadCachedHotel descarta el hotel de la cache y guarda el documento de la base de datos principal
+func (service Service) reloadCachedHotel(ctx context.Context, id string) (hotelsDAO.Hotel, error) {
+	if err := service.cacheRepository.Update(ctx, hotelsDAO.Hotel{ID: id}); err != nil {
+		return hotelsDAO.Hotel{}, fmt.Errorf("error invalidating hotel in cache: %w", err)
+	}
+	hotel, err := service.mainRepository.GetHotelByID(ctx, id)
+	if err != nil {
+		return hotelsDAO.Hotel{}, fmt.Errorf("error reloading hotel from main repository: %w", err)
 	}
+	// La cache no guarda los eventos pendientes del outbox
+	hotel.Outbox = nil
+	if _, err := service.cacheRepository.Create(ctx, hotel); err != nil {
+		return hotelsDAO.Hotel{}, fmt.Errorf("error creating hotel in cache: %w", err)
+	}
+	return hotel, nil
+}
 
-	return nil
+// patchableHotelFields son los campos del JSON del hotel que se pueden cambiar con PATCH
+var patchableHotelFields = map[string]bool{
+	"name":            true,
+	"description":     true,
+	"address":         true,
+	"city":            true,
+	"state":           true,
+	"country":         true,
+	"phone":           true,
+	"email":           true,
+	"price_per_night": true,
+	"rating":          true,
+	"avaiable_rooms":  true,
+	"check_in_time":   true,
+	"check_out_time":  true,
+	"amenities":       true,
+	"images":          true,
+	"currency":        true,
+	"latitude":        true,
+	"longitude":       true,
+}
+
+// applyHotelPatch aplica un JSON Merge Patch al JSON del hotel. Ningun campo editable es un objeto,
+// asi que alcanza con reemplazar o borrar cada campo del primer nivel (las listas se reemplazan enteras)
+func applyHotelPatch(hotel hotelsDomain.Hotel, patch map[string]json.RawMessage) (hotelsDomain.Hotel, error) {
+	data, err := json.Marshal(hotel)
+	if err != nil {
+		return hotelsDomain.Hotel{}, fmt.Errorf("error encoding hotel: %w", err)
+	}
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(data, &document); err != nil {
+		return hotelsDomain.Hotel{}, fmt.Errorf("error encoding hotel: %w", err)
+	}
+
+	for field, value := range patch {
+		if !patchableHotelFields[field] {
+			return hotelsDomain.Hotel{}, fmt.Errorf("%w: field %s cannot be patched", hotelsDomain.ErrInvalidHotelPatch, field)
+		}
+		if string(value) == "null" {
+			delete(document, field)
+			continue
+		}
+		document[field] = value
+	}
+
+	data, err = json.Marshal(document)
+	if err != nil {
+		return hotelsDomain.Hotel{}, fmt.Errorf("error encoding hotel: %w", err)
+	}
+	var patched hotelsDomain.Hotel
+	if err := json.Unmarshal(data, &patched); err != nil {
+		return hotelsDomain.Hotel{}, fmt.Errorf("%w: %s", hotelsDomain.ErrInvalidHotelPatch, err.Error())
+	}
+	return patched, nil
 }
 
-// Funcion que se encarga de eliminar un hotel, primero elimina todas las reservas asociadas, luego el hotel de la base de datos principal, luego de la cache y por ultimo se publica un evento para notificar que se elimino un hotel
-func (service Service) Delete(ctx context.Context, id string) error {
-	// Primero eliminar todas las reservas asociadas al hotel del repositorio principal (MongoDB)
+// Funcion que se encarga de eliminar un hotel si sigue en la version indicada: primero marca el hotel como borrado en la base de datos principal (junto con su evento DELETE en el outbox),
+// despues elimina sus reservas y por ultimo lo saca de la cache. Las reservas se borran despues para no perderlas si el hotel cambio de version
+func (service Service) Delete(ctx context.Context, id string, version int64) error {
+	current, err := service.currentHotel(ctx, id, version)
+	if err != nil {
+		return err
+	}
+
+	// Intenta eliminar el hotel del repositorio principal (MongoDB)
+	event := newOutboxEvent(hotelsDomain.OperationDelete)
+	if err := service.mainRepository.Delete(ctx, id, version, event); err != nil {
+		return hotelWriteError("error deleting hotel from main repository", id, err)
+	}
+
+	// Eliminar todas las reservas asociadas al hotel del repositorio principal (MongoDB)
 	if err := service.mainRepository.DeleteReservationsByHotelID(ctx, id); err != nil {
 		return fmt.Errorf("error deleting reservations for hotel %s from main repository: %w", id, err)
 	}
@@ -180,39 +526,71 @@ func (service Service) Delete(ctx context.Context, id string) error {
 		return fmt.Errorf("error deleting reservations for hotel %s from cache: %w", id, err)
 	}
 
-	// Intenta eliminar el hotel del repositorio principal (MongoDB)
-	err := service.mainRepository.Delete(ctx, id)
-	if err != nil {
-		return fmt.Errorf("error deleting hotel from main repository: %w", err)
-	}
-
 	// Intenta eliminar el hotel del repositorio de cache
-	if err := service.cacheRepository.Delete(ctx, id); err != nil {
+	if err := service.cacheRepository.Delete(ctx, id, version, event); err != nil {
 		return fmt.Errorf("error deleting hotel from cache: %w", err)
 	}
 
-	// Publica un evento para notificar la eliminación del hotel (RabbitMQ)
-	if err := service.eventsQueue.Publish(hotelsDomain.HotelNew{
-		Operation: "DELETE",
-		HotelID:   id,
-	}); err != nil {
-		return fmt.Errorf("error publishing hotel delete: %w", err)
-	}
-
+	service.audit(ctx, hotelsDomain.AuditActionHotelDelete, hotelsDomain.AuditTargetHotel, id, id, hotelToDomain(current), nil)
 	return nil
 }
 
+// Funcion que se encarga de crear una reserva, primero ocupa una habitacion por noche en el inventario del hotel (rechaza si esta completo), luego la guarda en la base de datos principal y en la cache
 func (service Service) CreateReservation(ctx context.Context, reservation hotelsDomain.Reservation) (string, error) {
+	// La estadia tiene que ser de al menos una noche (el dia de checkout no se ocupa)
+	if !reservation.CheckOut.Truncate(24 * time.Hour).After(reservation.CheckIn.Truncate(24 * time.Hour)) {
+		return "", hotelsDomain.ErrInvalidDateRange
+	}
+
+	// La capacidad se lee del repositorio principal, la cache puede estar desactualizada
+	hotel, err := service.mainRepository.GetHotelByID(ctx, reservation.HotelID)
+	if err != nil {
+		return "", fmt.Errorf("error getting hotel from main repository: %w", err)
+	}
+
+	// La capacidad es la del tipo de habitacion pedido (o AvaiableRooms si el hotel no tiene tipos)
+	capacity, err := roomTypeCapacity(hotel, reservation.RoomTypeID)
+	if err != nil {
+		return "", err
+	}
+
+	// Cotiza la estadia con las reglas de tarifa vigentes, el total queda guardado en la reserva
+	quote, err := quoteStay(hotel, reservation.RoomTypeID, reservation.CheckIn, reservation.CheckOut)
+	if err != nil {
+		return "", err
+	}
+
+	// Ocupa una habitacion por noche de forma atomica, si alguna noche esta completa se rechaza la reserva
+	reserved, err := service.mainRepository.ReserveRooms(ctx, reservation.HotelID, reservation.RoomTypeID, reservation.CheckIn, reservation.CheckOut, capacity)
+	if err != nil {
+		return "", fmt.Errorf("error reserving rooms in main repository: %w", err)
+	}
+	if !reserved {
+		return "", hotelsDomain.ErrNoAvailability
+	}
+
+	// Las habitaciones ya quedaron ocupadas, la reserva nace confirmada
 	record := hotelsDAO.Reservation{
-		HotelName: reservation.HotelName,
-		HotelID:   reservation.HotelID,
-		UserID:    reservation.UserID,
-		CheckIn:   reservation.CheckIn,
-		CheckOut:  reservation.CheckOut,
+		HotelName:  reservation.HotelName,
+		HotelID:    reservation.HotelID,
+		UserID:     reservation.UserID,
+		CheckIn:    reservation.CheckIn,
+		CheckOut:   reservation.CheckOut,
+		RoomTypeID: reservation.RoomTypeID,
+		TotalPrice: quote.Total,
+		Currency:   quote.Currency,
+		Status:     hotelsDomain.ReservationStatusConfirmed,
+		StatusHistory: []hotelsDAO.ReservationStatusChange{
+			{Status: hotelsDomain.ReservationStatusConfirmed, At: time.Now().UTC()},
+		},
 	}
 	// Crea la reserva en el repositorio principal (base de datos -> MongoDB)
 	id, err := service.mainRepository.CreateReservation(ctx, record)
 	if err != nil {
+		// Si no se pudo guardar la reserva se liberan las habitaciones ocupadas
+		if releaseErr := service.mainRepository.ReleaseRooms(ctx, reservation.HotelID, reservation.RoomTypeID, reservation.CheckIn, reservation.CheckOut); releaseErr != nil {
+			fmt.Printf("Error releasing rooms for hotel %s: %v\n", reservation.HotelID, releaseErr)
+		}
 		return "", fmt.Errorf("error creating reservation in main repository: %w", err)
 	}
 	// Crea la reserva en el repositorio de cache
@@ -221,6 +599,7 @@ func (service Service) CreateReservation(ctx context.Context, reservation hotels
 		return "", fmt.Errorf("error creating reservation in cache: %w", err)
 	}
 
+	service.audit(ctx, hotelsDomain.AuditActionReservationCreate, hotelsDomain.AuditTargetReservation, id, record.HotelID, nil, reservationToDomain(record))
 	return id, nil
 }
 
@@ -241,30 +620,71 @@ func (service Service) GetReservationByID(ctx context.Context, id string) (hotel
 	}
 
 	// Se convierte la reserva de formato de base de datos a formato de dominio
-	reservation := hotelsDomain.Reservation{
-		ID:        reservationDAO.ID,
-		HotelName: reservationDAO.HotelName,
-		HotelID:   reservationDAO.HotelID,
-		UserID:    reservationDAO.UserID,
-		CheckIn:   reservationDAO.CheckIn,
-		CheckOut:  reservationDAO.CheckOut,
-	}
-
-	return reservation, nil
+	return reservationToDomain(reservationDAO), nil
 }
 
+// Funcion que se encarga de cancelar una reserva, la reserva no se elimina sino que pasa a estado CANCELLED
 func (service Service) CancelReservation(ctx context.Context, id string) error {
-	// Intenta eliminar la reserva del repositorio principal (MongoDB)
-	err := service.mainRepository.CancelReservation(ctx, id)
+	return service.UpdateReservationStatus(ctx, id, hotelsDomain.ReservationStatusCancelled)
+}
+
+// Transiciones validas del ciclo de vida de una reserva (CANCELLED, COMPLETED y NO_SHOW son estados finales)
+var reservationTransitions = map[string][]string{
+	hotelsDomain.ReservationStatusPending:   {hotelsDomain.ReservationStatusConfirmed, hotelsDomain.ReservationStatusCancelled},
+	hotelsDomain.ReservationStatusConfirmed: {hotelsDomain.ReservationStatusCheckedIn, hotelsDomain.ReservationStatusCancelled, hotelsDomain.ReservationStatusNoShow},
+	hotelsDomain.ReservationStatusCheckedIn: {hotelsDomain.ReservationStatusCompleted},
+}
+
+// Funcion que se encarga de cambiar el estado de una reserva validando la transicion, primero en la base de datos principal, luego libera las habitaciones si se cancela y por ultimo actualiza la cache
+func (service Service) UpdateReservationStatus(ctx context.Context, id string, status string) error {
+	// Se obtiene la reserva del repositorio principal, el estado de la cache puede estar desactualizado
+	reservation, err := service.mainRepository.GetReservationByID(ctx, id)
 	if err != nil {
-		return fmt.Errorf("error canceling reservation from main repository: %w", err)
+		return fmt.Errorf("error getting reservation from main repository: %w", err)
+	}
+
+	// Valida la transicion desde el estado actual
+	current := reservationStatus(reservation)
+	allowed := false
+	for _, next := range reservationTransitions[current] {
+		if next == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("%w: %s -> %s", hotelsDomain.ErrInvalidStatusTransition, current, status)
 	}
 
-	// Intenta eliminar la reserva del repositorio de cache
-	if err := service.cacheRepository.CancelReservation(ctx, id); err != nil {
-		return fmt.Errorf("error canceling reservation from cache: %w", err)
+	// Cambia el estado en el repositorio principal, condicionado al estado leido
+	now := time.Now().UTC()
+	updated, err := service.mainRepository.UpdateReservationStatus(ctx, id, reservation.Status, status, now)
+	if err != nil {
+		return fmt.Errorf("error updating reservation status in main repository: %w", err)
+	}
+	if !updated {
+		return fmt.Errorf("%w: reservation %s was modified concurrently", hotelsDomain.ErrInvalidStatusTransition, id)
 	}
 
+	// Una reserva cancelada libera sus habitaciones; si falla el estado ya cambio, solo se loguea
+	if status == hotelsDomain.ReservationStatusCancelled {
+		if err := service.mainRepository.ReleaseRooms(ctx, reservation.HotelID, reservation.RoomTypeID, reservation.CheckIn, reservation.CheckOut); err != nil {
+			fmt.Printf("Error releasing rooms for reservation %s: %v\n", id, err)
+		}
+	}
+
+	// Actualiza el estado en la cache
+	if _, err := service.cacheRepository.UpdateReservationStatus(ctx, id, reservation.Status, status, now); err != nil {
+		return fmt.Errorf("error updating reservation status in cache: %w", err)
+	}
+
+	action := hotelsDomain.AuditActionReservationStatus
+	if status == hotelsDomain.ReservationStatusCancelled {
+		action = hotelsDomain.AuditActionReservationCancel
+	}
+	after := reservation
+	after.Status = status
+	service.audit(ctx, action, hotelsDomain.AuditTargetReservation, id, reservation.HotelID, reservationToDomain(reservation), reservationToDomain(after))
 	return nil
 }
 
@@ -283,19 +703,16 @@ func (service Service) GetReservationsByHotelID(ctx context.Context, hotelID str
 				return nil, fmt.Errorf("error creating reservation in cache: %w", err)
 			}
 		}
+		// La lista completa del hotel es la que usan el calendario y la disponibilidad desde la cache
+		if err := service.cacheRepository.SetReservationsByHotelID(ctx, hotelID, reservationsDAO); err != nil {
+			return nil, fmt.Errorf("error caching reservations of hotel %s: %w", hotelID, err)
+		}
 	}
 
 	// Se convierten las reservas de formato de base de datos a formato de dominio
 	reservations := make([]hotelsDomain.Reservation, 0)
 	for _, reservationDAO := range reservationsDAO {
-		reservations = append(reservations, hotelsDomain.Reservation{
-			ID:        reservationDAO.ID,
-			HotelName: reservationDAO.HotelName,
-			HotelID:   reservationDAO.HotelID,
-			UserID:    reservationDAO.UserID,
-			CheckIn:   reservationDAO.CheckIn,
-			CheckOut:  reservationDAO.CheckOut,
-		})
+		reservations = append(reservations, reservationToDomain(reservationDAO))
 	}
 
 	return reservations, nil
@@ -321,14 +738,7 @@ func (service Service) GetReservationsByUserAndHotelID(ctx context.Context, hote
 	// Se convierten las reservas de formato de base de datos a formato de dominio
 	reservations := make([]hotelsDomain.Reservation, 0)
 	for _, reservationDAO := range reservationsDAO {
-		reservations = append(reservations, hotelsDomain.Reservation{
-			ID:        reservationDAO.ID,
-			HotelName: reservationDAO.HotelName,
-			HotelID:   reservationDAO.HotelID,
-			UserID:    reservationDAO.UserID,
-			CheckIn:   reservationDAO.CheckIn,
-			CheckOut:  reservationDAO.CheckOut,
-		})
+		reservations = append(reservations, reservationToDomain(reservationDAO))
 	}
 
 	return reservations, nil
@@ -354,20 +764,13 @@ func (service Service) GetReservationsByUserID(ctx context.Context, userID strin
 	// Se convierten las reservas de formato de base de datos a formato de dominio
 	reservations := make([]hotelsDomain.Reservation, 0)
 	for _, reservationDAO := range reservationsDAO {
-		reservations = append(reservations, hotelsDomain.Reservation{
-			ID:        reservationDAO.ID,
-			HotelName: reservationDAO.HotelName,
-			HotelID:   reservationDAO.HotelID,
-			UserID:    reservationDAO.UserID,
-			CheckIn:   reservationDAO.CheckIn,
-			CheckOut:  reservationDAO.CheckOut,
-		})
+		reservations = append(reservations, reservationToDomain(reservationDAO))
 	}
 
 	return reservations, nil
 }
 
-// Hay que ver lo de hacerlo desde la cache
+// GetAvailability usa la cache solo si tiene los hoteles y sus listas completas de reservas; si no, consulta MongoDB
 func (service Service) GetAvailability(ctx context.Context, hotelIDs []string, checkIn, checkOut string) (map[string]bool, error) {
 	// Se intenta obtener la disponibilidad de los hoteles del repositorio de cache
 	availability, err := service.cacheRepository.GetAvailability(ctx, hotelIDs, checkIn, checkOut)
@@ -381,3 +784,464 @@ func (service Service) GetAvailability(ctx context.Context, hotelIDs []string, c
 
 	return availability, nil
 }
+
+// Funcion que se encarga de obtener los tipos de habitacion de un hotel (usa la cache igual que GetHotelByID)
+func (service Service) GetRoomTypes(ctx context.Context, hotelID string) ([]hotelsDomain.RoomType, error) {
+	hotel, err := service.GetHotelByID(ctx, hotelID)
+	if err != nil {
+		return nil, err
+	}
+	if hotel.RoomTypes == nil {
+		return []hotelsDomain.RoomType{}, nil
+	}
+	return hotel.RoomTypes, nil
+}
+
+// Funcion que se encarga de agregar un tipo de habitacion a un hotel, lo guarda en la base de datos principal junto con un evento UPDATE (search-api reindexa precios y capacidades) y descarta el hotel de la cache
+func (service Service) CreateRoomType(ctx context.Context, hotelID string, roomType hotelsDomain.RoomType) (string, error) {
+	if err := validateRoomType(roomType); err != nil {
+		return "", err
+	}
+
+	record := roomTypesToDAO([]hotelsDomain.RoomType{roomType})[0]
+	record.ID = uuid.New().String()
+	event := newOutboxEvent(hotelsDomain.OperationUpdate)
+	if err := service.mainRepository.AddRoomType(ctx, hotelID, record, event); err != nil {
+		return "", fmt.Errorf("error adding room type in main repository: %w", err)
+	}
+	if err := service.cacheRepository.AddRoomType(ctx, hotelID, record, event); err != nil {
+		return "", fmt.Errorf("error adding room type in cache: %w", err)
+	}
+
+	service.audit(ctx, hotelsDomain.AuditActionRoomTypeCreate, hotelsDomain.AuditTargetRoomType, record.ID, hotelID, nil, roomTypesToDomain([]hotelsDAO.RoomType{record})[0])
+	return record.ID, nil
+}
+
+// Funcion que se encarga de reemplazar un tipo de habitacion de un hotel, devuelve ErrRoomTypeNotFound si no existe
+func (service Service) UpdateRoomType(ctx context.Context, hotelID string, roomType hotelsDomain.RoomType) error {
+	if err := validateRoomType(roomType); err != nil {
+		return err
+	}
+
+	record := roomTypesToDAO([]hotelsDomain.RoomType{roomType})[0]
+	before := service.auditRoomType(ctx, hotelID, record.ID)
+	event := newOutboxEvent(hotelsDomain.OperationUpdate)
+	updated, err := service.mainRepository.UpdateRoomType(ctx, hotelID, record, event)
+	if err != nil {
+		return fmt.Errorf("error updating room type in main repository: %w", err)
+	}
+	if !updated {
+		return hotelsDomain.ErrRoomTypeNotFound
+	}
+	if _, err := service.cacheRepository.UpdateRoomType(ctx, hotelID, record, event); err != nil {
+		return fmt.Errorf("error updating room type in cache: %w", err)
+	}
+
+	service.audit(ctx, hotelsDomain.AuditActionRoomTypeUpdate, hotelsDomain.AuditTargetRoomType, record.ID, hotelID, before, roomTypesToDomain([]hotelsDAO.RoomType{record})[0])
+	return nil
+}
+
+// Funcion que se encarga de eliminar un tipo de habitacion de un hotel, devuelve ErrRoomTypeNotFound si no existe
+func (service Service) DeleteRoomType(ctx context.Context, hotelID string, roomTypeID string) error {
+	before := service.auditRoomType(ctx, hotelID, roomTypeID)
+	event := newOutboxEvent(hotelsDomain.OperationUpdate)
+	deleted, err := service.mainRepository.DeleteRoomType(ctx, hotelID, roomTypeID, event)
+	if err != nil {
+		return fmt.Errorf("error deleting room type in main repository: %w", err)
+	}
+	if !deleted {
+		return hotelsDomain.ErrRoomTypeNotFound
+	}
+	if _, err := service.cacheRepository.DeleteRoomType(ctx, hotelID, roomTypeID, event); err != nil {
+		return fmt.Errorf("error deleting room type in cache: %w", err)
+	}
+
+	service.audit(ctx, hotelsDomain.AuditActionRoomTypeDelete, hotelsDomain.AuditTargetRoomType, roomTypeID, hotelID, before, nil)
+	return nil
+}
+
+// Funcion que se encarga de cotizar una estadia (desglose por noche y total) sin reservar
+func (service Service) Quote(ctx context.Context, hotelID string, roomTypeID string, checkIn, checkOut time.Time) (hotelsDomain.Quote, error) {
+	hotel, err := service.cachedHotel(ctx, hotelID)
+	if err != nil {
+		return hotelsDomain.Quote{}, err
+	}
+
+	if _, err := roomTypeCapacity(hotel, roomTypeID); err != nil {
+		return hotelsDomain.Quote{}, err
+	}
+	return quoteStay(hotel, roomTypeID, checkIn, checkOut)
+}
+
+// Funcion que se encarga de armar el calendario de disponibilidad y precio por noche de un hotel en [from, to).
+// La ocupacion se lee de la lista de reservas en cache si esta cargada; si no, de Mongo en una sola agregacion.
+func (service Service) GetCalendar(ctx context.Context, hotelID string, from, to time.Time) (hotelsDomain.Calendar, error) {
+	if !to.After(from) || to.Sub(from) > hotelsDomain.MaxCalendarNights*24*time.Hour {
+		return hotelsDomain.Calendar{}, hotelsDomain.ErrInvalidDateRange
+	}
+
+	hotel, err := service.cachedHotel(ctx, hotelID)
+	if err != nil {
+		return hotelsDomain.Calendar{}, err
+	}
+
+	occupancy, err := service.cacheRepository.GetNightlyOccupancy(ctx, hotelID, from, to)
+	if err != nil {
+		occupancy, err = service.mainRepository.GetNightlyOccupancy(ctx, hotelID, from, to)
+		if err != nil {
+			return hotelsDomain.Calendar{}, fmt.Errorf("error getting occupancy from repository: %w", err)
+		}
+	}
+
+	// Habitaciones ocupadas por tipo y noche
+	reserved := make(map[string]map[string]int)
+	for _, night := range occupancy {
+		if reserved[night.RoomTypeID] == nil {
+			reserved[night.RoomTypeID] = make(map[string]int)
+		}
+		reserved[night.RoomTypeID][night.Night.Format("2006-01-02")] += night.Reserved
+	}
+
+	// Los hoteles sin tipos de habitacion tienen un unico pool "" con AvaiableRooms
+	pools := []hotelsDAO.RoomType{{ID: "", Count: hotel.AvaiableRooms}}
+	if len(hotel.RoomTypes) > 0 {
+		pools = hotel.RoomTypes
+	}
+
+	calendar := hotelsDomain.Calendar{
+		HotelID: hotel.ID,
+		From:    from,
+		To:      to,
+	}
+	for i, pool := range pools {
+		quote, err := quoteStay(hotel, pool.ID, from, to)
+		if err != nil {
+			return hotelsDomain.Calendar{}, err
+		}
+		if i == 0 {
+			calendar.Currency = quote.Currency
+			calendar.Nights = make([]hotelsDomain.CalendarNight, len(quote.Nights))
+		}
+
+		for n, rate := range quote.Nights {
+			remaining := pool.Count - reserved[pool.ID][rate.Date.Format("2006-01-02")]
+			if remaining < 0 {
+				remaining = 0
+			}
+
+			night := &calendar.Nights[n]
+			night.Date = rate.Date
+			// El precio de la noche es el menor entre los tipos con lugar (o entre todos si esta completo)
+			hasRooms := remaining > 0
+			if i == 0 || (hasRooms && night.RemainingRooms == 0) || (hasRooms == (night.RemainingRooms > 0) && rate.Price < night.Price) {
+				night.Price = rate.Price
+			}
+			night.RemainingRooms += remaining
+			if pool.ID != "" {
+				night.RoomTypes = append(night.RoomTypes, hotelsDomain.CalendarRoomTypeNight{
+					RoomTypeID:     pool.ID,
+					RemainingRooms: remaining,
+					Price:          rate.Price,
+				})
+			}
+		}
+	}
+
+	return calendar, nil
+}
+
+// Funcion que se encarga de obtener las reglas de tarifa de un hotel
+func (service Service) GetRateRules(ctx context.Context, hotelID string) ([]hotelsDomain.RateRule, error) {
+	hotel, err := service.GetHotelByID(ctx, hotelID)
+	if err != nil {
+		return nil, err
+	}
+	if hotel.RateRules == nil {
+		return []hotelsDomain.RateRule{}, nil
+	}
+	return hotel.RateRules, nil
+}
+
+// Funcion que se encarga de agregar una regla de tarifa a un hotel, la guarda en la base de datos principal y descarta el hotel de la cache
+func (service Service) CreateRateRule(ctx context.Context, hotelID string, rule hotelsDomain.RateRule) (string, error) {
+	if err := validateRateRule(rule); err != nil {
+		return "", err
+	}
+
+	record := rateRulesToDAO([]hotelsDomain.RateRule{rule})[0]
+	record.ID = uuid.New().String()
+	if err := service.mainRepository.AddRateRule(ctx, hotelID, record); err != nil {
+		return "", fmt.Errorf("error adding rate rule in main repository: %w", err)
+	}
+	if err := service.cacheRepository.AddRateRule(ctx, hotelID, record); err != nil {
+		return "", fmt.Errorf("error adding rate rule in cache: %w", err)
+	}
+
+	service.audit(ctx, hotelsDomain.AuditActionRateRuleCreate, hotelsDomain.AuditTargetRateRule, record.ID, hotelID, nil, rateRulesToDomain([]hotelsDAO.RateRule{record})[0])
+	return record.ID, nil
+}
+
+// Funcion que se encarga de reemplazar una regla de tarifa de un hotel, devuelve ErrRateRuleNotFound si no existe
+func (service Service) UpdateRateRule(ctx context.Context, hotelID string, rule hotelsDomain.RateRule) error {
+	if err := validateRateRule(rule); err != nil {
+		return err
+	}
+
+	record := rateRulesToDAO([]hotelsDomain.RateRule{rule})[0]
+	before := service.auditRateRule(ctx, hotelID, record.ID)
+	updated, err := service.mainRepository.UpdateRateRule(ctx, hotelID, record)
+	if err != nil {
+		return fmt.Errorf("error updating rate rule in main repository: %w", err)
+	}
+	if !updated {
+		return hotelsDomain.ErrRateRuleNotFound
+	}
+	if _, err := service.cacheRepository.UpdateRateRule(ctx, hotelID, record); err != nil {
+		return fmt.Errorf("error updating rate rule in cache: %w", err)
+	}
+
+	service.audit(ctx, hotelsDomain.AuditActionRateRuleUpdate, hotelsDomain.AuditTargetRateRule, record.ID, hotelID, before, rateRulesToDomain([]hotelsDAO.RateRule{record})[0])
+	return nil
+}
+
+// Funcion que se encarga de eliminar una regla de tarifa de un hotel, devuelve ErrRateRuleNotFound si no existe
+func (service Service) DeleteRateRule(ctx context.Context, hotelID string, ruleID string) error {
+	before := service.auditRateRule(ctx, hotelID, ruleID)
+	deleted, err := service.mainRepository.DeleteRateRule(ctx, hotelID, ruleID)
+	if err != nil {
+		return fmt.Errorf("error deleting rate rule in main repository: %w", err)
+	}
+	if !deleted {
+		return hotelsDomain.ErrRateRuleNotFound
+	}
+	if _, err := service.cacheRepository.DeleteRateRule(ctx, hotelID, ruleID); err != nil {
+		return fmt.Errorf("error deleting rate rule in cache: %w", err)
+	}
+
+	service.audit(ctx, hotelsDomain.AuditActionRateRuleDelete, hotelsDomain.AuditTargetRateRule, ruleID, hotelID, before, nil)
+	return nil
+}
+
+// cachedHotel obtiene el hotel con cache-aside, igual que GetHotelByID, sin convertirlo a dominio
+func (service Service) cachedHotel(ctx context.Context, hotelID string) (hotelsDAO.Hotel, error) {
+	hotel, err := service.cacheRepository.GetHotelByID(ctx, hotelID)
+	if err != nil {
+		hotel, err = service.mainRepository.GetHotelByID(ctx, hotelID)
+		if err != nil {
+			return hotelsDAO.Hotel{}, fmt.Errorf("error getting hotel from repository: %w", err)
+		}
+		if _, err := service.cacheRepository.Create(ctx, hotel); err != nil {
+			return hotelsDAO.Hotel{}, fmt.Errorf("error creating hotel in cache: %w", err)
+		}
+	}
+	return hotel, nil
+}
+
+// quoteStay cotiza una estadia con el precio base del tipo de habitacion (o el del hotel) y sus reglas de tarifa
+func quoteStay(hotel hotelsDAO.Hotel, roomTypeID string, checkIn, checkOut time.Time) (hotelsDomain.Quote, error) {
+	basePrice := hotel.PricePerNight
+	for _, roomType := range hotel.RoomTypes {
+		if roomType.ID == roomTypeID && roomType.BasePrice > 0 {
+			basePrice = roomType.BasePrice
+		}
+	}
+
+	quote, err := pricing.Quote(basePrice, hotel.Currency, roomTypeID, rateRulesToDomain(hotel.RateRules), checkIn, checkOut)
+	if err != nil {
+		return hotelsDomain.Quote{}, err
+	}
+	quote.HotelID = hotel.ID
+	return quote, nil
+}
+
+// validateRateRule valida los campos que necesita cada tipo de regla de tarifa
+func validateRateRule(rule hotelsDomain.RateRule) error {
+	switch rule.Type {
+	case hotelsDomain.RateRuleSeason:
+		if rule.StartDate.IsZero() || rule.EndDate.Before(rule.StartDate) || rule.Multiplier <= 0 {
+			return fmt.Errorf("%w: season rules need start_date <= end_date and a positive multiplier", hotelsDomain.ErrInvalidRateRule)
+		}
+	case hotelsDomain.RateRuleWeekend:
+		if rule.Multiplier <= 0 {
+			return fmt.Errorf("%w: weekend rules need a positive multiplier", hotelsDomain.ErrInvalidRateRule)
+		}
+	case hotelsDomain.RateRuleMinStay:
+		if rule.MinNights < 1 || rule.DiscountPercent <= 0 || rule.DiscountPercent >= 100 {
+			return fmt.Errorf("%w: min stay rules need min_nights >= 1 and 0 < discount_percent < 100", hotelsDomain.ErrInvalidRateRule)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", hotelsDomain.ErrInvalidRateRule, rule.Type)
+	}
+	return nil
+}
+
+// validateRoomType valida los datos minimos de un tipo de habitacion
+func validateRoomType(roomType hotelsDomain.RoomType) error {
+	if roomType.Name == "" || roomType.Capacity <= 0 || roomType.Count < 0 || roomType.BasePrice < 0 {
+		return fmt.Errorf("%w: name is required, capacity must be positive and count/base_price cannot be negative", hotelsDomain.ErrInvalidRoomType)
+	}
+	return nil
+}
+
+// roomTypeCapacity devuelve la cantidad de habitaciones del tipo pedido.
+// Un hotel sin tipos solo acepta reservas sin tipo (usa AvaiableRooms); uno con tipos exige un tipo existente.
+func roomTypeCapacity(hotel hotelsDAO.Hotel, roomTypeID string) (int, error) {
+	if len(hotel.RoomTypes) == 0 {
+		if roomTypeID != "" {
+			return 0, fmt.Errorf("%w: hotel %s has no room types", hotelsDomain.ErrInvalidRoomType, hotel.ID)
+		}
+		return hotel.AvaiableRooms, nil
+	}
+	for _, roomType := range hotel.RoomTypes {
+		if roomType.ID == roomTypeID {
+			return roomType.Count, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: unknown room type %q", hotelsDomain.ErrInvalidRoomType, roomTypeID)
+}
+
+// locationToDAO valida las coordenadas y las convierte a un punto GeoJSON, nil si el hotel no tiene ubicacion
+func locationToDAO(latitude *float64, longitude *float64) (*hotelsDAO.GeoPoint, error) {
+	if latitude == nil && longitude == nil {
+		return nil, nil
+	}
+	if latitude == nil || longitude == nil {
+		return nil, fmt.Errorf("%w: latitude and longitude must be set together", hotelsDomain.ErrInvalidLocation)
+	}
+	if *latitude < -90 || *latitude > 90 || *longitude < -180 || *longitude > 180 {
+		return nil, fmt.Errorf("%w: latitude must be within [-90, 90] and longitude within [-180, 180]", hotelsDomain.ErrInvalidLocation)
+	}
+	return &hotelsDAO.GeoPoint{Type: "Point", Coordinates: []float64{*longitude, *latitude}}, nil
+}
+
+// locationToDomain devuelve la latitud y longitud de un punto GeoJSON (nil si el hotel no tiene ubicacion)
+func locationToDomain(location *hotelsDAO.GeoPoint) (*float64, *float64) {
+	if location == nil || len(location.Coordinates) != 2 {
+		return nil, nil
+	}
+	longitude, latitude := location.Coordinates[0], location.Coordinates[1]
+	return &latitude, &longitude
+}
+
+// roomTypesToDomain convierte los tipos de habitacion de formato de base de datos a formato de dominio
+func roomTypesToDomain(roomTypes []hotelsDAO.RoomType) []hotelsDomain.RoomType {
+	if roomTypes == nil {
+		return nil
+	}
+	result := make([]hotelsDomain.RoomType, 0, len(roomTypes))
+	for _, roomType := range roomTypes {
+		result = append(result, hotelsDomain.RoomType{
+			ID:        roomType.ID,
+			Name:      roomType.Name,
+			Capacity:  roomType.Capacity,
+			Count:     roomType.Count,
+			BasePrice: roomType.BasePrice,
+			Amenities: roomType.Amenities,
+		})
+	}
+	return result
+}
+
+// roomTypesToDAO convierte los tipos de habitacion de formato de dominio a formato de base de datos, asignando ID a los nuevos
+func roomTypesToDAO(roomTypes []hotelsDomain.RoomType) []hotelsDAO.RoomType {
+	if roomTypes == nil {
+		return nil
+	}
+	result := make([]hotelsDAO.RoomType, 0, len(roomTypes))
+	for _, roomType := range roomTypes {
+		id := roomType.ID
+		if id == "" {
+			id = uuid.New().String()
+		}
+		result = append(result, hotelsDAO.RoomType{
+			ID:        id,
+			Name:      roomType.Name,
+			Capacity:  roomType.Capacity,
+			Count:     roomType.Count,
+			BasePrice: roomType.BasePrice,
+			Amenities: roomType.Amenities,
+		})
+	}
+	return result
+}
+
+// reservationStatus devuelve el estado de la reserva, las reservas guardadas antes del ciclo de vida se consideran confirmadas
+func reservationStatus(reservation hotelsDAO.Reservation) string {
+	if reservation.Status == "" {
+		return hotelsDomain.ReservationStatusConfirmed
+	}
+	return reservation.Status
+}
+
+// reservationToDomain convierte una reserva de formato de base de datos a formato de dominio
+func reservationToDomain(reservation hotelsDAO.Reservation) hotelsDomain.Reservation {
+	history := make([]hotelsDomain.ReservationStatusChange, 0, len(reservation.StatusHistory))
+	for _, change := range reservation.StatusHistory {
+		history = append(history, hotelsDomain.ReservationStatusChange{
+			Status: change.Status,
+			At:     change.At,
+		})
+	}
+
+	return hotelsDomain.Reservation{
+		ID:            reservation.ID,
+		HotelName:     reservation.HotelName,
+		HotelID:       reservation.HotelID,
+		UserID:        reservation.UserID,
+		CheckIn:       reservation.CheckIn,
+		CheckOut:      reservation.CheckOut,
+		RoomTypeID:    reservation.RoomTypeID,
+		TotalPrice:    reservation.TotalPrice,
+		Currency:      reservation.Currency,
+		Status:        reservationStatus(reservation),
+		StatusHistory: history,
+	}
+}
+
+// rateRulesToDomain convierte las reglas de tarifa de formato de base de datos a formato de dominio
+func rateRulesToDomain(rules []hotelsDAO.RateRule) []hotelsDomain.RateRule {
+	if rules == nil {
+		return nil
+	}
+	result := make([]hotelsDomain.RateRule, 0, len(rules))
+	for _, rule := range rules {
+		result = append(result, hotelsDomain.RateRule{
+			ID:              rule.ID,
+			Name:            rule.Name,
+			Type:            rule.Type,
+			RoomTypeID:      rule.RoomTypeID,
+			StartDate:       rule.StartDate,
+			EndDate:         rule.EndDate,
+			Multiplier:      rule.Multiplier,
+			MinNights:       rule.MinNights,
+			DiscountPercent: rule.DiscountPercent,
+		})
+	}
+	return result
+}
+
+// rateRulesToDAO convierte las reglas de tarifa de formato de dominio a formato de base de datos, asignando ID a las nuevas
+func rateRulesToDAO(rules []hotelsDomain.RateRule) []hotelsDAO.RateRule {
+	if rules == nil {
+		return nil
+	}
+	result := make([]hotelsDAO.RateRule, 0, len(rules))
+	for _, rule := range rules {
+		id := rule.ID
+		if id == "" {
+			id = uuid.New().String()
+		}
+		result = append(result, hotelsDAO.RateRule{
+			ID:              id,
+			Name:            rule.Name,
+			Type:            rule.Type,
+			RoomTypeID:      rule.RoomTypeID,
+			StartDate:       rule.StartDate,
+			EndDate:         rule.EndDate,
+			Multiplier:      rule.Multiplier,
+			MinNights:       rule.MinNights,
+			DiscountPercent: rule.DiscountPercent,
+		})
+	}
+	return result
+}
@@ -2,24 +2,22 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
 	hotelsDAO "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/dao/hotels"
 	hotelsDomain "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/domain/hotels"
+	"github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/repositories/audit"
 	"github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/repositories/hotels"
 )
 
-// Mock de la cola (no hace nada)
-type MockQueue struct{}
-
-func (mq MockQueue) Publish(hotelNew hotelsDomain.HotelNew) error { return nil }
-
 // Helper para crear el service con mocks reutilizables
 func getTestService() (Service, hotels.Mock, hotels.MockCache) {
 	mainRepo := hotels.NewMock()       // Repositorio principal
 	cacheRepo := hotels.NewMockCache() // Cache
-	return NewService(mainRepo, cacheRepo, MockQueue{}), mainRepo, cacheRepo
+	return NewService(mainRepo, cacheRepo, audit.NewMock()), mainRepo, cacheRepo
 }
 
 func TestCreateAndGetHotel(t *testing.T) {
@@ -43,13 +41,151 @@ func TestCreateAndGetHotel(t *testing.T) {
 	}
 }
 
+func TestCreateHotel_Location(t *testing.T) {
+	service, mainRepo, _ := getTestService()
+	ctx := context.Background()
+
+	latitude, longitude := -34.6037, -58.3816
+	id, err := service.Create(ctx, hotelsDomain.Hotel{Name: "Obelisco Hotel", Latitude: &latitude, Longitude: &longitude})
+	if err != nil {
+		t.Fatalf("error creating hotel: %v", err)
+	}
+
+	// Se guarda como punto GeoJSON [longitud, latitud]
+	stored, err := mainRepo.GetHotelByID(ctx, id)
+	if err != nil {
+		t.Fatalf("error getting stored hotel: %v", err)
+	}
+	if stored.Location == nil || stored.Location.Type != "Point" || stored.Location.Coordinates[0] != longitude || stored.Location.Coordinates[1] != latitude {
+		t.Fatalf("expected GeoJSON point [%v %v], got %+v", longitude, latitude, stored.Location)
+	}
+
+	got, err := service.GetHotelByID(ctx, id)
+	if err != nil {
+		t.Fatalf("error getting hotel: %v", err)
+	}
+	if got.Latitude == nil || *got.Latitude != latitude || got.Longitude == nil || *got.Longitude != longitude {
+		t.Fatalf("expected location %v,%v, got %v,%v", latitude, longitude, got.Latitude, got.Longitude)
+	}
+}
+
+func TestCreateHotel_InvalidLocation(t *testing.T) {
+	service, _, _ := getTestService()
+	ctx := context.Background()
+
+	latitude, longitude := 95.0, 10.0
+	cases := map[string]hotelsDomain.Hotel{
+		"only latitude":         {Name: "Hotel", Latitude: &latitude},
+		"only longitude":        {Name: "Hotel", Longitude: &longitude},
+		"latitude out of range": {Name: "Hotel", Latitude: &latitude, Longitude: &longitude},
+	}
+	for name, hotel := range cases {
+		if _, err := service.Create(ctx, hotel); !errors.Is(err, hotelsDomain.ErrInvalidLocation) {
+			t.Errorf("%s: expected ErrInvalidLocation, got %v", name, err)
+		}
+	}
+}
+
+func TestListHotels_Pages(t *testing.T) {
+	service, _, _ := getTestService()
+	ctx := context.Background()
+
+	created := map[string]bool{}
+	for _, name := range []string{"A", "B", "C"} {
+		id, err := service.Create(ctx, hotelsDomain.Hotel{Name: name})
+		if err != nil {
+			t.Fatalf("error creating hotel: %v", err)
+		}
+		created[id] = true
+	}
+
+	first, err := service.ListHotels(ctx, hotelsDomain.HotelListQuery{Limit: 2})
+	if err != nil {
+		t.Fatalf("error listing hotels: %v", err)
+	}
+	if len(first.Hotels) != 2 || first.NextCursor == "" {
+		t.Fatalf("expected 2 hotels and a next cursor, got %+v", first)
+	}
+
+	second, err := service.ListHotels(ctx, hotelsDomain.HotelListQuery{Cursor: first.NextCursor, Limit: 2})
+	if err != nil {
+		t.Fatalf("error listing hotels: %v", err)
+	}
+	if len(second.Hotels) != 1 || second.NextCursor != "" {
+		t.Fatalf("expected last page with 1 hotel, got %+v", second)
+	}
+
+	for _, hotel := range append(first.Hotels, second.Hotels...) {
+		if !created[hotel.ID] {
+			t.Errorf("unexpected or repeated hotel %s", hotel.ID)
+		}
+		delete(created, hotel.ID)
+	}
+}
+
+func TestListHotels_FiltersAndSort(t *testing.T) {
+	service, _, _ := getTestService()
+	ctx := context.Background()
+
+	for _, hotel := range []hotelsDomain.Hotel{
+		{Name: "Lima Inn", City: "Lima", Rating: 3.5},
+		{Name: "Lima Palace", City: "Lima", Rating: 4.8},
+		{Name: "Lima Suites", City: "lima", Rating: 4.2},
+		{Name: "Cusco Lodge", City: "Cusco", Rating: 4.9},
+	} {
+		if _, err := service.Create(ctx, hotel); err != nil {
+			t.Fatalf("error creating hotel: %v", err)
+		}
+	}
+
+	minRating := 4.0
+	query := hotelsDomain.HotelListQuery{City: "LIMA", MinRating: &minRating, Sort: hotelsDomain.HotelSortRatingDesc, Limit: 1}
+	first, err := service.ListHotels(ctx, query)
+	if err != nil {
+		t.Fatalf("error listing hotels: %v", err)
+	}
+	if len(first.Hotels) != 1 || first.Hotels[0].Name != "Lima Palace" || first.NextCursor == "" {
+		t.Fatalf("expected Lima Palace first with a next cursor, got %+v", first)
+	}
+
+	query.Cursor = first.NextCursor
+	second, err := service.ListHotels(ctx, query)
+	if err != nil {
+		t.Fatalf("error listing hotels: %v", err)
+	}
+	if len(second.Hotels) != 1 || second.Hotels[0].Name != "Lima Suites" || second.NextCursor != "" {
+		t.Fatalf("expected Lima Suites on the last page, got %+v", second)
+	}
+
+	// El cursor no sirve con otro orden
+	query.Sort = hotelsDomain.HotelSortNameAsc
+	if _, err := service.ListHotels(ctx, query); !errors.Is(err, hotelsDomain.ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestListHotels_InvalidQuery(t *testing.T) {
+	service, _, _ := getTestService()
+	ctx := context.Background()
+
+	if _, err := service.ListHotels(ctx, hotelsDomain.HotelListQuery{Sort: "stars", Limit: 10}); !errors.Is(err, hotelsDomain.ErrInvalidHotelQuery) {
+		t.Fatalf("expected ErrInvalidHotelQuery for unknown sort, got %v", err)
+	}
+	if _, err := service.ListHotels(ctx, hotelsDomain.HotelListQuery{Fields: []string{"name", "outbox"}, Limit: 10}); !errors.Is(err, hotelsDomain.ErrInvalidHotelQuery) {
+		t.Fatalf("expected ErrInvalidHotelQuery for unknown field, got %v", err)
+	}
+	if _, err := service.ListHotels(ctx, hotelsDomain.HotelListQuery{Cursor: "%%%", Limit: 10}); !errors.Is(err, hotelsDomain.ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor, got %v", err)
+	}
+}
+
 func TestUpdateHotel(t *testing.T) {
 	service, _, _ := getTestService()
 	ctx := context.Background()
 
 	hotel := hotelsDomain.Hotel{Name: "Old Name"}
 	id, _ := service.Create(ctx, hotel)
-	updated := hotelsDomain.Hotel{ID: id, Name: "New Name"}
+	updated := hotelsDomain.Hotel{ID: id, Name: "New Name", Version: 1}
 	err := service.Update(ctx, updated)
 	if err != nil {
 		t.Fatalf("error updating hotel: %v", err)
@@ -60,6 +196,149 @@ func TestUpdateHotel(t *testing.T) {
 	}
 }
 
+func TestUpdateHotel_FullReplace(t *testing.T) {
+	service, mainRepo, cacheRepo := getTestService()
+	ctx := context.Background()
+
+	id, _ := service.Create(ctx, hotelsDomain.Hotel{Name: "Closing Hotel", Rating: 4.5, AvaiableRooms: 10, Amenities: []string{"wifi"}})
+	if _, err := service.CreateRoomType(ctx, id, hotelsDomain.RoomType{Name: "Suite", Capacity: 2, Count: 1}); err != nil {
+		t.Fatalf("error creating room type: %v", err)
+	}
+
+	// Los campos que no vienen quedan en cero, los tipos de habitacion se conservan
+	if err := service.Update(ctx, hotelsDomain.Hotel{ID: id, Name: "Closing Hotel", Version: 2}); err != nil {
+		t.Fatalf("error updating hotel: %v", err)
+	}
+	stored, _ := mainRepo.GetHotelByID(ctx, id)
+	if stored.Rating != 0 || stored.AvaiableRooms != 0 || len(stored.Amenities) != 0 {
+		t.Fatalf("expected rating, rooms and amenities cleared, got %+v", stored)
+	}
+	if len(stored.RoomTypes) != 1 {
+		t.Fatalf("expected room types to be kept, got %+v", stored.RoomTypes)
+	}
+
+	// La cache tiene el documento de la base, no el del request
+	cached, err := cacheRepo.GetHotelByID(ctx, id)
+	if err != nil {
+		t.Fatalf("expected hotel reloaded in cache: %v", err)
+	}
+	if len(cached.RoomTypes) != 1 || len(cached.Outbox) != 0 {
+		t.Fatalf("expected stored hotel without outbox in cache, got %+v", cached)
+	}
+}
+
+func TestUpdateHotel_NotFound(t *testing.T) {
+	service, _, _ := getTestService()
+
+	err := service.Update(context.Background(), hotelsDomain.Hotel{ID: "missing", Name: "X"})
+	if !errors.Is(err, hotelsDomain.ErrHotelNotFound) {
+		t.Fatalf("expected ErrHotelNotFound, got %v", err)
+	}
+}
+
+func TestPatchHotel(t *testing.T) {
+	service, mainRepo, cacheRepo := getTestService()
+	ctx := context.Background()
+
+	latitude, longitude := -34.6037, -58.3816
+	id, _ := service.Create(ctx, hotelsDomain.Hotel{
+		Name: "Patch Hotel", City: "Cordoba", Rating: 4, AvaiableRooms: 5,
+		Amenities: []string{"wifi", "pool"}, Latitude: &latitude, Longitude: &longitude,
+	})
+
+	// null vacia el campo, 0 se guarda y lo que no viene queda igual
+	patch := map[string]json.RawMessage{
+		"rating":         json.RawMessage(`0`),
+		"avaiable_rooms": json.RawMessage(`0`),
+		"amenities":      json.RawMessage(`null`),
+		"latitude":       json.RawMessage(`null`),
+		"longitude":      json.RawMessage(`null`),
+	}
+	got, err := service.Patch(ctx, id, 1, patch)
+	if err != nil {
+		t.Fatalf("error patching hotel: %v", err)
+	}
+	if got.Name != "Patch Hotel" || got.City != "Cordoba" {
+		t.Fatalf("expected untouched fields to be kept, got %+v", got)
+	}
+	if got.Rating != 0 || got.AvaiableRooms != 0 || len(got.Amenities) != 0 || got.Latitude != nil {
+		t.Fatalf("expected patched fields cleared, got %+v", got)
+	}
+
+	stored, _ := mainRepo.GetHotelByID(ctx, id)
+	if stored.Rating != 0 || stored.Location != nil || len(stored.Outbox) != 2 {
+		t.Fatalf("expected stored hotel patched with an UPDATE event, got %+v", stored)
+	}
+	cached, err := cacheRepo.GetHotelByID(ctx, id)
+	if err != nil || cached.City != "Cordoba" || cached.Rating != 0 {
+		t.Fatalf("expected patched hotel reloaded in cache, got %+v (%v)", cached, err)
+	}
+}
+
+func TestPatchHotel_Invalid(t *testing.T) {
+	service, _, _ := getTestService()
+	ctx := context.Background()
+
+	latitude, longitude := -34.6037, -58.3816
+	id, _ := service.Create(ctx, hotelsDomain.Hotel{Name: "Patch Hotel", Latitude: &latitude, Longitude: &longitude})
+
+	if _, err := service.Patch(ctx, id, 1, map[string]json.RawMessage{"room_types": json.RawMessage(`[]`)}); !errors.Is(err, hotelsDomain.ErrInvalidHotelPatch) {
+		t.Fatalf("expected ErrInvalidHotelPatch for room_types, got %v", err)
+	}
+	if _, err := service.Patch(ctx, id, 1, map[string]json.RawMessage{"rating": json.RawMessage(`"five"`)}); !errors.Is(err, hotelsDomain.ErrInvalidHotelPatch) {
+		t.Fatalf("expected ErrInvalidHotelPatch for wrong type, got %v", err)
+	}
+	// Sacar solo la latitud deja la ubicacion incompleta
+	if _, err := service.Patch(ctx, id, 1, map[string]json.RawMessage{"latitude": json.RawMessage(`null`)}); !errors.Is(err, hotelsDomain.ErrInvalidLocation) {
+		t.Fatalf("expected ErrInvalidLocation, got %v", err)
+	}
+	if _, err := service.Patch(ctx, "missing", 1, map[string]json.RawMessage{"name": json.RawMessage(`"X"`)}); !errors.Is(err, hotelsDomain.ErrHotelNotFound) {
+		t.Fatalf("expected ErrHotelNotFound, got %v", err)
+	}
+}
+
+func TestHotelVersionConflict(t *testing.T) {
+	service, _, cacheRepo := getTestService()
+	ctx := context.Background()
+
+	id, _ := service.Create(ctx, hotelsDomain.Hotel{Name: "Shared Hotel", PricePerNight: 100})
+	got, _ := service.GetHotelByID(ctx, id)
+	if got.Version != 1 {
+		t.Fatalf("expected new hotel at version 1, got %d", got.Version)
+	}
+
+	// Dos admins editan a partir de la version 1: el segundo cambio se rechaza
+	if err := service.Update(ctx, hotelsDomain.Hotel{ID: id, Name: "Shared Hotel", PricePerNight: 120, Version: 1}); err != nil {
+		t.Fatalf("error updating hotel: %v", err)
+	}
+	if err := service.Update(ctx, hotelsDomain.Hotel{ID: id, Name: "Shared Hotel", PricePerNight: 90, Version: 1}); !errors.Is(err, hotelsDomain.ErrVersionConflict) {
+		t.Fatalf("expected ErrVersionConflict on stale PUT, got %v", err)
+	}
+	if _, err := service.Patch(ctx, id, 1, map[string]json.RawMessage{"price_per_night": json.RawMessage(`80`)}); !errors.Is(err, hotelsDomain.ErrVersionConflict) {
+		t.Fatalf("expected ErrVersionConflict on stale PATCH, got %v", err)
+	}
+	if err := service.Delete(ctx, id, 1); !errors.Is(err, hotelsDomain.ErrVersionConflict) {
+		t.Fatalf("expected ErrVersionConflict on stale DELETE, got %v", err)
+	}
+
+	// La cache tiene la version nueva y el primer cambio
+	cached, err := cacheRepo.GetHotelByID(ctx, id)
+	if err != nil || cached.Version != 2 || cached.PricePerNight != 120 {
+		t.Fatalf("expected version 2 with price 120 in cache, got %+v (%v)", cached, err)
+	}
+
+	// Los cambios de tipos de habitacion tambien pasan a otra version
+	if _, err := service.CreateRoomType(ctx, id, hotelsDomain.RoomType{Name: "Suite", Capacity: 2, Count: 1}); err != nil {
+		t.Fatalf("error creating room type: %v", err)
+	}
+	if got, _ := service.GetHotelByID(ctx, id); got.Version != 3 {
+		t.Fatalf("expected version 3 after adding a room type, got %d", got.Version)
+	}
+	if err := service.Delete(ctx, id, 3); err != nil {
+		t.Fatalf("error deleting hotel: %v", err)
+	}
+}
+
 func TestDeleteHotel(t *testing.T) {
 	service, _, _ := getTestService()
 	ctx := context.Background()
@@ -73,7 +352,7 @@ func TestDeleteHotel(t *testing.T) {
 		t.Fatalf("hotel not found before deletion: %v", err)
 	}
 
-	err = service.Delete(ctx, id)
+	err = service.Delete(ctx, id, 1)
 	if err != nil {
 		t.Fatalf("error deleting hotel: %v", err)
 	}
@@ -88,11 +367,13 @@ func TestCreateReservation(t *testing.T) {
 	service, _, _ := getTestService()
 	ctx := context.Background()
 
-	hotel := hotelsDomain.Hotel{Name: "HotelRes"}
+	hotel := hotelsDomain.Hotel{Name: "HotelRes", AvaiableRooms: 1}
 	hotelID, _ := service.Create(ctx, hotel)
 	res := hotelsDomain.Reservation{
-		HotelID: hotelID,
-		UserID:  "user1",
+		HotelID:  hotelID,
+		UserID:   "user1",
+		CheckIn:  parseDate(t, "2024-01-01"),
+		CheckOut: parseDate(t, "2024-01-02"),
 	}
 	resID, err := service.CreateReservation(ctx, res)
 	if err != nil {
@@ -118,11 +399,13 @@ func TestCancelReservation(t *testing.T) {
 	service, _, _ := getTestService()
 	ctx := context.Background()
 
-	hotel := hotelsDomain.Hotel{Name: "HotelResCancel"}
+	hotel := hotelsDomain.Hotel{Name: "HotelResCancel", AvaiableRooms: 1}
 	hotelID, _ := service.Create(ctx, hotel)
 	res := hotelsDomain.Reservation{
-		HotelID: hotelID,
-		UserID:  "user2",
+		HotelID:  hotelID,
+		UserID:   "user2",
+		CheckIn:  parseDate(t, "2024-01-01"),
+		CheckOut: parseDate(t, "2024-01-02"),
 	}
 	resID, _ := service.CreateReservation(ctx, res)
 	// Ahora cancela la reserva
@@ -130,12 +413,82 @@ func TestCancelReservation(t *testing.T) {
 	if err != nil {
 		t.Fatalf("error canceling reservation: %v", err)
 	}
-	// Verifica que la reserva ya no existe
-	resList, _ := service.GetReservationsByHotelID(ctx, hotelID)
-	for _, r := range resList {
-		if r.ID == resID {
-			t.Errorf("reservation was not canceled")
-		}
+	// Verifica que la reserva sigue existiendo pero cancelada
+	got, err := service.GetReservationByID(ctx, resID)
+	if err != nil {
+		t.Fatalf("error getting canceled reservation: %v", err)
+	}
+	if got.Status != hotelsDomain.ReservationStatusCancelled {
+		t.Errorf("expected status %s, got %s", hotelsDomain.ReservationStatusCancelled, got.Status)
+	}
+	if len(got.StatusHistory) != 2 {
+		t.Errorf("expected 2 status changes, got %d", len(got.StatusHistory))
+	}
+}
+
+// Ciclo completo: CONFIRMED -> CHECKED_IN -> COMPLETED, sin volver atras
+func TestUpdateReservationStatus_Lifecycle(t *testing.T) {
+	service, _, _ := getTestService()
+	ctx := context.Background()
+
+	hotelID, _ := service.Create(ctx, hotelsDomain.Hotel{Name: "HotelLifecycle", AvaiableRooms: 1})
+	resID, err := service.CreateReservation(ctx, hotelsDomain.Reservation{
+		HotelID:  hotelID,
+		UserID:   "user-lifecycle",
+		CheckIn:  parseDate(t, "2024-01-01"),
+		CheckOut: parseDate(t, "2024-01-02"),
+	})
+	if err != nil {
+		t.Fatalf("error creating reservation: %v", err)
+	}
+
+	// No se puede completar una reserva sin check-in
+	err = service.UpdateReservationStatus(ctx, resID, hotelsDomain.ReservationStatusCompleted)
+	if !errors.Is(err, hotelsDomain.ErrInvalidStatusTransition) {
+		t.Fatalf("expected ErrInvalidStatusTransition, got %v", err)
+	}
+
+	if err := service.UpdateReservationStatus(ctx, resID, hotelsDomain.ReservationStatusCheckedIn); err != nil {
+		t.Fatalf("error checking in: %v", err)
+	}
+	if err := service.UpdateReservationStatus(ctx, resID, hotelsDomain.ReservationStatusCompleted); err != nil {
+		t.Fatalf("error checking out: %v", err)
+	}
+
+	// Una reserva completada no se puede cancelar
+	err = service.CancelReservation(ctx, resID)
+	if !errors.Is(err, hotelsDomain.ErrInvalidStatusTransition) {
+		t.Fatalf("expected ErrInvalidStatusTransition, got %v", err)
+	}
+
+	got, _ := service.GetReservationByID(ctx, resID)
+	if got.Status != hotelsDomain.ReservationStatusCompleted {
+		t.Errorf("expected status %s, got %s", hotelsDomain.ReservationStatusCompleted, got.Status)
+	}
+}
+
+// Las reservas canceladas no cuentan para la disponibilidad
+func TestAvailabilityIgnoresCancelled(t *testing.T) {
+	service, _, _ := getTestService()
+	ctx := context.Background()
+
+	hotelID, _ := service.Create(ctx, hotelsDomain.Hotel{Name: "HotelCancelledAvail", AvaiableRooms: 1})
+	resID, _ := service.CreateReservation(ctx, hotelsDomain.Reservation{
+		HotelID:  hotelID,
+		UserID:   "user-avail",
+		CheckIn:  parseDate(t, "2024-01-01"),
+		CheckOut: parseDate(t, "2024-01-02"),
+	})
+	if err := service.CancelReservation(ctx, resID); err != nil {
+		t.Fatalf("error canceling reservation: %v", err)
+	}
+
+	availability, err := service.GetAvailability(ctx, []string{hotelID}, "2024-01-01", "2024-01-02")
+	if err != nil {
+		t.Fatalf("error getting availability: %v", err)
+	}
+	if !availability[hotelID] {
+		t.Errorf("expected hotel to be available after cancellation")
 	}
 }
 
@@ -143,11 +496,13 @@ func TestGetReservationsByHotelID(t *testing.T) {
 	service, _, _ := getTestService()
 	ctx := context.Background()
 
-	hotel := hotelsDomain.Hotel{Name: "HotelRes2"}
+	hotel := hotelsDomain.Hotel{Name: "HotelRes2", AvaiableRooms: 1}
 	hotelID, _ := service.Create(ctx, hotel)
 	res := hotelsDomain.Reservation{
-		HotelID: hotelID,
-		UserID:  "user2",
+		HotelID:  hotelID,
+		UserID:   "user2",
+		CheckIn:  parseDate(t, "2024-01-01"),
+		CheckOut: parseDate(t, "2024-01-02"),
 	}
 	service.CreateReservation(ctx, res)
 	resList, err := service.GetReservationsByHotelID(ctx, hotelID)
@@ -163,11 +518,13 @@ func TestGetReservationsByUserID(t *testing.T) {
 	service, _, _ := getTestService()
 	ctx := context.Background()
 
-	hotel := hotelsDomain.Hotel{Name: "HotelRes3"}
+	hotel := hotelsDomain.Hotel{Name: "HotelRes3", AvaiableRooms: 1}
 	hotelID, _ := service.Create(ctx, hotel)
 	res := hotelsDomain.Reservation{
-		HotelID: hotelID,
-		UserID:  "user3",
+		HotelID:  hotelID,
+		UserID:   "user3",
+		CheckIn:  parseDate(t, "2024-01-01"),
+		CheckOut: parseDate(t, "2024-01-02"),
 	}
 	service.CreateReservation(ctx, res)
 	resList, err := service.GetReservationsByUserID(ctx, "user3")
@@ -183,11 +540,13 @@ func TestGetReservationsByUserAndHotelID(t *testing.T) {
 	service, _, _ := getTestService()
 	ctx := context.Background()
 
-	hotel := hotelsDomain.Hotel{Name: "HotelRes4"}
+	hotel := hotelsDomain.Hotel{Name: "HotelRes4", AvaiableRooms: 1}
 	hotelID, _ := service.Create(ctx, hotel)
 	res := hotelsDomain.Reservation{
-		HotelID: hotelID,
-		UserID:  "user4",
+		HotelID:  hotelID,
+		UserID:   "user4",
+		CheckIn:  parseDate(t, "2024-01-01"),
+		CheckOut: parseDate(t, "2024-01-02"),
 	}
 	service.CreateReservation(ctx, res)
 	resList, err := service.GetReservationsByUserAndHotelID(ctx, hotelID, "user4")
@@ -222,7 +581,7 @@ func TestGetHotelByID_PopulatesCache(t *testing.T) {
 	// Crear repos separados para inyectarlos y reusarlos
 	mainRepo := hotels.NewMock()
 	cacheRepo := hotels.NewMockCache()
-	service := NewService(mainRepo, cacheRepo, MockQueue{})
+	service := NewService(mainRepo, cacheRepo, audit.NewMock())
 	ctx := context.Background()
 
 	// Crear hotel solo en el repo principal (no en cache)
@@ -251,7 +610,7 @@ func TestGetHotelByID_PopulatesCache(t *testing.T) {
 func TestGetReservationByID_PopulatesCache(t *testing.T) {
 	mainRepo := hotels.NewMock()
 	cacheRepo := hotels.NewMockCache()
-	service := NewService(mainRepo, cacheRepo, MockQueue{})
+	service := NewService(mainRepo, cacheRepo, audit.NewMock())
 	ctx := context.Background()
 
 	// Crear hotel en main para asociar reserva
@@ -324,7 +683,318 @@ func TestAvailabilityWithReservation(t *testing.T) {
 	}
 }
 
+// Sin habitaciones libres la reserva se rechaza con ErrNoAvailability
+func TestCreateReservation_NoAvailability(t *testing.T) {
+	service, _, _ := getTestService()
+	ctx := context.Background()
+
+	hotelID, _ := service.Create(ctx, hotelsDomain.Hotel{
+		Name:          "HotelFull",
+		AvaiableRooms: 1,
+	})
+
+	res := hotelsDomain.Reservation{
+		HotelID:  hotelID,
+		UserID:   "user-first",
+		CheckIn:  parseDate(t, "2024-01-01"),
+		CheckOut: parseDate(t, "2024-01-03"),
+	}
+	if _, err := service.CreateReservation(ctx, res); err != nil {
+		t.Fatalf("error creating first reservation: %v", err)
+	}
+
+	// Se solapa en la noche del 2024-01-02 con la primera reserva
+	res.UserID = "user-second"
+	res.CheckIn = parseDate(t, "2024-01-02")
+	res.CheckOut = parseDate(t, "2024-01-04")
+	_, err := service.CreateReservation(ctx, res)
+	if !errors.Is(err, hotelsDomain.ErrNoAvailability) {
+		t.Fatalf("expected ErrNoAvailability, got %v", err)
+	}
+
+	// El checkout de la primera reserva no ocupa noche
+	res.CheckIn = parseDate(t, "2024-01-03")
+	res.CheckOut = parseDate(t, "2024-01-04")
+	if _, err := service.CreateReservation(ctx, res); err != nil {
+		t.Fatalf("expected reservation after checkout to succeed, got %v", err)
+	}
+}
+
+// Una vez cancelada la reserva, la habitacion vuelve a estar libre
+func TestCancelReservation_FreesRoom(t *testing.T) {
+	service, _, _ := getTestService()
+	ctx := context.Background()
+
+	hotelID, _ := service.Create(ctx, hotelsDomain.Hotel{
+		Name:          "HotelFreeRoom",
+		AvaiableRooms: 1,
+	})
+	res := hotelsDomain.Reservation{
+		HotelID:  hotelID,
+		UserID:   "user-cancel",
+		CheckIn:  parseDate(t, "2024-01-01"),
+		CheckOut: parseDate(t, "2024-01-02"),
+	}
+	resID, err := service.CreateReservation(ctx, res)
+	if err != nil {
+		t.Fatalf("error creating reservation: %v", err)
+	}
+	if err := service.CancelReservation(ctx, resID); err != nil {
+		t.Fatalf("error canceling reservation: %v", err)
+	}
+	if _, err := service.CreateReservation(ctx, res); err != nil {
+		t.Fatalf("expected room to be free after cancel, got %v", err)
+	}
+}
+
+func TestCreateReservation_InvalidDateRange(t *testing.T) {
+	service, _, _ := getTestService()
+	ctx := context.Background()
+
+	hotelID, _ := service.Create(ctx, hotelsDomain.Hotel{Name: "HotelDates", AvaiableRooms: 1})
+	_, err := service.CreateReservation(ctx, hotelsDomain.Reservation{
+		HotelID:  hotelID,
+		UserID:   "user-dates",
+		CheckIn:  parseDate(t, "2024-01-02"),
+		CheckOut: parseDate(t, "2024-01-02"),
+	})
+	if !errors.Is(err, hotelsDomain.ErrInvalidDateRange) {
+		t.Fatalf("expected ErrInvalidDateRange, got %v", err)
+	}
+}
+
 // parseDate helper para tests de fechas
+func TestCreateReservation_PerRoomTypeInventory(t *testing.T) {
+	service, _, _ := getTestService()
+	ctx := context.Background()
+
+	hotelID, _ := service.Create(ctx, hotelsDomain.Hotel{
+		Name: "HotelRoomTypes",
+		RoomTypes: []hotelsDomain.RoomType{
+			{ID: "single", Name: "Single", Capacity: 1, Count: 1, BasePrice: 80},
+			{ID: "suite", Name: "Suite", Capacity: 4, Count: 1, BasePrice: 250},
+		},
+	})
+
+	res := hotelsDomain.Reservation{
+		HotelID:    hotelID,
+		UserID:     "user-1",
+		RoomTypeID: "single",
+		CheckIn:    parseDate(t, "2024-01-01"),
+		CheckOut:   parseDate(t, "2024-01-02"),
+	}
+	if _, err := service.CreateReservation(ctx, res); err != nil {
+		t.Fatalf("error creating single reservation: %v", err)
+	}
+
+	// La unica single esta ocupada, pero la suite sigue libre
+	_, err := service.CreateReservation(ctx, res)
+	if !errors.Is(err, hotelsDomain.ErrNoAvailability) {
+		t.Fatalf("expected ErrNoAvailability for single, got %v", err)
+	}
+	availability, err := service.GetAvailability(ctx, []string{hotelID}, "2024-01-01", "2024-01-02")
+	if err != nil || !availability[hotelID] {
+		t.Fatalf("expected hotel available through the suite, got %v (err=%v)", availability, err)
+	}
+
+	res.RoomTypeID = "suite"
+	if _, err := service.CreateReservation(ctx, res); err != nil {
+		t.Fatalf("error creating suite reservation: %v", err)
+	}
+	availability, err = service.GetAvailability(ctx, []string{hotelID}, "2024-01-01", "2024-01-02")
+	if err != nil || availability[hotelID] {
+		t.Fatalf("expected hotel fully booked, got %v (err=%v)", availability, err)
+	}
+}
+
+func TestCreateReservation_InvalidRoomType(t *testing.T) {
+	service, _, _ := getTestService()
+	ctx := context.Background()
+
+	hotelID, _ := service.Create(ctx, hotelsDomain.Hotel{
+		Name:      "HotelRoomTypes",
+		RoomTypes: []hotelsDomain.RoomType{{ID: "double", Name: "Double", Capacity: 2, Count: 3}},
+	})
+
+	for _, roomTypeID := range []string{"", "penthouse"} {
+		_, err := service.CreateReservation(ctx, hotelsDomain.Reservation{
+			HotelID:    hotelID,
+			UserID:     "user-1",
+			RoomTypeID: roomTypeID,
+			CheckIn:    parseDate(t, "2024-01-01"),
+			CheckOut:   parseDate(t, "2024-01-02"),
+		})
+		if !errors.Is(err, hotelsDomain.ErrInvalidRoomType) {
+			t.Fatalf("room_type_id=%q: expected ErrInvalidRoomType, got %v", roomTypeID, err)
+		}
+	}
+}
+
+func TestRoomTypeCRUD(t *testing.T) {
+	service, _, _ := getTestService()
+	ctx := context.Background()
+
+	hotelID, _ := service.Create(ctx, hotelsDomain.Hotel{Name: "HotelCRUD", AvaiableRooms: 5})
+
+	if _, err := service.CreateRoomType(ctx, hotelID, hotelsDomain.RoomType{Name: "Broken", Capacity: 0}); !errors.Is(err, hotelsDomain.ErrInvalidRoomType) {
+		t.Fatalf("expected ErrInvalidRoomType, got %v", err)
+	}
+
+	roomTypeID, err := service.CreateRoomType(ctx, hotelID, hotelsDomain.RoomType{Name: "Double", Capacity: 2, Count: 3, BasePrice: 120})
+	if err != nil || roomTypeID == "" {
+		t.Fatalf("error creating room type: %v", err)
+	}
+
+	// La cache se descarta al cambiar los tipos, la lectura tiene que traer el nuevo tipo
+	roomTypes, err := service.GetRoomTypes(ctx, hotelID)
+	if err != nil || len(roomTypes) != 1 || roomTypes[0].ID != roomTypeID {
+		t.Fatalf("expected the new room type, got %+v (err=%v)", roomTypes, err)
+	}
+
+	if err := service.UpdateRoomType(ctx, hotelID, hotelsDomain.RoomType{ID: roomTypeID, Name: "Double", Capacity: 2, Count: 4, BasePrice: 130}); err != nil {
+		t.Fatalf("error updating room type: %v", err)
+	}
+	roomTypes, _ = service.GetRoomTypes(ctx, hotelID)
+	if roomTypes[0].Count != 4 || roomTypes[0].BasePrice != 130 {
+		t.Fatalf("expected updated room type, got %+v", roomTypes[0])
+	}
+
+	if err := service.UpdateRoomType(ctx, hotelID, hotelsDomain.RoomType{ID: "missing", Name: "X", Capacity: 1}); !errors.Is(err, hotelsDomain.ErrRoomTypeNotFound) {
+		t.Fatalf("expected ErrRoomTypeNotFound on update, got %v", err)
+	}
+
+	if err := service.DeleteRoomType(ctx, hotelID, roomTypeID); err != nil {
+		t.Fatalf("error deleting room type: %v", err)
+	}
+	if err := service.DeleteRoomType(ctx, hotelID, roomTypeID); !errors.Is(err, hotelsDomain.ErrRoomTypeNotFound) {
+		t.Fatalf("expected ErrRoomTypeNotFound on second delete, got %v", err)
+	}
+	roomTypes, _ = service.GetRoomTypes(ctx, hotelID)
+	if len(roomTypes) != 0 {
+		t.Fatalf("expected no room types, got %+v", roomTypes)
+	}
+}
+
+func TestCreateReservation_StoresQuotedTotal(t *testing.T) {
+	service, mainRepo, _ := getTestService()
+	ctx := context.Background()
+
+	hotelID, _ := service.Create(ctx, hotelsDomain.Hotel{
+		Name:          "HotelPricing",
+		PricePerNight: 100,
+		AvaiableRooms: 5,
+		Currency:      "EUR",
+	})
+
+	// Regla de fin de semana: viernes y sabado +50%
+	if _, err := service.CreateRateRule(ctx, hotelID, hotelsDomain.RateRule{Name: "Weekend", Type: hotelsDomain.RateRuleWeekend, Multiplier: 1.5}); err != nil {
+		t.Fatalf("error creating rate rule: %v", err)
+	}
+
+	// Jueves 4 a domingo 7 de enero de 2024: 100 + 150 + 150
+	quote, err := service.Quote(ctx, hotelID, "", parseDate(t, "2024-01-04"), parseDate(t, "2024-01-07"))
+	if err != nil {
+		t.Fatalf("error quoting stay: %v", err)
+	}
+	if quote.Total != 400 || quote.Currency != "EUR" || len(quote.Nights) != 3 {
+		t.Fatalf("unexpected quote: %+v", quote)
+	}
+
+	id, err := service.CreateReservation(ctx, hotelsDomain.Reservation{
+		HotelID:  hotelID,
+		UserID:   "user-1",
+		CheckIn:  parseDate(t, "2024-01-04"),
+		CheckOut: parseDate(t, "2024-01-07"),
+		// El total que manda el cliente se ignora
+		TotalPrice: 1,
+	})
+	if err != nil {
+		t.Fatalf("error creating reservation: %v", err)
+	}
+
+	stored, _ := mainRepo.GetReservationByID(ctx, id)
+	if stored.TotalPrice != 400 || stored.Currency != "EUR" {
+		t.Fatalf("expected quoted total persisted, got %v %s", stored.TotalPrice, stored.Currency)
+	}
+}
+
+func TestRateRuleCRUD(t *testing.T) {
+	service, _, _ := getTestService()
+	ctx := context.Background()
+
+	hotelID, _ := service.Create(ctx, hotelsDomain.Hotel{Name: "HotelRules", PricePerNight: 100, AvaiableRooms: 1})
+
+	if _, err := service.CreateRateRule(ctx, hotelID, hotelsDomain.RateRule{Name: "Bad", Type: hotelsDomain.RateRuleMinStay, MinNights: 3, DiscountPercent: 150}); !errors.Is(err, hotelsDomain.ErrInvalidRateRule) {
+		t.Fatalf("expected ErrInvalidRateRule, got %v", err)
+	}
+
+	ruleID, err := service.CreateRateRule(ctx, hotelID, hotelsDomain.RateRule{Name: "Long stay", Type: hotelsDomain.RateRuleMinStay, MinNights: 3, DiscountPercent: 10})
+	if err != nil {
+		t.Fatalf("error creating rate rule: %v", err)
+	}
+
+	if err := service.UpdateRateRule(ctx, hotelID, hotelsDomain.RateRule{ID: ruleID, Name: "Long stay", Type: hotelsDomain.RateRuleMinStay, MinNights: 2, DiscountPercent: 20}); err != nil {
+		t.Fatalf("error updating rate rule: %v", err)
+	}
+	rules, _ := service.GetRateRules(ctx, hotelID)
+	if len(rules) != 1 || rules[0].MinNights != 2 || rules[0].DiscountPercent != 20 {
+		t.Fatalf("expected updated rule, got %+v", rules)
+	}
+
+	if err := service.DeleteRateRule(ctx, hotelID, ruleID); err != nil {
+		t.Fatalf("error deleting rate rule: %v", err)
+	}
+	if err := service.DeleteRateRule(ctx, hotelID, ruleID); !errors.Is(err, hotelsDomain.ErrRateRuleNotFound) {
+		t.Fatalf("expected ErrRateRuleNotFound, got %v", err)
+	}
+}
+
+func TestGetCalendar(t *testing.T) {
+	service, _, _ := getTestService()
+	ctx := context.Background()
+
+	hotelID, _ := service.Create(ctx, hotelsDomain.Hotel{
+		Name: "HotelCalendar",
+		RoomTypes: []hotelsDomain.RoomType{
+			{ID: "single", Name: "Single", Capacity: 1, Count: 1, BasePrice: 80},
+			{ID: "suite", Name: "Suite", Capacity: 4, Count: 1, BasePrice: 250},
+		},
+	})
+
+	if _, err := service.CreateReservation(ctx, hotelsDomain.Reservation{
+		HotelID:    hotelID,
+		UserID:     "user-1",
+		RoomTypeID: "single",
+		CheckIn:    parseDate(t, "2024-01-01"),
+		CheckOut:   parseDate(t, "2024-01-02"),
+	}); err != nil {
+		t.Fatalf("error creating reservation: %v", err)
+	}
+
+	calendar, err := service.GetCalendar(ctx, hotelID, parseDate(t, "2024-01-01"), parseDate(t, "2024-01-03"))
+	if err != nil {
+		t.Fatalf("error getting calendar: %v", err)
+	}
+	if len(calendar.Nights) != 2 {
+		t.Fatalf("expected 2 nights, got %+v", calendar.Nights)
+	}
+
+	// Primera noche: la single esta ocupada, el precio es el de la suite
+	first := calendar.Nights[0]
+	if first.RemainingRooms != 1 || first.Price != 250 || len(first.RoomTypes) != 2 || first.RoomTypes[0].RemainingRooms != 0 {
+		t.Fatalf("unexpected first night: %+v", first)
+	}
+	// Segunda noche: todo libre, el precio es el de la single
+	second := calendar.Nights[1]
+	if second.RemainingRooms != 2 || second.Price != 80 {
+		t.Fatalf("unexpected second night: %+v", second)
+	}
+
+	if _, err := service.GetCalendar(ctx, hotelID, parseDate(t, "2024-01-03"), parseDate(t, "2024-01-01")); !errors.Is(err, hotelsDomain.ErrInvalidDateRange) {
+		t.Fatalf("expected ErrInvalidDateRange, got %v", err)
+	}
+}
+
 func parseDate(t *testing.T, value string) time.Time {
 	t.Helper()
 	parsed, err := time.Parse("2006-01-02", value)
@@ -333,3 +1003,80 @@ func parseDate(t *testing.T, value string) time.Time {
 	}
 	return parsed
 }
+
+// getTestServiceWithCache usa la cache real (ccache) en vez de MockCache
+func getTestServiceWithCache() (Service, hotels.Mock) {
+	mainRepo := hotels.NewMock()
+	cacheRepo := hotels.NewCache(hotels.CacheConfig{MaxSize: 100, ItemsToPrune: 10, Duration: time.Minute})
+	return NewService(mainRepo, cacheRepo, audit.NewMock()), mainRepo
+}
+
+// Una reserva creada en una replica con la cache fria no puede pasar por la lista completa del hotel
+func TestGetCalendar_ColdCacheCountsAllReservations(t *testing.T) {
+	service, mainRepo := getTestServiceWithCache()
+	ctx := context.Background()
+
+	hotelID, err := service.Create(ctx, hotelsDomain.Hotel{Name: "Cold Cache Hotel", PricePerNight: 100, AvaiableRooms: 3})
+	if err != nil {
+		t.Fatalf("error creating hotel: %v", err)
+	}
+	// Reserva hecha por otra replica: solo esta en MongoDB
+	if _, err := mainRepo.CreateReservation(ctx, hotelsDAO.Reservation{
+		HotelID:  hotelID,
+		UserID:   "user1",
+		CheckIn:  parseDate(t, "2030-01-01"),
+		CheckOut: parseDate(t, "2030-01-02"),
+		Status:   hotelsDomain.ReservationStatusConfirmed,
+	}); err != nil {
+		t.Fatalf("error creating reservation in main repo: %v", err)
+	}
+	if _, err := service.CreateReservation(ctx, hotelsDomain.Reservation{
+		HotelID:  hotelID,
+		UserID:   "user2",
+		CheckIn:  parseDate(t, "2030-01-01"),
+		CheckOut: parseDate(t, "2030-01-02"),
+	}); err != nil {
+		t.Fatalf("error creating reservation: %v", err)
+	}
+
+	for _, step := range []string{"before loading the list", "after loading the list"} {
+		calendar, err := service.GetCalendar(ctx, hotelID, parseDate(t, "2030-01-01"), parseDate(t, "2030-01-02"))
+		if err != nil {
+			t.Fatalf("%s: error getting calendar: %v", step, err)
+		}
+		if got := calendar.Nights[0].RemainingRooms; got != 1 {
+			t.Errorf("%s: remaining rooms = %d, want 1", step, got)
+		}
+		// Carga la lista completa desde MongoDB: a partir de aca el calendario sale de la cache
+		if _, err := service.GetReservationsByHotelID(ctx, hotelID); err != nil {
+			t.Fatalf("error getting reservations: %v", err)
+		}
+	}
+}
+
+// Un hotel en cache sin reservas cargadas es un miss, no un hotel completo
+func TestGetAvailability_CachedHotelWithoutReservations(t *testing.T) {
+	service, _ := getTestServiceWithCache()
+	ctx := context.Background()
+
+	hotelID, err := service.Create(ctx, hotelsDomain.Hotel{Name: "Empty Hotel", AvaiableRooms: 2})
+	if err != nil {
+		t.Fatalf("error creating hotel: %v", err)
+	}
+	availability, err := service.GetAvailability(ctx, []string{hotelID}, "2030-01-01", "2030-01-02")
+	if err != nil {
+		t.Fatalf("error getting availability: %v", err)
+	}
+	if !availability[hotelID] {
+		t.Errorf("expected hotel without reservations to be available")
+	}
+
+	// Con la lista completa (vacia) cargada tambien esta disponible
+	if _, err := service.GetReservationsByHotelID(ctx, hotelID); err != nil {
+		t.Fatalf("error getting reservations: %v", err)
+	}
+	availability, err = service.GetAvailability(ctx, []string{hotelID}, "2030-01-01", "2030-01-02")
+	if err != nil || !availability[hotelID] {
+		t.Errorf("expected hotel with an empty reservation list to be available, err=%v", err)
+	}
+}
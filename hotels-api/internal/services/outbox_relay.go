@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	hotelsDAO "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/dao/hotels"
+	hotelsDomain "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/domain/hotels"
+)
+
+// OutboxRepository es lo que necesita el relay del repositorio principal: leer los eventos pendientes y marcarlos como publicados
+type OutboxRepository interface {
+	GetPendingOutbox(ctx context.Context, limit int64) ([]hotelsDAO.Hotel, error)
+	AckOutboxEvent(ctx context.Context, hotelID string, eventID string) error
+}
+
+// Queue publica los eventos de hoteles en tandas. PublishBatch devuelve cuantos eventos, desde el primero,
+// confirmo el broker: los siguientes al primero que fallo quedan sin confirmar aunque se hayan enviado
+type Queue interface {
+	PublishBatch(hotelNews []hotelsDomain.HotelNew) (int, error)
+}
+
+// OutboxRelay publica en RabbitMQ los eventos guardados en el outbox de los hoteles.
+// Si RabbitMQ no esta disponible los eventos quedan pendientes y se reintentan en la siguiente vuelta.
+type OutboxRelay struct {
+	repository  OutboxRepository
+	eventsQueue Queue
+	interval    time.Duration
+	batchSize   int64
+}
+
+// Funcion que se encarga de crear el relay con el repositorio principal, la cola de eventos y cada cuanto revisar el outbox
+func NewOutboxRelay(repository OutboxRepository, eventsQueue Queue, interval time.Duration, batchSize int64) OutboxRelay {
+	return OutboxRelay{
+		repository:  repository,
+		eventsQueue: eventsQueue,
+		interval:    interval,
+		batchSize:   batchSize,
+	}
+}
+
+// Run revisa el outbox cada interval hasta que se cancele el contexto (pensado para correr en una goroutine)
+func (relay OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(relay.interval)
+	defer ticker.Stop()
+
+	for {
+		// Si la vuelta llego al limite puede haber mas pendientes (por ejemplo despues de una importacion): se sigue sin esperar
+		for {
+			published, err := relay.PublishPending(ctx)
+			if err != nil {
+				log.Printf("Error publishing outbox events: %v", err)
+				break
+			}
+			if published < int(relay.batchSize) || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PublishPending publica en una sola tanda los eventos pendientes de hasta batchSize hoteles y devuelve cuantos se publicaron.
+// Los eventos de un mismo hotel van en orden: si uno falla, los siguientes esperan a la proxima vuelta.
+func (relay OutboxRelay) PublishPending(ctx context.Context) (int, error) {
+	hotels, err := relay.repository.GetPendingOutbox(ctx, relay.batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("error getting pending outbox events: %w", err)
+	}
+
+	var events []hotelsDomain.HotelNew
+	for _, hotel := range hotels {
+		for i, event := range hotel.Outbox {
+			// Cada evento confirmado avanza EventSequence, asi un evento republicado conserva su numero
+			events = append(events, hotelEvent(hotel, event, hotel.EventSequence+int64(i)+1))
+		}
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	confirmed, publishErr := relay.eventsQueue.PublishBatch(events)
+	var lastErr error
+	if publishErr != nil {
+		lastErr = fmt.Errorf("error publishing outbox events (%d of %d confirmed): %w", confirmed, len(events), publishErr)
+	}
+
+	// Se quitan del outbox los eventos confirmados. Si el ack de uno falla no se sigue con los de ese hotel,
+	// asi no avanza la secuencia salteando un evento; el evento se vuelve a publicar y search-api tolera duplicados
+	published := 0
+	failedHotels := make(map[string]bool)
+	for _, event := range events[:confirmed] {
+		if failedHotels[event.HotelID] {
+			continue
+		}
+		if err := relay.repository.AckOutboxEvent(ctx, event.HotelID, event.EventID); err != nil {
+			lastErr = fmt.Errorf("error acking outbox event %s: %w", event.EventID, err)
+			failedHotels[event.HotelID] = true
+			continue
+		}
+		published++
+	}
+
+	return published, lastErr
+}
+
+// hotelEvent arma el sobre v2 del evento con el estado actual del hotel (los DELETE no llevan hotel).
+// Si hay varios eventos pendientes todos llevan el ultimo estado, que es al que tiene que converger search-api
+func hotelEvent(hotel hotelsDAO.Hotel, event hotelsDAO.OutboxEvent, sequence int64) hotelsDomain.HotelNew {
+	hotelNew := hotelsDomain.HotelNew{
+		EventID:       event.ID,
+		Type:          event.Operation,
+		SchemaVersion: hotelsDomain.HotelEventSchemaVersion,
+		OccurredAt:    event.CreatedAt,
+		Sequence:      sequence,
+		Operation:     event.Operation,
+		HotelID:       hotel.ID,
+	}
+	if event.Operation != hotelsDomain.OperationDelete {
+		snapshot := hotelToDomain(hotel)
+		// El snapshot lleva la secuencia del evento que lo publica
+		snapshot.EventSequence = sequence
+		hotelNew.Hotel = &snapshot
+	}
+	return hotelNew
+}
+
+// newOutboxEvent crea un evento de outbox para la operacion indicada
+func newOutboxEvent(operation string) hotelsDAO.OutboxEvent {
+	return hotelsDAO.OutboxEvent{
+		ID:        uuid.New().String(),
+		Operation: operation,
+		CreatedAt: time.Now().UTC(),
+	}
+}
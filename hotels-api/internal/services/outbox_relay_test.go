@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	hotelsDomain "github.com/Julian0444/Hotel-Search-Booking-Microservices-Platform/hotels-api/internal/domain/hotels"
+)
+
+// Mock de la cola que guarda los mensajes publicados y puede simular RabbitMQ caido.
+// Con confirmLimit mayor a cero solo confirma esa cantidad de mensajes por tanda, como un nack a mitad de camino
+type MockQueue struct {
+	messages     *[]hotelsDomain.HotelNew
+	down         bool
+	confirmLimit int
+}
+
+func (mq MockQueue) PublishBatch(hotelNews []hotelsDomain.HotelNew) (int, error) {
+	if mq.down {
+		return 0, errors.New("rabbitmq unavailable")
+	}
+	if mq.confirmLimit > 0 && len(hotelNews) > mq.confirmLimit {
+		*mq.messages = append(*mq.messages, hotelNews[:mq.confirmLimit]...)
+		return mq.confirmLimit, errors.New("message nacked by the broker")
+	}
+	*mq.messages = append(*mq.messages, hotelNews...)
+	return len(hotelNews), nil
+}
+
+func TestOutboxRelay_PublishesAndAcks(t *testing.T) {
+	service, mainRepo, _ := getTestService()
+	ctx := context.Background()
+
+	id, err := service.Create(ctx, hotelsDomain.Hotel{Name: "Outbox Hotel"})
+	if err != nil {
+		t.Fatalf("error creating hotel: %v", err)
+	}
+	if err := service.Update(ctx, hotelsDomain.Hotel{ID: id, Name: "Renamed", Version: 1}); err != nil {
+		t.Fatalf("error updating hotel: %v", err)
+	}
+
+	var messages []hotelsDomain.HotelNew
+	relay := NewOutboxRelay(mainRepo, MockQueue{messages: &messages}, time.Second, 10)
+	published, err := relay.PublishPending(ctx)
+	if err != nil {
+		t.Fatalf("error publishing outbox: %v", err)
+	}
+	if published != 2 || len(messages) != 2 {
+		t.Fatalf("expected 2 published events, got %d (%d messages)", published, len(messages))
+	}
+	if messages[0].Operation != hotelsDomain.OperationCreate || messages[1].Operation != hotelsDomain.OperationUpdate || messages[0].HotelID != id {
+		t.Errorf("unexpected events order: %+v", messages)
+	}
+
+	// Los eventos v2 llevan el hotel completo y una secuencia creciente por hotel
+	for i, message := range messages {
+		if message.SchemaVersion != hotelsDomain.HotelEventSchemaVersion || message.EventID == "" || message.OccurredAt.IsZero() {
+			t.Errorf("event %d is missing envelope fields: %+v", i, message)
+		}
+		if message.Sequence != int64(i+1) {
+			t.Errorf("expected sequence %d, got %d", i+1, message.Sequence)
+		}
+		if message.Hotel == nil || message.Hotel.Name != "Renamed" || message.Hotel.EventSequence != message.Sequence {
+			t.Errorf("expected hotel snapshot with the latest name and the event sequence, got %+v", message.Hotel)
+		}
+	}
+
+	// Los eventos publicados se quitan del outbox
+	published, _ = relay.PublishPending(ctx)
+	if published != 0 {
+		t.Errorf("expected no pending events after ack, got %d", published)
+	}
+
+	// El listado (que usa el reindex de search-api) devuelve la secuencia del ultimo evento publicado
+	page, err := service.ListHotels(ctx, hotelsDomain.HotelListQuery{Limit: 10, Fields: []string{"event_sequence"}})
+	if err != nil || len(page.Hotels) != 1 || page.Hotels[0].EventSequence != 2 {
+		t.Errorf("expected event_sequence 2 in the listing, got %+v (err %v)", page.Hotels, err)
+	}
+}
+
+func TestOutboxRelay_QueueDownKeepsEventsPending(t *testing.T) {
+	service, mainRepo, _ := getTestService()
+	ctx := context.Background()
+
+	// Con RabbitMQ caido la escritura igual se completa
+	id, err := service.Create(ctx, hotelsDomain.Hotel{Name: "Pending Hotel"})
+	if err != nil {
+		t.Fatalf("create should not depend on the queue: %v", err)
+	}
+
+	var messages []hotelsDomain.HotelNew
+	if _, err := NewOutboxRelay(mainRepo, MockQueue{messages: &messages, down: true}, time.Second, 10).PublishPending(ctx); err == nil {
+		t.Fatal("expected publish error while queue is down")
+	}
+
+	published, err := NewOutboxRelay(mainRepo, MockQueue{messages: &messages}, time.Second, 10).PublishPending(ctx)
+	if err != nil || published != 1 || messages[0].HotelID != id {
+		t.Fatalf("expected pending CREATE to be published after recovery, got %d events (err %v)", published, err)
+	}
+}
+
+func TestOutboxRelay_DeletedHotelRemovedAfterPublish(t *testing.T) {
+	service, mainRepo, _ := getTestService()
+	ctx := context.Background()
+
+	id, _ := service.Create(ctx, hotelsDomain.Hotel{Name: "Gone"})
+	if err := service.Delete(ctx, id, 1); err != nil {
+		t.Fatalf("error deleting hotel: %v", err)
+	}
+
+	var messages []hotelsDomain.HotelNew
+	relay := NewOutboxRelay(mainRepo, MockQueue{messages: &messages}, time.Second, 10)
+	if _, err := relay.PublishPending(ctx); err != nil {
+		t.Fatalf("error publishing outbox: %v", err)
+	}
+	if len(messages) != 2 || messages[1].Operation != hotelsDomain.OperationDelete {
+		t.Fatalf("expected CREATE and DELETE events, got %+v", messages)
+	}
+	if messages[1].Hotel != nil {
+		t.Errorf("DELETE events should not carry a hotel snapshot")
+	}
+
+	pending, _ := mainRepo.GetPendingOutbox(ctx, 10)
+	if len(pending) != 0 {
+		t.Errorf("expected deleted hotel to leave the outbox, got %d hotels", len(pending))
+	}
+}
+
+func TestOutboxRelay_PartialBatchKeepsUnconfirmedPending(t *testing.T) {
+	service, mainRepo, _ := getTestService()
+	ctx := context.Background()
+
+	report, err := service.ImportHotels(ctx, hotelsDomain.HotelFormatNDJSON, strings.NewReader(
+		`{"name":"Uno"}`+"\n"+`{"name":"Dos"}`+"\n"+`{"name":"Tres"}`+"\n"), false)
+	if err != nil || report.Created != 3 {
+		t.Fatalf("error importing hotels: %v (%+v)", err, report)
+	}
+
+	// Todos los eventos salen en una tanda, el broker solo confirma los dos primeros
+	var messages []hotelsDomain.HotelNew
+	published, err := NewOutboxRelay(mainRepo, MockQueue{messages: &messages, confirmLimit: 2}, time.Second, 10).PublishPending(ctx)
+	if err == nil || published != 2 {
+		t.Fatalf("expected 2 confirmed events and an error, got %d (err %v)", published, err)
+	}
+
+	pending, _ := mainRepo.GetPendingOutbox(ctx, 10)
+	if len(pending) != 1 || len(pending[0].Outbox) != 1 {
+		t.Fatalf("expected only the unconfirmed event to stay pending, got %+v", pending)
+	}
+	for _, message := range messages {
+		if message.HotelID == pending[0].ID {
+			t.Fatalf("expected the pending hotel not to be among the confirmed events")
+		}
+	}
+
+	published, err = NewOutboxRelay(mainRepo, MockQueue{messages: &messages}, time.Second, 10).PublishPending(ctx)
+	if err != nil || published != 1 {
+		t.Fatalf("expected the pending event to be published on the next pass, got %d (err %v)", published, err)
+	}
+}
@@ -1,11 +1,20 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"search-api/internal/clients/queues"
 	"search-api/internal/config"
+	controllersDeadLetters "search-api/internal/controllers/deadletters"
+	controllersReindex "search-api/internal/controllers/reindex"
 	controllers "search-api/internal/controllers/search"
 	repositories "search-api/internal/repositories/hotels"
 	services "search-api/internal/services/search"
@@ -19,18 +28,11 @@ func main() {
 
 	// Solr
 	solrRepo := repositories.NewSolr(repositories.SolrConfig{
-		Host:       config.SolrHost,
-		Port:       config.SolrPort,
-		Collection: config.SolrCollection,
-	})
-
-	// Rabbit - consume de la cola de RabbitMQ
-	eventsQueue := queues.NewRabbit(queues.RabbitConfig{
-		Host:      config.RabbitHost,
-		Port:      config.RabbitPort,
-		Username:  config.RabbitUsername,
-		Password:  config.RabbitPassword,
-		QueueName: config.RabbitQueueName,
+		Host:         config.SolrHost,
+		Port:         config.SolrPort,
+		Collection:   config.SolrCollection,
+		ConfigSet:    config.SolrConfigSet,
+		CommitWithin: config.SolrCommitWithin,
 	})
 
 	// Hotels API
@@ -39,13 +41,52 @@ func main() {
 		Port: config.HotelsAPIPort,
 	})
 
+	// Reindex completo del indice desde hotels-api
+	reindexer := services.NewReindexer(solrRepo, hotelsAPI, config.ReindexPageSize, config.ReindexRetryWait)
+
+	// Modo reindex (`./app reindex`): reconstruye el indice y termina, sin levantar el servidor ni el consumidor.
+	// Los eventos que lleguen mientras tanto a otra instancia no se copian al core nuevo, con el servidor andando conviene POST /admin/reindex
+	if len(os.Args) > 1 && os.Args[1] == "reindex" {
+		status, err := reindexer.Run(context.Background())
+		report, _ := json.MarshalIndent(status, "", "  ")
+		fmt.Println(string(report))
+		if err != nil {
+			log.Fatalf("Error running reindex: %v", err)
+		}
+		return
+	}
+
+	// Rabbit - consume de la cola de RabbitMQ
+	eventsQueue := queues.NewRabbit(queues.RabbitConfig{
+		Host:       config.RabbitHost,
+		Port:       config.RabbitPort,
+		Username:   config.RabbitUsername,
+		Password:   config.RabbitPassword,
+		QueueName:  config.RabbitQueueName,
+		MaxRetries: config.RabbitMaxRetries,
+		RetryDelay: config.RabbitRetryDelay,
+		Prefetch:   config.RabbitPrefetch,
+	})
+
+	// Tandas de escrituras en Solr con los cambios de los eventos
+	batcher := services.NewBatcher(solrRepo, config.SolrBatchSize, config.SolrBatchWait)
+
+	// Cache de sugerencias
+	suggestCache := repositories.NewCache(repositories.CacheConfig{
+		MaxSize:      int64(config.SuggestCacheSize),
+		ItemsToPrune: 100,
+		Duration:     config.SuggestCacheTTL,
+	})
+
 	// Services
-	service := services.NewService(solrRepo, hotelsAPI)
+	service := services.NewService(solrRepo, hotelsAPI, batcher, suggestCache, config.AvailabilityBudget)
 
 	// Controllers
 	controller := controllers.NewController(service)
+	deadLettersController := controllersDeadLetters.NewController(eventsQueue)
+	reindexController := controllersReindex.NewController(reindexer)
 
-	// Launch rabbit consumer
+	// Launch rabbit consumer (se registra cuando RabbitMQ este disponible y en cada reconexion)
 	if err := eventsQueue.StartConsumer(service.HandleHotelNew); err != nil {
 		log.Fatalf("Error running consumer: %v", err)
 	}
@@ -58,18 +99,59 @@ func main() {
 
 	// Routes
 	router.GET("/search", controller.Search)
+	router.GET("/search/suggest", controller.Suggest)
+
+	// Rutas de administracion de la cola de eventos y del indice (solo admins)
+	adminRoutes := router.Group("/admin", utils.JWTMiddleware(config.JWTSecret), utils.AdminOnly())
+	{
+		adminRoutes.GET("/dead-letters", deadLettersController.List)
+		adminRoutes.POST("/dead-letters/replay", deadLettersController.Replay)
+		adminRoutes.GET("/reindex", reindexController.Status)
+		adminRoutes.POST("/reindex", reindexController.Start)
+	}
 
 	// Health check
+	// Si el consumidor de RabbitMQ no esta consumiendo la busqueda sigue funcionando, pero el indice puede quedar desactualizado.
+	// indexer tiene las metricas de las tandas de escrituras en Solr (tamaño y latencia)
 	router.GET("/health", func(c *gin.Context) {
+		consumer := eventsQueue.Status()
+		status := "ok"
+		if consumer.State != queues.StateConsuming {
+			status = "degraded"
+		}
 		c.JSON(200, gin.H{
-			"status":    "ok",
+			"status":    status,
 			"service":   "search-api",
+			"consumer":  consumer,
+			"indexer":   batcher.Stats(),
 			"timestamp": time.Now().Format(time.RFC3339),
 		})
 	})
 
 	// Run server
-	if err := router.Run(":" + config.Port); err != nil {
-		log.Fatalf("Error running application: %v", err)
+	server := &http.Server{Addr: ":" + config.Port, Handler: router}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Error running application: %v", err)
+		}
+	}()
+
+	// Apagado: se deja de consumir, se manda la tanda pendiente a Solr y se confirman sus mensajes antes de cerrar RabbitMQ
+	stop, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	<-stop.Done()
+	log.Println("Shutting down search-api...")
+
+	ctx, cancelShutdown := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancelShutdown()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Error shutting down server: %v", err)
+	}
+	if err := eventsQueue.StopConsumer(); err != nil {
+		log.Printf("Error stopping consumer: %v", err)
+	}
+	if err := batcher.Close(ctx); err != nil {
+		log.Printf("Error flushing Solr batch: %v", err)
 	}
+	eventsQueue.Close()
 }
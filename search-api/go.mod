@@ -4,6 +4,9 @@ go 1.22
 
 require (
 	github.com/gin-gonic/gin v1.9.1
+	github.com/golang-jwt/jwt/v5 v5.3.0
+	github.com/karlseguin/ccache v2.0.3+incompatible
+	github.com/karlseguin/ccache v2.0.3+incompatible
 	github.com/stevenferrer/solr-go v0.4.0
 	github.com/streadway/amqp v1.1.0
 	github.com/stretchr/testify v1.11.1
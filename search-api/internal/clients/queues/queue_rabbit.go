@@ -2,55 +2,239 @@ package queues
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
+	"time"
 
 	"search-api/internal/domain/hotels"
 
 	"github.com/streadway/amqp"
 )
 
+const (
+	// Backoff exponencial entre intentos de conexion (se reintenta para siempre)
+	initialBackoff = 1 * time.Second
+	maxBackoff     = 30 * time.Second
+	backoffFactor  = 2.0
+
+	// Estados del consumidor que se informan en /health
+	StateConnecting   = "connecting"
+	StateConnected    = "connected"
+	StateConsuming    = "consuming"
+	StateDisconnected = "disconnected"
+
+	// Tag del consumidor, para poder cancelarlo al apagar el servicio
+	consumerTag = "search-api"
+
+	// Header con la cantidad de veces que fallo el mensaje
+	retryCountHeader = "x-retry-count"
+	// Headers que se agregan al mandar un mensaje a la cola de dead letters
+	errorHeader    = "x-last-error"
+	failedAtHeader = "x-failed-at"
+)
+
 type RabbitConfig struct {
-	Host      string
-	Port      string
-	Username  string
-	Password  string
-	QueueName string
+	Host       string
+	Port       string
+	Username   string
+	Password   string
+	QueueName  string
+	MaxRetries int           // Reintentos antes de mandar el mensaje a la cola de dead letters
+	RetryDelay time.Duration // Tiempo que espera un mensaje en la cola de reintentos
+	Prefetch   int           // Mensajes sin confirmar que se reciben a la vez (tienen que alcanzar para armar las tandas de Solr)
 }
 
+// Handler procesa un evento. Se llama en el orden de la cola y devuelve un canal con el resultado:
+// el mensaje se confirma (o se reintenta) cuando llega, mientras tanto se siguen recibiendo mensajes
+type Handler func(hotels.HotelNew) <-chan error
+
 type Rabbit struct {
+	config     RabbitConfig
+	mu         sync.RWMutex
 	connection *amqp.Connection
 	channel    *amqp.Channel
-	queue      amqp.Queue
+	handler    Handler
+	consuming  chan struct{}  // Se cierra cuando termina el loop del consumidor actual
+	inflight   sync.WaitGroup // Mensajes recibidos que todavia no se confirmaron
+	state      string
+	lastError  string
+	since      time.Time
+	closed     bool
+}
+
+// ConsumerStatus es el estado del consumidor que se muestra en /health
+type ConsumerStatus struct {
+	State     string    `json:"state"`
+	Queue     string    `json:"queue"`
+	LastError string    `json:"last_error,omitempty"`
+	Since     time.Time `json:"since"`
+}
+
+// Funcion para crear el cliente de RabbitMQ. No bloquea ni falla si el broker no esta disponible:
+// se conecta en segundo plano con backoff exponencial y se reconecta si la conexion se cae
+func NewRabbit(config RabbitConfig) *Rabbit {
+	queue := &Rabbit{
+		config: config,
+		state:  StateConnecting,
+		since:  time.Now().UTC(),
+	}
+	go queue.run()
+	return queue
+}
+
+// run mantiene la conexion: conecta con backoff, espera a que se cierre y vuelve a conectar
+func (queue *Rabbit) run() {
+	backoff := initialBackoff
+	for attempt := 1; ; attempt++ {
+		if queue.isClosed() {
+			return
+		}
+
+		closed, err := queue.connect()
+		if err != nil {
+			queue.setState(StateDisconnected, err)
+			log.Printf("RabbitMQ connection attempt %d failed: %v. Retrying in %v...", attempt, err, backoff)
+			time.Sleep(backoff)
+
+			// Incrementar backoff exponencialmente
+			backoff = time.Duration(float64(backoff) * backoffFactor)
+			if backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+			continue
+		}
+		log.Printf("Successfully connected to RabbitMQ on attempt %d", attempt)
+		attempt, backoff = 0, initialBackoff
+
+		// Esperar a que se cierre la conexion o el canal
+		closeErr := <-closed
+		if queue.isClosed() {
+			return
+		}
+		log.Printf("RabbitMQ connection closed unexpectedly: %v", closeErr)
+		queue.mu.Lock()
+		queue.closeUnsafe()
+		queue.mu.Unlock()
+		queue.setState(StateDisconnected, closeErr)
+	}
 }
 
-// Funcion para crear una nueva conexion a RabbitMQ
-func NewRabbit(config RabbitConfig) Rabbit {
+// connect abre la conexion y el canal, declara las colas y, si ya hay un handler, vuelve a registrar el consumidor.
+// Devuelve un canal que recibe el error cuando se cierra la conexion o el canal
+func (queue *Rabbit) connect() (<-chan *amqp.Error, error) {
 	//Dial crea una nueva conexion a RabbitMQ
-	connection, err := amqp.Dial(fmt.Sprintf("amqp://%s:%s@%s:%s/", config.Username, config.Password, config.Host, config.Port))
+	connection, err := amqp.Dial(fmt.Sprintf("amqp://%s:%s@%s:%s/", queue.config.Username, queue.config.Password, queue.config.Host, queue.config.Port))
 	if err != nil {
-		log.Fatalf("error getting Rabbit connection: %v", err)
+		return nil, fmt.Errorf("error getting Rabbit connection: %w", err)
 	}
-	// Channel crea un nuevo canal de comunic
+	// Channel crea un nuevo canal de comunicacion
 	channel, err := connection.Channel()
 	if err != nil {
-		log.Fatalf("error creating Rabbit channel: %v", err)
+		connection.Close()
+		return nil, fmt.Errorf("error creating Rabbit channel: %w", err)
+	}
+	// Qos limita los mensajes sin confirmar que manda el broker
+	if queue.config.Prefetch > 0 {
+		if err := channel.Qos(queue.config.Prefetch, 0, false); err != nil {
+			connection.Close()
+			return nil, fmt.Errorf("error setting Rabbit prefetch: %w", err)
+		}
+	}
+	// QueueDeclare crea la cola principal y despues las de reintentos y dead letters
+	if _, err := channel.QueueDeclare(queue.config.QueueName, true, false, false, false, nil); err != nil {
+		connection.Close()
+		return nil, fmt.Errorf("error declaring Rabbit queue: %w", err)
+	}
+	if err := declareRetryQueues(channel, queue.config); err != nil {
+		connection.Close()
+		return nil, err
+	}
+
+	// Un solo canal recibe el cierre de la conexion o del canal (el canal se puede cerrar por un error de protocolo)
+	closed := make(chan *amqp.Error, 2)
+	connection.NotifyClose(forward(closed))
+	channel.NotifyClose(forward(closed))
+
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	if queue.closed {
+		connection.Close()
+		return nil, fmt.Errorf("rabbit client closed")
+	}
+	queue.connection = connection
+	queue.channel = channel
+	queue.setStateUnsafe(StateConnected, nil)
+
+	if queue.handler != nil {
+		if err := queue.consumeUnsafe(); err != nil {
+			queue.closeUnsafe()
+			return nil, err
+		}
 	}
-	// QueueDeclare crea una nueva cola en RabbitMQ
-	queue, err := channel.QueueDeclare(config.QueueName, true, false, false, false, nil)
-	return Rabbit{
-		connection: connection,
-		channel:    channel,
-		queue:      queue,
+	return closed, nil
+}
+
+// forward crea un canal de notificacion de cierre que reenvia el error a out
+func forward(out chan *amqp.Error) chan *amqp.Error {
+	in := make(chan *amqp.Error, 1)
+	go func() {
+		if err, ok := <-in; ok {
+			out <- err
+		} else {
+			out <- nil
+		}
+	}()
+	return in
+}
+
+// retryQueueName es la cola donde esperan los mensajes fallidos antes de volver a la cola principal
+func retryQueueName(queueName string) string {
+	return queueName + ".retry"
+}
+
+// deadLetterQueueName es la cola donde quedan los mensajes que agotaron los reintentos
+func deadLetterQueueName(queueName string) string {
+	return queueName + ".dlq"
+}
+
+// declareRetryQueues declara la cola de reintentos (con TTL, al vencer vuelve a la cola principal) y la de dead letters
+func declareRetryQueues(channel *amqp.Channel, config RabbitConfig) error {
+	if _, err := channel.QueueDeclare(retryQueueName(config.QueueName), true, false, false, false, amqp.Table{
+		"x-message-ttl":             int64(config.RetryDelay / time.Millisecond),
+		"x-dead-letter-exchange":    "",
+		"x-dead-letter-routing-key": config.QueueName,
+	}); err != nil {
+		return fmt.Errorf("error declaring retry queue: %w", err)
+	}
+	if _, err := channel.QueueDeclare(deadLetterQueueName(config.QueueName), true, false, false, false, nil); err != nil {
+		return fmt.Errorf("error declaring dead letter queue: %w", err)
+	}
+	return nil
+}
+
+// Inicia el consumidor de la cola de RabbitMQ (El que carga los mensaje ya esta definido en la api de hoteles).
+// Cada mensaje se confirma solo si el handler no devuelve error; si falla se reintenta y despues va a dead letters.
+// Si el broker todavia no esta disponible el consumidor se registra cuando se conecte (y en cada reconexion)
+func (queue *Rabbit) StartConsumer(handler Handler) error {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	queue.handler = handler
+	if queue.channel == nil {
+		return nil
 	}
+	return queue.consumeUnsafe()
 }
 
-// Inicia el consumidor de la cola de RabbitMQ (El que carga los mensaje ya esta definido en la api de hoteles)
-func (queue Rabbit) StartConsumer(handler func(hotels.HotelNew)) error {
-	messages, err := queue.channel.Consume(
-		queue.queue.Name,
-		"",
-		true, // Auto-acknowledge messages
+// consumeUnsafe registra el consumidor en el canal actual (debe llamarse con mu bloqueado)
+func (queue *Rabbit) consumeUnsafe() error {
+	channel, handler := queue.channel, queue.handler
+	messages, err := channel.Consume(
+		queue.config.QueueName,
+		consumerTag,
+		false, // Ack manual, despues de procesar el mensaje
 		false,
 		false,
 		false,
@@ -59,32 +243,269 @@ func (queue Rabbit) StartConsumer(handler func(hotels.HotelNew)) error {
 	if err != nil {
 		return fmt.Errorf("error registering consumer: %w", err)
 	}
+	queue.setStateUnsafe(StateConsuming, nil)
 
 	//Una goroutine es una funcion que se ejecuta en paralelo con el resto del programa
+	//Termina sola cuando se cierra el canal, la reconexion registra un consumidor nuevo
+	consuming := make(chan struct{})
+	queue.consuming = consuming
 	go func() {
+		defer close(consuming)
 		//Hace un for para recorrer los mensajes que llegan a la cola
 		for msg := range messages {
-			var hotelUpdate hotels.HotelNew
-			//Unmarshal convierte el json en un objeto de tipo HotelNew
-			if err := json.Unmarshal(msg.Body, &hotelUpdate); err != nil {
-				log.Printf("error unmarshaling message: %v", err)
-				continue
-			}
-			handler(hotelUpdate)
+			queue.handleDelivery(channel, msg, handler)
 		}
 	}()
 
 	return nil
 }
 
-// Cierra la conexion a RabbitMQ
-func (queue Rabbit) Close() {
+// handleDelivery pasa el mensaje al handler y espera su resultado en segundo plano, para no frenar
+// los mensajes siguientes (el handler los junta en tandas)
+func (queue *Rabbit) handleDelivery(channel *amqp.Channel, msg amqp.Delivery, handler Handler) {
+	var hotelNew hotels.HotelNew
+	//Unmarshal convierte el json en un objeto de tipo HotelNew
+	if err := json.Unmarshal(msg.Body, &hotelNew); err != nil {
+		queue.settle(channel, msg, fmt.Errorf("%w: error unmarshaling message: %v", hotels.ErrInvalidEvent, err))
+		return
+	}
+
+	result := handler(hotelNew)
+	queue.inflight.Add(1)
+	go func() {
+		defer queue.inflight.Done()
+		queue.settle(channel, msg, <-result)
+	}()
+}
+
+// settle decide con el resultado del handler si confirmar el mensaje, reintentarlo o mandarlo a dead letters
+func (queue *Rabbit) settle(channel *amqp.Channel, msg amqp.Delivery, err error) {
+	if err == nil {
+		if ackErr := msg.Ack(false); ackErr != nil {
+			log.Printf("error acking message: %v", ackErr)
+		}
+		return
+	}
+
+	attempts := retryCount(msg.Headers) + 1
+	// Un evento invalido no se arregla reintentando, va directo a dead letters
+	if errors.Is(err, hotels.ErrInvalidEvent) || attempts > queue.config.MaxRetries {
+		log.Printf("[RabbitMQ] Mensaje enviado a dead letters tras %d intentos: %v", attempts, err)
+		err = republish(channel, deadLetterQueueName(queue.config.QueueName), msg, amqp.Table{
+			retryCountHeader: int32(attempts),
+			errorHeader:      err.Error(),
+			failedAtHeader:   time.Now().UTC().Format(time.RFC3339),
+		})
+	} else {
+		log.Printf("[RabbitMQ] Reintento %d/%d en %v: %v", attempts, queue.config.MaxRetries, queue.config.RetryDelay, err)
+		err = republish(channel, retryQueueName(queue.config.QueueName), msg, amqp.Table{
+			retryCountHeader: int32(attempts),
+		})
+	}
+
+	// Si no se pudo mover el mensaje, vuelve a la cola principal para no perderlo
+	if err != nil {
+		log.Printf("error moving failed message: %v", err)
+		if nackErr := msg.Nack(false, true); nackErr != nil {
+			log.Printf("error nacking message: %v", nackErr)
+		}
+		return
+	}
+	if ackErr := msg.Ack(false); ackErr != nil {
+		log.Printf("error acking message: %v", ackErr)
+	}
+}
+
+// republish publica el cuerpo del mensaje en otra cola con los headers indicados
+func republish(channel *amqp.Channel, queueName string, msg amqp.Delivery, headers amqp.Table) error {
+	return channel.Publish("", queueName, false, false, amqp.Publishing{
+		ContentType:  msg.ContentType,
+		DeliveryMode: amqp.Persistent,
+		Headers:      headers,
+		Body:         msg.Body,
+	})
+}
+
+// retryCount lee la cantidad de intentos fallidos del header del mensaje
+func retryCount(headers amqp.Table) int {
+	switch count := headers[retryCountHeader].(type) {
+	case int32:
+		return int(count)
+	case int64:
+		return int(count)
+	case int:
+		return count
+	}
+	return 0
+}
+
+// DeadLetters lista hasta limit mensajes de la cola de dead letters sin sacarlos de la cola
+func (queue *Rabbit) DeadLetters(limit int) ([]hotels.DeadLetter, error) {
+	deadLetters := make([]hotels.DeadLetter, 0)
+	err := queue.scanDeadLetters(limit, func(channel *amqp.Channel, msg amqp.Delivery) error {
+		deadLetters = append(deadLetters, toDeadLetter(msg))
+		return nil
+	})
+	return deadLetters, err
+}
+
+// ReplayDeadLetters vuelve a publicar en la cola principal hasta limit mensajes de dead letters (solo el evento indicado si eventID no esta vacio).
+// Los mensajes reenviados empiezan de nuevo con sus reintentos; devuelve cuantos se reenviaron
+func (queue *Rabbit) ReplayDeadLetters(eventID string, limit int) (int, error) {
+	replayed := 0
+	err := queue.scanDeadLetters(limit, func(channel *amqp.Channel, msg amqp.Delivery) error {
+		if eventID != "" && toDeadLetter(msg).Event.EventID != eventID {
+			return nil
+		}
+		if err := channel.Publish("", queue.config.QueueName, false, false, amqp.Publishing{
+			ContentType:  msg.ContentType,
+			DeliveryMode: amqp.Persistent,
+			Body:         msg.Body,
+		}); err != nil {
+			return fmt.Errorf("error replaying message: %w", err)
+		}
+		if err := msg.Ack(false); err != nil {
+			return fmt.Errorf("error acking replayed message: %w", err)
+		}
+		replayed++
+		return nil
+	})
+	return replayed, err
+}
+
+// scanDeadLetters recorre hasta limit mensajes de dead letters en un canal propio.
+// Los mensajes que visit no confirma vuelven a la cola cuando se cierra el canal
+func (queue *Rabbit) scanDeadLetters(limit int, visit func(channel *amqp.Channel, msg amqp.Delivery) error) error {
+	queue.mu.RLock()
+	connection := queue.connection
+	queue.mu.RUnlock()
+	if connection == nil {
+		return fmt.Errorf("RabbitMQ not connected")
+	}
+
+	channel, err := connection.Channel()
+	if err != nil {
+		return fmt.Errorf("error creating Rabbit channel: %w", err)
+	}
+	defer channel.Close()
+
+	for i := 0; i < limit; i++ {
+		msg, ok, err := channel.Get(deadLetterQueueName(queue.config.QueueName), false)
+		if err != nil {
+			return fmt.Errorf("error reading dead letters: %w", err)
+		}
+		if !ok {
+			return nil
+		}
+		if err := visit(channel, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toDeadLetter convierte un mensaje de la cola de dead letters al formato de dominio
+func toDeadLetter(msg amqp.Delivery) hotels.DeadLetter {
+	deadLetter := hotels.DeadLetter{
+		Body:     string(msg.Body),
+		Attempts: retryCount(msg.Headers),
+	}
+	if err := json.Unmarshal(msg.Body, &deadLetter.Event); err != nil {
+		deadLetter.Event = hotels.HotelNew{}
+	}
+	if lastError, ok := msg.Headers[errorHeader].(string); ok {
+		deadLetter.Error = lastError
+	}
+	if failedAt, ok := msg.Headers[failedAtHeader].(string); ok {
+		deadLetter.FailedAt, _ = time.Parse(time.RFC3339, failedAt)
+	}
+	return deadLetter
+}
+
+// Status devuelve el estado actual del consumidor
+func (queue *Rabbit) Status() ConsumerStatus {
+	queue.mu.RLock()
+	defer queue.mu.RUnlock()
+	return ConsumerStatus{
+		State:     queue.state,
+		Queue:     queue.config.QueueName,
+		LastError: queue.lastError,
+		Since:     queue.since,
+	}
+}
+
+// setState actualiza el estado del consumidor
+func (queue *Rabbit) setState(state string, err error) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	queue.setStateUnsafe(state, err)
+}
+
+// setStateUnsafe actualiza el estado del consumidor (debe llamarse con mu bloqueado)
+func (queue *Rabbit) setStateUnsafe(state string, err error) {
+	if state != queue.state {
+		queue.since = time.Now().UTC()
+	}
+	queue.state = state
+	if err != nil {
+		queue.lastError = err.Error()
+	} else if state != StateDisconnected {
+		queue.lastError = ""
+	}
+}
+
+// isClosed indica si se llamo a Close
+func (queue *Rabbit) isClosed() bool {
+	queue.mu.RLock()
+	defer queue.mu.RUnlock()
+	return queue.closed
+}
+
+// closeUnsafe cierra el canal y la conexion sin bloqueo (debe llamarse con mu bloqueado)
+func (queue *Rabbit) closeUnsafe() {
 	// Close cierra el canal de comunicacion
-	if err := queue.channel.Close(); err != nil {
-		log.Printf("error closing Rabbit channel: %v", err)
+	if queue.channel != nil {
+		if err := queue.channel.Close(); err != nil && err != amqp.ErrClosed {
+			log.Printf("error closing Rabbit channel: %v", err)
+		}
+		queue.channel = nil
 	}
 	// Close cierra la conexion a RabbitMQ
-	if err := queue.connection.Close(); err != nil {
-		log.Printf("error closing Rabbit connection: %v", err)
+	if queue.connection != nil {
+		if err := queue.connection.Close(); err != nil && err != amqp.ErrClosed {
+			log.Printf("error closing Rabbit connection: %v", err)
+		}
+		queue.connection = nil
 	}
 }
+
+// StopConsumer deja de recibir mensajes (tampoco se vuelve a registrar al reconectar) y espera a que
+// termine el loop del consumidor. Los mensajes ya recibidos se siguen confirmando hasta el Close
+func (queue *Rabbit) StopConsumer() error {
+	queue.mu.Lock()
+	queue.handler = nil
+	channel, consuming := queue.channel, queue.consuming
+	queue.consuming = nil
+	queue.mu.Unlock()
+
+	if channel == nil || consuming == nil {
+		return nil
+	}
+	if err := channel.Cancel(consumerTag, false); err != nil {
+		return fmt.Errorf("error canceling consumer: %w", err)
+	}
+	<-consuming
+	return nil
+}
+
+// Cierra la conexion a RabbitMQ y detiene la reconexion. Antes espera a que se confirmen los mensajes
+// que ya estaban en proceso (llamar primero a StopConsumer para que no lleguen mas)
+func (queue *Rabbit) Close() {
+	queue.inflight.Wait()
+
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	queue.closed = true
+	queue.closeUnsafe()
+	queue.setStateUnsafe(StateDisconnected, nil)
+}
@@ -0,0 +1,115 @@
+package queues
+
+import (
+	"testing"
+	"time"
+
+	"search-api/internal/domain/hotels"
+
+	"github.com/streadway/amqp"
+)
+
+func TestRetryCount(t *testing.T) {
+	if got := retryCount(nil); got != 0 {
+		t.Fatalf("expected 0 retries without headers, got %d", got)
+	}
+	if got := retryCount(amqp.Table{retryCountHeader: int32(3)}); got != 3 {
+		t.Fatalf("expected 3 retries, got %d", got)
+	}
+	if got := retryCount(amqp.Table{retryCountHeader: int64(4)}); got != 4 {
+		t.Fatalf("expected 4 retries, got %d", got)
+	}
+}
+
+func TestToDeadLetter(t *testing.T) {
+	failedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	msg := amqp.Delivery{
+		Body: []byte(`{"event_id":"evt-1","type":"UPDATE","hotel_id":"hotel1"}`),
+		Headers: amqp.Table{
+			retryCountHeader: int32(6),
+			errorHeader:      "solr down",
+			failedAtHeader:   failedAt.Format(time.RFC3339),
+		},
+	}
+
+	deadLetter := toDeadLetter(msg)
+	if deadLetter.Event.EventID != "evt-1" || deadLetter.Event.HotelID != "hotel1" {
+		t.Fatalf("unexpected event: %+v", deadLetter.Event)
+	}
+	if deadLetter.Attempts != 6 || deadLetter.Error != "solr down" || !deadLetter.FailedAt.Equal(failedAt) {
+		t.Fatalf("unexpected dead letter metadata: %+v", deadLetter)
+	}
+}
+
+func TestToDeadLetterInvalidBody(t *testing.T) {
+	deadLetter := toDeadLetter(amqp.Delivery{Body: []byte("not json")})
+	if deadLetter.Body != "not json" || deadLetter.Event.HotelID != "" {
+		t.Fatalf("expected raw body to be kept, got %+v", deadLetter)
+	}
+}
+
+func TestNewRabbit_StartsWithoutBroker(t *testing.T) {
+	// Puerto donde no hay ningun broker: no debe bloquear ni terminar el proceso
+	queue := NewRabbit(RabbitConfig{
+		Host:      "127.0.0.1",
+		Port:      "1",
+		Username:  "guest",
+		Password:  "guest",
+		QueueName: "hotels-news",
+	})
+	defer queue.Close()
+
+	if err := queue.StartConsumer(func(hotels.HotelNew) <-chan error { return nil }); err != nil {
+		t.Fatalf("expected consumer to be registered lazily, got %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for queue.Status().State != StateDisconnected && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	status := queue.Status()
+	if status.State != StateDisconnected {
+		t.Fatalf("expected state %q, got %q", StateDisconnected, status.State)
+	}
+	if status.LastError == "" {
+		t.Fatalf("expected last error to be reported")
+	}
+	if status.Queue != "hotels-news" {
+		t.Fatalf("expected queue hotels-news, got %s", status.Queue)
+	}
+
+	if _, err := queue.DeadLetters(10); err == nil {
+		t.Fatalf("expected error listing dead letters while disconnected")
+	}
+}
+
+func TestRabbit_CloseStopsReconnecting(t *testing.T) {
+	queue := NewRabbit(RabbitConfig{Host: "127.0.0.1", Port: "1", QueueName: "hotels-news"})
+	queue.Close()
+
+	if !queue.isClosed() {
+		t.Fatalf("expected client to be closed")
+	}
+	if state := queue.Status().State; state != StateDisconnected {
+		t.Fatalf("expected state %q after close, got %q", StateDisconnected, state)
+	}
+}
+
+func TestRabbit_StopConsumerWithoutBroker(t *testing.T) {
+	queue := NewRabbit(RabbitConfig{Host: "127.0.0.1", Port: "1", QueueName: "hotels-news"})
+	defer queue.Close()
+
+	if err := queue.StartConsumer(func(hotels.HotelNew) <-chan error { return nil }); err != nil {
+		t.Fatalf("expected consumer to be registered lazily, got %v", err)
+	}
+	if err := queue.StopConsumer(); err != nil {
+		t.Fatalf("expected no error stopping a consumer that never connected, got %v", err)
+	}
+
+	// Sin handler no se vuelve a registrar el consumidor al conectar
+	queue.mu.RLock()
+	defer queue.mu.RUnlock()
+	if queue.handler != nil {
+		t.Fatalf("expected handler to be cleared")
+	}
+}
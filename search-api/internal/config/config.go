@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"time"
 )
 
 var (
@@ -9,6 +11,13 @@ var (
 	SolrHost       = getEnv("SOLR_HOST", "solr")
 	SolrPort       = getEnv("SOLR_PORT", "8983")
 	SolrCollection = getEnv("SOLR_COLLECTION", "hotels")
+	SolrConfigSet  = getEnv("SOLR_CONFIGSET", "hotels")
+
+	// Escrituras en Solr: los eventos se mandan en tandas de hasta SOLR_BATCH_SIZE cambios o cada SOLR_BATCH_WAIT,
+	// y Solr los hace visibles con un soft commit dentro de SOLR_COMMIT_WITHIN
+	SolrBatchSize    = getIntEnv("SOLR_BATCH_SIZE", 100)
+	SolrBatchWait    = getDurationEnv("SOLR_BATCH_WAIT", 500*time.Millisecond)
+	SolrCommitWithin = getDurationEnv("SOLR_COMMIT_WITHIN", time.Second)
 
 	// RabbitMQ
 	RabbitHost      = getEnv("RABBIT_HOST", "rabbitmq")
@@ -17,12 +26,35 @@ var (
 	RabbitPassword  = getEnv("RABBIT_PASSWORD", "root")
 	RabbitQueueName = getEnv("RABBIT_QUEUE_NAME", "hotels-news")
 
+	// Reintentos del consumidor: cuantas veces se reintenta un evento y cuanto espera entre intentos
+	RabbitMaxRetries = getIntEnv("RABBIT_MAX_RETRIES", 5)
+	RabbitRetryDelay = getDurationEnv("RABBIT_RETRY_DELAY", 10*time.Second)
+
+	// Mensajes sin confirmar que recibe el consumidor (mas que una tanda de Solr, para armar la siguiente mientras se manda una)
+	RabbitPrefetch = getIntEnv("RABBIT_PREFETCH", 200)
+
 	// Hotels API
 	HotelsAPIHost = getEnv("HOTELS_API_HOST", "hotels-api")
 	HotelsAPIPort = getEnv("HOTELS_API_PORT", "8081")
 
+	// Tiempo maximo que una busqueda con fechas espera por la disponibilidad de hotels-api
+	AvailabilityBudget = getDurationEnv("AVAILABILITY_BUDGET", 2*time.Second)
+
+	// Reindex: hoteles por pagina de hotels-api y espera antes de reintentar una pagina
+	ReindexPageSize  = getIntEnv("REINDEX_PAGE_SIZE", 200)
+	ReindexRetryWait = getDurationEnv("REINDEX_RETRY_WAIT", 2*time.Second)
+
+	// Cache de sugerencias de autocompletado: cantidad de prefijos guardados y cuanto duran
+	SuggestCacheSize = getIntEnv("SUGGEST_CACHE_SIZE", 1000)
+	SuggestCacheTTL  = getDurationEnv("SUGGEST_CACHE_TTL", time.Minute)
+
+	// JWT - debe coincidir con users-api
+	JWTSecret = getEnv("JWT_SECRET", "your-secret-key-change-in-production")
+
 	// Server
 	Port = getEnv("PORT", "8082")
+	// Tiempo maximo para terminar los requests y mandar la tanda pendiente a Solr al apagar
+	ShutdownTimeout = getDurationEnv("SHUTDOWN_TIMEOUT", 10*time.Second)
 )
 
 func getEnv(key, defaultValue string) string {
@@ -31,3 +63,21 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getIntEnv(key string, defaultValue int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(key); exists {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
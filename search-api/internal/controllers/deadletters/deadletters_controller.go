@@ -0,0 +1,91 @@
+package deadletters
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	hotelsDomain "search-api/internal/domain/hotels"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Cantidad de mensajes que se leen de dead letters si no se indica limit
+const defaultLimit = 50
+
+// Funciones de la cola de dead letters del consumidor de RabbitMQ
+type Queue interface {
+	DeadLetters(limit int) ([]hotelsDomain.DeadLetter, error)
+	ReplayDeadLetters(eventID string, limit int) (int, error)
+}
+
+type Controller struct {
+	queue Queue
+}
+
+func NewController(queue Queue) Controller {
+	return Controller{
+		queue: queue,
+	}
+}
+
+// Funcion para listar los eventos que agotaron sus reintentos
+func (controller Controller) List(c *gin.Context) {
+	limit, err := parseLimit(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", err),
+		})
+		return
+	}
+
+	deadLetters, err := controller.queue.DeadLetters(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("error getting dead letters: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, deadLetters)
+}
+
+// Funcion para reenviar eventos de dead letters a la cola principal (todos o solo ?event_id=...)
+func (controller Controller) Replay(c *gin.Context) {
+	limit, err := parseLimit(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", err),
+		})
+		return
+	}
+
+	replayed, err := controller.queue.ReplayDeadLetters(c.Query("event_id"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":    fmt.Sprintf("error replaying dead letters: %s", err.Error()),
+			"replayed": replayed,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"replayed": replayed,
+	})
+}
+
+// parseLimit lee el parametro limit (opcional, positivo)
+func parseLimit(c *gin.Context) (int, error) {
+	value := c.Query("limit")
+	if value == "" {
+		return defaultLimit, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if limit <= 0 {
+		return 0, fmt.Errorf("limit must be positive")
+	}
+	return limit, nil
+}
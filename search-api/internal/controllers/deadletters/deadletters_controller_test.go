@@ -0,0 +1,125 @@
+package deadletters_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	controllers "search-api/internal/controllers/deadletters"
+	hotelsDomain "search-api/internal/domain/hotels"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// mockQueue implementa la interfaz Queue del controller para testing.
+type mockQueue struct {
+	mock.Mock
+}
+
+func (m *mockQueue) DeadLetters(limit int) ([]hotelsDomain.DeadLetter, error) {
+	args := m.Called(limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]hotelsDomain.DeadLetter), args.Error(1)
+}
+
+func (m *mockQueue) ReplayDeadLetters(eventID string, limit int) (int, error) {
+	args := m.Called(eventID, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func setupRouter(queue *mockQueue) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	controller := controllers.NewController(queue)
+	router.GET("/admin/dead-letters", controller.List)
+	router.POST("/admin/dead-letters/replay", controller.Replay)
+
+	return router
+}
+
+func TestController_List(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		queue := &mockQueue{}
+		router := setupRouter(queue)
+
+		queue.On("DeadLetters", 10).Return([]hotelsDomain.DeadLetter{
+			{Event: hotelsDomain.HotelNew{EventID: "evt-1", Type: "UPDATE", HotelID: "hotel1"}, Error: "solr down", Attempts: 6},
+		}, nil).Once()
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/admin/dead-letters?limit=10", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response []hotelsDomain.DeadLetter
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Len(t, response, 1)
+		assert.Equal(t, "evt-1", response[0].Event.EventID)
+		assert.Equal(t, 6, response[0].Attempts)
+		queue.AssertExpectations(t)
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		queue := &mockQueue{}
+		router := setupRouter(queue)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/admin/dead-letters?limit=0", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		queue.AssertNotCalled(t, "DeadLetters", mock.Anything)
+	})
+
+	t.Run("queue error", func(t *testing.T) {
+		queue := &mockQueue{}
+		router := setupRouter(queue)
+
+		queue.On("DeadLetters", 50).Return(nil, errors.New("rabbit down")).Once()
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/admin/dead-letters", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		queue.AssertExpectations(t)
+	})
+}
+
+func TestController_Replay(t *testing.T) {
+	t.Run("replay one event", func(t *testing.T) {
+		queue := &mockQueue{}
+		router := setupRouter(queue)
+
+		queue.On("ReplayDeadLetters", "evt-1", 50).Return(1, nil).Once()
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/admin/dead-letters/replay?event_id=evt-1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"replayed":1}`, w.Body.String())
+		queue.AssertExpectations(t)
+	})
+
+	t.Run("queue error", func(t *testing.T) {
+		queue := &mockQueue{}
+		router := setupRouter(queue)
+
+		queue.On("ReplayDeadLetters", "", 5).Return(2, errors.New("publish failed")).Once()
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/admin/dead-letters/replay?limit=5", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		queue.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,52 @@
+package reindex
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	hotelsDomain "search-api/internal/domain/hotels"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Funciones del reindexer que reconstruye el indice de Solr
+type Reindexer interface {
+	Start() (hotelsDomain.ReindexStatus, error)
+	Status() hotelsDomain.ReindexStatus
+}
+
+type Controller struct {
+	reindexer Reindexer
+}
+
+func NewController(reindexer Reindexer) Controller {
+	return Controller{
+		reindexer: reindexer,
+	}
+}
+
+// Funcion para arrancar un reindex completo en segundo plano (el progreso se consulta con Status)
+func (controller Controller) Start(c *gin.Context) {
+	status, err := controller.reindexer.Start()
+	if errors.Is(err, hotelsDomain.ErrReindexRunning) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":  fmt.Sprintf("error starting reindex: %s", err.Error()),
+			"status": status,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("error starting reindex: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, status)
+}
+
+// Funcion para ver el progreso del reindex en curso o el resultado del ultimo
+func (controller Controller) Status(c *gin.Context) {
+	c.JSON(http.StatusOK, controller.reindexer.Status())
+}
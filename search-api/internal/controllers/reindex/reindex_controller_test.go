@@ -0,0 +1,89 @@
+package reindex_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	controllers "search-api/internal/controllers/reindex"
+	hotelsDomain "search-api/internal/domain/hotels"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// mockReindexer implementa la interfaz Reindexer del controller para testing.
+type mockReindexer struct {
+	mock.Mock
+}
+
+func (m *mockReindexer) Start() (hotelsDomain.ReindexStatus, error) {
+	args := m.Called()
+	return args.Get(0).(hotelsDomain.ReindexStatus), args.Error(1)
+}
+
+func (m *mockReindexer) Status() hotelsDomain.ReindexStatus {
+	args := m.Called()
+	return args.Get(0).(hotelsDomain.ReindexStatus)
+}
+
+func setupRouter(reindexer *mockReindexer) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	controller := controllers.NewController(reindexer)
+	router.GET("/admin/reindex", controller.Status)
+	router.POST("/admin/reindex", controller.Start)
+
+	return router
+}
+
+func TestController_Start(t *testing.T) {
+	t.Run("accepted", func(t *testing.T) {
+		reindexer := &mockReindexer{}
+		router := setupRouter(reindexer)
+
+		reindexer.On("Start").Return(hotelsDomain.ReindexStatus{State: hotelsDomain.ReindexRunning}, nil).Once()
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/admin/reindex", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		var status hotelsDomain.ReindexStatus
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+		assert.Equal(t, hotelsDomain.ReindexRunning, status.State)
+		reindexer.AssertExpectations(t)
+	})
+
+	t.Run("already running -> 409", func(t *testing.T) {
+		reindexer := &mockReindexer{}
+		router := setupRouter(reindexer)
+
+		reindexer.On("Start").Return(hotelsDomain.ReindexStatus{State: hotelsDomain.ReindexRunning, Indexed: 400}, hotelsDomain.ErrReindexRunning).Once()
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/admin/reindex", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), `"indexed":400`)
+	})
+}
+
+func TestController_Status(t *testing.T) {
+	reindexer := &mockReindexer{}
+	router := setupRouter(reindexer)
+
+	reindexer.On("Status").Return(hotelsDomain.ReindexStatus{State: hotelsDomain.ReindexFailed, Error: "hotels-api down"}).Once()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/admin/reindex", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"state":"failed"`)
+	assert.Contains(t, w.Body.String(), `"error":"hotels-api down"`)
+}
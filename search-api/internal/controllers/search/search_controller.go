@@ -3,8 +3,11 @@ package search
 import (
 	"context"
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	hotelsDomain "search-api/internal/domain/hotels"
 
@@ -12,9 +15,16 @@ import (
 )
 
 type Service interface {
-	Search(ctx context.Context, query string, offset int, limit int) ([]hotelsDomain.Hotel, error)
+	Search(ctx context.Context, query string, filters hotelsDomain.SearchFilters, sort string, offset int, limit int, cursor string) (hotelsDomain.SearchResponse, error)
+	Suggest(ctx context.Context, prefix string, limit int) ([]hotelsDomain.Suggestion, error)
 }
 
+// Cantidad de sugerencias por defecto y maxima de GET /search/suggest
+const (
+	defaultSuggestLimit = 8
+	maxSuggestLimit     = 20
+)
+
 type Controller struct {
 	service Service
 }
@@ -25,17 +35,29 @@ func NewController(service Service) Controller {
 	}
 }
 
-
 // Funcion para buscar hoteles en Solr
 func (controller Controller) Search(c *gin.Context) {
 	// Saca el query de la URL
 	query := c.Query("q")
 
-	// Saca el offset de la URL
-	offset, err := strconv.Atoi(c.Query("offset"))
-	if err != nil {
+	// Saca el cursor de la URL ("*" para empezar a paginar con cursorMark, despues el next_cursor de la respuesta)
+	cursor := c.Query("cursor")
+
+	// Saca el offset de la URL (con cursor es opcional y solo puede ser 0)
+	offset := 0
+	var err error
+	if rawOffset, ok := c.GetQuery("offset"); ok || cursor == "" {
+		offset, err = strconv.Atoi(rawOffset)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("invalid request: %s", err),
+			})
+			return
+		}
+	}
+	if offset < 0 || (cursor != "" && offset != 0) {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Sprintf("invalid request: %s", err),
+			"error": "invalid request: offset must be non-negative and cannot be combined with cursor",
 		})
 		return
 	}
@@ -48,9 +70,41 @@ func (controller Controller) Search(c *gin.Context) {
 		})
 		return
 	}
+	if limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: limit must be non-negative",
+		})
+		return
+	}
+
+	// Saca los filtros de la URL
+	filters, err := parseFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", err),
+		})
+		return
+	}
+
+	// Con fechas se pagina por offset (la disponibilidad se revisa por tandas y no se puede seguir un cursorMark)
+	if cursor != "" && filters.HasDates() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: cursor cannot be combined with check_in/check_out",
+		})
+		return
+	}
+
+	// Saca el orden de la URL
+	sort, err := parseSort(c.Query("sort"), filters)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", err),
+		})
+		return
+	}
 
 	// Llama a la funcion de busqueda de hoteles del servicio
-	hotels, err := controller.service.Search(c.Request.Context(), query, offset, limit)
+	response, err := controller.service.Search(c.Request.Context(), query, filters, sort, offset, limit, cursor)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("error searching hotels: %s", err.Error()),
@@ -58,6 +112,179 @@ func (controller Controller) Search(c *gin.Context) {
 		return
 	}
 
-	// Devuelve los hoteles encontrados
-	c.JSON(http.StatusOK, hotels)
+	// Modo de compatibilidad: format=array devuelve solo la lista de hoteles, como antes del envelope
+	if c.Query("format") == "array" {
+		c.JSON(http.StatusOK, response.Results)
+		return
+	}
+
+	// Devuelve los hoteles encontrados con el total, los facets y la paginacion
+	c.JSON(http.StatusOK, response)
+}
+
+// Funcion para sugerir ciudades y nombres de hoteles mientras el usuario escribe
+func (controller Controller) Suggest(c *gin.Context) {
+	// Saca el limit de la URL (opcional)
+	limit := defaultSuggestLimit
+	if rawLimit := c.Query("limit"); rawLimit != "" {
+		var err error
+		limit, err = strconv.Atoi(rawLimit)
+		if err != nil || limit < 1 || limit > maxSuggestLimit {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("invalid request: limit must be between 1 and %d", maxSuggestLimit),
+			})
+			return
+		}
+	}
+
+	// Llama a la funcion de sugerencias del servicio (sin q devuelve una lista vacia)
+	suggestions, err := controller.service.Suggest(c.Request.Context(), c.Query("q"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("error getting suggestions: %s", err.Error()),
+		})
+		return
+	}
+
+	// Devuelve las sugerencias ordenadas
+	c.JSON(http.StatusOK, gin.H{
+		"suggestions": suggestions,
+	})
+}
+
+// parseFilters lee los filtros opcionales de la URL:
+// city, country, min_price, max_price, min_rating, amenities (repetido o separado por comas), rooms, guests, lat/lng/radius_km y check_in/check_out
+func parseFilters(c *gin.Context) (hotelsDomain.SearchFilters, error) {
+	filters := hotelsDomain.SearchFilters{
+		City:    strings.TrimSpace(c.Query("city")),
+		Country: strings.TrimSpace(c.Query("country")),
+	}
+
+	var err error
+	if filters.MinPrice, err = parseOptionalFloat(c, "min_price"); err != nil {
+		return filters, err
+	}
+	if filters.MaxPrice, err = parseOptionalFloat(c, "max_price"); err != nil {
+		return filters, err
+	}
+	if filters.MinPrice != nil && filters.MaxPrice != nil && *filters.MinPrice > *filters.MaxPrice {
+		return filters, fmt.Errorf("min_price must be less than or equal to max_price")
+	}
+	if filters.MinRating, err = parseOptionalFloat(c, "min_rating"); err != nil {
+		return filters, err
+	}
+
+	for _, value := range c.QueryArray("amenities") {
+		for _, amenity := range strings.Split(value, ",") {
+			if amenity = strings.TrimSpace(amenity); amenity != "" {
+				filters.Amenities = append(filters.Amenities, amenity)
+			}
+		}
+	}
+
+	if rooms := c.Query("rooms"); rooms != "" {
+		filters.Rooms, err = strconv.Atoi(rooms)
+		if err != nil || filters.Rooms < 0 {
+			return filters, fmt.Errorf("rooms must be a non-negative integer")
+		}
+	}
+
+	if guests := c.Query("guests"); guests != "" {
+		filters.Guests, err = strconv.Atoi(guests)
+		if err != nil || filters.Guests < 1 {
+			return filters, fmt.Errorf("guests must be a positive integer")
+		}
+	}
+
+	if err := parseLocation(c, &filters); err != nil {
+		return filters, err
+	}
+
+	if err := parseDates(c, &filters); err != nil {
+		return filters, err
+	}
+
+	return filters, nil
+}
+
+// parseOptionalFloat lee un parametro numerico no negativo, nil si no viene
+func parseOptionalFloat(c *gin.Context, name string) (*float64, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || value < 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, fmt.Errorf("%s must be a non-negative number", name)
+	}
+	return &value, nil
+}
+
+// parseLocation lee el punto (lat y lng, juntos) y el radio en km de la busqueda por cercania
+func parseLocation(c *gin.Context, filters *hotelsDomain.SearchFilters) error {
+	rawLatitude, rawLongitude := c.Query("lat"), c.Query("lng")
+	if rawLatitude != "" || rawLongitude != "" {
+		latitude, err := strconv.ParseFloat(rawLatitude, 64)
+		if err != nil || latitude < -90 || latitude > 90 {
+			return fmt.Errorf("lat must be a number within [-90, 90] and lng is required with it")
+		}
+		longitude, err := strconv.ParseFloat(rawLongitude, 64)
+		if err != nil || longitude < -180 || longitude > 180 {
+			return fmt.Errorf("lng must be a number within [-180, 180] and lat is required with it")
+		}
+		filters.Latitude, filters.Longitude = &latitude, &longitude
+	}
+
+	radius, err := parseOptionalFloat(c, "radius_km")
+	if err != nil {
+		return err
+	}
+	if radius != nil {
+		if !filters.HasLocation() {
+			return fmt.Errorf("radius_km requires lat and lng")
+		}
+		if *radius <= 0 {
+			return fmt.Errorf("radius_km must be greater than 0")
+		}
+		filters.RadiusKm = radius
+	}
+	return nil
+}
+
+// parseDates lee las fechas de la estadia (check_in y check_out, juntas y en formato YYYY-MM-DD)
+func parseDates(c *gin.Context, filters *hotelsDomain.SearchFilters) error {
+	rawCheckIn, rawCheckOut := c.Query("check_in"), c.Query("check_out")
+	if rawCheckIn == "" && rawCheckOut == "" {
+		return nil
+	}
+	checkIn, err := time.Parse("2006-01-02", rawCheckIn)
+	if err != nil {
+		return fmt.Errorf("check_in must be a YYYY-MM-DD date and check_out is required with it")
+	}
+	checkOut, err := time.Parse("2006-01-02", rawCheckOut)
+	if err != nil {
+		return fmt.Errorf("check_out must be a YYYY-MM-DD date and check_in is required with it")
+	}
+	if !checkOut.After(checkIn) {
+		return fmt.Errorf("check_out must be after check_in")
+	}
+	filters.CheckIn, filters.CheckOut = rawCheckIn, rawCheckOut
+	return nil
+}
+
+// parseSort valida el orden contra los aceptados (vacio = relevancia)
+func parseSort(sort string, filters hotelsDomain.SearchFilters) (string, error) {
+	switch sort {
+	case "", hotelsDomain.SortRelevance:
+		return hotelsDomain.SortRelevance, nil
+	case hotelsDomain.SortPriceAsc, hotelsDomain.SortPriceDesc, hotelsDomain.SortRatingDesc, hotelsDomain.SortNameAsc:
+		return sort, nil
+	case hotelsDomain.SortDistance:
+		if !filters.HasLocation() {
+			return "", fmt.Errorf("sort %s requires lat and lng", sort)
+		}
+		return sort, nil
+	default:
+		return "", fmt.Errorf("sort must be one of relevance, price_asc, price_desc, rating_desc, name_asc, distance")
+	}
 }
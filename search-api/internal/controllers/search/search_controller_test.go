@@ -6,6 +6,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	controllers "search-api/internal/controllers/search"
@@ -21,12 +22,20 @@ type mockService struct {
 	mock.Mock
 }
 
-func (m *mockService) Search(ctx context.Context, query string, offset int, limit int) ([]hotelsDomain.Hotel, error) {
-	args := m.Called(ctx, query, offset, limit)
+func (m *mockService) Search(ctx context.Context, query string, filters hotelsDomain.SearchFilters, sort string, offset int, limit int, cursor string) (hotelsDomain.SearchResponse, error) {
+	args := m.Called(ctx, query, filters, sort, offset, limit, cursor)
+	if args.Get(0) == nil {
+		return hotelsDomain.SearchResponse{}, args.Error(1)
+	}
+	return args.Get(0).(hotelsDomain.SearchResponse), args.Error(1)
+}
+
+func (m *mockService) Suggest(ctx context.Context, prefix string, limit int) ([]hotelsDomain.Suggestion, error) {
+	args := m.Called(ctx, prefix, limit)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]hotelsDomain.Hotel), args.Error(1)
+	return args.Get(0).([]hotelsDomain.Suggestion), args.Error(1)
 }
 
 func setupRouter(svc *mockService) *gin.Engine {
@@ -37,6 +46,7 @@ func setupRouter(svc *mockService) *gin.Engine {
 	controller := controllers.NewController(svc)
 
 	router.GET("/search", controller.Search)
+	router.GET("/search/suggest", controller.Suggest)
 
 	return router
 }
@@ -70,7 +80,7 @@ func TestController_Search(t *testing.T) {
 			},
 		}
 
-		svc.On("Search", mock.Anything, "paradise", 0, 10).Return(mockHotels, nil).Once()
+		svc.On("Search", mock.Anything, "paradise", hotelsDomain.SearchFilters{}, "relevance", 0, 10, "").Return(hotelsDomain.SearchResponse{Results: mockHotels, Total: len(mockHotels)}, nil).Once()
 
 		req := httptest.NewRequest(http.MethodGet, "/search?q=paradise&offset=0&limit=10", nil)
 		rr := httptest.NewRecorder()
@@ -78,13 +88,13 @@ func TestController_Search(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, rr.Code)
 
-		var got []hotelsDomain.Hotel
+		var got hotelsDomain.SearchResponse
 		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
-		assert.Len(t, got, 2)
-		assert.Equal(t, "hotel1", got[0].ID)
-		assert.Equal(t, "Hotel Paradise", got[0].Name)
-		assert.Equal(t, 4.5, got[0].Rating)
-		assert.Equal(t, "Hotel Sunset", got[1].Name)
+		assert.Len(t, got.Results, 2)
+		assert.Equal(t, "hotel1", got.Results[0].ID)
+		assert.Equal(t, "Hotel Paradise", got.Results[0].Name)
+		assert.Equal(t, 4.5, got.Results[0].Rating)
+		assert.Equal(t, "Hotel Sunset", got.Results[1].Name)
 
 		svc.AssertExpectations(t)
 	})
@@ -93,7 +103,7 @@ func TestController_Search(t *testing.T) {
 		svc := &mockService{}
 		router := setupRouter(svc)
 
-		svc.On("Search", mock.Anything, "nonexistent", 0, 10).Return([]hotelsDomain.Hotel{}, nil).Once()
+		svc.On("Search", mock.Anything, "nonexistent", hotelsDomain.SearchFilters{}, "relevance", 0, 10, "").Return(hotelsDomain.SearchResponse{Results: []hotelsDomain.Hotel{}}, nil).Once()
 
 		req := httptest.NewRequest(http.MethodGet, "/search?q=nonexistent&offset=0&limit=10", nil)
 		rr := httptest.NewRecorder()
@@ -101,9 +111,9 @@ func TestController_Search(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, rr.Code)
 
-		var got []hotelsDomain.Hotel
+		var got hotelsDomain.SearchResponse
 		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
-		assert.Empty(t, got)
+		assert.Empty(t, got.Results)
 
 		svc.AssertExpectations(t)
 	})
@@ -122,7 +132,7 @@ func TestController_Search(t *testing.T) {
 		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
 		assert.Contains(t, got["error"], "invalid request")
 
-		svc.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		svc.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 	})
 
 	t.Run("missing limit -> 400", func(t *testing.T) {
@@ -139,7 +149,7 @@ func TestController_Search(t *testing.T) {
 		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
 		assert.Contains(t, got["error"], "invalid request")
 
-		svc.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		svc.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 	})
 
 	t.Run("invalid offset -> 400", func(t *testing.T) {
@@ -156,7 +166,7 @@ func TestController_Search(t *testing.T) {
 		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
 		assert.Contains(t, got["error"], "invalid request")
 
-		svc.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		svc.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 	})
 
 	t.Run("invalid limit -> 400", func(t *testing.T) {
@@ -173,14 +183,14 @@ func TestController_Search(t *testing.T) {
 		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
 		assert.Contains(t, got["error"], "invalid request")
 
-		svc.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		svc.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 	})
 
 	t.Run("service error -> 500", func(t *testing.T) {
 		svc := &mockService{}
 		router := setupRouter(svc)
 
-		svc.On("Search", mock.Anything, "test", 0, 10).Return(nil, errors.New("solr connection error")).Once()
+		svc.On("Search", mock.Anything, "test", hotelsDomain.SearchFilters{}, "relevance", 0, 10, "").Return(nil, errors.New("solr connection error")).Once()
 
 		req := httptest.NewRequest(http.MethodGet, "/search?q=test&offset=0&limit=10", nil)
 		rr := httptest.NewRecorder()
@@ -203,7 +213,7 @@ func TestController_Search(t *testing.T) {
 			{ID: "hotel3", Name: "Paginated Hotel"},
 		}
 
-		svc.On("Search", mock.Anything, "hotel", 20, 5).Return(mockHotels, nil).Once()
+		svc.On("Search", mock.Anything, "hotel", hotelsDomain.SearchFilters{}, "relevance", 20, 5, "").Return(hotelsDomain.SearchResponse{Results: mockHotels, Total: len(mockHotels)}, nil).Once()
 
 		req := httptest.NewRequest(http.MethodGet, "/search?q=hotel&offset=20&limit=5", nil)
 		rr := httptest.NewRecorder()
@@ -211,10 +221,10 @@ func TestController_Search(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, rr.Code)
 
-		var got []hotelsDomain.Hotel
+		var got hotelsDomain.SearchResponse
 		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
-		assert.Len(t, got, 1)
-		assert.Equal(t, "hotel3", got[0].ID)
+		assert.Len(t, got.Results, 1)
+		assert.Equal(t, "hotel3", got.Results[0].ID)
 
 		svc.AssertExpectations(t)
 	})
@@ -228,7 +238,7 @@ func TestController_Search(t *testing.T) {
 			{ID: "hotel2", Name: "Hotel Two"},
 		}
 
-		svc.On("Search", mock.Anything, "", 0, 10).Return(mockHotels, nil).Once()
+		svc.On("Search", mock.Anything, "", hotelsDomain.SearchFilters{}, "relevance", 0, 10, "").Return(hotelsDomain.SearchResponse{Results: mockHotels, Total: len(mockHotels)}, nil).Once()
 
 		req := httptest.NewRequest(http.MethodGet, "/search?q=&offset=0&limit=10", nil)
 		rr := httptest.NewRecorder()
@@ -236,9 +246,9 @@ func TestController_Search(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, rr.Code)
 
-		var got []hotelsDomain.Hotel
+		var got hotelsDomain.SearchResponse
 		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
-		assert.Len(t, got, 2)
+		assert.Len(t, got.Results, 2)
 
 		svc.AssertExpectations(t)
 	})
@@ -251,7 +261,7 @@ func TestController_Search(t *testing.T) {
 			{ID: "hotel1", Name: "Hotel & Spa"},
 		}
 
-		svc.On("Search", mock.Anything, "hotel & spa", 0, 10).Return(mockHotels, nil).Once()
+		svc.On("Search", mock.Anything, "hotel & spa", hotelsDomain.SearchFilters{}, "relevance", 0, 10, "").Return(hotelsDomain.SearchResponse{Results: mockHotels, Total: len(mockHotels)}, nil).Once()
 
 		req := httptest.NewRequest(http.MethodGet, "/search?q=hotel+%26+spa&offset=0&limit=10", nil)
 		rr := httptest.NewRecorder()
@@ -259,11 +269,332 @@ func TestController_Search(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, rr.Code)
 
+		var got hotelsDomain.SearchResponse
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+		assert.Len(t, got.Results, 1)
+		assert.Equal(t, "Hotel & Spa", got.Results[0].Name)
+
+		svc.AssertExpectations(t)
+	})
+}
+
+func TestController_Search_Filters(t *testing.T) {
+	t.Run("filters are parsed", func(t *testing.T) {
+		svc := &mockService{}
+		router := setupRouter(svc)
+
+		minPrice, maxPrice, minRating := 50.0, 200.0, 4.0
+		expected := hotelsDomain.SearchFilters{
+			City:      "Buenos Aires",
+			Country:   "Argentina",
+			MinPrice:  &minPrice,
+			MaxPrice:  &maxPrice,
+			MinRating: &minRating,
+			Amenities: []string{"wifi", "pool", "spa"},
+			Rooms:     2,
+			Guests:    3,
+		}
+		svc.On("Search", mock.Anything, "hotel", expected, "relevance", 0, 10, "").Return(hotelsDomain.SearchResponse{Results: []hotelsDomain.Hotel{}}, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/search?q=hotel&offset=0&limit=10&city=Buenos+Aires&country=Argentina&min_price=50&max_price=200&min_rating=4&amenities=wifi,pool&amenities=spa&rooms=2&guests=3", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		svc.AssertExpectations(t)
+	})
+
+	invalid := map[string]string{
+		"invalid min_price":     "min_price=abc",
+		"negative max_price":    "max_price=-1",
+		"min_price > max_price": "min_price=300&max_price=100",
+		"invalid min_rating":    "min_rating=NaN",
+		"invalid rooms":         "rooms=two",
+		"invalid guests":        "guests=many",
+		"zero guests":           "guests=0",
+	}
+	for name, params := range invalid {
+		t.Run(name+" -> 400", func(t *testing.T) {
+			svc := &mockService{}
+			router := setupRouter(svc)
+
+			req := httptest.NewRequest(http.MethodGet, "/search?q=test&offset=0&limit=10&"+params, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+			var got map[string]string
+			assert.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+			assert.Contains(t, got["error"], "invalid request")
+
+			svc.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestController_Search_Pagination(t *testing.T) {
+	t.Run("array compatibility mode", func(t *testing.T) {
+		svc := &mockService{}
+		router := setupRouter(svc)
+
+		svc.On("Search", mock.Anything, "hotel", hotelsDomain.SearchFilters{}, "relevance", 0, 10, "").Return(hotelsDomain.SearchResponse{
+			Results: []hotelsDomain.Hotel{{ID: "hotel1"}, {ID: "hotel2"}},
+			Total:   2,
+		}, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/search?q=hotel&offset=0&limit=10&format=array", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
 		var got []hotelsDomain.Hotel
 		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
-		assert.Len(t, got, 1)
-		assert.Equal(t, "Hotel & Spa", got[0].Name)
+		assert.Len(t, got, 2)
+		svc.AssertExpectations(t)
+	})
+
+	t.Run("envelope includes pagination", func(t *testing.T) {
+		svc := &mockService{}
+		router := setupRouter(svc)
+
+		next := 20
+		svc.On("Search", mock.Anything, "hotel", hotelsDomain.SearchFilters{}, "relevance", 10, 10, "").Return(hotelsDomain.SearchResponse{
+			Results:    []hotelsDomain.Hotel{},
+			Total:      35,
+			Offset:     10,
+			Limit:      10,
+			NextOffset: &next,
+		}, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/search?q=hotel&offset=10&limit=10", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var got map[string]interface{}
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&got))
+		assert.Equal(t, float64(35), got["total"])
+		assert.Equal(t, float64(20), got["next_offset"])
+		assert.Contains(t, got, "prev_offset")
+		assert.Nil(t, got["prev_offset"])
+		svc.AssertExpectations(t)
+	})
+
+	t.Run("cursor without offset", func(t *testing.T) {
+		svc := &mockService{}
+		router := setupRouter(svc)
+
+		svc.On("Search", mock.Anything, "hotel", hotelsDomain.SearchFilters{}, "relevance", 0, 50, "AoE").Return(hotelsDomain.SearchResponse{
+			Results: []hotelsDomain.Hotel{},
+			Cursor:  "AoE",
+		}, nil).Once()
 
+		req := httptest.NewRequest(http.MethodGet, "/search?q=hotel&limit=50&cursor=AoE", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
 		svc.AssertExpectations(t)
 	})
+
+	invalid := map[string]string{
+		"cursor with offset": "offset=10&limit=10&cursor=*",
+		"negative offset":    "offset=-10&limit=10",
+		"negative limit":     "offset=0&limit=-1",
+	}
+	for name, params := range invalid {
+		t.Run(name+" -> 400", func(t *testing.T) {
+			svc := &mockService{}
+			router := setupRouter(svc)
+
+			req := httptest.NewRequest(http.MethodGet, "/search?q=test&"+params, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			svc.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestController_Search_Sort(t *testing.T) {
+	for _, sort := range []string{"relevance", "price_asc", "price_desc", "rating_desc", "name_asc"} {
+		t.Run(sort, func(t *testing.T) {
+			svc := &mockService{}
+			router := setupRouter(svc)
+
+			svc.On("Search", mock.Anything, "hotel", hotelsDomain.SearchFilters{}, sort, 0, 10, "").Return(hotelsDomain.SearchResponse{Results: []hotelsDomain.Hotel{}}, nil).Once()
+
+			req := httptest.NewRequest(http.MethodGet, "/search?q=hotel&offset=0&limit=10&sort="+sort, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+
+	for _, sort := range []string{"price", "id desc", "distance"} {
+		t.Run(sort+" -> 400", func(t *testing.T) {
+			svc := &mockService{}
+			router := setupRouter(svc)
+
+			req := httptest.NewRequest(http.MethodGet, "/search?q=hotel&offset=0&limit=10&sort="+url.QueryEscape(sort), nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			svc.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestController_Search_Location(t *testing.T) {
+	t.Run("lat, lng, radius and distance sort", func(t *testing.T) {
+		svc := &mockService{}
+		router := setupRouter(svc)
+
+		latitude, longitude, radius := -34.6037, -58.3816, 5.0
+		expected := hotelsDomain.SearchFilters{Latitude: &latitude, Longitude: &longitude, RadiusKm: &radius}
+		svc.On("Search", mock.Anything, "", expected, "distance", 0, 10, "").Return(hotelsDomain.SearchResponse{Results: []hotelsDomain.Hotel{}}, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/search?offset=0&limit=10&lat=-34.6037&lng=-58.3816&radius_km=5&sort=distance", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		svc.AssertExpectations(t)
+	})
+
+	invalid := map[string]string{
+		"lat without lng":           "lat=10",
+		"lng without lat":           "lng=10",
+		"lat out of range":          "lat=91&lng=10",
+		"lng out of range":          "lat=10&lng=181",
+		"radius without location":   "radius_km=5",
+		"zero radius":               "lat=10&lng=10&radius_km=0",
+		"distance without location": "sort=distance",
+	}
+	for name, params := range invalid {
+		t.Run(name+" -> 400", func(t *testing.T) {
+			svc := &mockService{}
+			router := setupRouter(svc)
+
+			req := httptest.NewRequest(http.MethodGet, "/search?offset=0&limit=10&"+params, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			svc.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestController_Search_Dates(t *testing.T) {
+	t.Run("check_in and check_out", func(t *testing.T) {
+		svc := &mockService{}
+		router := setupRouter(svc)
+
+		expected := hotelsDomain.SearchFilters{CheckIn: "2026-11-01", CheckOut: "2026-11-05"}
+		svc.On("Search", mock.Anything, "", expected, hotelsDomain.SortRelevance, 0, 10, "").Return(hotelsDomain.SearchResponse{Results: []hotelsDomain.Hotel{}}, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/search?offset=0&limit=10&check_in=2026-11-01&check_out=2026-11-05", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		svc.AssertExpectations(t)
+	})
+
+	invalid := map[string]string{
+		"check_in without check_out": "offset=0&check_in=2026-11-01",
+		"check_out without check_in": "offset=0&check_out=2026-11-05",
+		"bad date format":            "offset=0&check_in=01/11/2026&check_out=2026-11-05",
+		"check_out before check_in":  "offset=0&check_in=2026-11-05&check_out=2026-11-01",
+		"same day":                   "offset=0&check_in=2026-11-01&check_out=2026-11-01",
+		"with cursor":                "cursor=*&check_in=2026-11-01&check_out=2026-11-05",
+	}
+	for name, params := range invalid {
+		t.Run(name+" -> 400", func(t *testing.T) {
+			svc := &mockService{}
+			router := setupRouter(svc)
+
+			req := httptest.NewRequest(http.MethodGet, "/search?limit=10&"+params, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			svc.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestController_Suggest(t *testing.T) {
+	t.Run("success with default limit", func(t *testing.T) {
+		svc := &mockService{}
+		router := setupRouter(svc)
+
+		svc.On("Suggest", mock.Anything, "bue", 8).Return([]hotelsDomain.Suggestion{
+			{Text: "Buenos Aires", Type: hotelsDomain.SuggestionCity, Count: 3},
+			{Text: "Hotel Buenavista", Type: hotelsDomain.SuggestionHotel, HotelID: "hotel1", City: "Cancun"},
+		}, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/search/suggest?q=bue", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var body struct {
+			Suggestions []hotelsDomain.Suggestion `json:"suggestions"`
+		}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		assert.Len(t, body.Suggestions, 2)
+		assert.Equal(t, "Buenos Aires", body.Suggestions[0].Text)
+		assert.Equal(t, "hotel1", body.Suggestions[1].HotelID)
+		svc.AssertExpectations(t)
+	})
+
+	t.Run("custom limit", func(t *testing.T) {
+		svc := &mockService{}
+		router := setupRouter(svc)
+
+		svc.On("Suggest", mock.Anything, "par", 3).Return([]hotelsDomain.Suggestion{}, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/search/suggest?q=par&limit=3", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		svc.AssertExpectations(t)
+	})
+
+	for _, limit := range []string{"0", "21", "abc"} {
+		t.Run("invalid limit "+limit+" -> 400", func(t *testing.T) {
+			svc := &mockService{}
+			router := setupRouter(svc)
+
+			req := httptest.NewRequest(http.MethodGet, "/search/suggest?q=bue&limit="+limit, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			svc.AssertNotCalled(t, "Suggest", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("service error -> 500", func(t *testing.T) {
+		svc := &mockService{}
+		router := setupRouter(svc)
+
+		svc.On("Suggest", mock.Anything, "bue", 8).Return(nil, errors.New("solr down")).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/search/suggest?q=bue", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
 }
@@ -3,20 +3,91 @@ package hotels
 import "time"
 
 type Hotel struct {
-	ID        string   `bson:"_id,omitempty"`
-	Name      string   `bson:"name"`
-	Description string `bson:"description"`
-	Address   string   `bson:"address"`
-	City      string   `bson:"city"`
-	State     string   `bson:"state"`
-	Country   string   `bson:"country"`
-	Phone     string   `bson:"phone"`
-	Email     string   `bson:"email"`
-	PricePerNight float64 `bson:"price_per_night"`
-	Rating    float64  `bson:"rating"`
-	AvaiableRooms int `bson:"avaiable_rooms"`
-	CheckInTime time.Time `bson:"check_in_time"`
-	CheckOutTime time.Time `bson:"check_out_time"`
-	Amenities []string `bson:"amenities"`
-	Images    []string `bson:"images"`
-}
\ No newline at end of file
+	ID             string    `bson:"_id,omitempty"`
+	Name           string    `bson:"name"`
+	Description    string    `bson:"description"`
+	Address        string    `bson:"address"`
+	City           string    `bson:"city"`
+	State          string    `bson:"state"`
+	Country        string    `bson:"country"`
+	Phone          string    `bson:"phone"`
+	Email          string    `bson:"email"`
+	PricePerNight  float64   `bson:"price_per_night"`
+	Rating         float64   `bson:"rating"`
+	AvaiableRooms  int       `bson:"avaiable_rooms"`
+	CheckInTime    time.Time `bson:"check_in_time"`
+	CheckOutTime   time.Time `bson:"check_out_time"`
+	Amenities      []string  `bson:"amenities"`
+	Images         []string  `bson:"images"`
+	MinPrice       float64   `bson:"min_price"`       // Precio mas bajo entre los tipos de habitacion (o price_per_night)
+	RoomCapacities []int     `bson:"room_capacities"` // Capacidad de cada tipo de habitacion
+	MaxCapacity    int       `bson:"max_capacity"`    // Mayor capacidad entre los tipos de habitacion
+	TotalRooms     int       `bson:"total_rooms"`     // Habitaciones del hotel: suma de los tipos (o avaiable_rooms si no tiene tipos)
+	Latitude       *float64  `bson:"latitude"`        // Se indexa junto con Longitude en el campo location de Solr
+	Longitude      *float64  `bson:"longitude"`
+	DistanceKm     *float64  `bson:"-"`              // Distancia al punto buscado, solo en busquedas con ubicacion
+	EventSequence  int64     `bson:"event_sequence"` // Secuencia del ultimo evento aplicado (0 si vino de un evento v1)
+}
+
+// SearchFilters son los filtros de la busqueda, cada uno se manda a Solr como un fq (se cachean aparte de la query)
+type SearchFilters struct {
+	City      string
+	Country   string
+	MinPrice  *float64 // Se compara contra min_price (el precio "desde" del hotel)
+	MaxPrice  *float64
+	MinRating *float64
+	Amenities []string // El hotel tiene que tener todas
+	Rooms     int      // Minimo de habitaciones del hotel, se compara contra total_rooms
+	Guests    int      // Se compara contra max_capacity (los hoteles sin tipos de habitacion no informan capacidad)
+	Latitude  *float64 // Punto de la busqueda por cercania
+	Longitude *float64
+	RadiusKm  *float64 // Solo hoteles a esta distancia del punto (requiere Latitude/Longitude)
+}
+
+// SearchResult es una pagina de resultados de Solr con el total de coincidencias y los facets
+type SearchResult struct {
+	Hotels     []Hotel
+	Total      int
+	Facets     Facets
+	NextCursor string // Cursor de la pagina siguiente (solo con cursorMark, vacio en la ultima)
+}
+
+// Facets son los conteos por valor (city, country, amenities) y por rango (precio, rating)
+type Facets struct {
+	City      []FacetBucket
+	Country   []FacetBucket
+	Amenities []FacetBucket
+	Price     []FacetBucket
+	Rating    []FacetBucket
+}
+
+// FacetBucket es un valor o rango con la cantidad de hoteles que lo cumplen (From/To solo en los rangos)
+type FacetBucket struct {
+	Value string
+	Count int
+	From  *float64
+	To    *float64
+}
+
+// Suggestions son las sugerencias de autocompletado de Solr para un prefijo:
+// hoteles cuyo nombre empieza asi (solo ID, Name y City, por relevancia) y ciudades con su cantidad de hoteles
+type Suggestions struct {
+	Hotels []Hotel
+	Cities []FacetBucket
+}
+
+// IndexChange es un cambio del indice que llega por un evento: Hotel para agregarlo o reemplazarlo, o DeleteID para borrarlo.
+// Sequence es la secuencia del evento en el hotel: un cambio con una secuencia que ya se aplico se descarta (0 = sin secuencia, evento v1)
+type IndexChange struct {
+	Hotel    *Hotel
+	DeleteID string
+	Sequence int64
+}
+
+// HotelID devuelve el ID del hotel que cambia
+func (change IndexChange) HotelID() string {
+	if change.Hotel != nil {
+		return change.Hotel.ID
+	}
+	return change.DeleteID
+}
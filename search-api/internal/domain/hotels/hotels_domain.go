@@ -1,27 +1,208 @@
 package hotels
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 type Hotel struct {
-	ID string `json:"id"`
-	Name string `json:"name"`
-	Description string `json:"description"`
-	Address string `json:"address"`
-	City string `json:"city"`
-	State string `json:"state"`
-	Country string `json:"country"`
-	Phone string `json:"phone"`
-	Email string `json:"email"`
-	PricePerNight float64 `json:"price_per_night"`
-	Rating float64 `json:"rating"`
-	AvaiableRooms int `json:"avaiable_rooms"`
-	CheckInTime time.Time `json:"check_in_time"`
-	CheckOutTime time.Time `json:"check_out_time"`
+	ID                      string     `json:"id"`
+	Name                    string     `json:"name"`
+	Description             string     `json:"description"`
+	Address                 string     `json:"address"`
+	City                    string     `json:"city"`
+	State                   string     `json:"state"`
+	Country                 string     `json:"country"`
+	Phone                   string     `json:"phone"`
+	Email                   string     `json:"email"`
+	PricePerNight           float64    `json:"price_per_night"`
+	Rating                  float64    `json:"rating"`
+	AvaiableRooms           int        `json:"avaiable_rooms"`
+	CheckInTime             time.Time  `json:"check_in_time"`
+	CheckOutTime            time.Time  `json:"check_out_time"`
+	Amenities               []string   `json:"amenities"`
+	Images                  []string   `json:"images"`
+	RoomTypes               []RoomType `json:"room_types,omitempty"`
+	MinPrice                float64    `json:"min_price"`
+	MaxCapacity             int        `json:"max_capacity"`
+	TotalRooms              int        `json:"total_rooms"`
+	RoomCapacities          []int      `json:"room_capacities,omitempty"`
+	Latitude                *float64   `json:"latitude,omitempty"`
+	Longitude               *float64   `json:"longitude,omitempty"`
+	DistanceKm              *float64   `json:"distance_km,omitempty"`              // Solo en busquedas con lat/lng
+	AvailabilityUnconfirmed bool       `json:"availability_unconfirmed,omitempty"` // Solo en busquedas con fechas: hotels-api no confirmo la disponibilidad a tiempo
+	EventSequence           int64      `json:"event_sequence,omitempty"`           // Solo en el listado de hotels-api del reindex: secuencia del ultimo evento del hotel
+}
+
+// RoomType es un tipo de habitacion tal como lo devuelve hotels-api
+type RoomType struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Capacity  int      `json:"capacity"`
+	Count     int      `json:"count"`
+	BasePrice float64  `json:"base_price"`
 	Amenities []string `json:"amenities"`
-	Images []string `json:"images"`
 }
 
+// Version del sobre de eventos desde la que hotels-api manda el hotel completo
+const HotelEventSchemaVersion = 2
+
+// HotelNew es el evento de cambio de hotel que publica hotels-api.
+// Los mensajes v1 (sin schema_version) solo traen operation y hotel_id; los v2 traen ademas el hotel completo
 type HotelNew struct {
-	Operation string `json:"operation"`
-	HotelID   string `json:"hotel_id"`
+	EventID       string    `json:"event_id"`
+	Type          string    `json:"type"`
+	SchemaVersion int       `json:"schema_version"`
+	OccurredAt    time.Time `json:"occurred_at"`
+	Sequence      int64     `json:"sequence"`
+	Operation     string    `json:"operation"`
+	HotelID       string    `json:"hotel_id"`
+	Hotel         *Hotel    `json:"hotel,omitempty"`
+}
+
+// ErrInvalidEvent marca un evento que no se puede procesar aunque se reintente (va directo a dead letters)
+var ErrInvalidEvent = errors.New("invalid hotel event")
+
+// DeadLetter es un evento que agoto sus reintentos y quedo en la cola de dead letters
+type DeadLetter struct {
+	Event    HotelNew  `json:"event"`
+	Body     string    `json:"body"` // Mensaje original, por si no se pudo decodificar
+	Error    string    `json:"error"`
+	Attempts int       `json:"attempts"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// Ordenes aceptados por el parametro sort de GET /search (relevance es el default)
+const (
+	SortRelevance  = "relevance"
+	SortPriceAsc   = "price_asc"
+	SortPriceDesc  = "price_desc"
+	SortRatingDesc = "rating_desc"
+	SortNameAsc    = "name_asc"
+	SortDistance   = "distance" // Solo si la busqueda tiene una ubicacion
+)
+
+// SearchFilters son los filtros opcionales de GET /search (los punteros en nil no filtran)
+type SearchFilters struct {
+	City      string   `json:"city,omitempty"`
+	Country   string   `json:"country,omitempty"`
+	MinPrice  *float64 `json:"min_price,omitempty"`
+	MaxPrice  *float64 `json:"max_price,omitempty"`
+	MinRating *float64 `json:"min_rating,omitempty"`
+	Amenities []string `json:"amenities,omitempty"`
+	Rooms     int      `json:"rooms,omitempty"`
+	Guests    int      `json:"guests,omitempty"` // Personas que tienen que entrar en un tipo de habitacion
+	Latitude  *float64 `json:"lat,omitempty"`
+	Longitude *float64 `json:"lng,omitempty"`
+	RadiusKm  *float64 `json:"radius_km,omitempty"`
+	CheckIn   string   `json:"check_in,omitempty"`  // YYYY-MM-DD, junto con CheckOut
+	CheckOut  string   `json:"check_out,omitempty"` // YYYY-MM-DD
+}
+
+// HasLocation indica si la busqueda tiene un punto (lat y lng)
+func (filters SearchFilters) HasLocation() bool {
+	return filters.Latitude != nil && filters.Longitude != nil
+}
+
+// HasDates indica si la busqueda tiene fechas (solo se devuelven hoteles con habitaciones libres)
+func (filters SearchFilters) HasDates() bool {
+	return filters.CheckIn != "" && filters.CheckOut != ""
+}
+
+// SearchResponse es la respuesta de GET /search.
+// Con offset, next_offset/prev_offset son null cuando no hay pagina siguiente/anterior;
+// con cursor, next_cursor viene vacio en la ultima pagina (cursorMark solo avanza, no hay anterior)
+// con fechas, total es la cantidad de hoteles antes de filtrar por disponibilidad, offset/next_offset son posiciones
+// en esos resultados (la pagina puede saltear hoteles sin lugar) y prev_offset es siempre null
+type SearchResponse struct {
+	Results    []Hotel `json:"results"`
+	Facets     Facets  `json:"facets"`
+	Total      int     `json:"total"`
+	Offset     int     `json:"offset"`
+	Limit      int     `json:"limit"`
+	NextOffset *int    `json:"next_offset"`
+	PrevOffset *int    `json:"prev_offset"`
+	Cursor     string  `json:"cursor,omitempty"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// Facets son los conteos para los filtros del sidebar, calculados sobre la busqueda con sus filtros aplicados
+type Facets struct {
+	City      []FacetBucket `json:"city"`
+	Country   []FacetBucket `json:"country"`
+	Amenities []FacetBucket `json:"amenities"`
+	Price     []FacetBucket `json:"price"`
+	Rating    []FacetBucket `json:"rating"`
+}
+
+// FacetBucket es un valor ("Buenos Aires") o un rango ("100-200", con from/to) y su cantidad de hoteles
+type FacetBucket struct {
+	Value string   `json:"value"`
+	Count int      `json:"count"`
+	From  *float64 `json:"from,omitempty"`
+	To    *float64 `json:"to,omitempty"`
+}
+
+// Tipos de sugerencia de GET /search/suggest
+const (
+	SuggestionCity  = "city"
+	SuggestionHotel = "hotel"
+)
+
+// Suggestion es una sugerencia de autocompletado: una ciudad (con su cantidad de hoteles) o un hotel (con su id y ciudad)
+type Suggestion struct {
+	Text    string `json:"text"`
+	Type    string `json:"type"`
+	HotelID string `json:"hotel_id,omitempty"`
+	City    string `json:"city,omitempty"`
+	Count   int    `json:"count,omitempty"`
+}
+
+// HotelPage es una pagina de GET /hotels de hotels-api (NextCursor vacio en la ultima)
+type HotelPage struct {
+	Hotels     []Hotel `json:"hotels"`
+	NextCursor string  `json:"next_cursor"`
+}
+
+// Estados de un reindex
+const (
+	ReindexIdle      = "idle"
+	ReindexRunning   = "running"
+	ReindexSucceeded = "succeeded"
+	ReindexFailed    = "failed"
+)
+
+// ErrReindexRunning indica que ya hay un reindex en curso
+var ErrReindexRunning = errors.New("reindex already running")
+
+// ReindexStatus es el progreso del ultimo reindex. Errors tiene los primeros errores por hotel (Failed los cuenta todos)
+// y Error el motivo por el que se aborto, si fallo (en ese caso el indice anterior sigue activo)
+type ReindexStatus struct {
+	State      string     `json:"state"`
+	Core       string     `json:"core,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Pages      int        `json:"pages"`
+	Indexed    int        `json:"indexed"`
+	Failed     int        `json:"failed"`
+	Errors     []string   `json:"errors,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// ErrIndexerClosed indica que el evento llego despues de cerrar el batcher (el consumidor lo reintenta)
+var ErrIndexerClosed = errors.New("indexer closed")
+
+// IndexerStats son las metricas de las escrituras por lotes en Solr que se muestran en /health.
+// Una tanda fallida se vuelve a mandar de a un cambio, FailedChanges cuenta los que fallaron igual
+type IndexerStats struct {
+	Batches       int64   `json:"batches"`
+	Changes       int64   `json:"changes"`
+	FailedBatches int64   `json:"failed_batches"`
+	FailedChanges int64   `json:"failed_changes"`
+	LastSize      int     `json:"last_size"`
+	MaxSize       int     `json:"max_size"`
+	AvgSize       float64 `json:"avg_size"`
+	LastLatencyMs float64 `json:"last_latency_ms"`
+	MaxLatencyMs  float64 `json:"max_latency_ms"`
+	AvgLatencyMs  float64 `json:"avg_latency_ms"`
 }
@@ -0,0 +1,52 @@
+package hotels
+
+import (
+	"fmt"
+	"time"
+
+	hotelsDomain "search-api/internal/domain/hotels"
+
+	"github.com/karlseguin/ccache"
+)
+
+const (
+	suggestKeyFormat = "suggest:%s"
+)
+
+type CacheConfig struct {
+	MaxSize      int64
+	ItemsToPrune uint32
+	Duration     time.Duration
+}
+
+// Cache es un LRU en memoria para las sugerencias de los prefijos mas buscados
+type Cache struct {
+	client   *ccache.Cache
+	duration time.Duration
+}
+
+// Crea una nueva instancia de Cache
+func NewCache(config CacheConfig) Cache {
+	client := ccache.New(ccache.Configure().
+		MaxSize(config.MaxSize).
+		ItemsToPrune(config.ItemsToPrune))
+	return Cache{
+		client:   client,
+		duration: config.Duration,
+	}
+}
+
+// GetSuggestions devuelve las sugerencias guardadas para key, false si no estan o vencieron
+func (repository Cache) GetSuggestions(key string) ([]hotelsDomain.Suggestion, bool) {
+	item := repository.client.Get(fmt.Sprintf(suggestKeyFormat, key))
+	if item == nil || item.Expired() {
+		return nil, false
+	}
+	suggestions, ok := item.Value().([]hotelsDomain.Suggestion)
+	return suggestions, ok
+}
+
+// SetSuggestions guarda las sugerencias de key durante la duracion configurada
+func (repository Cache) SetSuggestions(key string, suggestions []hotelsDomain.Suggestion) {
+	repository.client.Set(fmt.Sprintf(suggestKeyFormat, key), suggestions, repository.duration)
+}
@@ -1,11 +1,14 @@
 package hotels
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	hotelsDomain "search-api/internal/domain/hotels"
 )
@@ -16,7 +19,9 @@ type HTTPConfig struct {
 }
 
 type HTTP struct {
-	baseURL func(hotelID string) string
+	baseURL         func(hotelID string) string
+	availabilityURL string
+	listURL         string
 }
 
 func NewHTTP(config HTTPConfig) HTTP {
@@ -25,6 +30,8 @@ func NewHTTP(config HTTPConfig) HTTP {
 		baseURL: func(hotelID string) string {
 			return fmt.Sprintf("http://%s:%s/hotels/%s", config.Host, config.Port, hotelID)
 		},
+		availabilityURL: fmt.Sprintf("http://%s:%s/hotels/availability", config.Host, config.Port),
+		listURL:         fmt.Sprintf("http://%s:%s/hotels", config.Host, config.Port),
 	}
 }
 
@@ -55,3 +62,69 @@ func (repository HTTP) GetHotelByID(ctx context.Context, id string) (hotelsDomai
 
 	return hotel, nil
 }
+
+// GetAvailability consulta en un solo POST /hotels/availability si los hoteles tienen lugar entre checkIn y checkOut.
+// Respeta el deadline del contexto, asi la busqueda no espera a hotels-api mas de lo que tiene de presupuesto
+func (repository HTTP) GetAvailability(ctx context.Context, hotelIDs []string, checkIn, checkOut string) (map[string]bool, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"hotel_ids": hotelIDs,
+		"check_in":  checkIn,
+		"check_out": checkOut,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error marshaling availability request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, repository.availabilityURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("Error creating availability request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching availability: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Failed to fetch availability: received status code %d", resp.StatusCode)
+	}
+
+	var availability map[string]bool
+	if err := json.NewDecoder(resp.Body).Decode(&availability); err != nil {
+		return nil, fmt.Errorf("Error unmarshaling availability data: %w", err)
+	}
+
+	return availability, nil
+}
+
+// ListHotels pide a GET /hotels de hotels-api una pagina de hasta limit hoteles despues de cursor (vacio = la primera)
+func (repository HTTP) ListHotels(ctx context.Context, cursor string, limit int) (hotelsDomain.HotelPage, error) {
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(limit))
+	if cursor != "" {
+		params.Set("cursor", cursor)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, repository.listURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return hotelsDomain.HotelPage{}, fmt.Errorf("Error creating hotels list request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return hotelsDomain.HotelPage{}, fmt.Errorf("Error fetching hotels page (%s): %w", cursor, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return hotelsDomain.HotelPage{}, fmt.Errorf("Failed to fetch hotels page (%s): received status code %d", cursor, resp.StatusCode)
+	}
+
+	var page hotelsDomain.HotelPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return hotelsDomain.HotelPage{}, fmt.Errorf("Error unmarshaling hotels page (%s): %w", cursor, err)
+	}
+	return page, nil
+}
@@ -18,27 +18,58 @@ func NewMock() *Mock {
 	return &Mock{}
 }
 
-func (m *Mock) Index(ctx context.Context, hotel hotelsDAO.Hotel) (string, error) {
-	args := m.Called(ctx, hotel)
+func (m *Mock) Apply(ctx context.Context, changes []hotelsDAO.IndexChange) error {
+	args := m.Called(ctx, changes)
+	return args.Error(0)
+}
+
+func (m *Mock) Sequences(ctx context.Context, ids []string) (map[string]int64, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]int64), args.Error(1)
+}
+
+func (m *Mock) Search(ctx context.Context, query string, filters hotelsDAO.SearchFilters, sort string, limit int, offset int, cursor string) (hotelsDAO.SearchResult, error) {
+	args := m.Called(ctx, query, filters, sort, limit, offset, cursor)
+	if args.Get(0) == nil {
+		return hotelsDAO.SearchResult{}, args.Error(1)
+	}
+	return args.Get(0).(hotelsDAO.SearchResult), args.Error(1)
+}
+
+func (m *Mock) Suggest(ctx context.Context, prefix string, limit int) (hotelsDAO.Suggestions, error) {
+	args := m.Called(ctx, prefix, limit)
+	if args.Get(0) == nil {
+		return hotelsDAO.Suggestions{}, args.Error(1)
+	}
+	return args.Get(0).(hotelsDAO.Suggestions), args.Error(1)
+}
+
+func (m *Mock) CreateBuildCore(ctx context.Context) (string, error) {
+	args := m.Called(ctx)
 	return args.String(0), args.Error(1)
 }
 
-func (m *Mock) Update(ctx context.Context, hotel hotelsDAO.Hotel) error {
-	args := m.Called(ctx, hotel)
+func (m *Mock) IndexBatch(ctx context.Context, core string, hotels []hotelsDAO.Hotel) error {
+	args := m.Called(ctx, core, hotels)
 	return args.Error(0)
 }
 
-func (m *Mock) Delete(ctx context.Context, id string) error {
-	args := m.Called(ctx, id)
+func (m *Mock) CopyTombstones(ctx context.Context, core string) (int, error) {
+	args := m.Called(ctx, core)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *Mock) SwapBuildCore(ctx context.Context, core string) error {
+	args := m.Called(ctx, core)
 	return args.Error(0)
 }
 
-func (m *Mock) Search(ctx context.Context, query string, limit int, offset int) ([]hotelsDAO.Hotel, error) {
-	args := m.Called(ctx, query, limit, offset)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).([]hotelsDAO.Hotel), args.Error(1)
+func (m *Mock) DropBuildCore(ctx context.Context, core string) error {
+	args := m.Called(ctx, core)
+	return args.Error(0)
 }
 
 // ExternalMock implementa la interfaz ExternalRepository (Hotels API) para testing.
@@ -54,3 +85,16 @@ func (m *ExternalMock) GetHotelByID(ctx context.Context, id string) (hotelsDomai
 	args := m.Called(ctx, id)
 	return args.Get(0).(hotelsDomain.Hotel), args.Error(1)
 }
+
+func (m *ExternalMock) GetAvailability(ctx context.Context, hotelIDs []string, checkIn, checkOut string) (map[string]bool, error) {
+	args := m.Called(ctx, hotelIDs, checkIn, checkOut)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]bool), args.Error(1)
+}
+
+func (m *ExternalMock) ListHotels(ctx context.Context, cursor string, limit int) (hotelsDomain.HotelPage, error) {
+	args := m.Called(ctx, cursor, limit)
+	return args.Get(0).(hotelsDomain.HotelPage), args.Error(1)
+}
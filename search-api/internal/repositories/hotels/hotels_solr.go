@@ -5,6 +5,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"search-api/internal/dao/hotels"
@@ -13,14 +20,21 @@ import (
 )
 
 type SolrConfig struct {
-	Host       string // Solr host
-	Port       string // Solr port
-	Collection string // Solr collection name
+	Host         string        // Solr host
+	Port         string        // Solr port
+	Collection   string        // Solr collection name
+	ConfigSet    string        // Configset con el que se crean los cores nuevos en un reindex
+	CommitWithin time.Duration // Tiempo maximo hasta que un cambio se ve en las busquedas
 }
 
 type Solr struct {
-	Client     *solr.JSONClient
-	Collection string
+	Client        *solr.JSONClient
+	Collection    string
+	baseURL       string
+	configSet     string
+	commitWithin  time.Duration
+	requestSender solr.RequestSender // Para los requests que solr-go no cubre (nextCursorMark, commitWithin, CoreAdmin)
+	build         *buildCore         // Core del reindex en curso (compartido entre las copias de Solr)
 }
 
 // Funcion para crear una nueva conexion a Solr
@@ -32,157 +46,472 @@ func NewSolr(config SolrConfig) Solr {
 
 	// Devuelve una nueva instancia de Solr
 	return Solr{
-		Client:     client,
-		Collection: config.Collection,
+		Client:        client,
+		Collection:    config.Collection,
+		baseURL:       baseURL,
+		configSet:     config.ConfigSet,
+		commitWithin:  config.CommitWithin,
+		requestSender: solr.NewDefaultRequestSender(),
+		build:         &buildCore{},
 	}
 }
 
-// Index crea un nuevo documento de hotel en la coleccion de Solr
-func (searchEngine Solr) Index(ctx context.Context, hotel hotels.Hotel) (string, error) {
-	// Prepara el documento para Solr
-	doc := map[string]interface{}{
-		"id":        hotel.ID,
-		"name":      hotel.Name,
-		"description": hotel.Description,
-		"address":   hotel.Address,
-		"city":      hotel.City,
-		"state":     hotel.State,
-		"country":   hotel.Country,
-		"phone":     hotel.Phone,
-		"email":     hotel.Email,
-		"price_per_night": hotel.PricePerNight,
-		"avaiable_rooms": hotel.AvaiableRooms,
-		"check_in_time": hotel.CheckInTime,
-		"check_out_time": hotel.CheckOutTime,
-		"rating":    hotel.Rating,
-		"amenities": hotel.Amenities,
-		"images":    hotel.Images,
+// Apply aplica varios cambios en la coleccion en un solo request de update, sin commit: Solr los hace visibles
+// con un soft commit dentro de commitWithin. Si un hotel cambia mas de una vez en la tanda queda el ultimo cambio
+func (searchEngine Solr) Apply(ctx context.Context, changes []hotels.IndexChange) error {
+	body, err := json.Marshal(updateRequest(changes))
+	if err != nil {
+		return fmt.Errorf("error marshaling hotel documents: %w", err)
 	}
 
-	// Prepara el request de indexacion
-	indexRequest := map[string]interface{}{
-		"add": []interface{}{doc}, // Usa "add" con una lista de documentos para indexar varios a la vez 
+	if err := searchEngine.update(ctx, searchEngine.Collection, body); err != nil {
+		return fmt.Errorf("error updating hotels: %w", err)
 	}
 
-	// Indexa el documento en Solr (Lo pasa a JSON)
-	body, err := json.Marshal(indexRequest)
-	if err != nil {
-		return "", fmt.Errorf("error marshaling hotel document: %w", err)
+	// Si hay un reindex en curso los cambios tambien van al core nuevo
+	if err := searchEngine.mirrorToBuild(ctx, body); err != nil {
+		return err
 	}
 
-	// Manda el request de indexacion usando el metodo Update
-	resp, err := searchEngine.Client.Update(ctx, searchEngine.Collection, solr.JSON, bytes.NewReader(body))
-	if err != nil {
-		return "", fmt.Errorf("error indexing hotel: %w", err)
+	return nil
+}
+
+// updateRequest arma el request de update con los hoteles a agregar ("add") y los IDs a borrar ("delete").
+// Un borrado con secuencia deja una lapida en vez de borrar el documento, para que un evento viejo reintentado no reviva al hotel.
+// Como cada hotel queda con un solo cambio, el orden entre los dos comandos no importa
+func updateRequest(changes []hotels.IndexChange) map[string]interface{} {
+	last := make(map[string]int, len(changes))
+	for i, change := range changes {
+		last[change.HotelID()] = i
 	}
-	if resp.Error != nil {
-		return "", fmt.Errorf("failed to index hotel: %v", resp.Error)
+
+	docs := make([]interface{}, 0, len(changes))
+	deletes := make([]string, 0)
+	for i, change := range changes {
+		if last[change.HotelID()] != i {
+			continue
+		}
+		switch {
+		case change.Hotel != nil:
+			docs = append(docs, hotelDocument(*change.Hotel))
+		case change.Sequence > 0:
+			docs = append(docs, tombstoneDocument(change.DeleteID, change.Sequence))
+		default:
+			deletes = append(deletes, change.DeleteID)
+		}
 	}
 
-	// Commitea los cambios
-	if err := searchEngine.Client.Commit(ctx, searchEngine.Collection); err != nil {
-		return "", fmt.Errorf("error committing changes to Solr: %w", err)
+	request := map[string]interface{}{}
+	if len(docs) > 0 {
+		request["add"] = docs
 	}
+	if len(deletes) > 0 {
+		request["delete"] = deletes
+	}
+	return request
+}
 
-	// Si todo sale bien, devuelve el ID del hotel
-	return hotel.ID, nil
+// update manda un request de update JSON al core indicado con commitWithin (solr-go no deja agregar parametros al update)
+func (searchEngine Solr) update(ctx context.Context, core string, body []byte) error {
+	params := url.Values{
+		"commitWithin": {strconv.FormatInt(searchEngine.commitWithin.Milliseconds(), 10)},
+		"wt":           {"json"},
+	}
+	endpoint := fmt.Sprintf("%s/solr/%s/update?%s", searchEngine.baseURL, core, params.Encode())
+	httpResp, err := searchEngine.requestSender.SendRequest(ctx, http.MethodPost, endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+	return checkStatusResponse(httpResp, "update")
 }
 
-// Funcion para actualizar un documento de hotel en la coleccion de Solr
-func (searchEngine Solr) Update(ctx context.Context, hotel hotels.Hotel) error {
-	// Prepara el documento para Solr
+// hotelDocument arma el documento de Solr de un hotel
+func hotelDocument(hotel hotels.Hotel) map[string]interface{} {
 	doc := map[string]interface{}{
-		"id":        hotel.ID,
-		"name":      hotel.Name,
-		"description": hotel.Description,
-		"address":   hotel.Address,
-		"city":      hotel.City,
-		"state":     hotel.State,
-		"country":   hotel.Country,
-		"phone":     hotel.Phone,
-		"email":     hotel.Email,
+		"id":              hotel.ID,
+		"name":            hotel.Name,
+		"description":     hotel.Description,
+		"address":         hotel.Address,
+		"city":            hotel.City,
+		"state":           hotel.State,
+		"country":         hotel.Country,
+		"phone":           hotel.Phone,
+		"email":           hotel.Email,
 		"price_per_night": hotel.PricePerNight,
-		"rating":    hotel.Rating,
-		"avaiable_rooms": hotel.AvaiableRooms,
-		"check_in_time": hotel.CheckInTime,
-		"check_out_time": hotel.CheckOutTime,
-		"amenities": hotel.Amenities,
-		"images":    hotel.Images,
+		"avaiable_rooms":  hotel.AvaiableRooms,
+		"check_in_time":   hotel.CheckInTime,
+		"check_out_time":  hotel.CheckOutTime,
+		"rating":          hotel.Rating,
+		"amenities":       hotel.Amenities,
+		"images":          hotel.Images,
+		"min_price":       hotel.MinPrice,
+		"room_capacities": hotel.RoomCapacities,
+		"max_capacity":    hotel.MaxCapacity,
+		"total_rooms":     hotel.TotalRooms,
+		"event_sequence":  hotel.EventSequence,
+	}
+	// La ubicacion se indexa como "lat,lng" (solo si el hotel la tiene)
+	if location := locationValue(hotel.Latitude, hotel.Longitude); location != "" {
+		doc["location"] = location
 	}
+	return doc
+}
 
-	// Prepara el request de actualizacion
-	updateRequest := map[string]interface{}{
-		"add": []interface{}{doc}, // Use "add" with a list of documents
+// tombstoneDocument es la lapida de un hotel borrado: solo guarda la secuencia del borrado y no aparece en las busquedas
+func tombstoneDocument(id string, sequence int64) map[string]interface{} {
+	return map[string]interface{}{
+		"id":             id,
+		"deleted":        true,
+		"event_sequence": sequence,
 	}
+}
 
-	// Hace el update del documento en Solr (Lo pasa a JSON)
-	body, err := json.Marshal(updateRequest)
-	if err != nil {
-		return fmt.Errorf("error marshaling hotel document: %w", err)
+// liveDocumentsFilter deja afuera de las busquedas las lapidas de los hoteles borrados
+const liveDocumentsFilter = "-deleted:true"
+
+// Sequences devuelve la secuencia del ultimo evento aplicado de cada hotel (tambien de las lapidas).
+// Usa el real-time get de Solr, que ve los cambios todavia no commiteados; los hoteles que no estan no vienen en el mapa
+func (searchEngine Solr) Sequences(ctx context.Context, ids []string) (map[string]int64, error) {
+	return searchEngine.sequences(ctx, searchEngine.Collection, ids)
+}
+
+// sequences hace el real-time get de Sequences en el core indicado
+func (searchEngine Solr) sequences(ctx context.Context, core string, ids []string) (map[string]int64, error) {
+	sequences := make(map[string]int64, len(ids))
+	if len(ids) == 0 {
+		return sequences, nil
 	}
 
-	// Ejecuta el request de actualizacion usando el metodo Update
-	resp, err := searchEngine.Client.Update(ctx, searchEngine.Collection, solr.JSON, bytes.NewReader(body))
+	params := url.Values{
+		"id": ids,
+		"fl": {"id,event_sequence"},
+		"wt": {"json"},
+	}
+	endpoint := fmt.Sprintf("%s/solr/%s/get?%s", searchEngine.baseURL, core, params.Encode())
+	httpResp, err := searchEngine.requestSender.SendRequest(ctx, http.MethodGet, endpoint, "", nil)
 	if err != nil {
-		return fmt.Errorf("error updating hotel: %w", err)
+		return nil, fmt.Errorf("error getting event sequences: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	var resp struct {
+		Response struct {
+			Docs []map[string]interface{} `json:"docs"`
+		} `json:"response"`
+		Error *struct {
+			Msg string `json:"msg"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("error decoding real-time get response (status %d): %w", httpResp.StatusCode, err)
 	}
 	if resp.Error != nil {
-		return fmt.Errorf("failed to update hotel: %v", resp.Error)
+		return nil, fmt.Errorf("error getting event sequences: %s", resp.Error.Msg)
+	}
+	for _, doc := range resp.Response.Docs {
+		sequences[getStringField(doc, "id")] = int64(getFloatField(doc, "event_sequence"))
+	}
+	return sequences, nil
+}
+
+// buildCore es el core que se esta armando en un reindex. Mientras tiene nombre, los cambios que llegan
+// por eventos se escriben tambien ahi, asi no se pierden cuando el core nuevo reemplaza al actual
+type buildCore struct {
+	mu   sync.RWMutex
+	name string
+}
+
+func (build *buildCore) current() string {
+	if build == nil {
+		return ""
 	}
+	build.mu.RLock()
+	defer build.mu.RUnlock()
+	return build.name
+}
 
-	// Hace commit de los cambios 
-	if err := searchEngine.Client.Commit(ctx, searchEngine.Collection); err != nil {
-		return fmt.Errorf("error committing changes to Solr: %w", err)
+func (build *buildCore) set(name string) {
+	if build == nil {
+		return
 	}
+	build.mu.Lock()
+	defer build.mu.Unlock()
+	build.name = name
+}
 
+// mirrorToBuild manda el mismo request de update al core del reindex en curso, si hay uno
+func (searchEngine Solr) mirrorToBuild(ctx context.Context, body []byte) error {
+	core := searchEngine.build.current()
+	if core == "" {
+		return nil
+	}
+	if err := searchEngine.update(ctx, core, body); err != nil {
+		return fmt.Errorf("error copying changes to reindex core %s: %w", core, err)
+	}
 	return nil
 }
 
-func (searchEngine Solr) Delete(ctx context.Context, id string) error {
-	// Papara el documento a borrar, con el ID del hotel a borrar
-	docToDelete := map[string]interface{}{
-		"delete": map[string]interface{}{
-			"id": id,
-		},
+// CreateBuildCore crea un core vacio (con el configset de los hoteles) para armar el indice nuevo de un reindex
+func (searchEngine Solr) CreateBuildCore(ctx context.Context) (string, error) {
+	core := fmt.Sprintf("%s_build_%d", searchEngine.Collection, time.Now().Unix())
+	err := searchEngine.coreAdmin(ctx, url.Values{
+		"action":      {"CREATE"},
+		"name":        {core},
+		"instanceDir": {core},
+		"configSet":   {searchEngine.configSet},
+	})
+	if err != nil {
+		return "", fmt.Errorf("error creating core %s: %w", core, err)
+	}
+	searchEngine.build.set(core)
+	return core, nil
+}
+
+// IndexBatch agrega varios hoteles al core indicado en un solo request, sin commit (lo hace SwapBuildCore)
+func (searchEngine Solr) IndexBatch(ctx context.Context, core string, hotelsList []hotels.Hotel) error {
+	docs := make([]interface{}, 0, len(hotelsList))
+	for _, hotel := range hotelsList {
+		docs = append(docs, hotelDocument(hotel))
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"add": docs,
+	})
+	if err != nil {
+		return fmt.Errorf("error marshaling hotel documents: %w", err)
 	}
 
-	// Convierte el documento a JSON
-	body, err := json.Marshal(docToDelete)
+	resp, err := searchEngine.Client.Update(ctx, core, solr.JSON, bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("error marshaling hotel document: %w", err)
+		return fmt.Errorf("error indexing hotels in core %s: %w", core, err)
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("failed to index hotels in core %s: %v", core, resp.Error)
+	}
+	return nil
+}
+
+// Cuantas lapidas se leen por request al copiarlas al core del reindex
+const tombstonesPageSize = 500
+
+// CopyTombstones copia al core del reindex las lapidas del indice actual, asi despues del cambio un evento viejo
+// reintentado (o reprocesado de la DLQ) no vuelve a crear un hotel borrado. Las que el core ya tiene con una
+// secuencia igual o mayor (por un cambio que llego durante el reindex) no se pisan. Devuelve cuantas copio
+func (searchEngine Solr) CopyTombstones(ctx context.Context, core string) (int, error) {
+	copied := 0
+	cursor := "*"
+	for {
+		page, next, err := searchEngine.tombstones(ctx, cursor)
+		if err != nil {
+			return copied, err
+		}
+
+		ids := make([]string, 0, len(page))
+		for id := range page {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		current, err := searchEngine.sequences(ctx, core, ids)
+		if err != nil {
+			return copied, err
+		}
+		docs := make([]interface{}, 0, len(page))
+		for _, id := range ids {
+			if sequence, ok := current[id]; ok && sequence >= page[id] {
+				continue
+			}
+			docs = append(docs, tombstoneDocument(id, page[id]))
+		}
+		if len(docs) > 0 {
+			body, err := json.Marshal(map[string]interface{}{"add": docs})
+			if err != nil {
+				return copied, fmt.Errorf("error marshaling tombstones: %w", err)
+			}
+			if err := searchEngine.update(ctx, core, body); err != nil {
+				return copied, fmt.Errorf("error copying tombstones to core %s: %w", core, err)
+			}
+			copied += len(docs)
+		}
+
+		if next == "" || next == cursor {
+			return copied, nil
+		}
+		cursor = next
 	}
+}
 
-	// Ejecuta el request de borrado usando el metodo Update
-	//La diferencia entre el metodo Update y Delete es que el metodo Update permite borrar varios documentos a la vez
-	resp, err := searchEngine.Client.Update(ctx, searchEngine.Collection, solr.JSON, bytes.NewReader(body))
+// tombstones lee una pagina de lapidas del indice actual (id y secuencia) con cursorMark y devuelve el cursor siguiente
+func (searchEngine Solr) tombstones(ctx context.Context, cursor string) (map[string]int64, string, error) {
+	params := url.Values{
+		"q":          {"deleted:true"},
+		"fl":         {"id,event_sequence"},
+		"sort":       {"id asc"},
+		"rows":       {strconv.Itoa(tombstonesPageSize)},
+		"cursorMark": {cursor},
+		"wt":         {"json"},
+	}
+	endpoint := fmt.Sprintf("%s/solr/%s/select?%s", searchEngine.baseURL, searchEngine.Collection, params.Encode())
+	httpResp, err := searchEngine.requestSender.SendRequest(ctx, http.MethodGet, endpoint, "", nil)
 	if err != nil {
-		return fmt.Errorf("error deleting hotel: %w", err)
+		return nil, "", fmt.Errorf("error listing tombstones: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	var resp struct {
+		Response struct {
+			Docs []map[string]interface{} `json:"docs"`
+		} `json:"response"`
+		NextCursorMark string `json:"nextCursorMark"`
+		Error          *struct {
+			Msg string `json:"msg"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, "", fmt.Errorf("error decoding tombstones response (status %d): %w", httpResp.StatusCode, err)
 	}
 	if resp.Error != nil {
-		return fmt.Errorf("failed to index hotel: %v", resp.Error)
+		return nil, "", fmt.Errorf("error listing tombstones: %s", resp.Error.Msg)
+	}
+	page := make(map[string]int64, len(resp.Response.Docs))
+	for _, doc := range resp.Response.Docs {
+		page[getStringField(doc, "id")] = int64(getFloatField(doc, "event_sequence"))
 	}
+	return page, resp.NextCursorMark, nil
+}
 
-	// Hace commit de los cambios
-	if err := searchEngine.Client.Commit(ctx, searchEngine.Collection); err != nil {
-		return fmt.Errorf("error committing changes to Solr: %w", err)
+// SwapBuildCore commitea el core del reindex y lo intercambia con el de la coleccion (SWAP es atomico:
+// las busquedas pasan del indice viejo al nuevo sin cortes). Despues descarta el indice viejo, que quedo con el nombre del core del reindex
+func (searchEngine Solr) SwapBuildCore(ctx context.Context, core string) error {
+	if err := searchEngine.Client.Commit(ctx, core); err != nil {
+		return fmt.Errorf("error committing core %s: %w", core, err)
 	}
+	err := searchEngine.coreAdmin(ctx, url.Values{
+		"action": {"SWAP"},
+		"core":   {searchEngine.Collection},
+		"other":  {core},
+	})
+	if err != nil {
+		return fmt.Errorf("error swapping core %s with %s: %w", core, searchEngine.Collection, err)
+	}
+	searchEngine.build.set("")
+
+	// El directorio del core viejo se conserva (sin indice) para que solr-precreate no vuelva a crear la coleccion al reiniciar
+	err = searchEngine.coreAdmin(ctx, url.Values{
+		"action":        {"UNLOAD"},
+		"core":          {core},
+		"deleteIndex":   {"true"},
+		"deleteDataDir": {"true"},
+	})
+	if err != nil {
+		log.Printf("error unloading previous index (core %s): %v", core, err)
+	}
+	return nil
+}
 
+// DropBuildCore descarta el core de un reindex que no termino; el indice actual no cambia
+func (searchEngine Solr) DropBuildCore(ctx context.Context, core string) error {
+	searchEngine.build.set("")
+	err := searchEngine.coreAdmin(ctx, url.Values{
+		"action":            {"UNLOAD"},
+		"core":              {core},
+		"deleteInstanceDir": {"true"},
+	})
+	if err != nil {
+		return fmt.Errorf("error dropping core %s: %w", core, err)
+	}
 	return nil
 }
 
+// statusResponse es la parte de la respuesta de Solr (update y CoreAdmin) que se revisa
+type statusResponse struct {
+	ResponseHeader struct {
+		Status int `json:"status"`
+	} `json:"responseHeader"`
+	Error *struct {
+		Msg string `json:"msg"`
+	} `json:"error"`
+}
+
+// coreAdmin ejecuta una accion de la CoreAdmin API (/solr/admin/cores), que solr-go no cubre
+func (searchEngine Solr) coreAdmin(ctx context.Context, params url.Values) error {
+	params.Set("wt", "json")
+	endpoint := fmt.Sprintf("%s/solr/admin/cores?%s", searchEngine.baseURL, params.Encode())
+	httpResp, err := searchEngine.requestSender.SendRequest(ctx, http.MethodGet, endpoint, "", nil)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+	return checkStatusResponse(httpResp, "core admin "+params.Get("action"))
+}
+
+// checkStatusResponse devuelve el error de una respuesta de Solr, si lo hay
+func checkStatusResponse(httpResp *http.Response, operation string) error {
+	var resp statusResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return fmt.Errorf("error decoding %s response (status %d): %w", operation, httpResp.StatusCode, err)
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("%s", resp.Error.Msg)
+	}
+	if httpResp.StatusCode != http.StatusOK || resp.ResponseHeader.Status != 0 {
+		return fmt.Errorf("%s failed with status %d", operation, httpResp.StatusCode)
+	}
+	return nil
+}
 
 // Funcion para buscar hoteles en Solr
-func (searchEngine Solr) Search(ctx context.Context, query string, limit int, offset int) ([]hotels.Hotel, error) {
-	// Construye la query de busqueda
-	solrQuery := fmt.Sprintf("q=(name:%s OR description:%s)&rows=%d&start=%d", query, query, limit, offset)
+// Devuelve la pagina pedida, el total de coincidencias y los facets para el sidebar.
+// Si se pasa cursor ("*" para la primera pagina) se pagina con cursorMark en vez de offset
+func (searchEngine Solr) Search(ctx context.Context, query string, filters hotels.SearchFilters, sort string, limit int, offset int, cursor string) (hotels.SearchResult, error) {
+	sortClause, ok := sortClauses[sort]
+	if !ok {
+		return hotels.SearchResult{}, fmt.Errorf("unsupported sort %q", sort)
+	}
+	hasLocation := filters.Latitude != nil && filters.Longitude != nil
+	if sort == "distance" && !hasLocation {
+		return hotels.SearchResult{}, fmt.Errorf("sort distance requires a location")
+	}
+
+	// Construye la query de busqueda (sin texto se buscan todos los hoteles y solo aplican los filtros).
+	// El texto del usuario se escapa y se busca con edismax, nunca se arma sintaxis de Solr con el
+	solrQuery := "*:*"
+	params := solr.M{}
+	if text := escapeQueryText(query); text != "" {
+		solrQuery = text
+		for key, value := range edismaxParams() {
+			params[key] = value
+		}
+	}
+
+	// Los filtros van como fq: no afectan el score y Solr los cachea por separado
+	request := solr.NewQuery(solrQuery).
+		Filters(append(buildFilterQueries(filters), liveDocumentsFilter)...).
+		Limit(limit).
+		Facets(buildFacets()...).
+		Sort(sortClause)
+	if hasLocation {
+		// geodist() usa sfield/pt: devuelve la distancia en km de cada hotel y permite ordenar por ella
+		params["sfield"] = "location"
+		params["pt"] = locationValue(filters.Latitude, filters.Longitude)
+		request = request.Fields("*", "distance:geodist()")
+	}
+	if cursor != "" {
+		// Con cursorMark Solr no acepta start, la posicion la da el cursor
+		params["cursorMark"] = cursor
+	} else {
+		request = request.Offset(offset)
+	}
+	if len(params) > 0 {
+		request = request.Params(params)
+	}
 
 	// Ejecuta la query en Solr
-	resp, err := searchEngine.Client.Query(ctx, searchEngine.Collection, solr.NewQuery(solrQuery))
+	resp, err := searchEngine.query(ctx, request)
 	if err != nil {
-		return nil, fmt.Errorf("error executing search query: %w", err)
+		return hotels.SearchResult{}, fmt.Errorf("error executing search query: %w", err)
 	}
-	if resp.Error != nil {
-		return nil, fmt.Errorf("failed to execute search query: %v", resp.Error)
+	if resp.BaseResponse != nil && resp.Error != nil {
+		return hotels.SearchResult{}, fmt.Errorf("failed to execute search query: %v", resp.Error)
 	}
 
 	// Itera sobre los documentos de la respuesta y los convierte en hoteles
@@ -210,31 +539,348 @@ func (searchEngine Solr) Search(ctx context.Context, query string, limit int, of
 
 		// Lo convierte en un objeto de tipo Hotel y lo agrega a la lista
 		hotel := hotels.Hotel{
-			ID:        getStringField(doc, "id"),
-			Name:      getStringField(doc, "name"),
-			Description: getStringField(doc, "description"),
-			Address:   getStringField(doc, "address"),
-			City:      getStringField(doc, "city"),
-			State:     getStringField(doc, "state"),
-			Country:   getStringField(doc, "country"),
-			Phone:     getStringField(doc, "phone"),
-			Email:     getStringField(doc, "email"),
-			PricePerNight: getFloatField(doc, "price_per_night"),
-			AvaiableRooms: int(getFloatField(doc, "avaiable_rooms")),
-			CheckInTime: getTimeField(doc, "check_in_time"),
-			CheckOutTime: getTimeField(doc, "check_out_time"),
-			Rating:    getFloatField(doc, "rating"),
-			Amenities: amenities,
-			Images: images,
+			ID:             getStringField(doc, "id"),
+			Name:           getStringField(doc, "name"),
+			Description:    getStringField(doc, "description"),
+			Address:        getStringField(doc, "address"),
+			City:           getStringField(doc, "city"),
+			State:          getStringField(doc, "state"),
+			Country:        getStringField(doc, "country"),
+			Phone:          getStringField(doc, "phone"),
+			Email:          getStringField(doc, "email"),
+			PricePerNight:  getFloatField(doc, "price_per_night"),
+			AvaiableRooms:  int(getFloatField(doc, "avaiable_rooms")),
+			CheckInTime:    getTimeField(doc, "check_in_time"),
+			CheckOutTime:   getTimeField(doc, "check_out_time"),
+			Rating:         getFloatField(doc, "rating"),
+			Amenities:      amenities,
+			Images:         images,
+			MinPrice:       getFloatField(doc, "min_price"),
+			RoomCapacities: getIntsField(doc, "room_capacities"),
+			MaxCapacity:    int(getFloatField(doc, "max_capacity")),
+			TotalRooms:     int(getFloatField(doc, "total_rooms")),
+			EventSequence:  int64(getFloatField(doc, "event_sequence")),
+		}
+		hotel.Latitude, hotel.Longitude = parseLocation(getStringField(doc, "location"))
+		if distance, ok := doc["distance"].(float64); ok {
+			hotel.DistanceKm = &distance
 		}
 		// Agrega el hotel a la lista
 		hotelsList = append(hotelsList, hotel)
 	}
 
-	// Devuelve la lista de hoteles
-	return hotelsList, nil
+	// Devuelve la lista de hoteles con el total y los facets
+	return hotels.SearchResult{
+		Hotels: hotelsList,
+		Total:  resp.Response.NumFound,
+		Facets: parseFacets(resp.Facets),
+		// Solr devuelve el mismo cursor cuando no hay mas resultados
+		NextCursor: nextCursor(cursor, resp.NextCursorMark),
+	}, nil
+}
+
+// Suggest busca hoteles cuyo nombre y ciudades que empiezan con prefix (cada palabra es un prefijo de alguna palabra del campo),
+// hasta limit de cada uno. Los hoteles vienen por relevancia y despues rating; las ciudades por cantidad de hoteles
+func (searchEngine Solr) Suggest(ctx context.Context, prefix string, limit int) (hotels.Suggestions, error) {
+	text := escapeQueryText(prefix)
+	if text == "" || limit <= 0 {
+		return hotels.Suggestions{}, nil
+	}
+
+	// El texto va en un parametro aparte (sq) que usan las dos queries: nombres en la principal, ciudades en el dominio del facet
+	request := solr.NewQuery("{!edismax qf=name_suggest v=$sq}").
+		Fields("id", "name", "city").
+		Limit(limit).
+		Sort("score desc, rating desc, name_sort asc").
+		Facets(solr.NewTermsFacet("cities").
+			Field("city_facet").
+			Limit(limit).
+			MinCount(1).
+			AddToDomain("query", "{!edismax qf=city_suggest v=$sq}")).
+		Params(solr.M{
+			"sq": text,
+			"mm": "100%",
+			"uf": "-*",
+		})
+
+	resp, err := searchEngine.query(ctx, request)
+	if err != nil {
+		return hotels.Suggestions{}, fmt.Errorf("error executing suggest query: %w", err)
+	}
+	if resp.BaseResponse != nil && resp.Error != nil {
+		return hotels.Suggestions{}, fmt.Errorf("failed to execute suggest query: %v", resp.Error)
+	}
+
+	suggestions := hotels.Suggestions{
+		Cities: parseTermsFacet(resp.Facets, "cities"),
+	}
+	for _, doc := range resp.Response.Documents {
+		suggestions.Hotels = append(suggestions.Hotels, hotels.Hotel{
+			ID:   getStringField(doc, "id"),
+			Name: getStringField(doc, "name"),
+			City: getStringField(doc, "city"),
+		})
+	}
+	return suggestions, nil
+}
+
+// Desempate de todos los ordenes: relevancia y despues id, asi el orden es estable entre paginas (cursorMark lo exige)
+const sortTieBreaker = "score desc, id asc"
+
+// sortClauses mapea los ordenes aceptados ("" es relevancia) a la clausula sort de Solr
+var sortClauses = map[string]string{
+	"":            sortTieBreaker,
+	"relevance":   sortTieBreaker,
+	"price_asc":   "min_price asc, " + sortTieBreaker,
+	"price_desc":  "min_price desc, " + sortTieBreaker,
+	"rating_desc": "rating desc, " + sortTieBreaker,
+	"name_asc":    "name_sort asc, " + sortTieBreaker,
+	"distance":    "geodist() asc, " + sortTieBreaker,
+}
+
+// locationValue arma el punto "lat,lng" que usa Solr, vacio si falta alguna coordenada
+func locationValue(latitude *float64, longitude *float64) string {
+	if latitude == nil || longitude == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*latitude, 'f', -1, 64) + "," + strconv.FormatFloat(*longitude, 'f', -1, 64)
+}
+
+// parseLocation lee un punto "lat,lng" de Solr
+func parseLocation(value string) (*float64, *float64) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 2 {
+		return nil, nil
+	}
+	latitude, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return nil, nil
+	}
+	longitude, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return nil, nil
+	}
+	return &latitude, &longitude
+}
+
+// edismaxParams son los parametros del parser edismax: campos con boost (qf) y boost de frase (pf).
+// uf=-* impide que el usuario consulte campos con "campo:valor" aunque se colara algun ':'
+func edismaxParams() solr.M {
+	return solr.M{
+		"defType": "edismax",
+		"qf":      "name^3 city^2 description",
+		"pf":      "name^3 city^2 description",
+		"uf":      "-*",
+	}
+}
+
+// Caracteres especiales de la sintaxis de Solr/Lucene que se escapan con \
+const solrSpecialChars = `\+-&|!(){}[]^"~*?:/`
+
+// escapeQueryText escapa los caracteres especiales de Solr y neutraliza los operadores AND/OR/NOT
+// (se pasan a minuscula, los campos de texto no distinguen mayusculas), asi la entrada del usuario
+// siempre se interpreta como texto libre
+func escapeQueryText(query string) string {
+	var words []string
+	for _, word := range strings.Fields(query) {
+		var escaped strings.Builder
+		for _, char := range word {
+			if strings.ContainsRune(solrSpecialChars, char) {
+				escaped.WriteRune('\\')
+			}
+			escaped.WriteRune(char)
+		}
+		words = append(words, escaped.String())
+	}
+	return strings.ToLower(strings.Join(words, " "))
+}
+
+// cursorQueryResponse es la respuesta de /query con nextCursorMark, que el cliente de solr-go no expone
+type cursorQueryResponse struct {
+	solr.QueryResponse
+	NextCursorMark string `json:"nextCursorMark,omitempty"`
+}
+
+// query ejecuta la query contra /query igual que solr-go pero leyendo tambien nextCursorMark
+func (searchEngine Solr) query(ctx context.Context, request *solr.Query) (*cursorQueryResponse, error) {
+	body, err := json.Marshal(request.BuildQuery())
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling search query: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/solr/%s/query", searchEngine.baseURL, searchEngine.Collection)
+	httpResp, err := searchEngine.requestSender.SendRequest(ctx, http.MethodPost, url, solr.JSON.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	var resp cursorQueryResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("error decoding search response (status %d): %w", httpResp.StatusCode, err)
+	}
+	return &resp, nil
+}
+
+// nextCursor devuelve el cursor de la pagina siguiente, vacio si no se pagina con cursor o si ya no hay mas
+func nextCursor(cursor string, next string) string {
+	if cursor == "" || next == cursor {
+		return ""
+	}
+	return next
+}
+
+// facetRange es un rango fijo de los facets de precio y rating (To nil = sin limite superior)
+type facetRange struct {
+	Label string
+	From  float64
+	To    *float64
+}
+
+func upTo(value float64) *float64 {
+	return &value
+}
+
+// Rangos de precio (sobre min_price) y de rating minimo que se cuentan en cada busqueda
+var (
+	priceFacetRanges = []facetRange{
+		{Label: "0-100", From: 0, To: upTo(100)},
+		{Label: "100-200", From: 100, To: upTo(200)},
+		{Label: "200-300", From: 200, To: upTo(300)},
+		{Label: "300+", From: 300},
+	}
+	ratingFacetRanges = []facetRange{
+		{Label: "4+", From: 4},
+		{Label: "3+", From: 3},
+		{Label: "2+", From: 2},
+	}
+)
+
+// Cantidad maxima de valores que se devuelven en los facets de terminos
+const termsFacetLimit = 20
+
+// buildFacets arma los facets de la JSON Facet API: terminos para city, country y amenities, y un query facet por rango
+func buildFacets() []solr.Faceter {
+	facets := []solr.Faceter{
+		solr.NewTermsFacet("city").Field("city_facet").Limit(termsFacetLimit).MinCount(1),
+		solr.NewTermsFacet("country").Field("country_facet").Limit(termsFacetLimit).MinCount(1),
+		solr.NewTermsFacet("amenities").Field("amenities").Limit(termsFacetLimit).MinCount(1),
+	}
+	// Los rangos son [From TO To} para que un precio de 100 cuente solo en "100-200"
+	for i, priceRange := range priceFacetRanges {
+		upper := "*]"
+		if priceRange.To != nil {
+			upper = strconv.FormatFloat(*priceRange.To, 'f', -1, 64) + "}"
+		}
+		facets = append(facets, solr.NewQueryFacet(fmt.Sprintf("price_%d", i)).
+			Query(fmt.Sprintf("min_price:[%s TO %s", strconv.FormatFloat(priceRange.From, 'f', -1, 64), upper)))
+	}
+	for i, ratingRange := range ratingFacetRanges {
+		facets = append(facets, solr.NewQueryFacet(fmt.Sprintf("rating_%d", i)).
+			Query(fmt.Sprintf("rating:[%s TO *]", strconv.FormatFloat(ratingRange.From, 'f', -1, 64))))
+	}
+	return facets
+}
+
+// parseFacets convierte la seccion "facets" de la respuesta de Solr
+func parseFacets(facets map[string]interface{}) hotels.Facets {
+	result := hotels.Facets{
+		City:      parseTermsFacet(facets, "city"),
+		Country:   parseTermsFacet(facets, "country"),
+		Amenities: parseTermsFacet(facets, "amenities"),
+	}
+	for i, priceRange := range priceFacetRanges {
+		result.Price = append(result.Price, parseRangeFacet(facets, fmt.Sprintf("price_%d", i), priceRange))
+	}
+	for i, ratingRange := range ratingFacetRanges {
+		result.Rating = append(result.Rating, parseRangeFacet(facets, fmt.Sprintf("rating_%d", i), ratingRange))
+	}
+	return result
+}
+
+// parseTermsFacet lee los buckets {val, count} de un facet de terminos
+func parseTermsFacet(facets map[string]interface{}, name string) []hotels.FacetBucket {
+	var buckets []hotels.FacetBucket
+	facet, ok := facets[name].(map[string]interface{})
+	if !ok {
+		return buckets
+	}
+	items, _ := facet["buckets"].([]interface{})
+	for _, item := range items {
+		bucket, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		buckets = append(buckets, hotels.FacetBucket{
+			Value: fmt.Sprint(bucket["val"]),
+			Count: int(getFloatField(bucket, "count")),
+		})
+	}
+	return buckets
+}
+
+// parseRangeFacet lee el conteo de un query facet de rango
+func parseRangeFacet(facets map[string]interface{}, name string, facetRange facetRange) hotels.FacetBucket {
+	from := facetRange.From
+	bucket := hotels.FacetBucket{
+		Value: facetRange.Label,
+		From:  &from,
+		To:    facetRange.To,
+	}
+	if facet, ok := facets[name].(map[string]interface{}); ok {
+		bucket.Count = int(getFloatField(facet, "count"))
+	}
+	return bucket
+}
+
+// buildFilterQueries arma un fq por cada filtro para que cada uno se cachee por separado en Solr
+func buildFilterQueries(filters hotels.SearchFilters) []string {
+	var fqs []string
+	if filters.City != "" {
+		fqs = append(fqs, fmt.Sprintf("city:%s", quoteFilterValue(filters.City)))
+	}
+	if filters.Country != "" {
+		fqs = append(fqs, fmt.Sprintf("country:%s", quoteFilterValue(filters.Country)))
+	}
+	if filters.MinPrice != nil || filters.MaxPrice != nil {
+		fqs = append(fqs, fmt.Sprintf("min_price:[%s TO %s]", rangeBound(filters.MinPrice), rangeBound(filters.MaxPrice)))
+	}
+	if filters.MinRating != nil {
+		fqs = append(fqs, fmt.Sprintf("rating:[%s TO *]", rangeBound(filters.MinRating)))
+	}
+	// Un fq por amenity: el hotel tiene que tenerlas todas
+	for _, amenity := range filters.Amenities {
+		if amenity != "" {
+			fqs = append(fqs, fmt.Sprintf("amenities:%s", quoteFilterValue(amenity)))
+		}
+	}
+	if filters.Rooms > 0 {
+		fqs = append(fqs, fmt.Sprintf("total_rooms:[%d TO *]", filters.Rooms))
+	}
+	// Tiene que haber un tipo de habitacion donde entren todos
+	if filters.Guests > 0 {
+		fqs = append(fqs, fmt.Sprintf("max_capacity:[%d TO *]", filters.Guests))
+	}
+	// Radio alrededor del punto, en km
+	if point := locationValue(filters.Latitude, filters.Longitude); point != "" && filters.RadiusKm != nil {
+		fqs = append(fqs, fmt.Sprintf("{!geofilt sfield=location pt=%s d=%s}", point, rangeBound(filters.RadiusKm)))
+	}
+	return fqs
+}
+
+// quoteFilterValue pone el valor entre comillas escapando las comillas y barras que tenga
+func quoteFilterValue(value string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+	return `"` + escaped + `"`
 }
 
+// rangeBound devuelve el limite de un rango de Solr, * si no hay limite
+func rangeBound(value *float64) string {
+	if value == nil {
+		return "*"
+	}
+	return strconv.FormatFloat(*value, 'f', -1, 64)
+}
 
 // Funcion auxiliar para obtener campos de tipo time de un documento
 func getTimeField(doc map[string]interface{}, field string) time.Time {
@@ -244,7 +890,6 @@ func getTimeField(doc map[string]interface{}, field string) time.Time {
 	return time.Time{}
 }
 
-
 // Funcion auxiliar para obtener campos de tipo string de un documento
 func getStringField(doc map[string]interface{}, field string) string {
 	if val, ok := doc[field].(string); ok {
@@ -271,3 +916,16 @@ func getFloatField(doc map[string]interface{}, field string) float64 {
 	// Devuelve 0.0 si no se encuentra el campo
 	return 0.0
 }
+
+// Funcion auxiliar para obtener campos multivaluados de tipo int de un documento
+func getIntsField(doc map[string]interface{}, field string) []int {
+	var values []int
+	if val, ok := doc[field].([]interface{}); ok {
+		for _, item := range val {
+			if floatVal, ok := item.(float64); ok {
+				values = append(values, int(floatVal))
+			}
+		}
+	}
+	return values
+}
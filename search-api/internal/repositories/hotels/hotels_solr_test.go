@@ -0,0 +1,609 @@
+package hotels
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	hotelsDAO "search-api/internal/dao/hotels"
+
+	"github.com/stevenferrer/solr-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildFilterQueries(t *testing.T) {
+	t.Run("no filters", func(t *testing.T) {
+		assert.Empty(t, buildFilterQueries(hotelsDAO.SearchFilters{}))
+	})
+
+	t.Run("all filters", func(t *testing.T) {
+		minPrice, maxPrice, minRating := 50.0, 199.99, 4.5
+		fqs := buildFilterQueries(hotelsDAO.SearchFilters{
+			City:      "Buenos Aires",
+			Country:   "Argentina",
+			MinPrice:  &minPrice,
+			MaxPrice:  &maxPrice,
+			MinRating: &minRating,
+			Amenities: []string{"wifi", "pool"},
+			Rooms:     2,
+			Guests:    4,
+		})
+
+		assert.Equal(t, []string{
+			`city:"Buenos Aires"`,
+			`country:"Argentina"`,
+			`min_price:[50 TO 199.99]`,
+			`rating:[4.5 TO *]`,
+			`amenities:"wifi"`,
+			`amenities:"pool"`,
+			`total_rooms:[2 TO *]`,
+			`max_capacity:[4 TO *]`,
+		}, fqs)
+	})
+
+	t.Run("open price range", func(t *testing.T) {
+		maxPrice := 100.0
+		fqs := buildFilterQueries(hotelsDAO.SearchFilters{MaxPrice: &maxPrice})
+		assert.Equal(t, []string{`min_price:[* TO 100]`}, fqs)
+	})
+
+	t.Run("values are quoted and escaped", func(t *testing.T) {
+		fqs := buildFilterQueries(hotelsDAO.SearchFilters{City: `New "York" \ OR *:*`})
+		assert.Equal(t, []string{`city:"New \"York\" \\ OR *:*"`}, fqs)
+	})
+}
+
+func TestParseFacets(t *testing.T) {
+	facets := parseFacets(map[string]interface{}{
+		"count": float64(12),
+		"city": map[string]interface{}{
+			"buckets": []interface{}{
+				map[string]interface{}{"val": "Buenos Aires", "count": float64(7)},
+				map[string]interface{}{"val": "Cordoba", "count": float64(5)},
+			},
+		},
+		"amenities": map[string]interface{}{
+			"buckets": []interface{}{
+				map[string]interface{}{"val": "wifi", "count": float64(12)},
+			},
+		},
+		"price_0":  map[string]interface{}{"count": float64(3)},
+		"price_3":  map[string]interface{}{"count": float64(1)},
+		"rating_0": map[string]interface{}{"count": float64(6)},
+	})
+
+	assert.Equal(t, []hotelsDAO.FacetBucket{{Value: "Buenos Aires", Count: 7}, {Value: "Cordoba", Count: 5}}, facets.City)
+	assert.Empty(t, facets.Country)
+	assert.Equal(t, []hotelsDAO.FacetBucket{{Value: "wifi", Count: 12}}, facets.Amenities)
+
+	assert.Len(t, facets.Price, len(priceFacetRanges))
+	assert.Equal(t, "0-100", facets.Price[0].Value)
+	assert.Equal(t, 3, facets.Price[0].Count)
+	assert.Equal(t, 100.0, *facets.Price[0].To)
+	assert.Equal(t, 0, facets.Price[1].Count)
+	assert.Equal(t, "300+", facets.Price[3].Value)
+	assert.Nil(t, facets.Price[3].To)
+
+	assert.Len(t, facets.Rating, len(ratingFacetRanges))
+	assert.Equal(t, "4+", facets.Rating[0].Value)
+	assert.Equal(t, 6, facets.Rating[0].Count)
+}
+
+func TestBuildFacets(t *testing.T) {
+	built := map[string]interface{}{}
+	for _, facet := range buildFacets() {
+		built[facet.Name()] = facet.BuildFacet()
+	}
+
+	assert.Equal(t, "city_facet", built["city"].(solr.M)["field"])
+	assert.Equal(t, "country_facet", built["country"].(solr.M)["field"])
+	assert.Equal(t, "amenities", built["amenities"].(solr.M)["field"])
+	assert.Equal(t, "min_price:[100 TO 200}", built["price_1"].(solr.M)["q"])
+	assert.Equal(t, "min_price:[300 TO *]", built["price_3"].(solr.M)["q"])
+	assert.Equal(t, "rating:[4 TO *]", built["rating_0"].(solr.M)["q"])
+}
+
+// fakeRequestSender responde siempre con el mismo cuerpo y guarda el request
+type fakeRequestSender struct {
+	response string
+	body     map[string]interface{}
+}
+
+func (sender *fakeRequestSender) SendRequest(ctx context.Context, method, urlStr, contentType string, body io.Reader) (*http.Response, error) {
+	if err := json.NewDecoder(body).Decode(&sender.body); err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(sender.response)),
+	}, nil
+}
+
+func TestSolr_Search_Cursor(t *testing.T) {
+	t.Run("cursor paging", func(t *testing.T) {
+		sender := &fakeRequestSender{response: `{"responseHeader":{"status":0},"response":{"numFound":120,"docs":[{"id":"hotel1","name":"Hotel Paradise"}]},"nextCursorMark":"AoE"}`}
+		searchEngine := Solr{Collection: "hotels", baseURL: "http://solr:8983", requestSender: sender}
+
+		result, err := searchEngine.Search(context.Background(), "", hotelsDAO.SearchFilters{}, "", 10, 0, "*")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 120, result.Total)
+		assert.Equal(t, "AoE", result.NextCursor)
+		assert.Len(t, result.Hotels, 1)
+		assert.Equal(t, "*", sender.body["params"].(map[string]interface{})["cursorMark"])
+		assert.Equal(t, sortTieBreaker, sender.body["sort"])
+		assert.NotContains(t, sender.body, "offset")
+	})
+
+	t.Run("last cursor page", func(t *testing.T) {
+		sender := &fakeRequestSender{response: `{"responseHeader":{"status":0},"response":{"numFound":120,"docs":[]},"nextCursorMark":"AoE"}`}
+		searchEngine := Solr{Collection: "hotels", baseURL: "http://solr:8983", requestSender: sender}
+
+		result, err := searchEngine.Search(context.Background(), "", hotelsDAO.SearchFilters{}, "", 10, 0, "AoE")
+
+		assert.NoError(t, err)
+		assert.Empty(t, result.NextCursor)
+	})
+
+	t.Run("offset paging", func(t *testing.T) {
+		sender := &fakeRequestSender{response: `{"responseHeader":{"status":0},"response":{"numFound":120,"docs":[]}}`}
+		searchEngine := Solr{Collection: "hotels", baseURL: "http://solr:8983", requestSender: sender}
+
+		result, err := searchEngine.Search(context.Background(), "", hotelsDAO.SearchFilters{}, "", 10, 30, "")
+
+		assert.NoError(t, err)
+		assert.Empty(t, result.NextCursor)
+		assert.Equal(t, float64(30), sender.body["offset"])
+		assert.NotContains(t, sender.body, "params")
+	})
+
+	t.Run("solr error", func(t *testing.T) {
+		sender := &fakeRequestSender{response: `{"responseHeader":{"status":400},"error":{"code":400,"msg":"Cursor functionality requires a sort containing a uniqueKey field tie breaker"}}`}
+		searchEngine := Solr{Collection: "hotels", baseURL: "http://solr:8983", requestSender: sender}
+
+		_, err := searchEngine.Search(context.Background(), "", hotelsDAO.SearchFilters{}, "", 10, 0, "*")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "uniqueKey")
+	})
+}
+
+func TestEscapeQueryText(t *testing.T) {
+	cases := map[string]string{
+		"":                  "",
+		"   ":               "",
+		"Hotel Paradise":    "hotel paradise",
+		"  hotel   spa ":    "hotel spa",
+		"name:*":            `name\:\*`,
+		"*:*":               `\*\:\*`,
+		"hotel&rows=1000":   `hotel\&rows=1000`,
+		"hotel & spa":       `hotel \& spa`,
+		"paradise OR id:*":  `paradise or id\:\*`,
+		"NOT hotel AND spa": "not hotel and spa",
+		`"unbalanced`:       `\"unbalanced`,
+		"(hotel":            `\(hotel`,
+		`back\slash`:        `back\\slash`,
+		"a && b || !c":      `a \&\& b \|\| \!c`,
+		"price:[0 TO 100]":  `price\:\[0 to 100\]`,
+		"{!func}sum(1,1)":   `\{\!func\}sum\(1,1\)`,
+		"hotel^100 spa~2":   `hotel\^100 spa\~2`,
+		"+must -not":        `\+must \-not`,
+		"/regex.*/":         `\/regex.\*\/`,
+		"wildcard? hotel":   `wildcard\? hotel`,
+		"São Paulo":         "são paulo",
+	}
+	for input, expected := range cases {
+		assert.Equal(t, expected, escapeQueryText(input), "input %q", input)
+	}
+}
+
+func TestSolr_Search_Edismax(t *testing.T) {
+	t.Run("text query uses edismax", func(t *testing.T) {
+		sender := &fakeRequestSender{response: `{"responseHeader":{"status":0},"response":{"numFound":0,"docs":[]}}`}
+		searchEngine := Solr{Collection: "hotels", baseURL: "http://solr:8983", requestSender: sender}
+
+		_, err := searchEngine.Search(context.Background(), "hotel&rows=1000 name:*", hotelsDAO.SearchFilters{}, "", 10, 0, "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, `hotel\&rows=1000 name\:\*`, sender.body["query"])
+		params := sender.body["params"].(map[string]interface{})
+		assert.Equal(t, "edismax", params["defType"])
+		assert.Equal(t, "name^3 city^2 description", params["qf"])
+		assert.Equal(t, "name^3 city^2 description", params["pf"])
+		assert.Equal(t, "-*", params["uf"])
+		assert.Equal(t, float64(10), sender.body["limit"])
+	})
+
+	t.Run("empty query matches all", func(t *testing.T) {
+		sender := &fakeRequestSender{response: `{"responseHeader":{"status":0},"response":{"numFound":0,"docs":[]}}`}
+		searchEngine := Solr{Collection: "hotels", baseURL: "http://solr:8983", requestSender: sender}
+
+		_, err := searchEngine.Search(context.Background(), "  ", hotelsDAO.SearchFilters{}, "", 10, 0, "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "*:*", sender.body["query"])
+		assert.NotContains(t, sender.body, "params")
+	})
+
+	t.Run("edismax with cursor", func(t *testing.T) {
+		sender := &fakeRequestSender{response: `{"responseHeader":{"status":0},"response":{"numFound":0,"docs":[]},"nextCursorMark":"*"}`}
+		searchEngine := Solr{Collection: "hotels", baseURL: "http://solr:8983", requestSender: sender}
+
+		_, err := searchEngine.Search(context.Background(), "spa", hotelsDAO.SearchFilters{}, "", 10, 0, "*")
+
+		assert.NoError(t, err)
+		params := sender.body["params"].(map[string]interface{})
+		assert.Equal(t, "edismax", params["defType"])
+		assert.Equal(t, "*", params["cursorMark"])
+	})
+}
+
+func TestSolr_Search_Sort(t *testing.T) {
+	cases := map[string]string{
+		"":            "score desc, id asc",
+		"relevance":   "score desc, id asc",
+		"price_asc":   "min_price asc, score desc, id asc",
+		"price_desc":  "min_price desc, score desc, id asc",
+		"rating_desc": "rating desc, score desc, id asc",
+		"name_asc":    "name_sort asc, score desc, id asc",
+	}
+	for sort, expected := range cases {
+		sender := &fakeRequestSender{response: `{"responseHeader":{"status":0},"response":{"numFound":0,"docs":[]}}`}
+		searchEngine := Solr{Collection: "hotels", baseURL: "http://solr:8983", requestSender: sender}
+
+		_, err := searchEngine.Search(context.Background(), "hotel", hotelsDAO.SearchFilters{}, sort, 10, 0, "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, sender.body["sort"], "sort %q", sort)
+	}
+
+	t.Run("unsupported sort", func(t *testing.T) {
+		sender := &fakeRequestSender{}
+		searchEngine := Solr{Collection: "hotels", baseURL: "http://solr:8983", requestSender: sender}
+
+		_, err := searchEngine.Search(context.Background(), "hotel", hotelsDAO.SearchFilters{}, "id desc", 10, 0, "")
+
+		assert.Error(t, err)
+		assert.Nil(t, sender.body)
+	})
+}
+
+func TestSolr_Search_Location(t *testing.T) {
+	latitude, longitude, radius := -34.6037, -58.3816, 5.0
+	filters := hotelsDAO.SearchFilters{Latitude: &latitude, Longitude: &longitude, RadiusKm: &radius}
+
+	t.Run("geofilt, geodist and distance sort", func(t *testing.T) {
+		sender := &fakeRequestSender{response: `{"responseHeader":{"status":0},"response":{"numFound":1,"docs":[{"id":"hotel1","location":"-34.6,-58.38","distance":0.41}]}}`}
+		searchEngine := Solr{Collection: "hotels", baseURL: "http://solr:8983", requestSender: sender}
+
+		result, err := searchEngine.Search(context.Background(), "", filters, "distance", 10, 0, "")
+
+		assert.NoError(t, err)
+		assert.Contains(t, sender.body["filter"], "{!geofilt sfield=location pt=-34.6037,-58.3816 d=5}")
+		// Las lapidas de los hoteles borrados nunca aparecen en la busqueda
+		assert.Contains(t, sender.body["filter"], "-deleted:true")
+		assert.Equal(t, "geodist() asc, score desc, id asc", sender.body["sort"])
+		assert.Equal(t, []interface{}{"*", "distance:geodist()"}, sender.body["fields"])
+		params := sender.body["params"].(map[string]interface{})
+		assert.Equal(t, "location", params["sfield"])
+		assert.Equal(t, "-34.6037,-58.3816", params["pt"])
+
+		hotel := result.Hotels[0]
+		assert.Equal(t, -34.6, *hotel.Latitude)
+		assert.Equal(t, -58.38, *hotel.Longitude)
+		assert.Equal(t, 0.41, *hotel.DistanceKm)
+	})
+
+	t.Run("distance sort without location", func(t *testing.T) {
+		sender := &fakeRequestSender{}
+		searchEngine := Solr{Collection: "hotels", baseURL: "http://solr:8983", requestSender: sender}
+
+		_, err := searchEngine.Search(context.Background(), "", hotelsDAO.SearchFilters{}, "distance", 10, 0, "")
+
+		assert.Error(t, err)
+		assert.Nil(t, sender.body)
+	})
+
+	t.Run("no location, no distance", func(t *testing.T) {
+		sender := &fakeRequestSender{response: `{"responseHeader":{"status":0},"response":{"numFound":1,"docs":[{"id":"hotel1"}]}}`}
+		searchEngine := Solr{Collection: "hotels", baseURL: "http://solr:8983", requestSender: sender}
+
+		result, err := searchEngine.Search(context.Background(), "", hotelsDAO.SearchFilters{}, "", 10, 0, "")
+
+		assert.NoError(t, err)
+		assert.NotContains(t, sender.body, "fields")
+		assert.Nil(t, result.Hotels[0].Latitude)
+		assert.Nil(t, result.Hotels[0].DistanceKm)
+	})
+}
+
+func TestLocationValue(t *testing.T) {
+	latitude, longitude := 40.4168, -3.7038
+	assert.Equal(t, "40.4168,-3.7038", locationValue(&latitude, &longitude))
+	assert.Equal(t, "", locationValue(&latitude, nil))
+
+	parsedLatitude, parsedLongitude := parseLocation("40.4168,-3.7038")
+	assert.Equal(t, latitude, *parsedLatitude)
+	assert.Equal(t, longitude, *parsedLongitude)
+
+	parsedLatitude, parsedLongitude = parseLocation("not a point")
+	assert.Nil(t, parsedLatitude)
+	assert.Nil(t, parsedLongitude)
+}
+
+func TestSolr_Suggest(t *testing.T) {
+	t.Run("names by relevance and cities from the facet", func(t *testing.T) {
+		sender := &fakeRequestSender{response: `{"responseHeader":{"status":0},"response":{"numFound":1,"docs":[{"id":"hotel1","name":"Hotel Buenavista","city":"Cancun"}]},"facets":{"count":3,"cities":{"buckets":[{"val":"Buenos Aires","count":3}]}}}`}
+		searchEngine := Solr{Collection: "hotels", baseURL: "http://solr:8983", requestSender: sender}
+
+		suggestions, err := searchEngine.Suggest(context.Background(), "bue (", 5)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "{!edismax qf=name_suggest v=$sq}", sender.body["query"])
+		assert.Equal(t, float64(5), sender.body["limit"])
+		params := sender.body["params"].(map[string]interface{})
+		assert.Equal(t, `bue \(`, params["sq"])
+		assert.Equal(t, "-*", params["uf"])
+		facet := sender.body["facet"].(map[string]interface{})["cities"].(map[string]interface{})
+		assert.Equal(t, "city_facet", facet["field"])
+		assert.Equal(t, map[string]interface{}{"query": "{!edismax qf=city_suggest v=$sq}"}, facet["domain"])
+
+		assert.Equal(t, []hotelsDAO.FacetBucket{{Value: "Buenos Aires", Count: 3}}, suggestions.Cities)
+		assert.Equal(t, []hotelsDAO.Hotel{{ID: "hotel1", Name: "Hotel Buenavista", City: "Cancun"}}, suggestions.Hotels)
+	})
+
+	t.Run("empty prefix does not query solr", func(t *testing.T) {
+		sender := &fakeRequestSender{}
+		searchEngine := Solr{Collection: "hotels", baseURL: "http://solr:8983", requestSender: sender}
+
+		suggestions, err := searchEngine.Suggest(context.Background(), "  ", 5)
+
+		assert.NoError(t, err)
+		assert.Empty(t, suggestions.Hotels)
+		assert.Nil(t, sender.body)
+	})
+}
+
+// coreAdminSender responde como la CoreAdmin API (o el update) y guarda las URLs y los cuerpos pedidos
+type coreAdminSender struct {
+	response  string
+	responses []string // Si tiene respuestas, contesta una por request (en orden) en lugar de response
+	urls      []*url.URL
+	bodies    []map[string]interface{}
+}
+
+func (sender *coreAdminSender) SendRequest(ctx context.Context, method, urlStr, contentType string, body io.Reader) (*http.Response, error) {
+	parsed, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, err
+	}
+	sender.urls = append(sender.urls, parsed)
+	if body != nil {
+		var decoded map[string]interface{}
+		if err := json.NewDecoder(body).Decode(&decoded); err != nil {
+			return nil, err
+		}
+		sender.bodies = append(sender.bodies, decoded)
+	}
+	response := sender.response
+	if len(sender.responses) > 0 {
+		response, sender.responses = sender.responses[0], sender.responses[1:]
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(response)),
+	}, nil
+}
+
+func TestSolr_BuildCore(t *testing.T) {
+	t.Run("create uses the configset and starts mirroring", func(t *testing.T) {
+		sender := &coreAdminSender{response: `{"responseHeader":{"status":0}}`}
+		searchEngine := Solr{Collection: "hotels", baseURL: "http://solr:8983", configSet: "hotels", requestSender: sender, build: &buildCore{}}
+
+		core, err := searchEngine.CreateBuildCore(context.Background())
+
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(core, "hotels_build_"))
+		assert.Equal(t, core, searchEngine.build.current())
+		params := sender.urls[0].Query()
+		assert.Equal(t, "/solr/admin/cores", sender.urls[0].Path)
+		assert.Equal(t, "CREATE", params.Get("action"))
+		assert.Equal(t, core, params.Get("name"))
+		assert.Equal(t, "hotels", params.Get("configSet"))
+	})
+
+	t.Run("create error", func(t *testing.T) {
+		sender := &coreAdminSender{response: `{"responseHeader":{"status":400},"error":{"msg":"Could not load configuration"}}`}
+		searchEngine := Solr{Collection: "hotels", baseURL: "http://solr:8983", configSet: "hotels", requestSender: sender, build: &buildCore{}}
+
+		_, err := searchEngine.CreateBuildCore(context.Background())
+
+		assert.ErrorContains(t, err, "Could not load configuration")
+		assert.Equal(t, "", searchEngine.build.current())
+	})
+
+	t.Run("drop unloads the core and stops mirroring", func(t *testing.T) {
+		sender := &coreAdminSender{response: `{"responseHeader":{"status":0}}`}
+		searchEngine := Solr{Collection: "hotels", baseURL: "http://solr:8983", requestSender: sender, build: &buildCore{name: "hotels_build_1"}}
+
+		err := searchEngine.DropBuildCore(context.Background(), "hotels_build_1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "", searchEngine.build.current())
+		params := sender.urls[0].Query()
+		assert.Equal(t, "UNLOAD", params.Get("action"))
+		assert.Equal(t, "hotels_build_1", params.Get("core"))
+		assert.Equal(t, "true", params.Get("deleteInstanceDir"))
+	})
+}
+
+func TestHotelDocument(t *testing.T) {
+	latitude, longitude := -34.6, -58.38
+	doc := hotelDocument(hotelsDAO.Hotel{ID: "hotel1", Name: "Hotel Paradise", MinPrice: 80, TotalRooms: 6, Latitude: &latitude, Longitude: &longitude})
+
+	assert.Equal(t, "hotel1", doc["id"])
+	assert.Equal(t, "Hotel Paradise", doc["name"])
+	assert.Equal(t, float64(80), doc["min_price"])
+	assert.Equal(t, 6, doc["total_rooms"])
+	assert.Equal(t, "-34.6,-58.38", doc["location"])
+
+	assert.NotContains(t, hotelDocument(hotelsDAO.Hotel{ID: "hotel2"}), "location")
+}
+
+func TestSolr_Apply(t *testing.T) {
+	t.Run("one update request with commitWithin", func(t *testing.T) {
+		sender := &coreAdminSender{response: `{"responseHeader":{"status":0}}`}
+		searchEngine := Solr{Collection: "hotels", baseURL: "http://solr:8983", commitWithin: time.Second, requestSender: sender, build: &buildCore{}}
+
+		err := searchEngine.Apply(context.Background(), []hotelsDAO.IndexChange{
+			{Hotel: &hotelsDAO.Hotel{ID: "hotel1", Name: "Hotel Paradise"}},
+			{DeleteID: "hotel2"},
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, sender.urls, 1)
+		assert.Equal(t, "/solr/hotels/update", sender.urls[0].Path)
+		assert.Equal(t, "1000", sender.urls[0].Query().Get("commitWithin"))
+		docs := sender.bodies[0]["add"].([]interface{})
+		assert.Len(t, docs, 1)
+		assert.Equal(t, "hotel1", docs[0].(map[string]interface{})["id"])
+		assert.Equal(t, []interface{}{"hotel2"}, sender.bodies[0]["delete"])
+	})
+
+	t.Run("changes are copied to the reindex core", func(t *testing.T) {
+		sender := &coreAdminSender{response: `{"responseHeader":{"status":0}}`}
+		searchEngine := Solr{Collection: "hotels", baseURL: "http://solr:8983", commitWithin: time.Second, requestSender: sender, build: &buildCore{name: "hotels_build_1"}}
+
+		err := searchEngine.Apply(context.Background(), []hotelsDAO.IndexChange{{DeleteID: "hotel1"}})
+
+		assert.NoError(t, err)
+		assert.Len(t, sender.urls, 2)
+		assert.Equal(t, "/solr/hotels_build_1/update", sender.urls[1].Path)
+		assert.Equal(t, sender.bodies[0], sender.bodies[1])
+	})
+
+	t.Run("solr error", func(t *testing.T) {
+		sender := &coreAdminSender{response: `{"responseHeader":{"status":400},"error":{"msg":"Document is missing mandatory uniqueKey field: id"}}`}
+		searchEngine := Solr{Collection: "hotels", baseURL: "http://solr:8983", commitWithin: time.Second, requestSender: sender, build: &buildCore{name: "hotels_build_1"}}
+
+		err := searchEngine.Apply(context.Background(), []hotelsDAO.IndexChange{{Hotel: &hotelsDAO.Hotel{}}})
+
+		assert.ErrorContains(t, err, "mandatory uniqueKey")
+		// Si falla la coleccion no se copia al core del reindex
+		assert.Len(t, sender.urls, 1)
+	})
+}
+
+func TestUpdateRequest(t *testing.T) {
+	// Si un hotel cambia varias veces en la tanda queda el ultimo cambio
+	request := updateRequest([]hotelsDAO.IndexChange{
+		{Hotel: &hotelsDAO.Hotel{ID: "hotel1", Name: "Old name"}},
+		{DeleteID: "hotel2"},
+		{Hotel: &hotelsDAO.Hotel{ID: "hotel1", Name: "New name"}},
+		{Hotel: &hotelsDAO.Hotel{ID: "hotel2"}},
+		{Hotel: &hotelsDAO.Hotel{ID: "hotel3"}},
+		{DeleteID: "hotel3"},
+	})
+
+	docs := request["add"].([]interface{})
+	assert.Len(t, docs, 2)
+	assert.Equal(t, "New name", docs[0].(map[string]interface{})["name"])
+	assert.Equal(t, "hotel2", docs[1].(map[string]interface{})["id"])
+	assert.Equal(t, []string{"hotel3"}, request["delete"])
+
+	assert.NotContains(t, updateRequest([]hotelsDAO.IndexChange{{DeleteID: "hotel1"}}), "add")
+
+	// Un borrado con secuencia deja una lapida en vez de borrar el documento
+	tombstone := updateRequest([]hotelsDAO.IndexChange{{DeleteID: "hotel1", Sequence: 7}})
+	assert.NotContains(t, tombstone, "delete")
+	assert.Equal(t, []interface{}{map[string]interface{}{"id": "hotel1", "deleted": true, "event_sequence": int64(7)}}, tombstone["add"])
+}
+
+func TestSolr_Sequences(t *testing.T) {
+	t.Run("real-time get of the applied sequences", func(t *testing.T) {
+		sender := &coreAdminSender{response: `{"response":{"numFound":2,"start":0,"docs":[{"id":"hotel1","event_sequence":4},{"id":"hotel2","event_sequence":9}]}}`}
+		searchEngine := Solr{Collection: "hotels", baseURL: "http://solr:8983", requestSender: sender}
+
+		sequences, err := searchEngine.Sequences(context.Background(), []string{"hotel1", "hotel2", "hotel3"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]int64{"hotel1": 4, "hotel2": 9}, sequences)
+		assert.Equal(t, "/solr/hotels/get", sender.urls[0].Path)
+		assert.Equal(t, []string{"hotel1", "hotel2", "hotel3"}, sender.urls[0].Query()["id"])
+	})
+
+	t.Run("solr error", func(t *testing.T) {
+		sender := &coreAdminSender{response: `{"error":{"code":400,"msg":"missing core"}}`}
+		searchEngine := Solr{Collection: "hotels", baseURL: "http://solr:8983", requestSender: sender}
+
+		_, err := searchEngine.Sequences(context.Background(), []string{"hotel1"})
+
+		assert.ErrorContains(t, err, "missing core")
+	})
+
+	t.Run("no ids", func(t *testing.T) {
+		sender := &coreAdminSender{}
+		searchEngine := Solr{Collection: "hotels", baseURL: "http://solr:8983", requestSender: sender}
+
+		sequences, err := searchEngine.Sequences(context.Background(), nil)
+
+		assert.NoError(t, err)
+		assert.Empty(t, sequences)
+		assert.Empty(t, sender.urls)
+	})
+}
+
+func TestSolr_CopyTombstones(t *testing.T) {
+	t.Run("copies the tombstones the new core does not have yet", func(t *testing.T) {
+		sender := &coreAdminSender{responses: []string{
+			`{"response":{"docs":[{"id":"hotel1","event_sequence":3},{"id":"hotel2","event_sequence":5}]},"nextCursorMark":"AoE1"}`,
+			// hotel2 ya se borro en el core nuevo con un evento posterior que llego durante el reindex
+			`{"response":{"docs":[{"id":"hotel2","event_sequence":6}]}}`,
+			`{"responseHeader":{"status":0}}`,
+			`{"response":{"docs":[]},"nextCursorMark":"AoE1"}`,
+		}}
+		searchEngine := Solr{Collection: "hotels", baseURL: "http://solr:8983", requestSender: sender}
+
+		copied, err := searchEngine.CopyTombstones(context.Background(), "hotels_build_1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, copied)
+		assert.Len(t, sender.urls, 4)
+		params := sender.urls[0].Query()
+		assert.Equal(t, "/solr/hotels/select", sender.urls[0].Path)
+		assert.Equal(t, "deleted:true", params.Get("q"))
+		assert.Equal(t, "*", params.Get("cursorMark"))
+		assert.Equal(t, "/solr/hotels_build_1/get", sender.urls[1].Path)
+		assert.Equal(t, []string{"hotel1", "hotel2"}, sender.urls[1].Query()["id"])
+		assert.Equal(t, "/solr/hotels_build_1/update", sender.urls[2].Path)
+		assert.Equal(t, []interface{}{map[string]interface{}{"id": "hotel1", "deleted": true, "event_sequence": float64(3)}}, sender.bodies[0]["add"])
+		assert.Equal(t, "AoE1", sender.urls[3].Query().Get("cursorMark"))
+	})
+
+	t.Run("no tombstones", func(t *testing.T) {
+		sender := &coreAdminSender{response: `{"response":{"docs":[]},"nextCursorMark":"*"}`}
+		searchEngine := Solr{Collection: "hotels", baseURL: "http://solr:8983", requestSender: sender}
+
+		copied, err := searchEngine.CopyTombstones(context.Background(), "hotels_build_1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, copied)
+		assert.Len(t, sender.urls, 1)
+	})
+
+	t.Run("solr error", func(t *testing.T) {
+		sender := &coreAdminSender{response: `{"error":{"code":400,"msg":"undefined field deleted"}}`}
+		searchEngine := Solr{Collection: "hotels", baseURL: "http://solr:8983", requestSender: sender}
+
+		_, err := searchEngine.CopyTombstones(context.Background(), "hotels_build_1")
+
+		assert.ErrorContains(t, err, "undefined field deleted")
+	})
+}
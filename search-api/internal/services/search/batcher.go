@@ -0,0 +1,245 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	hotelsDAO "search-api/internal/dao/hotels"
+	hotelsDomain "search-api/internal/domain/hotels"
+)
+
+// Funciones de solr para aplicar una tanda de cambios en un solo request y leer la secuencia aplicada de cada hotel
+type BatchRepository interface {
+	Apply(ctx context.Context, changes []hotelsDAO.IndexChange) error
+	Sequences(ctx context.Context, ids []string) (map[string]int64, error)
+}
+
+// Batcher junta los cambios que llegan por eventos y los manda a Solr en tandas: cuando se juntan maxSize cambios
+// o cuando pasa maxWait desde el primero de la tanda. Cada cambio recibe el resultado de su tanda por un canal,
+// asi el consumidor confirma el mensaje recien cuando el cambio esta en Solr
+type Batcher struct {
+	repository BatchRepository
+	maxSize    int
+	maxWait    time.Duration
+	requests   chan batchRequest
+	done       chan struct{}
+
+	mu     sync.RWMutex // Protege closed (Submit lo tiene tomado mientras encola)
+	closed bool
+
+	statsMu sync.RWMutex
+	stats   hotelsDomain.IndexerStats
+}
+
+// batchRequest es un cambio pendiente con el canal por el que se avisa el resultado
+type batchRequest struct {
+	change hotelsDAO.IndexChange
+	result chan error
+}
+
+// Funcion para crear el batcher, empieza a juntar cambios en segundo plano
+func NewBatcher(repository BatchRepository, maxSize int, maxWait time.Duration) *Batcher {
+	if maxSize < 1 {
+		maxSize = 1
+	}
+	batcher := &Batcher{
+		repository: repository,
+		maxSize:    maxSize,
+		maxWait:    maxWait,
+		requests:   make(chan batchRequest, maxSize),
+		done:       make(chan struct{}),
+	}
+	go batcher.run()
+	return batcher
+}
+
+// Submit encola un cambio. Los cambios se aplican en el orden en que llegan (si un hotel cambia dos veces
+// en la misma tanda queda el ultimo). El canal recibe nil cuando el cambio esta en Solr, o el error
+func (batcher *Batcher) Submit(change hotelsDAO.IndexChange) <-chan error {
+	result := make(chan error, 1)
+
+	batcher.mu.RLock()
+	defer batcher.mu.RUnlock()
+	if batcher.closed {
+		result <- hotelsDomain.ErrIndexerClosed
+		return result
+	}
+	batcher.requests <- batchRequest{change: change, result: result}
+	return result
+}
+
+// Close deja de aceptar cambios y manda la tanda pendiente. Espera a que termine o a que venza ctx
+func (batcher *Batcher) Close(ctx context.Context) error {
+	batcher.mu.Lock()
+	if !batcher.closed {
+		batcher.closed = true
+		close(batcher.requests)
+	}
+	batcher.mu.Unlock()
+
+	select {
+	case <-batcher.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("error flushing pending changes: %w", ctx.Err())
+	}
+}
+
+// Stats devuelve las metricas de las tandas mandadas hasta ahora
+func (batcher *Batcher) Stats() hotelsDomain.IndexerStats {
+	batcher.statsMu.RLock()
+	defer batcher.statsMu.RUnlock()
+	return batcher.stats
+}
+
+// run junta los cambios en tandas hasta que se cierra el batcher
+func (batcher *Batcher) run() {
+	defer close(batcher.done)
+
+	var pending []batchRequest
+	var timer *time.Timer
+	var timeout <-chan time.Time
+	for {
+		select {
+		case request, ok := <-batcher.requests:
+			if !ok {
+				// Se cerro el batcher: se manda lo que quedo pendiente
+				if timer != nil {
+					timer.Stop()
+				}
+				batcher.flush(pending)
+				return
+			}
+			pending = append(pending, request)
+			// La ventana de tiempo arranca con el primer cambio de la tanda
+			if len(pending) == 1 {
+				timer = time.NewTimer(batcher.maxWait)
+				timeout = timer.C
+			}
+			if len(pending) >= batcher.maxSize {
+				timer.Stop()
+				timeout = nil
+				batcher.flush(pending)
+				pending = nil
+			}
+		case <-timeout:
+			timeout = nil
+			batcher.flush(pending)
+			pending = nil
+		}
+	}
+}
+
+// flush manda una tanda en un solo request. Si Solr la rechaza se manda de a un cambio,
+// asi un documento invalido no hace fallar (y reintentar) a los demas
+func (batcher *Batcher) flush(pending []batchRequest) {
+	pending = batcher.discardStale(pending)
+	if len(pending) == 0 {
+		return
+	}
+
+	changes := make([]hotelsDAO.IndexChange, 0, len(pending))
+	for _, request := range pending {
+		changes = append(changes, request.change)
+	}
+
+	start := time.Now()
+	err := batcher.repository.Apply(context.Background(), changes)
+	latency := time.Since(start)
+
+	failed := 0
+	if err == nil {
+		for _, request := range pending {
+			request.result <- nil
+		}
+		fmt.Printf("[Solr] Tanda de %d cambios aplicada en %v\n", len(pending), latency)
+	} else if len(pending) == 1 {
+		failed = 1
+		pending[0].result <- fmt.Errorf("error applying change for hotel (%s) in Solr: %w", pending[0].change.HotelID(), err)
+	} else {
+		fmt.Printf("[Solr] Error aplicando tanda de %d cambios, se reintenta de a uno: %v\n", len(pending), err)
+		for _, request := range pending {
+			if err := batcher.repository.Apply(context.Background(), []hotelsDAO.IndexChange{request.change}); err != nil {
+				failed++
+				request.result <- fmt.Errorf("error applying change for hotel (%s) in Solr: %w", request.change.HotelID(), err)
+				continue
+			}
+			request.result <- nil
+		}
+	}
+
+	batcher.record(len(pending), latency, err != nil, failed)
+}
+
+// discardStale confirma sin aplicar los cambios viejos: los que tienen una secuencia que ya esta en Solr
+// (un reintento que llega despues de eventos mas nuevos) o menor a la de otro cambio del mismo hotel en la tanda.
+// Devuelve los cambios que hay que aplicar; si no se pueden leer las secuencias falla la tanda completa
+func (batcher *Batcher) discardStale(pending []batchRequest) []batchRequest {
+	latest := make(map[string]int64)
+	var ids []string
+	for _, request := range pending {
+		if request.change.Sequence <= 0 {
+			continue
+		}
+		id := request.change.HotelID()
+		if _, ok := latest[id]; !ok {
+			ids = append(ids, id)
+		}
+		if request.change.Sequence > latest[id] {
+			latest[id] = request.change.Sequence
+		}
+	}
+	if len(ids) == 0 {
+		return pending
+	}
+
+	applied, err := batcher.repository.Sequences(context.Background(), ids)
+	if err != nil {
+		for _, request := range pending {
+			request.result <- fmt.Errorf("error checking event sequence for hotel (%s) in Solr: %w", request.change.HotelID(), err)
+		}
+		batcher.record(len(pending), 0, true, len(pending))
+		return nil
+	}
+
+	fresh := pending[:0:0]
+	for _, request := range pending {
+		id := request.change.HotelID()
+		sequence := request.change.Sequence
+		if sequence > 0 && (sequence <= applied[id] || sequence < latest[id]) {
+			fmt.Printf("[Solr] Se descarta el evento %d del hotel %s (el ultimo es el %d)\n", sequence, id, max(applied[id], latest[id]))
+			request.result <- nil
+			continue
+		}
+		fresh = append(fresh, request)
+	}
+	return fresh
+}
+
+// record actualiza las metricas con una tanda (la latencia es la del request de la tanda completa)
+func (batcher *Batcher) record(size int, latency time.Duration, failedBatch bool, failedChanges int) {
+	batcher.statsMu.Lock()
+	defer batcher.statsMu.Unlock()
+
+	stats := &batcher.stats
+	latencyMs := float64(latency.Microseconds()) / 1000
+	stats.Batches++
+	stats.Changes += int64(size)
+	if failedBatch {
+		stats.FailedBatches++
+	}
+	stats.FailedChanges += int64(failedChanges)
+	stats.LastSize = size
+	if size > stats.MaxSize {
+		stats.MaxSize = size
+	}
+	stats.AvgSize = float64(stats.Changes) / float64(stats.Batches)
+	stats.LastLatencyMs = latencyMs
+	if latencyMs > stats.MaxLatencyMs {
+		stats.MaxLatencyMs = latencyMs
+	}
+	// Promedio acumulado para no guardar todas las latencias
+	stats.AvgLatencyMs += (latencyMs - stats.AvgLatencyMs) / float64(stats.Batches)
+}
@@ -0,0 +1,148 @@
+package search_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	hotelsDAO "search-api/internal/dao/hotels"
+	hotelsDomain "search-api/internal/domain/hotels"
+	hotelsRepo "search-api/internal/repositories/hotels"
+	service "search-api/internal/services/search"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// changeIDs devuelve un matcher de la tanda con los cambios de esos hoteles, en ese orden
+func changeIDs(ids ...string) interface{} {
+	return mock.MatchedBy(func(changes []hotelsDAO.IndexChange) bool {
+		if len(changes) != len(ids) {
+			return false
+		}
+		for i, change := range changes {
+			if change.HotelID() != ids[i] {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func TestBatcher_Submit(t *testing.T) {
+	t.Run("a full batch is sent in one request", func(t *testing.T) {
+		solrRepo := hotelsRepo.NewMock()
+		batcher := service.NewBatcher(solrRepo, 3, time.Hour)
+
+		solrRepo.On("Apply", mock.Anything, changeIDs("h1", "h2", "h3")).Return(nil).Once()
+
+		results := []<-chan error{
+			batcher.Submit(hotelsDAO.IndexChange{Hotel: &hotelsDAO.Hotel{ID: "h1"}}),
+			batcher.Submit(hotelsDAO.IndexChange{DeleteID: "h2"}),
+			batcher.Submit(hotelsDAO.IndexChange{Hotel: &hotelsDAO.Hotel{ID: "h3"}}),
+		}
+		for _, result := range results {
+			assert.NoError(t, <-result)
+		}
+
+		stats := batcher.Stats()
+		assert.Equal(t, int64(1), stats.Batches)
+		assert.Equal(t, int64(3), stats.Changes)
+		assert.Equal(t, 3, stats.LastSize)
+		assert.Equal(t, 3, stats.MaxSize)
+		solrRepo.AssertExpectations(t)
+	})
+
+	t.Run("a partial batch is sent when the window ends", func(t *testing.T) {
+		solrRepo := hotelsRepo.NewMock()
+		batcher := service.NewBatcher(solrRepo, 100, 10*time.Millisecond)
+
+		solrRepo.On("Apply", mock.Anything, changeIDs("h1", "h2")).Return(nil).Once()
+
+		first := batcher.Submit(hotelsDAO.IndexChange{Hotel: &hotelsDAO.Hotel{ID: "h1"}})
+		second := batcher.Submit(hotelsDAO.IndexChange{Hotel: &hotelsDAO.Hotel{ID: "h2"}})
+		assert.NoError(t, <-first)
+		assert.NoError(t, <-second)
+
+		assert.Equal(t, 2, batcher.Stats().LastSize)
+		solrRepo.AssertExpectations(t)
+	})
+
+	t.Run("a rejected batch is retried change by change", func(t *testing.T) {
+		solrRepo := hotelsRepo.NewMock()
+		batcher := service.NewBatcher(solrRepo, 2, time.Hour)
+
+		solrRepo.On("Apply", mock.Anything, changeIDs("h1", "h2")).Return(errors.New("bad document")).Once()
+		solrRepo.On("Apply", mock.Anything, changeIDs("h1")).Return(nil).Once()
+		solrRepo.On("Apply", mock.Anything, changeIDs("h2")).Return(errors.New("bad document")).Once()
+
+		first := batcher.Submit(hotelsDAO.IndexChange{Hotel: &hotelsDAO.Hotel{ID: "h1"}})
+		second := batcher.Submit(hotelsDAO.IndexChange{Hotel: &hotelsDAO.Hotel{ID: "h2"}})
+		assert.NoError(t, <-first)
+		assert.ErrorContains(t, <-second, "hotel (h2)")
+
+		stats := batcher.Stats()
+		assert.Equal(t, int64(1), stats.FailedBatches)
+		assert.Equal(t, int64(1), stats.FailedChanges)
+		solrRepo.AssertExpectations(t)
+	})
+}
+
+func TestBatcher_Submit_Sequence(t *testing.T) {
+	t.Run("an older change of the same hotel in the batch is discarded", func(t *testing.T) {
+		solrRepo := hotelsRepo.NewMock()
+		batcher := service.NewBatcher(solrRepo, 3, time.Hour)
+
+		solrRepo.On("Sequences", mock.Anything, []string{"h1", "h2"}).Return(map[string]int64{"h2": 8}, nil).Once()
+		solrRepo.On("Apply", mock.Anything, mock.MatchedBy(func(changes []hotelsDAO.IndexChange) bool {
+			return len(changes) == 1 && changes[0].HotelID() == "h1" && changes[0].Sequence == 5
+		})).Return(nil).Once()
+
+		results := []<-chan error{
+			batcher.Submit(hotelsDAO.IndexChange{Hotel: &hotelsDAO.Hotel{ID: "h1"}, Sequence: 5}),
+			batcher.Submit(hotelsDAO.IndexChange{Hotel: &hotelsDAO.Hotel{ID: "h1"}, Sequence: 4}),
+			// h2 ya tiene aplicado el 8 en Solr
+			batcher.Submit(hotelsDAO.IndexChange{DeleteID: "h2", Sequence: 8}),
+		}
+		for _, result := range results {
+			assert.NoError(t, <-result)
+		}
+
+		assert.Equal(t, 1, batcher.Stats().LastSize)
+		solrRepo.AssertExpectations(t)
+	})
+
+	t.Run("changes without sequence are not checked", func(t *testing.T) {
+		solrRepo := hotelsRepo.NewMock()
+		batcher := service.NewBatcher(solrRepo, 2, time.Hour)
+
+		solrRepo.On("Apply", mock.Anything, changeIDs("h1", "h1")).Return(nil).Once()
+
+		first := batcher.Submit(hotelsDAO.IndexChange{Hotel: &hotelsDAO.Hotel{ID: "h1"}})
+		second := batcher.Submit(hotelsDAO.IndexChange{DeleteID: "h1"})
+		assert.NoError(t, <-first)
+		assert.NoError(t, <-second)
+
+		solrRepo.AssertNotCalled(t, "Sequences", mock.Anything, mock.Anything)
+		solrRepo.AssertExpectations(t)
+	})
+}
+
+func TestBatcher_Close(t *testing.T) {
+	solrRepo := hotelsRepo.NewMock()
+	batcher := service.NewBatcher(solrRepo, 100, time.Hour)
+
+	solrRepo.On("Apply", mock.Anything, changeIDs("h1")).Return(nil).Once()
+
+	result := batcher.Submit(hotelsDAO.IndexChange{Hotel: &hotelsDAO.Hotel{ID: "h1"}})
+
+	// Al cerrar se manda la tanda pendiente sin esperar la ventana
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, batcher.Close(ctx))
+	assert.NoError(t, <-result)
+
+	assert.ErrorIs(t, <-batcher.Submit(hotelsDAO.IndexChange{DeleteID: "h2"}), hotelsDomain.ErrIndexerClosed)
+	solrRepo.AssertExpectations(t)
+}
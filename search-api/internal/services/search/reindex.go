@@ -0,0 +1,223 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	hotelsDAO "search-api/internal/dao/hotels"
+	hotelsDomain "search-api/internal/domain/hotels"
+)
+
+// Funciones de solr para armar el indice en un core aparte y reemplazar el actual de una vez
+type Index interface {
+	CreateBuildCore(ctx context.Context) (string, error)
+	IndexBatch(ctx context.Context, core string, hotels []hotelsDAO.Hotel) error
+	CopyTombstones(ctx context.Context, core string) (int, error)
+	SwapBuildCore(ctx context.Context, core string) error
+	DropBuildCore(ctx context.Context, core string) error
+}
+
+const (
+	// Intentos para pedir cada pagina a hotels-api antes de abortar el reindex
+	reindexPageAttempts = 3
+	// Cuantos errores por hotel se guardan en el estado (Failed los cuenta todos)
+	maxReindexErrors = 20
+)
+
+// Reindexer reconstruye el indice de Solr desde hotels-api: recorre todos los hoteles de a paginas,
+// los indexa en un core nuevo y, si termina bien, lo cambia por el actual. Hay un solo reindex a la vez
+type Reindexer struct {
+	index     Index
+	hotelsAPI ExternalRepository
+	pageSize  int
+	retryWait time.Duration
+
+	mu     sync.Mutex
+	status hotelsDomain.ReindexStatus
+}
+
+// Funcion para crear un nuevo reindexer. pageSize es la cantidad de hoteles por pagina de hotels-api
+// y retryWait la espera antes de volver a pedir una pagina que fallo (crece con cada intento)
+func NewReindexer(index Index, hotelsAPI ExternalRepository, pageSize int, retryWait time.Duration) *Reindexer {
+	return &Reindexer{
+		index:     index,
+		hotelsAPI: hotelsAPI,
+		pageSize:  pageSize,
+		retryWait: retryWait,
+		status:    hotelsDomain.ReindexStatus{State: hotelsDomain.ReindexIdle},
+	}
+}
+
+// Status devuelve el progreso del reindex en curso o el resultado del ultimo
+func (reindexer *Reindexer) Status() hotelsDomain.ReindexStatus {
+	reindexer.mu.Lock()
+	defer reindexer.mu.Unlock()
+	status := reindexer.status
+	status.Errors = append([]string(nil), reindexer.status.Errors...)
+	return status
+}
+
+// Start arranca un reindex en segundo plano (para el endpoint de admin) y devuelve el estado inicial
+func (reindexer *Reindexer) Start() (hotelsDomain.ReindexStatus, error) {
+	if err := reindexer.begin(); err != nil {
+		return reindexer.Status(), err
+	}
+	go reindexer.run(context.Background())
+	return reindexer.Status(), nil
+}
+
+// Run hace un reindex completo y espera a que termine (para el comando reindex)
+func (reindexer *Reindexer) Run(ctx context.Context) (hotelsDomain.ReindexStatus, error) {
+	if err := reindexer.begin(); err != nil {
+		return reindexer.Status(), err
+	}
+	err := reindexer.run(ctx)
+	return reindexer.Status(), err
+}
+
+// begin marca el reindex como en curso, o devuelve ErrReindexRunning si ya hay uno
+func (reindexer *Reindexer) begin() error {
+	reindexer.mu.Lock()
+	defer reindexer.mu.Unlock()
+	if reindexer.status.State == hotelsDomain.ReindexRunning {
+		return hotelsDomain.ErrReindexRunning
+	}
+	now := time.Now()
+	reindexer.status = hotelsDomain.ReindexStatus{
+		State:     hotelsDomain.ReindexRunning,
+		StartedAt: &now,
+	}
+	return nil
+}
+
+func (reindexer *Reindexer) run(ctx context.Context) error {
+	core, err := reindexer.index.CreateBuildCore(ctx)
+	if err != nil {
+		return reindexer.fail(err)
+	}
+	reindexer.update(func(status *hotelsDomain.ReindexStatus) { status.Core = core })
+	fmt.Printf("[Reindex] Indexando hoteles en el core %s\n", core)
+
+	cursor := ""
+	for {
+		page, err := reindexer.fetchPage(ctx, cursor)
+		if err != nil {
+			return reindexer.abort(core, err)
+		}
+
+		indexed, failures := reindexer.indexPage(ctx, core, page.Hotels)
+		status := reindexer.update(func(status *hotelsDomain.ReindexStatus) {
+			status.Pages++
+			status.Indexed += indexed
+			status.Failed += len(failures)
+			for _, failure := range failures {
+				if len(status.Errors) < maxReindexErrors {
+					status.Errors = append(status.Errors, failure)
+				}
+			}
+		})
+		fmt.Printf("[Reindex] Pagina %d: %d hoteles indexados, %d con error\n", status.Pages, status.Indexed, status.Failed)
+
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	// Sin las lapidas del indice actual, un evento viejo reprocesado despues del cambio volveria a crear un hotel borrado
+	tombstones, err := reindexer.index.CopyTombstones(ctx, core)
+	if err != nil {
+		return reindexer.abort(core, err)
+	}
+	fmt.Printf("[Reindex] %d hoteles borrados copiados al core %s\n", tombstones, core)
+
+	if err := reindexer.index.SwapBuildCore(ctx, core); err != nil {
+		return reindexer.abort(core, err)
+	}
+
+	status := reindexer.update(func(status *hotelsDomain.ReindexStatus) {
+		now := time.Now()
+		status.State = hotelsDomain.ReindexSucceeded
+		status.FinishedAt = &now
+	})
+	fmt.Printf("[Reindex] Terminado: %d hoteles indexados, %d con error\n", status.Indexed, status.Failed)
+	return nil
+}
+
+// fetchPage pide una pagina a hotels-api, reintentando si falla
+func (reindexer *Reindexer) fetchPage(ctx context.Context, cursor string) (hotelsDomain.HotelPage, error) {
+	var err error
+	for attempt := 1; attempt <= reindexPageAttempts; attempt++ {
+		var page hotelsDomain.HotelPage
+		page, err = reindexer.hotelsAPI.ListHotels(ctx, cursor, reindexer.pageSize)
+		if err == nil {
+			return page, nil
+		}
+		fmt.Printf("[Reindex] Error pidiendo hoteles a hotels-api (intento %d): %v\n", attempt, err)
+		if attempt == reindexPageAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return hotelsDomain.HotelPage{}, ctx.Err()
+		case <-time.After(time.Duration(attempt) * reindexer.retryWait):
+		}
+	}
+	return hotelsDomain.HotelPage{}, fmt.Errorf("error listing hotels from hotels-api: %w", err)
+}
+
+// indexPage indexa la pagina en un solo request. Si Solr la rechaza se indexa hotel por hotel,
+// asi un documento invalido no deja afuera al resto de la pagina
+func (reindexer *Reindexer) indexPage(ctx context.Context, core string, hotels []hotelsDomain.Hotel) (int, []string) {
+	if len(hotels) == 0 {
+		return 0, nil
+	}
+	batch := make([]hotelsDAO.Hotel, 0, len(hotels))
+	for _, hotel := range hotels {
+		batch = append(batch, hotelToDAO(hotel))
+	}
+	if err := reindexer.index.IndexBatch(ctx, core, batch); err == nil {
+		return len(batch), nil
+	}
+
+	indexed := 0
+	var failures []string
+	for _, hotel := range batch {
+		if err := reindexer.index.IndexBatch(ctx, core, []hotelsDAO.Hotel{hotel}); err != nil {
+			failures = append(failures, fmt.Sprintf("hotel %s: %v", hotel.ID, err))
+			continue
+		}
+		indexed++
+	}
+	return indexed, failures
+}
+
+// abort descarta el core nuevo (el indice actual queda como estaba) y marca el reindex como fallido
+func (reindexer *Reindexer) abort(core string, cause error) error {
+	if err := reindexer.index.DropBuildCore(context.Background(), core); err != nil {
+		cause = errors.Join(cause, err)
+	}
+	return reindexer.fail(cause)
+}
+
+func (reindexer *Reindexer) fail(cause error) error {
+	reindexer.update(func(status *hotelsDomain.ReindexStatus) {
+		now := time.Now()
+		status.State = hotelsDomain.ReindexFailed
+		status.FinishedAt = &now
+		status.Error = cause.Error()
+	})
+	fmt.Printf("[Reindex] Fallo: %v\n", cause)
+	return fmt.Errorf("reindex failed: %w", cause)
+}
+
+// update modifica el estado bajo el lock y devuelve una copia
+func (reindexer *Reindexer) update(change func(status *hotelsDomain.ReindexStatus)) hotelsDomain.ReindexStatus {
+	reindexer.mu.Lock()
+	defer reindexer.mu.Unlock()
+	change(&reindexer.status)
+	return reindexer.status
+}
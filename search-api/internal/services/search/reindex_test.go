@@ -0,0 +1,168 @@
+package search_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	hotelsDAO "search-api/internal/dao/hotels"
+	hotelsDomain "search-api/internal/domain/hotels"
+	hotelsRepo "search-api/internal/repositories/hotels"
+	service "search-api/internal/services/search"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestReindexer() (*service.Reindexer, *hotelsRepo.Mock, *hotelsRepo.ExternalMock) {
+	solrRepo := hotelsRepo.NewMock()
+	hotelsAPI := hotelsRepo.NewExternalMock()
+	return service.NewReindexer(solrRepo, hotelsAPI, 2, time.Millisecond), solrRepo, hotelsAPI
+}
+
+// hotelIDs devuelve un matcher de la tanda de hoteles que se manda a Solr
+func hotelIDs(ids ...string) interface{} {
+	return mock.MatchedBy(func(hotels []hotelsDAO.Hotel) bool {
+		if len(hotels) != len(ids) {
+			return false
+		}
+		for i, hotel := range hotels {
+			if hotel.ID != ids[i] {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func TestReindexer_Run(t *testing.T) {
+	t.Run("pages through hotels-api and swaps the new core", func(t *testing.T) {
+		reindexer, solrRepo, hotelsAPI := newTestReindexer()
+
+		solrRepo.On("CreateBuildCore", mock.Anything).Return("hotels_build_1", nil).Once()
+		hotelsAPI.On("ListHotels", mock.Anything, "", 2).Return(hotelsDomain.HotelPage{
+			Hotels:     []hotelsDomain.Hotel{{ID: "h1", PricePerNight: 80, EventSequence: 4}, {ID: "h2"}},
+			NextCursor: "h2",
+		}, nil).Once()
+		hotelsAPI.On("ListHotels", mock.Anything, "h2", 2).Return(hotelsDomain.HotelPage{
+			Hotels: []hotelsDomain.Hotel{{ID: "h3"}},
+		}, nil).Once()
+		solrRepo.On("IndexBatch", mock.Anything, "hotels_build_1", mock.MatchedBy(func(hotels []hotelsDAO.Hotel) bool {
+			// Los hoteles se indexan con los mismos campos resumidos que los eventos y con la secuencia de su ultimo evento
+			return len(hotels) == 2 && hotels[0].ID == "h1" && hotels[0].MinPrice == 80 && hotels[0].EventSequence == 4
+		})).Return(nil).Once()
+		solrRepo.On("IndexBatch", mock.Anything, "hotels_build_1", hotelIDs("h3")).Return(nil).Once()
+		solrRepo.On("CopyTombstones", mock.Anything, "hotels_build_1").Return(0, nil).Once()
+		solrRepo.On("SwapBuildCore", mock.Anything, "hotels_build_1").Return(nil).Once()
+
+		status, err := reindexer.Run(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, hotelsDomain.ReindexSucceeded, status.State)
+		assert.Equal(t, "hotels_build_1", status.Core)
+		assert.Equal(t, 2, status.Pages)
+		assert.Equal(t, 3, status.Indexed)
+		assert.Equal(t, 0, status.Failed)
+		assert.NotNil(t, status.FinishedAt)
+		solrRepo.AssertExpectations(t)
+		hotelsAPI.AssertExpectations(t)
+	})
+
+	t.Run("a rejected page is retried hotel by hotel", func(t *testing.T) {
+		reindexer, solrRepo, hotelsAPI := newTestReindexer()
+
+		solrRepo.On("CreateBuildCore", mock.Anything).Return("hotels_build_1", nil).Once()
+		hotelsAPI.On("ListHotels", mock.Anything, "", 2).Return(hotelsDomain.HotelPage{
+			Hotels: []hotelsDomain.Hotel{{ID: "h1"}, {ID: "h2"}},
+		}, nil).Once()
+		solrRepo.On("IndexBatch", mock.Anything, "hotels_build_1", hotelIDs("h1", "h2")).Return(errors.New("bad document")).Once()
+		solrRepo.On("IndexBatch", mock.Anything, "hotels_build_1", hotelIDs("h1")).Return(nil).Once()
+		solrRepo.On("IndexBatch", mock.Anything, "hotels_build_1", hotelIDs("h2")).Return(errors.New("bad document")).Once()
+		solrRepo.On("CopyTombstones", mock.Anything, "hotels_build_1").Return(0, nil).Once()
+		solrRepo.On("SwapBuildCore", mock.Anything, "hotels_build_1").Return(nil).Once()
+
+		status, err := reindexer.Run(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, hotelsDomain.ReindexSucceeded, status.State)
+		assert.Equal(t, 1, status.Indexed)
+		assert.Equal(t, 1, status.Failed)
+		assert.Len(t, status.Errors, 1)
+		assert.Contains(t, status.Errors[0], "hotel h2")
+		solrRepo.AssertExpectations(t)
+	})
+
+	t.Run("hotels-api down drops the new core and keeps the current index", func(t *testing.T) {
+		reindexer, solrRepo, hotelsAPI := newTestReindexer()
+
+		solrRepo.On("CreateBuildCore", mock.Anything).Return("hotels_build_1", nil).Once()
+		hotelsAPI.On("ListHotels", mock.Anything, "", 2).Return(hotelsDomain.HotelPage{}, errors.New("connection refused")).Times(3)
+		solrRepo.On("DropBuildCore", mock.Anything, "hotels_build_1").Return(nil).Once()
+
+		status, err := reindexer.Run(context.Background())
+
+		assert.Error(t, err)
+		assert.Equal(t, hotelsDomain.ReindexFailed, status.State)
+		assert.Contains(t, status.Error, "connection refused")
+		solrRepo.AssertNotCalled(t, "SwapBuildCore", mock.Anything, mock.Anything)
+		solrRepo.AssertExpectations(t)
+		hotelsAPI.AssertExpectations(t)
+	})
+
+	t.Run("tombstone copy error drops the new core", func(t *testing.T) {
+		reindexer, solrRepo, hotelsAPI := newTestReindexer()
+
+		solrRepo.On("CreateBuildCore", mock.Anything).Return("hotels_build_1", nil).Once()
+		hotelsAPI.On("ListHotels", mock.Anything, "", 2).Return(hotelsDomain.HotelPage{
+			Hotels: []hotelsDomain.Hotel{{ID: "h1"}},
+		}, nil).Once()
+		solrRepo.On("IndexBatch", mock.Anything, "hotels_build_1", hotelIDs("h1")).Return(nil).Once()
+		solrRepo.On("CopyTombstones", mock.Anything, "hotels_build_1").Return(0, errors.New("solr unavailable")).Once()
+		solrRepo.On("DropBuildCore", mock.Anything, "hotels_build_1").Return(nil).Once()
+
+		status, err := reindexer.Run(context.Background())
+
+		assert.Error(t, err)
+		assert.Equal(t, hotelsDomain.ReindexFailed, status.State)
+		solrRepo.AssertNotCalled(t, "SwapBuildCore", mock.Anything, mock.Anything)
+		solrRepo.AssertExpectations(t)
+	})
+
+	t.Run("core creation error", func(t *testing.T) {
+		reindexer, solrRepo, hotelsAPI := newTestReindexer()
+
+		solrRepo.On("CreateBuildCore", mock.Anything).Return("", errors.New("configset not found")).Once()
+
+		status, err := reindexer.Run(context.Background())
+
+		assert.Error(t, err)
+		assert.Equal(t, hotelsDomain.ReindexFailed, status.State)
+		hotelsAPI.AssertNotCalled(t, "ListHotels", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestReindexer_Start(t *testing.T) {
+	reindexer, solrRepo, hotelsAPI := newTestReindexer()
+
+	assert.Equal(t, hotelsDomain.ReindexIdle, reindexer.Status().State)
+
+	// La creacion del core se bloquea hasta que el test la libere, asi el reindex sigue en curso
+	release := make(chan struct{})
+	solrRepo.On("CreateBuildCore", mock.Anything).Run(func(mock.Arguments) { <-release }).Return("hotels_build_1", nil).Once()
+	hotelsAPI.On("ListHotels", mock.Anything, "", 2).Return(hotelsDomain.HotelPage{}, nil).Once()
+	solrRepo.On("CopyTombstones", mock.Anything, "hotels_build_1").Return(0, nil).Once()
+	solrRepo.On("SwapBuildCore", mock.Anything, "hotels_build_1").Return(nil).Once()
+
+	status, err := reindexer.Start()
+	assert.NoError(t, err)
+	assert.Equal(t, hotelsDomain.ReindexRunning, status.State)
+
+	_, err = reindexer.Start()
+	assert.ErrorIs(t, err, hotelsDomain.ErrReindexRunning)
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		return reindexer.Status().State == hotelsDomain.ReindexSucceeded
+	}, time.Second, 10*time.Millisecond)
+}
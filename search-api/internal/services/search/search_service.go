@@ -3,6 +3,8 @@ package search
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	hotelsDAO "search-api/internal/dao/hotels"
 	hotelsDomain "search-api/internal/domain/hotels"
@@ -10,128 +12,432 @@ import (
 
 // Funciones de solr
 type Repository interface {
-	Index(ctx context.Context, hotel hotelsDAO.Hotel) (string, error)
-	Update(ctx context.Context, hotel hotelsDAO.Hotel) error
-	Delete(ctx context.Context, id string) error
-	Search(ctx context.Context, query string, limit int, offset int) ([]hotelsDAO.Hotel, error) // Updated signature
+	Search(ctx context.Context, query string, filters hotelsDAO.SearchFilters, sort string, limit int, offset int, cursor string) (hotelsDAO.SearchResult, error)
+	Suggest(ctx context.Context, prefix string, limit int) (hotelsDAO.Suggestions, error)
 }
 
 // Funcion de la API de hoteles
 type ExternalRepository interface {
 	GetHotelByID(ctx context.Context, id string) (hotelsDomain.Hotel, error)
+	ListHotels(ctx context.Context, cursor string, limit int) (hotelsDomain.HotelPage, error)
+	GetAvailability(ctx context.Context, hotelIDs []string, checkIn, checkOut string) (map[string]bool, error)
+}
+
+// Escritura en tandas de los cambios que llegan por eventos
+type Indexer interface {
+	Submit(change hotelsDAO.IndexChange) <-chan error
+}
+
+// Busqueda con fechas: se piden a Solr tandas de al menos minAvailabilityBatch hoteles (y como mucho
+// maxAvailabilityBatch), y se recorren como mucho maxAvailabilityBatches tandas por pedido
+const (
+	minAvailabilityBatch   = 20
+	maxAvailabilityBatch   = 100
+	maxAvailabilityBatches = 5
+)
+
+// Cache en memoria de las sugerencias por prefijo
+type SuggestCache interface {
+	GetSuggestions(key string) ([]hotelsDomain.Suggestion, bool)
+	SetSuggestions(key string, suggestions []hotelsDomain.Suggestion)
 }
 
 type Service struct {
-	repository Repository         // Este seria nuestro repositorio de solr
-	hotelsAPI  ExternalRepository // Este seria nuestro repositorio de la API de hoteles
+	repository         Repository         // Este seria nuestro repositorio de solr
+	hotelsAPI          ExternalRepository // Este seria nuestro repositorio de la API de hoteles
+	indexer            Indexer            // Junta los cambios de los eventos en tandas para Solr
+	suggestCache       SuggestCache       // LRU de los prefijos mas buscados
+	availabilityBudget time.Duration      // Tiempo maximo de una busqueda con fechas
 }
 
 // Funcion para crear un nuevo servicio
-func NewService(repository Repository, hotelsAPI ExternalRepository) Service {
+func NewService(repository Repository, hotelsAPI ExternalRepository, indexer Indexer, suggestCache SuggestCache, availabilityBudget time.Duration) Service {
 	return Service{
-		repository: repository,
-		hotelsAPI:  hotelsAPI,
+		repository:         repository,
+		hotelsAPI:          hotelsAPI,
+		indexer:            indexer,
+		suggestCache:       suggestCache,
+		availabilityBudget: availabilityBudget,
 	}
 }
 
-// Funcion para buscar hoteles en Solr
-func (service Service) Search(ctx context.Context, query string, offset int, limit int) ([]hotelsDomain.Hotel, error) {
+// Funcion para buscar hoteles en Solr aplicando los filtros, devuelve los resultados con el total, los facets y la paginacion.
+// Si viene cursor se pagina con cursorMark (para recorrer muchos resultados) y se ignora el offset.
+// sort es uno de los ordenes de hotelsDomain (vacio = relevancia)
+func (service Service) Search(ctx context.Context, query string, filters hotelsDomain.SearchFilters, sort string, offset int, limit int, cursor string) (hotelsDomain.SearchResponse, error) {
+	if cursor != "" {
+		offset = 0
+	}
+
+	searchFilters := hotelsDAO.SearchFilters{
+		City:      filters.City,
+		Country:   filters.Country,
+		MinPrice:  filters.MinPrice,
+		MaxPrice:  filters.MaxPrice,
+		MinRating: filters.MinRating,
+		Amenities: filters.Amenities,
+		Rooms:     filters.Rooms,
+		Guests:    filters.Guests,
+		Latitude:  filters.Latitude,
+		Longitude: filters.Longitude,
+		RadiusKm:  filters.RadiusKm,
+	}
+
+	// Con fechas se filtra por disponibilidad en hotels-api
+	if filters.HasDates() {
+		return service.searchAvailable(ctx, query, searchFilters, filters.CheckIn, filters.CheckOut, sort, offset, limit)
+	}
+
 	// Llama al metodo Search del repositorio
-	hotelsDAOList, err := service.repository.Search(ctx, query, limit, offset)
+	result, err := service.repository.Search(ctx, query, searchFilters, sort, limit, offset, cursor)
 	if err != nil {
-		return nil, fmt.Errorf("error searching hotels: %w", err)
+		return hotelsDomain.SearchResponse{}, fmt.Errorf("error searching hotels: %w", err)
 	}
 
 	// Hace un mapeo de los hoteles de la lista de hoteles de Solr a la lista de hoteles de dominio
 	hotelsDomainList := make([]hotelsDomain.Hotel, 0)
-	for _, hotel := range hotelsDAOList {
-		hotelsDomainList = append(hotelsDomainList, hotelsDomain.Hotel{
-			ID:            hotel.ID,
-			Name:          hotel.Name,
-			Description:   hotel.Description,
-			Address:       hotel.Address,
-			City:          hotel.City,
-			State:         hotel.State,
-			Country:       hotel.Country,
-			Phone:         hotel.Phone,
-			Email:         hotel.Email,
-			Rating:        hotel.Rating,
-			PricePerNight: hotel.PricePerNight,
-			AvaiableRooms: hotel.AvaiableRooms,
-			CheckInTime:   hotel.CheckInTime,
-			CheckOutTime:  hotel.CheckOutTime,
-			Amenities:     hotel.Amenities,
-			Images:        hotel.Images,
-		})
+	for _, hotel := range result.Hotels {
+		hotelsDomainList = append(hotelsDomainList, hotelToDomain(hotel))
 	}
 
-	// Devuelve la lista de hoteles
-	return hotelsDomainList, nil
+	// Devuelve la lista de hoteles con el total y los facets
+	return hotelsDomain.SearchResponse{
+		Results:    hotelsDomainList,
+		Facets:     facetsToDomain(result.Facets),
+		Total:      result.Total,
+		Offset:     offset,
+		Limit:      limit,
+		NextOffset: nextOffset(cursor, offset, limit, result.Total),
+		PrevOffset: prevOffset(cursor, offset, limit),
+		Cursor:     cursor,
+		NextCursor: result.NextCursor,
+	}, nil
 }
 
-// Funcion para manejar la creacion y eliminacion de hoteles
-func (service Service) HandleHotelNew(hotelNew hotelsDomain.HotelNew) {
-	fmt.Printf("[RabbitMQ] Evento recibido: Operación=%s, HotelID=%s\n", hotelNew.Operation, hotelNew.HotelID)
-	// Hacemos un switch para manejar las operaciones de creacion, actualizacion y eliminacion
-	switch hotelNew.Operation {
-	// Caso en el que se crea o actualiza un hotel
-	case "CREATE", "UPDATE":
-		fmt.Printf("[RabbitMQ] Obteniendo hotel desde hotels-api: %s\n", hotelNew.HotelID)
-		// Obtenemos el hotel de la API de hoteles
-		hotel, err := service.hotelsAPI.GetHotelByID(context.Background(), hotelNew.HotelID)
-		if err != nil {
-			fmt.Printf("[ERROR] Error obteniendo hotel (%s) desde hotels-api: %v\n", hotelNew.HotelID, err)
-			return
+// searchAvailable busca en Solr por tandas desde offset y se queda con los hoteles que tienen lugar entre checkIn y checkOut,
+// hasta juntar limit o agotar el presupuesto de tiempo. Si hotels-api no responde a tiempo los hoteles de esa tanda
+// se devuelven marcados con AvailabilityUnconfirmed en vez de descartarlos
+func (service Service) searchAvailable(ctx context.Context, query string, filters hotelsDAO.SearchFilters, checkIn string, checkOut string, sort string, offset int, limit int) (hotelsDomain.SearchResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, service.availabilityBudget)
+	defer cancel()
+
+	batchSize := limit
+	if batchSize < minAvailabilityBatch {
+		batchSize = minAvailabilityBatch
+	}
+	if batchSize > maxAvailabilityBatch {
+		batchSize = maxAvailabilityBatch
+	}
+
+	// La primera tanda define el total y los facets (antes de filtrar por disponibilidad)
+	result, err := service.repository.Search(ctx, query, filters, sort, batchSize, offset, "")
+	if err != nil {
+		return hotelsDomain.SearchResponse{}, fmt.Errorf("error searching hotels: %w", err)
+	}
+	total, facets := result.Total, result.Facets
+
+	// position es la posicion en los resultados de Solr del proximo hotel a revisar
+	position := offset
+	hotelsDomainList := make([]hotelsDomain.Hotel, 0)
+	for batch := 1; len(result.Hotels) > 0; batch++ {
+		hotelIDs := make([]string, 0, len(result.Hotels))
+		for _, hotel := range result.Hotels {
+			hotelIDs = append(hotelIDs, hotel.ID)
 		}
-		fmt.Printf("[RabbitMQ] Hotel obtenido correctamente: %s\n", hotel.Name)
-
-		hotelDAO := hotelsDAO.Hotel{
-			ID:            hotel.ID,
-			Name:          hotel.Name,
-			Description:   hotel.Description,
-			Address:       hotel.Address,
-			City:          hotel.City,
-			State:         hotel.State,
-			Country:       hotel.Country,
-			Phone:         hotel.Phone,
-			Email:         hotel.Email,
-			Rating:        hotel.Rating,
-			PricePerNight: hotel.PricePerNight,
-			AvaiableRooms: hotel.AvaiableRooms,
-			CheckInTime:   hotel.CheckInTime,
-			CheckOutTime:  hotel.CheckOutTime,
-			Amenities:     hotel.Amenities,
-			Images:        hotel.Images,
+		availability, availabilityErr := service.hotelsAPI.GetAvailability(ctx, hotelIDs, checkIn, checkOut)
+		if availabilityErr != nil {
+			fmt.Printf("Error checking availability in hotels-api: %v\n", availabilityErr)
 		}
 
-		// Caso en el que se crea un hotel
-		if hotelNew.Operation == "CREATE" {
-			fmt.Printf("[RabbitMQ] Indexando hotel en Solr: %s\n", hotelNew.HotelID)
-			// Llama al metodo Index del repositorio para indexar el hotel en Solr
-			if _, err := service.repository.Index(context.Background(), hotelDAO); err != nil {
-				fmt.Printf("[ERROR] Error indexando hotel (%s) en Solr: %v\n", hotelNew.HotelID, err)
-			} else {
-				fmt.Println("[RabbitMQ] Hotel indexado correctamente en Solr:", hotelNew.HotelID)
+		for _, hotel := range result.Hotels {
+			if len(hotelsDomainList) >= limit {
+				break
 			}
-		} else { // Caso en el que se actualiza un hotel
-			fmt.Printf("[RabbitMQ] Actualizando hotel en Solr: %s\n", hotelNew.HotelID)
-			// Llama al metodo Update del repositorio para actualizar el hotel en Solr
-			if err := service.repository.Update(context.Background(), hotelDAO); err != nil {
-				fmt.Printf("[ERROR] Error actualizando hotel (%s) en Solr: %v\n", hotelNew.HotelID, err)
-			} else {
-				fmt.Println("[RabbitMQ] Hotel actualizado correctamente en Solr:", hotelNew.HotelID)
+			position++
+			available, confirmed := availability[hotel.ID]
+			if confirmed && !available {
+				continue
 			}
+			hotelDomain := hotelToDomain(hotel)
+			hotelDomain.AvailabilityUnconfirmed = !confirmed
+			hotelsDomainList = append(hotelsDomainList, hotelDomain)
+		}
+
+		// Corta si se lleno la pagina, si no hay mas resultados, si hotels-api fallo o se acabo el presupuesto
+		if len(hotelsDomainList) >= limit || position >= total || availabilityErr != nil || batch >= maxAvailabilityBatches || ctx.Err() != nil {
+			break
+		}
+
+		result, err = service.repository.Search(ctx, query, filters, sort, batchSize, position, "")
+		if err != nil {
+			// Se devuelve lo que se encontro hasta aca, next_offset permite seguir desde position
+			fmt.Printf("Error searching next availability batch: %v\n", err)
+			break
+		}
+	}
+
+	var next *int
+	if position < total {
+		next = &position
+	}
+
+	return hotelsDomain.SearchResponse{
+		Results:    hotelsDomainList,
+		Facets:     facetsToDomain(facets),
+		Total:      total,
+		Offset:     offset,
+		Limit:      limit,
+		NextOffset: next,
+	}, nil
+}
+
+// Funcion para sugerir ciudades y hoteles para lo que el usuario lleva escrito (prefix), como mucho limit en total.
+// Las ciudades van primero (hasta la mitad del limite, por cantidad de hoteles) y despues los hoteles por relevancia.
+// Los prefijos ya consultados se responden desde la cache
+func (service Service) Suggest(ctx context.Context, prefix string, limit int) ([]hotelsDomain.Suggestion, error) {
+	normalized := strings.ToLower(strings.Join(strings.Fields(prefix), " "))
+	if normalized == "" || limit <= 0 {
+		return []hotelsDomain.Suggestion{}, nil
+	}
+
+	key := fmt.Sprintf("%d:%s", limit, normalized)
+	if suggestions, ok := service.suggestCache.GetSuggestions(key); ok {
+		return suggestions, nil
+	}
+
+	result, err := service.repository.Suggest(ctx, normalized, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error getting suggestions: %w", err)
+	}
+
+	cities := result.Cities
+	if maxCities := (limit + 1) / 2; len(cities) > maxCities {
+		cities = cities[:maxCities]
+	}
+	suggestions := make([]hotelsDomain.Suggestion, 0, limit)
+	for _, city := range cities {
+		suggestions = append(suggestions, hotelsDomain.Suggestion{
+			Text:  city.Value,
+			Type:  hotelsDomain.SuggestionCity,
+			Count: city.Count,
+		})
+	}
+	for _, hotel := range result.Hotels {
+		if len(suggestions) >= limit {
+			break
+		}
+		suggestions = append(suggestions, hotelsDomain.Suggestion{
+			Text:    hotel.Name,
+			Type:    hotelsDomain.SuggestionHotel,
+			HotelID: hotel.ID,
+			City:    hotel.City,
+		})
+	}
+
+	service.suggestCache.SetSuggestions(key, suggestions)
+	return suggestions, nil
+}
+
+// hotelToDomain mapea un hotel de Solr a un hotel de dominio
+func hotelToDomain(hotel hotelsDAO.Hotel) hotelsDomain.Hotel {
+	return hotelsDomain.Hotel{
+		ID:             hotel.ID,
+		Name:           hotel.Name,
+		Description:    hotel.Description,
+		Address:        hotel.Address,
+		City:           hotel.City,
+		State:          hotel.State,
+		Country:        hotel.Country,
+		Phone:          hotel.Phone,
+		Email:          hotel.Email,
+		Rating:         hotel.Rating,
+		PricePerNight:  hotel.PricePerNight,
+		AvaiableRooms:  hotel.AvaiableRooms,
+		CheckInTime:    hotel.CheckInTime,
+		CheckOutTime:   hotel.CheckOutTime,
+		Amenities:      hotel.Amenities,
+		Images:         hotel.Images,
+		MinPrice:       hotel.MinPrice,
+		MaxCapacity:    hotel.MaxCapacity,
+		TotalRooms:     hotel.TotalRooms,
+		RoomCapacities: hotel.RoomCapacities,
+		Latitude:       hotel.Latitude,
+		Longitude:      hotel.Longitude,
+		DistanceKm:     hotel.DistanceKm,
+	}
+}
+
+// facetsToDomain mapea los facets de Solr
+func facetsToDomain(facets hotelsDAO.Facets) hotelsDomain.Facets {
+	return hotelsDomain.Facets{
+		City:      facetBucketsToDomain(facets.City),
+		Country:   facetBucketsToDomain(facets.Country),
+		Amenities: facetBucketsToDomain(facets.Amenities),
+		Price:     facetBucketsToDomain(facets.Price),
+		Rating:    facetBucketsToDomain(facets.Rating),
+	}
+}
+
+// nextOffset es el offset de la pagina siguiente, nil si es la ultima o si se pagina con cursor
+func nextOffset(cursor string, offset int, limit int, total int) *int {
+	if cursor != "" || limit <= 0 || offset+limit >= total {
+		return nil
+	}
+	next := offset + limit
+	return &next
+}
+
+// prevOffset es el offset de la pagina anterior, nil si es la primera o si se pagina con cursor
+func prevOffset(cursor string, offset int, limit int) *int {
+	if cursor != "" || offset <= 0 {
+		return nil
+	}
+	prev := offset - limit
+	if prev < 0 {
+		prev = 0
+	}
+	return &prev
+}
+
+// facetBucketsToDomain mapea los buckets de Solr (siempre devuelve una lista, aunque este vacia)
+func facetBucketsToDomain(buckets []hotelsDAO.FacetBucket) []hotelsDomain.FacetBucket {
+	result := make([]hotelsDomain.FacetBucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		result = append(result, hotelsDomain.FacetBucket{
+			Value: bucket.Value,
+			Count: bucket.Count,
+			From:  bucket.From,
+			To:    bucket.To,
+		})
+	}
+	return result
+}
+
+// Funcion para manejar la creacion, actualizacion y eliminacion de hoteles.
+// El cambio se encola para mandarlo a Solr en una tanda; el canal devuelve nil cuando se aplico o el error,
+// asi el consumidor confirma el evento recien ahi o lo reintenta en vez de perderlo
+func (service Service) HandleHotelNew(hotelNew hotelsDomain.HotelNew) <-chan error {
+	// Los eventos v2 traen la operacion en Type, los v1 solo en Operation
+	operation := hotelNew.Operation
+	if hotelNew.Type != "" {
+		operation = hotelNew.Type
+	}
+	fmt.Printf("[RabbitMQ] Evento recibido: Operación=%s, HotelID=%s, Version=%d, Secuencia=%d\n", operation, hotelNew.HotelID, hotelNew.SchemaVersion, hotelNew.Sequence)
+	// Hacemos un switch para manejar las operaciones de creacion, actualizacion y eliminacion
+	switch operation {
+	// Caso en el que se crea o actualiza un hotel (en Solr las dos reemplazan el documento)
+	case "CREATE", "UPDATE":
+		hotel, err := service.eventHotel(hotelNew)
+		if err != nil {
+			return failed(fmt.Errorf("error getting hotel (%s) from hotels-api: %w", hotelNew.HotelID, err))
 		}
+
+		hotelDAO := hotelToDAO(hotel)
+		fmt.Printf("[RabbitMQ] Encolando hotel para indexar en Solr: %s\n", hotelNew.HotelID)
+		hotelDAO.EventSequence = hotelNew.Sequence
+		return service.indexer.Submit(hotelsDAO.IndexChange{Hotel: &hotelDAO, Sequence: hotelNew.Sequence})
 	// Caso en el que se elimina un hotel
 	case "DELETE":
-		fmt.Printf("[RabbitMQ] Eliminando hotel de Solr: %s\n", hotelNew.HotelID)
-		// Llama al metodo Delete del repositorio para eliminar el hotel de Solr
-		if err := service.repository.Delete(context.Background(), hotelNew.HotelID); err != nil {
-			fmt.Printf("[ERROR] Error eliminando hotel (%s) de Solr: %v\n", hotelNew.HotelID, err)
-		} else {
-			fmt.Println("[RabbitMQ] Hotel eliminado correctamente de Solr:", hotelNew.HotelID)
-		}
+		fmt.Printf("[RabbitMQ] Encolando borrado del hotel en Solr: %s\n", hotelNew.HotelID)
+		return service.indexer.Submit(hotelsDAO.IndexChange{DeleteID: hotelNew.HotelID, Sequence: hotelNew.Sequence})
 	default:
-		fmt.Printf("[RabbitMQ] Operación desconocida: %s\n", hotelNew.Operation)
+		// Una operacion desconocida no se arregla reintentando
+		return failed(fmt.Errorf("%w: unknown operation %q", hotelsDomain.ErrInvalidEvent, operation))
+	}
+}
+
+// failed devuelve el resultado de un evento que fallo antes de llegar al batcher
+func failed(err error) <-chan error {
+	result := make(chan error, 1)
+	result <- err
+	return result
+}
+
+// eventHotel devuelve el hotel del evento. Los eventos v2 lo traen completo;
+// solo los mensajes v1 (o un v2 sin hotel) se resuelven pidiendolo a hotels-api
+func (service Service) eventHotel(hotelNew hotelsDomain.HotelNew) (hotelsDomain.Hotel, error) {
+	if hotelNew.SchemaVersion >= hotelsDomain.HotelEventSchemaVersion && hotelNew.Hotel != nil {
+		return *hotelNew.Hotel, nil
+	}
+
+	fmt.Printf("[RabbitMQ] Obteniendo hotel desde hotels-api: %s\n", hotelNew.HotelID)
+	hotel, err := service.hotelsAPI.GetHotelByID(context.Background(), hotelNew.HotelID)
+	if err != nil {
+		return hotelsDomain.Hotel{}, err
+	}
+	fmt.Printf("[RabbitMQ] Hotel obtenido correctamente: %s\n", hotel.Name)
+	return hotel, nil
+}
+
+// hotelToDAO arma el documento de Solr de un hotel de hotels-api
+func hotelToDAO(hotel hotelsDomain.Hotel) hotelsDAO.Hotel {
+	hotelDAO := hotelsDAO.Hotel{
+		ID:            hotel.ID,
+		Name:          hotel.Name,
+		Description:   hotel.Description,
+		Address:       hotel.Address,
+		City:          hotel.City,
+		State:         hotel.State,
+		Country:       hotel.Country,
+		Phone:         hotel.Phone,
+		Email:         hotel.Email,
+		Rating:        hotel.Rating,
+		PricePerNight: hotel.PricePerNight,
+		AvaiableRooms: hotel.AvaiableRooms,
+		CheckInTime:   hotel.CheckInTime,
+		CheckOutTime:  hotel.CheckOutTime,
+		Amenities:     hotel.Amenities,
+		Images:        hotel.Images,
+		Latitude:      hotel.Latitude,
+		Longitude:     hotel.Longitude,
+		EventSequence: hotel.EventSequence,
+	}
+	// Resume los tipos de habitacion en campos filtrables (precio minimo y capacidades)
+	hotelDAO.MinPrice, hotelDAO.RoomCapacities, hotelDAO.MaxCapacity = summarizeRoomTypes(hotel)
+	hotelDAO.TotalRooms = totalRooms(hotel)
+	return hotelDAO
+}
+
+// totalRooms cuenta las habitaciones de un hotel: como en hotels-api, si tiene tipos de habitacion el stock
+// esta en la cantidad de cada tipo y avaiable_rooms solo vale para los hoteles sin tipos
+func totalRooms(hotel hotelsDomain.Hotel) int {
+	if len(hotel.RoomTypes) == 0 {
+		return hotel.AvaiableRooms
+	}
+	total := 0
+	for _, roomType := range hotel.RoomTypes {
+		total += roomType.Count
+	}
+	return total
+}
+
+// summarizeRoomTypes calcula el precio minimo y las capacidades de los tipos de habitacion de un hotel.
+// Un hotel sin tipos de habitacion usa su price_per_night y no informa capacidades.
+func summarizeRoomTypes(hotel hotelsDomain.Hotel) (float64, []int, int) {
+	if len(hotel.RoomTypes) == 0 {
+		return hotel.PricePerNight, nil, 0
+	}
+
+	minPrice := roomTypePrice(hotel, hotel.RoomTypes[0])
+	maxCapacity := 0
+	capacities := make([]int, 0, len(hotel.RoomTypes))
+	for _, roomType := range hotel.RoomTypes {
+		if price := roomTypePrice(hotel, roomType); price < minPrice {
+			minPrice = price
+		}
+		if roomType.Capacity > maxCapacity {
+			maxCapacity = roomType.Capacity
+		}
+		capacities = append(capacities, roomType.Capacity)
+	}
+	return minPrice, capacities, maxCapacity
+}
+
+// roomTypePrice es el precio por noche de un tipo de habitacion: como en hotels-api,
+// un base_price en cero significa que el tipo usa el price_per_night del hotel
+func roomTypePrice(hotel hotelsDomain.Hotel, roomType hotelsDomain.RoomType) float64 {
+	if roomType.BasePrice > 0 {
+		return roomType.BasePrice
 	}
+	return hotel.PricePerNight
 }
@@ -19,10 +19,29 @@ func newTestService() (service.Service, *hotelsRepo.Mock, *hotelsRepo.ExternalMo
 	solrRepo := hotelsRepo.NewMock()
 	hotelsAPI := hotelsRepo.NewExternalMock()
 
-	svc := service.NewService(solrRepo, hotelsAPI)
+	cache := hotelsRepo.NewCache(hotelsRepo.CacheConfig{MaxSize: 100, ItemsToPrune: 10, Duration: time.Minute})
+
+	// Tandas de un cambio: cada evento se manda a Solr apenas llega
+	batcher := service.NewBatcher(solrRepo, 1, time.Millisecond)
+
+	svc := service.NewService(solrRepo, hotelsAPI, batcher, cache, time.Second)
 	return svc, solrRepo, hotelsAPI
 }
 
+// indexed devuelve un matcher de una tanda con un solo hotel a indexar que cumple match
+func indexed(match func(hotelsDAO.Hotel) bool) interface{} {
+	return mock.MatchedBy(func(changes []hotelsDAO.IndexChange) bool {
+		return len(changes) == 1 && changes[0].Hotel != nil && match(*changes[0].Hotel)
+	})
+}
+
+// deleted devuelve un matcher de una tanda con solo el borrado del hotel id
+func deleted(id string) interface{} {
+	return mock.MatchedBy(func(changes []hotelsDAO.IndexChange) bool {
+		return len(changes) == 1 && changes[0].Hotel == nil && changes[0].DeleteID == id
+	})
+}
+
 func TestService_Search(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		svc, solrRepo, _ := newTestService()
@@ -54,17 +73,17 @@ func TestService_Search(t *testing.T) {
 			},
 		}
 
-		solrRepo.On("Search", mock.Anything, "paradise", 10, 0).Return(mockHotels, nil).Once()
+		solrRepo.On("Search", mock.Anything, "paradise", hotelsDAO.SearchFilters{}, "", 10, 0, "").Return(hotelsDAO.SearchResult{Hotels: mockHotels, Total: len(mockHotels)}, nil).Once()
 
-		result, err := svc.Search(context.Background(), "paradise", 0, 10)
+		result, err := svc.Search(context.Background(), "paradise", hotelsDomain.SearchFilters{}, "", 0, 10, "")
 
 		assert.NoError(t, err)
-		assert.Len(t, result, 2)
-		assert.Equal(t, "hotel1", result[0].ID)
-		assert.Equal(t, "Hotel Paradise", result[0].Name)
-		assert.Equal(t, 4.5, result[0].Rating)
-		assert.Equal(t, "Hotel Sunset", result[1].Name)
-		assert.Equal(t, "Mexico", result[1].Country)
+		assert.Len(t, result.Results, 2)
+		assert.Equal(t, "hotel1", result.Results[0].ID)
+		assert.Equal(t, "Hotel Paradise", result.Results[0].Name)
+		assert.Equal(t, 4.5, result.Results[0].Rating)
+		assert.Equal(t, "Hotel Sunset", result.Results[1].Name)
+		assert.Equal(t, "Mexico", result.Results[1].Country)
 
 		solrRepo.AssertExpectations(t)
 	})
@@ -72,12 +91,12 @@ func TestService_Search(t *testing.T) {
 	t.Run("empty results", func(t *testing.T) {
 		svc, solrRepo, _ := newTestService()
 
-		solrRepo.On("Search", mock.Anything, "nonexistent", 10, 0).Return([]hotelsDAO.Hotel{}, nil).Once()
+		solrRepo.On("Search", mock.Anything, "nonexistent", hotelsDAO.SearchFilters{}, "", 10, 0, "").Return(hotelsDAO.SearchResult{}, nil).Once()
 
-		result, err := svc.Search(context.Background(), "nonexistent", 0, 10)
+		result, err := svc.Search(context.Background(), "nonexistent", hotelsDomain.SearchFilters{}, "", 0, 10, "")
 
 		assert.NoError(t, err)
-		assert.Empty(t, result)
+		assert.Empty(t, result.Results)
 
 		solrRepo.AssertExpectations(t)
 	})
@@ -85,12 +104,12 @@ func TestService_Search(t *testing.T) {
 	t.Run("solr error", func(t *testing.T) {
 		svc, solrRepo, _ := newTestService()
 
-		solrRepo.On("Search", mock.Anything, "test", 10, 0).Return(nil, errors.New("solr connection error")).Once()
+		solrRepo.On("Search", mock.Anything, "test", hotelsDAO.SearchFilters{}, "", 10, 0, "").Return(nil, errors.New("solr connection error")).Once()
 
-		result, err := svc.Search(context.Background(), "test", 0, 10)
+		result, err := svc.Search(context.Background(), "test", hotelsDomain.SearchFilters{}, "", 0, 10, "")
 
 		assert.Error(t, err)
-		assert.Nil(t, result)
+		assert.Empty(t, result.Results)
 		assert.Contains(t, err.Error(), "error searching hotels")
 
 		solrRepo.AssertExpectations(t)
@@ -103,13 +122,292 @@ func TestService_Search(t *testing.T) {
 			{ID: "hotel3", Name: "Hotel Paginated", City: "Madrid"},
 		}
 
-		solrRepo.On("Search", mock.Anything, "hotel", 5, 10).Return(mockHotels, nil).Once()
+		solrRepo.On("Search", mock.Anything, "hotel", hotelsDAO.SearchFilters{}, "", 5, 10, "").Return(hotelsDAO.SearchResult{Hotels: mockHotels, Total: len(mockHotels)}, nil).Once()
+
+		result, err := svc.Search(context.Background(), "hotel", hotelsDomain.SearchFilters{}, "", 10, 5, "")
+
+		assert.NoError(t, err)
+		assert.Len(t, result.Results, 1)
+		assert.Equal(t, "hotel3", result.Results[0].ID)
+
+		solrRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_Search_Filters(t *testing.T) {
+	svc, solrRepo, _ := newTestService()
+
+	minPrice, minRating := 80.0, 4.5
+	solrRepo.On("Search", mock.Anything, "", hotelsDAO.SearchFilters{
+		City:      "Cancun",
+		MinPrice:  &minPrice,
+		MinRating: &minRating,
+		Amenities: []string{"wifi", "pool"},
+		Rooms:     3,
+	}, "", 10, 0, "").Return(hotelsDAO.SearchResult{Hotels: []hotelsDAO.Hotel{{ID: "hotel2", City: "Cancun"}}, Total: 1}, nil).Once()
+
+	result, err := svc.Search(context.Background(), "", hotelsDomain.SearchFilters{
+		City:      "Cancun",
+		MinPrice:  &minPrice,
+		MinRating: &minRating,
+		Amenities: []string{"wifi", "pool"},
+		Rooms:     3,
+	}, "", 0, 10, "")
+
+	assert.NoError(t, err)
+	assert.Len(t, result.Results, 1)
+	assert.Equal(t, "hotel2", result.Results[0].ID)
+
+	solrRepo.AssertExpectations(t)
+}
+
+func TestService_Search_FacetsAndTotal(t *testing.T) {
+	svc, solrRepo, _ := newTestService()
+
+	from, to := 100.0, 200.0
+	solrRepo.On("Search", mock.Anything, "hotel", hotelsDAO.SearchFilters{}, "", 1, 0, "").Return(hotelsDAO.SearchResult{
+		Hotels: []hotelsDAO.Hotel{{ID: "hotel1"}},
+		Total:  42,
+		Facets: hotelsDAO.Facets{
+			City:      []hotelsDAO.FacetBucket{{Value: "Buenos Aires", Count: 17}},
+			Amenities: []hotelsDAO.FacetBucket{{Value: "wifi", Count: 42}},
+			Price:     []hotelsDAO.FacetBucket{{Value: "100-200", Count: 9, From: &from, To: &to}},
+		},
+	}, nil).Once()
+
+	result, err := svc.Search(context.Background(), "hotel", hotelsDomain.SearchFilters{}, "", 0, 1, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 42, result.Total)
+	assert.Len(t, result.Results, 1)
+	assert.Equal(t, []hotelsDomain.FacetBucket{{Value: "Buenos Aires", Count: 17}}, result.Facets.City)
+	assert.Equal(t, []hotelsDomain.FacetBucket{{Value: "wifi", Count: 42}}, result.Facets.Amenities)
+	assert.Equal(t, []hotelsDomain.FacetBucket{{Value: "100-200", Count: 9, From: &from, To: &to}}, result.Facets.Price)
+	// Los facets sin valores se devuelven como listas vacias, no null
+	assert.NotNil(t, result.Facets.Country)
+	assert.Empty(t, result.Facets.Country)
+
+	solrRepo.AssertExpectations(t)
+}
+
+func TestService_Search_Pagination(t *testing.T) {
+	t.Run("middle page has next and prev offsets", func(t *testing.T) {
+		svc, solrRepo, _ := newTestService()
+		solrRepo.On("Search", mock.Anything, "hotel", hotelsDAO.SearchFilters{}, "", 10, 10, "").Return(hotelsDAO.SearchResult{Total: 35}, nil).Once()
+
+		result, err := svc.Search(context.Background(), "hotel", hotelsDomain.SearchFilters{}, "", 10, 10, "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 35, result.Total)
+		assert.Equal(t, 10, result.Offset)
+		assert.Equal(t, 10, result.Limit)
+		assert.Equal(t, 20, *result.NextOffset)
+		assert.Equal(t, 0, *result.PrevOffset)
+		assert.Empty(t, result.NextCursor)
+	})
+
+	t.Run("first and last page", func(t *testing.T) {
+		svc, solrRepo, _ := newTestService()
+		solrRepo.On("Search", mock.Anything, "hotel", hotelsDAO.SearchFilters{}, "", 10, 0, "").Return(hotelsDAO.SearchResult{Total: 8}, nil).Once()
+
+		result, err := svc.Search(context.Background(), "hotel", hotelsDomain.SearchFilters{}, "", 0, 10, "")
+
+		assert.NoError(t, err)
+		assert.Nil(t, result.NextOffset)
+		assert.Nil(t, result.PrevOffset)
+	})
+
+	t.Run("cursor paging ignores offset", func(t *testing.T) {
+		svc, solrRepo, _ := newTestService()
+		solrRepo.On("Search", mock.Anything, "hotel", hotelsDAO.SearchFilters{}, "", 10, 0, "*").Return(hotelsDAO.SearchResult{Total: 500, NextCursor: "AoE"}, nil).Once()
+
+		result, err := svc.Search(context.Background(), "hotel", hotelsDomain.SearchFilters{}, "", 30, 10, "*")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, result.Offset)
+		assert.Equal(t, "*", result.Cursor)
+		assert.Equal(t, "AoE", result.NextCursor)
+		assert.Nil(t, result.NextOffset)
+		assert.Nil(t, result.PrevOffset)
+		solrRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_Search_Availability(t *testing.T) {
+	dates := hotelsDomain.SearchFilters{CheckIn: "2026-11-01", CheckOut: "2026-11-05"}
+	batch := func(ids ...string) []hotelsDAO.Hotel {
+		hotels := make([]hotelsDAO.Hotel, 0, len(ids))
+		for _, id := range ids {
+			hotels = append(hotels, hotelsDAO.Hotel{ID: id})
+		}
+		return hotels
+	}
+
+	t.Run("skips full hotels", func(t *testing.T) {
+		svc, solrRepo, hotelsAPI := newTestService()
+
+		solrRepo.On("Search", mock.Anything, "", hotelsDAO.SearchFilters{}, "", 20, 0, "").Return(hotelsDAO.SearchResult{Hotels: batch("h1", "h2", "h3"), Total: 3}, nil).Once()
+		hotelsAPI.On("GetAvailability", mock.Anything, []string{"h1", "h2", "h3"}, "2026-11-01", "2026-11-05").Return(map[string]bool{"h1": true, "h2": false, "h3": true}, nil).Once()
+
+		result, err := svc.Search(context.Background(), "", dates, "", 0, 2, "")
+
+		assert.NoError(t, err)
+		assert.Len(t, result.Results, 2)
+		assert.Equal(t, "h1", result.Results[0].ID)
+		assert.Equal(t, "h3", result.Results[1].ID)
+		assert.False(t, result.Results[0].AvailabilityUnconfirmed)
+		assert.Equal(t, 3, result.Total)
+		assert.Nil(t, result.NextOffset)
+		assert.Nil(t, result.PrevOffset)
+
+		solrRepo.AssertExpectations(t)
+		hotelsAPI.AssertExpectations(t)
+	})
+
+	t.Run("pages through solr until the page is full", func(t *testing.T) {
+		svc, solrRepo, hotelsAPI := newTestService()
+
+		first := batch("h1", "h2")
+		for i := 3; i <= 20; i++ {
+			first = append(first, hotelsDAO.Hotel{ID: "full"})
+		}
+		full := map[string]bool{"h1": true, "h2": false, "full": false}
+		solrRepo.On("Search", mock.Anything, "", hotelsDAO.SearchFilters{}, "", 20, 0, "").Return(hotelsDAO.SearchResult{Hotels: first, Total: 50}, nil).Once()
+		solrRepo.On("Search", mock.Anything, "", hotelsDAO.SearchFilters{}, "", 20, 20, "").Return(hotelsDAO.SearchResult{Hotels: batch("h21", "h22", "h23"), Total: 50}, nil).Once()
+		hotelsAPI.On("GetAvailability", mock.Anything, mock.Anything, "2026-11-01", "2026-11-05").Return(full, nil).Once()
+		hotelsAPI.On("GetAvailability", mock.Anything, []string{"h21", "h22", "h23"}, "2026-11-01", "2026-11-05").Return(map[string]bool{"h21": false, "h22": true, "h23": true}, nil).Once()
+
+		result, err := svc.Search(context.Background(), "", dates, "", 0, 2, "")
 
-		result, err := svc.Search(context.Background(), "hotel", 10, 5)
+		assert.NoError(t, err)
+		assert.Len(t, result.Results, 2)
+		assert.Equal(t, "h1", result.Results[0].ID)
+		assert.Equal(t, "h22", result.Results[1].ID)
+		assert.Equal(t, 50, result.Total)
+		// El proximo hotel a revisar es h23 (posicion 22)
+		assert.Equal(t, 22, *result.NextOffset)
+
+		solrRepo.AssertExpectations(t)
+		hotelsAPI.AssertExpectations(t)
+	})
+
+	t.Run("hotels-api error flags results as unconfirmed", func(t *testing.T) {
+		svc, solrRepo, hotelsAPI := newTestService()
+
+		solrRepo.On("Search", mock.Anything, "", hotelsDAO.SearchFilters{}, "", 20, 0, "").Return(hotelsDAO.SearchResult{Hotels: batch("h1", "h2"), Total: 30}, nil).Once()
+		hotelsAPI.On("GetAvailability", mock.Anything, []string{"h1", "h2"}, "2026-11-01", "2026-11-05").Return(nil, context.DeadlineExceeded).Once()
+
+		result, err := svc.Search(context.Background(), "", dates, "", 0, 10, "")
+
+		assert.NoError(t, err)
+		assert.Len(t, result.Results, 2)
+		assert.True(t, result.Results[0].AvailabilityUnconfirmed)
+		assert.True(t, result.Results[1].AvailabilityUnconfirmed)
+		assert.Equal(t, 2, *result.NextOffset)
+
+		solrRepo.AssertExpectations(t)
+		hotelsAPI.AssertExpectations(t)
+	})
+
+	t.Run("hotel missing from the availability map is unconfirmed", func(t *testing.T) {
+		svc, solrRepo, hotelsAPI := newTestService()
+
+		solrRepo.On("Search", mock.Anything, "", hotelsDAO.SearchFilters{}, "", 20, 0, "").Return(hotelsDAO.SearchResult{Hotels: batch("h1", "h2"), Total: 2}, nil).Once()
+		hotelsAPI.On("GetAvailability", mock.Anything, []string{"h1", "h2"}, "2026-11-01", "2026-11-05").Return(map[string]bool{"h1": true}, nil).Once()
+
+		result, err := svc.Search(context.Background(), "", dates, "", 0, 10, "")
 
 		assert.NoError(t, err)
-		assert.Len(t, result, 1)
-		assert.Equal(t, "hotel3", result[0].ID)
+		assert.Len(t, result.Results, 2)
+		assert.False(t, result.Results[0].AvailabilityUnconfirmed)
+		assert.True(t, result.Results[1].AvailabilityUnconfirmed)
+	})
+
+	t.Run("solr error", func(t *testing.T) {
+		svc, solrRepo, hotelsAPI := newTestService()
+
+		solrRepo.On("Search", mock.Anything, "", hotelsDAO.SearchFilters{}, "", 20, 0, "").Return(nil, errors.New("solr down")).Once()
+
+		_, err := svc.Search(context.Background(), "", dates, "", 0, 10, "")
+
+		assert.Error(t, err)
+		hotelsAPI.AssertNotCalled(t, "GetAvailability", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("searches with the time budget as deadline", func(t *testing.T) {
+		svc, solrRepo, hotelsAPI := newTestService()
+
+		solrRepo.On("Search", mock.MatchedBy(func(ctx context.Context) bool {
+			deadline, ok := ctx.Deadline()
+			return ok && time.Until(deadline) <= time.Second
+		}), "", hotelsDAO.SearchFilters{}, "", 20, 0, "").Return(hotelsDAO.SearchResult{}, nil).Once()
+
+		result, err := svc.Search(context.Background(), "", dates, "", 0, 10, "")
+
+		assert.NoError(t, err)
+		assert.Empty(t, result.Results)
+		solrRepo.AssertExpectations(t)
+		hotelsAPI.AssertNotCalled(t, "GetAvailability", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_Suggest(t *testing.T) {
+	t.Run("cities first, then hotels up to the limit", func(t *testing.T) {
+		svc, solrRepo, _ := newTestService()
+
+		solrRepo.On("Suggest", mock.Anything, "bue", 4).Return(hotelsDAO.Suggestions{
+			Cities: []hotelsDAO.FacetBucket{{Value: "Buenos Aires", Count: 5}, {Value: "Buenaventura", Count: 2}, {Value: "Bueu", Count: 1}},
+			Hotels: []hotelsDAO.Hotel{{ID: "h1", Name: "Buen Retiro", City: "Madrid"}, {ID: "h2", Name: "Hotel Buenavista", City: "Cancun"}, {ID: "h3", Name: "Bueno"}},
+		}, nil).Once()
+
+		suggestions, err := svc.Suggest(context.Background(), "  Bue ", 4)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []hotelsDomain.Suggestion{
+			{Text: "Buenos Aires", Type: hotelsDomain.SuggestionCity, Count: 5},
+			{Text: "Buenaventura", Type: hotelsDomain.SuggestionCity, Count: 2},
+			{Text: "Buen Retiro", Type: hotelsDomain.SuggestionHotel, HotelID: "h1", City: "Madrid"},
+			{Text: "Hotel Buenavista", Type: hotelsDomain.SuggestionHotel, HotelID: "h2", City: "Cancun"},
+		}, suggestions)
+		solrRepo.AssertExpectations(t)
+	})
+
+	t.Run("hot prefixes are served from the cache", func(t *testing.T) {
+		svc, solrRepo, _ := newTestService()
+
+		solrRepo.On("Suggest", mock.Anything, "par", 8).Return(hotelsDAO.Suggestions{
+			Hotels: []hotelsDAO.Hotel{{ID: "h1", Name: "Hotel Paradise"}},
+		}, nil).Once()
+
+		first, err := svc.Suggest(context.Background(), "par", 8)
+		assert.NoError(t, err)
+		second, err := svc.Suggest(context.Background(), "PAR", 8)
+		assert.NoError(t, err)
+
+		assert.Equal(t, first, second)
+		solrRepo.AssertNumberOfCalls(t, "Suggest", 1)
+	})
+
+	t.Run("empty prefix", func(t *testing.T) {
+		svc, solrRepo, _ := newTestService()
+
+		suggestions, err := svc.Suggest(context.Background(), "   ", 8)
+
+		assert.NoError(t, err)
+		assert.Empty(t, suggestions)
+		solrRepo.AssertNotCalled(t, "Suggest", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("solr error is not cached", func(t *testing.T) {
+		svc, solrRepo, _ := newTestService()
+
+		solrRepo.On("Suggest", mock.Anything, "bue", 8).Return(nil, errors.New("solr down")).Once()
+		solrRepo.On("Suggest", mock.Anything, "bue", 8).Return(hotelsDAO.Suggestions{}, nil).Once()
+
+		_, err := svc.Suggest(context.Background(), "bue", 8)
+		assert.Error(t, err)
+		_, err = svc.Suggest(context.Background(), "bue", 8)
+		assert.NoError(t, err)
 
 		solrRepo.AssertExpectations(t)
 	})
@@ -135,22 +433,106 @@ func TestService_HandleHotelNew_Create(t *testing.T) {
 		}
 
 		hotelsAPI.On("GetHotelByID", mock.Anything, "hotel1").Return(hotelDomain, nil).Once()
-		solrRepo.On("Index", mock.Anything, mock.MatchedBy(func(h hotelsDAO.Hotel) bool {
+		solrRepo.On("Apply", mock.Anything, indexed(func(h hotelsDAO.Hotel) bool {
 			return h.ID == "hotel1" && h.Name == "New Hotel"
-		})).Return("hotel1", nil).Once()
+		})).Return(nil).Once()
 
 		hotelNew := hotelsDomain.HotelNew{
 			Operation: "CREATE",
 			HotelID:   "hotel1",
 		}
 
-		// HandleHotelNew no devuelve error, solo imprime logs
-		svc.HandleHotelNew(hotelNew)
+		assert.NoError(t, <-svc.HandleHotelNew(hotelNew))
+
+		solrRepo.AssertExpectations(t)
+		hotelsAPI.AssertExpectations(t)
+	})
+
+	t.Run("create - room types summary", func(t *testing.T) {
+		svc, solrRepo, hotelsAPI := newTestService()
+
+		hotelDomain := hotelsDomain.Hotel{
+			ID:            "hotel1",
+			Name:          "Room Types Hotel",
+			PricePerNight: 300.0,
+			RoomTypes: []hotelsDomain.RoomType{
+				{ID: "rt1", Name: "Suite", Capacity: 4, Count: 2, BasePrice: 250.0},
+				{ID: "rt2", Name: "Single", Capacity: 1, Count: 10, BasePrice: 80.0},
+			},
+		}
+
+		hotelsAPI.On("GetHotelByID", mock.Anything, "hotel1").Return(hotelDomain, nil).Once()
+		solrRepo.On("Apply", mock.Anything, indexed(func(h hotelsDAO.Hotel) bool {
+			return h.MinPrice == 80.0 && h.MaxCapacity == 4 && assert.ObjectsAreEqual([]int{4, 1}, h.RoomCapacities)
+		})).Return(nil).Once()
+
+		assert.NoError(t, <-svc.HandleHotelNew(hotelsDomain.HotelNew{Operation: "CREATE", HotelID: "hotel1"}))
+
+		solrRepo.AssertExpectations(t)
+		hotelsAPI.AssertExpectations(t)
+	})
+
+	t.Run("create - room type without base price uses the hotel price", func(t *testing.T) {
+		svc, solrRepo, hotelsAPI := newTestService()
+
+		hotelDomain := hotelsDomain.Hotel{
+			ID:            "hotel1",
+			Name:          "Hotel Price Rooms",
+			PricePerNight: 120.0,
+			RoomTypes: []hotelsDomain.RoomType{
+				{ID: "rt1", Name: "Doble", Capacity: 2, Count: 5},
+				{ID: "rt2", Name: "Suite", Capacity: 4, Count: 1, BasePrice: 200.0},
+			},
+		}
+
+		hotelsAPI.On("GetHotelByID", mock.Anything, "hotel1").Return(hotelDomain, nil).Once()
+		solrRepo.On("Apply", mock.Anything, indexed(func(h hotelsDAO.Hotel) bool {
+			return h.MinPrice == 120.0 && h.MaxCapacity == 4
+		})).Return(nil).Once()
+
+		assert.NoError(t, <-svc.HandleHotelNew(hotelsDomain.HotelNew{Operation: "CREATE", HotelID: "hotel1"}))
+
+		solrRepo.AssertExpectations(t)
+		hotelsAPI.AssertExpectations(t)
+	})
+
+	t.Run("create - total rooms come from the room types", func(t *testing.T) {
+		svc, solrRepo, hotelsAPI := newTestService()
+
+		// Con tipos de habitacion el stock esta en cada tipo, avaiable_rooms queda en cero
+		hotelDomain := hotelsDomain.Hotel{
+			ID:   "hotel1",
+			Name: "Hotel Room Types",
+			RoomTypes: []hotelsDomain.RoomType{
+				{ID: "rt1", Name: "Doble", Capacity: 2, Count: 5, BasePrice: 100.0},
+				{ID: "rt2", Name: "Suite", Capacity: 4, Count: 1, BasePrice: 200.0},
+			},
+		}
+
+		hotelsAPI.On("GetHotelByID", mock.Anything, "hotel1").Return(hotelDomain, nil).Once()
+		solrRepo.On("Apply", mock.Anything, indexed(func(h hotelsDAO.Hotel) bool {
+			return h.TotalRooms == 6 && h.AvaiableRooms == 0
+		})).Return(nil).Once()
+
+		assert.NoError(t, <-svc.HandleHotelNew(hotelsDomain.HotelNew{Operation: "CREATE", HotelID: "hotel1"}))
 
 		solrRepo.AssertExpectations(t)
 		hotelsAPI.AssertExpectations(t)
 	})
 
+	t.Run("create - total rooms of a hotel without room types", func(t *testing.T) {
+		svc, solrRepo, hotelsAPI := newTestService()
+
+		hotelsAPI.On("GetHotelByID", mock.Anything, "hotel1").Return(hotelsDomain.Hotel{ID: "hotel1", AvaiableRooms: 10}, nil).Once()
+		solrRepo.On("Apply", mock.Anything, indexed(func(h hotelsDAO.Hotel) bool {
+			return h.TotalRooms == 10
+		})).Return(nil).Once()
+
+		assert.NoError(t, <-svc.HandleHotelNew(hotelsDomain.HotelNew{Operation: "CREATE", HotelID: "hotel1"}))
+
+		solrRepo.AssertExpectations(t)
+	})
+
 	t.Run("create - hotels api error", func(t *testing.T) {
 		svc, solrRepo, hotelsAPI := newTestService()
 
@@ -161,10 +543,10 @@ func TestService_HandleHotelNew_Create(t *testing.T) {
 			HotelID:   "hotel1",
 		}
 
-		svc.HandleHotelNew(hotelNew)
+		assert.Error(t, <-svc.HandleHotelNew(hotelNew))
 
-		// Index no debería ser llamado si falla obtener el hotel
-		solrRepo.AssertNotCalled(t, "Index", mock.Anything, mock.Anything)
+		// Apply no debería ser llamado si falla obtener el hotel
+		solrRepo.AssertNotCalled(t, "Apply", mock.Anything, mock.Anything)
 		hotelsAPI.AssertExpectations(t)
 	})
 
@@ -177,14 +559,14 @@ func TestService_HandleHotelNew_Create(t *testing.T) {
 		}
 
 		hotelsAPI.On("GetHotelByID", mock.Anything, "hotel1").Return(hotelDomain, nil).Once()
-		solrRepo.On("Index", mock.Anything, mock.Anything).Return("", errors.New("solr error")).Once()
+		solrRepo.On("Apply", mock.Anything, mock.Anything).Return(errors.New("solr error")).Once()
 
 		hotelNew := hotelsDomain.HotelNew{
 			Operation: "CREATE",
 			HotelID:   "hotel1",
 		}
 
-		svc.HandleHotelNew(hotelNew)
+		assert.Error(t, <-svc.HandleHotelNew(hotelNew))
 
 		solrRepo.AssertExpectations(t)
 		hotelsAPI.AssertExpectations(t)
@@ -206,7 +588,7 @@ func TestService_HandleHotelNew_Update(t *testing.T) {
 		}
 
 		hotelsAPI.On("GetHotelByID", mock.Anything, "hotel1").Return(hotelDomain, nil).Once()
-		solrRepo.On("Update", mock.Anything, mock.MatchedBy(func(h hotelsDAO.Hotel) bool {
+		solrRepo.On("Apply", mock.Anything, indexed(func(h hotelsDAO.Hotel) bool {
 			return h.ID == "hotel1" && h.Name == "Updated Hotel"
 		})).Return(nil).Once()
 
@@ -215,7 +597,7 @@ func TestService_HandleHotelNew_Update(t *testing.T) {
 			HotelID:   "hotel1",
 		}
 
-		svc.HandleHotelNew(hotelNew)
+		assert.NoError(t, <-svc.HandleHotelNew(hotelNew))
 
 		solrRepo.AssertExpectations(t)
 		hotelsAPI.AssertExpectations(t)
@@ -230,14 +612,14 @@ func TestService_HandleHotelNew_Update(t *testing.T) {
 		}
 
 		hotelsAPI.On("GetHotelByID", mock.Anything, "hotel1").Return(hotelDomain, nil).Once()
-		solrRepo.On("Update", mock.Anything, mock.Anything).Return(errors.New("solr update error")).Once()
+		solrRepo.On("Apply", mock.Anything, mock.Anything).Return(errors.New("solr update error")).Once()
 
 		hotelNew := hotelsDomain.HotelNew{
 			Operation: "UPDATE",
 			HotelID:   "hotel1",
 		}
 
-		svc.HandleHotelNew(hotelNew)
+		assert.Error(t, <-svc.HandleHotelNew(hotelNew))
 
 		solrRepo.AssertExpectations(t)
 		hotelsAPI.AssertExpectations(t)
@@ -248,14 +630,14 @@ func TestService_HandleHotelNew_Delete(t *testing.T) {
 	t.Run("delete success", func(t *testing.T) {
 		svc, solrRepo, _ := newTestService()
 
-		solrRepo.On("Delete", mock.Anything, "hotel1").Return(nil).Once()
+		solrRepo.On("Apply", mock.Anything, deleted("hotel1")).Return(nil).Once()
 
 		hotelNew := hotelsDomain.HotelNew{
 			Operation: "DELETE",
 			HotelID:   "hotel1",
 		}
 
-		svc.HandleHotelNew(hotelNew)
+		assert.NoError(t, <-svc.HandleHotelNew(hotelNew))
 
 		solrRepo.AssertExpectations(t)
 	})
@@ -263,14 +645,14 @@ func TestService_HandleHotelNew_Delete(t *testing.T) {
 	t.Run("delete - solr error", func(t *testing.T) {
 		svc, solrRepo, _ := newTestService()
 
-		solrRepo.On("Delete", mock.Anything, "hotel1").Return(errors.New("solr delete error")).Once()
+		solrRepo.On("Apply", mock.Anything, deleted("hotel1")).Return(errors.New("solr delete error")).Once()
 
 		hotelNew := hotelsDomain.HotelNew{
 			Operation: "DELETE",
 			HotelID:   "hotel1",
 		}
 
-		svc.HandleHotelNew(hotelNew)
+		assert.Error(t, <-svc.HandleHotelNew(hotelNew))
 
 		solrRepo.AssertExpectations(t)
 	})
@@ -285,12 +667,128 @@ func TestService_HandleHotelNew_UnknownOperation(t *testing.T) {
 			HotelID:   "hotel1",
 		}
 
-		svc.HandleHotelNew(hotelNew)
+		err := <-svc.HandleHotelNew(hotelNew)
+		assert.ErrorIs(t, err, hotelsDomain.ErrInvalidEvent)
 
 		// No debería llamar a ningún método del repositorio
-		solrRepo.AssertNotCalled(t, "Index", mock.Anything, mock.Anything)
-		solrRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
-		solrRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+		solrRepo.AssertNotCalled(t, "Apply", mock.Anything, mock.Anything)
+		hotelsAPI.AssertNotCalled(t, "GetHotelByID", mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_HandleHotelNew_V2Snapshot(t *testing.T) {
+	t.Run("v2 event uses the hotel snapshot", func(t *testing.T) {
+		svc, solrRepo, hotelsAPI := newTestService()
+
+		solrRepo.On("Sequences", mock.Anything, []string{"hotel1"}).Return(map[string]int64{}, nil).Once()
+		solrRepo.On("Apply", mock.Anything, indexed(func(h hotelsDAO.Hotel) bool {
+			return h.ID == "hotel1" && h.Name == "Snapshot Hotel" && h.MinPrice == 90.0 && h.EventSequence == 3
+		})).Return(nil).Once()
+
+		err := <-svc.HandleHotelNew(hotelsDomain.HotelNew{
+			EventID:       "evt-1",
+			Type:          "UPDATE",
+			SchemaVersion: hotelsDomain.HotelEventSchemaVersion,
+			Sequence:      3,
+			HotelID:       "hotel1",
+			Hotel:         &hotelsDomain.Hotel{ID: "hotel1", Name: "Snapshot Hotel", PricePerNight: 90.0},
+		})
+		assert.NoError(t, err)
+
+		solrRepo.AssertExpectations(t)
+		// Con el hotel en el evento no hace falta llamar a hotels-api
 		hotelsAPI.AssertNotCalled(t, "GetHotelByID", mock.Anything, mock.Anything)
 	})
+
+	t.Run("v2 event keeps the hotel location", func(t *testing.T) {
+		svc, solrRepo, _ := newTestService()
+
+		latitude, longitude := -34.6037, -58.3816
+		solrRepo.On("Apply", mock.Anything, indexed(func(h hotelsDAO.Hotel) bool {
+			return h.Latitude != nil && *h.Latitude == latitude && h.Longitude != nil && *h.Longitude == longitude
+		})).Return(nil).Once()
+
+		err := <-svc.HandleHotelNew(hotelsDomain.HotelNew{
+			Type:          "CREATE",
+			SchemaVersion: hotelsDomain.HotelEventSchemaVersion,
+			HotelID:       "hotel1",
+			Hotel:         &hotelsDomain.Hotel{ID: "hotel1", Latitude: &latitude, Longitude: &longitude},
+		})
+		assert.NoError(t, err)
+
+		solrRepo.AssertExpectations(t)
+	})
+
+	t.Run("v2 delete", func(t *testing.T) {
+		svc, solrRepo, hotelsAPI := newTestService()
+
+		solrRepo.On("Apply", mock.Anything, deleted("hotel1")).Return(nil).Once()
+
+		assert.NoError(t, <-svc.HandleHotelNew(hotelsDomain.HotelNew{Type: "DELETE", SchemaVersion: hotelsDomain.HotelEventSchemaVersion, HotelID: "hotel1"}))
+
+		solrRepo.AssertExpectations(t)
+		hotelsAPI.AssertNotCalled(t, "GetHotelByID", mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_HandleHotelNew_Sequence(t *testing.T) {
+	t.Run("a retried older event does not overwrite a newer one", func(t *testing.T) {
+		svc, solrRepo, _ := newTestService()
+
+		// applied hace de Solr: Sequences devuelve el mismo mapa que actualiza Apply
+		applied := map[string]int64{}
+		solrRepo.On("Sequences", mock.Anything, []string{"hotel1"}).Return(applied, nil)
+		solrRepo.On("Apply", mock.Anything, indexed(func(h hotelsDAO.Hotel) bool {
+			return h.Name == "Hotel v2" && h.EventSequence == 2
+		})).Run(func(args mock.Arguments) { applied["hotel1"] = 2 }).Return(nil).Once()
+
+		event := func(sequence int64, name string) hotelsDomain.HotelNew {
+			return hotelsDomain.HotelNew{
+				Type:          "UPDATE",
+				SchemaVersion: hotelsDomain.HotelEventSchemaVersion,
+				Sequence:      sequence,
+				HotelID:       "hotel1",
+				Hotel:         &hotelsDomain.Hotel{ID: "hotel1", Name: name},
+			}
+		}
+		assert.NoError(t, <-svc.HandleHotelNew(event(2, "Hotel v2")))
+		// El reintento del evento 1 llega despues: se confirma sin pisar el snapshot del 2
+		assert.NoError(t, <-svc.HandleHotelNew(event(1, "Hotel v1")))
+
+		solrRepo.AssertExpectations(t)
+		solrRepo.AssertNumberOfCalls(t, "Apply", 1)
+	})
+
+	t.Run("a retried create does not bring back a deleted hotel", func(t *testing.T) {
+		svc, solrRepo, _ := newTestService()
+
+		applied := map[string]int64{}
+		solrRepo.On("Sequences", mock.Anything, []string{"hotel1"}).Return(applied, nil)
+		solrRepo.On("Apply", mock.Anything, mock.MatchedBy(func(changes []hotelsDAO.IndexChange) bool {
+			return len(changes) == 1 && changes[0].DeleteID == "hotel1" && changes[0].Sequence == 3
+		})).Run(func(args mock.Arguments) { applied["hotel1"] = 3 }).Return(nil).Once()
+
+		assert.NoError(t, <-svc.HandleHotelNew(hotelsDomain.HotelNew{Type: "DELETE", SchemaVersion: hotelsDomain.HotelEventSchemaVersion, Sequence: 3, HotelID: "hotel1"}))
+		assert.NoError(t, <-svc.HandleHotelNew(hotelsDomain.HotelNew{
+			Type:          "CREATE",
+			SchemaVersion: hotelsDomain.HotelEventSchemaVersion,
+			Sequence:      1,
+			HotelID:       "hotel1",
+			Hotel:         &hotelsDomain.Hotel{ID: "hotel1", Name: "Hotel Paradise"},
+		}))
+
+		solrRepo.AssertExpectations(t)
+		solrRepo.AssertNumberOfCalls(t, "Apply", 1)
+	})
+
+	t.Run("solr error reading sequences", func(t *testing.T) {
+		svc, solrRepo, _ := newTestService()
+
+		solrRepo.On("Sequences", mock.Anything, []string{"hotel1"}).Return(nil, errors.New("solr down")).Once()
+
+		err := <-svc.HandleHotelNew(hotelsDomain.HotelNew{Type: "DELETE", SchemaVersion: hotelsDomain.HotelEventSchemaVersion, Sequence: 2, HotelID: "hotel1"})
+		assert.ErrorContains(t, err, "solr down")
+
+		solrRepo.AssertNotCalled(t, "Apply", mock.Anything, mock.Anything)
+	})
 }
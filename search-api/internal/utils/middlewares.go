@@ -1,7 +1,12 @@
 package utils
 
 import (
+	"fmt"
+	"net/http"
+	"strings"
+
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 )
 
 func CorsMiddleware() gin.HandlerFunc {
@@ -20,3 +25,51 @@ func CorsMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// Valida el token JWT emitido por users-api (mismo secreto que hotels-api) y guarda el tipo de usuario en el contexto
+func JWTMiddleware(secretKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header format must be Bearer {token}"})
+			return
+		}
+
+		token, err := jwt.Parse(parts[1], func(token *jwt.Token) (interface{}, error) {
+			// Verifica el método de firma
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			return []byte(secretKey), nil
+		})
+		if err != nil || !token.Valid {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			return
+		}
+
+		claims, ok := token.Claims.(jwt.MapClaims)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+			return
+		}
+		userType, ok := claims["tipo"].(string)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User type not found in token"})
+			return
+		}
+
+		c.Set("userType", userType)
+		c.Next()
+	}
+}
+
+// Solo deja pasar a los administradores (usar despues de JWTMiddleware)
+func AdminOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if userType, _ := c.Get("userType"); userType != "administrador" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden: Administrators only"})
+			return
+		}
+		c.Next()
+	}
+}